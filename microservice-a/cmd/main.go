@@ -1,10 +1,13 @@
 package main
 
 import (
+	"fmt"
 	"log"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
@@ -12,6 +15,7 @@ import (
 	grpcClient "sensor_project/microservice-a/internal/delivery/grpc"
 	httpDelivery "sensor_project/microservice-a/internal/delivery/http"
 	"sensor_project/microservice-a/internal/domain"
+	"sensor_project/microservice-a/internal/repository/spool"
 	"sensor_project/microservice-a/internal/usecase"
 
 	"github.com/labstack/echo/v4"
@@ -20,16 +24,44 @@ import (
 
 func main() {
 	// Load configuration
-	cfg := config.LoadConfig()
+	configManager, err := config.NewManager(os.Args[1:])
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+	cfg := configManager.Current()
+
+	// Set up leveled logging; standard log output is routed through the same handler
+	logLevel := new(slog.LevelVar)
+	logLevel.Set(cfg.SlogLevel())
+	slog.SetDefault(slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: logLevel})))
 
 	// Create sensor generator
-	generator := usecase.NewSensorGenerator(cfg.SensorConfig)
+	generator, err := usecase.NewSensorGenerator(cfg.SensorConfig)
+	if err != nil {
+		log.Fatalf("Failed to create sensor generator: %v", err)
+	}
 
 	// Create gRPC client
-	client, err := grpcClient.NewSensorClient(cfg.GRPCServerAddr)
+	var client domain.SensorSender
+	client, err = newSensorSender(cfg)
 	if err != nil {
 		log.Fatalf("Failed to create gRPC client: %v", err)
 	}
+
+	// Buffer readings on disk while microservice-b is unreachable
+	stopChan := make(chan struct{})
+	var buffer domain.SensorBuffer
+	if cfg.SpoolDir != "" {
+		fileSpool, err := spool.NewFileSpool(cfg.SpoolDir, cfg.SpoolMaxSegmentBytes, cfg.SpoolMaxTotalBytes)
+		if err != nil {
+			log.Fatalf("Failed to open spool: %v", err)
+		}
+		buffer = fileSpool
+
+		bufferedSender := usecase.NewBufferedSender(client, buffer, cfg.SpoolRetryInterval)
+		go bufferedSender.Run(stopChan)
+		client = bufferedSender
+	}
 	defer client.Close()
 
 	// Create HTTP server
@@ -38,7 +70,7 @@ func main() {
 	e.Use(middleware.Recover())
 
 	// Set up HTTP routes
-	handler := httpDelivery.NewHandler(generator)
+	handler := httpDelivery.NewHandler(generator, buffer, configManager)
 	handler.SetupRoutes(e)
 
 	// Start HTTP server in a goroutine
@@ -49,13 +81,18 @@ func main() {
 	}()
 
 	// Start data generation in a goroutine
-	stopChan := make(chan struct{})
 	go generateAndSendData(generator, client, stopChan)
 
-	// Wait for interrupt signal to gracefully shut down the server
+	// Wait for interrupt signal to gracefully shut down the server, reloading the
+	// configuration on SIGHUP
 	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-	<-quit
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
+	for sig := range quit {
+		if sig != syscall.SIGHUP {
+			break
+		}
+		reloadConfig(configManager, generator, logLevel)
+	}
 
 	// Stop data generation
 	close(stopChan)
@@ -63,8 +100,42 @@ func main() {
 	log.Println("Shutting down server...")
 }
 
+// reloadConfig re-reads the configuration and applies the settings that are safe to
+// change at runtime
+func reloadConfig(configManager *config.Manager, generator domain.SensorGenerator, logLevel *slog.LevelVar) {
+	previous := configManager.Current()
+	cfg, restartRequired, err := configManager.Reload()
+	if err != nil {
+		log.Printf("Failed to reload configuration, keeping current settings: %v", err)
+		return
+	}
+
+	// Only touch the generation rate if it changed, so a rate set via the REST API is kept
+	if cfg.SensorConfig.GenerationRate != previous.SensorConfig.GenerationRate {
+		generator.SetGenerationRate(cfg.SensorConfig.GenerationRate)
+	}
+	logLevel.Set(cfg.SlogLevel())
+
+	if len(restartRequired) > 0 {
+		log.Printf("Changes to %s take effect after a restart", strings.Join(restartRequired, ", "))
+	}
+	log.Println("Configuration reloaded")
+}
+
+// newSensorSender creates the gRPC sender for the configured send mode
+func newSensorSender(cfg *config.Config) (domain.SensorSender, error) {
+	switch cfg.SendMode {
+	case config.SendModeStream:
+		return grpcClient.NewSensorStreamClient(cfg.GRPCServerAddr, cfg.APIKey)
+	case config.SendModeUnary:
+		return grpcClient.NewSensorClient(cfg.GRPCServerAddr, cfg.APIKey)
+	default:
+		return nil, fmt.Errorf("unknown send mode %q", cfg.SendMode)
+	}
+}
+
 // generateAndSendData continuously generates and sends sensor data
-func generateAndSendData(generator domain.SensorGenerator, client *grpcClient.SensorClient, stopChan <-chan struct{}) {
+func generateAndSendData(generator domain.SensorGenerator, client domain.SensorSender, stopChan <-chan struct{}) {
 	for {
 		select {
 		case <-stopChan:
@@ -77,7 +148,7 @@ func generateAndSendData(generator domain.SensorGenerator, client *grpcClient.Se
 			if err := client.SendSensorData(data); err != nil {
 				log.Printf("Error sending sensor data: %v", err)
 			} else {
-				log.Printf("Sent sensor data: %+v", data)
+				slog.Debug("Sent sensor data", "data", data)
 			}
 
 			// Wait for the next generation cycle
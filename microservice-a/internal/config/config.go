@@ -1,30 +1,151 @@
 package config
 
 import (
-	"sensor_project/microservice-a/internal/domain"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
 	"time"
+
+	"sensor_project/microservice-a/internal/domain"
+)
+
+// Send modes for delivering sensor data to microservice-b
+const (
+	SendModeUnary  = "unary"
+	SendModeStream = "stream"
 )
 
 // Config holds the application configuration
 type Config struct {
 	ServerPort     string
 	GRPCServerAddr string
+	APIKey         string
+	SendMode       string
 	SensorConfig   domain.SensorConfig
+	LogLevel       string
+
+	// Outbound buffer for readings that could not be sent; an empty SpoolDir disables it
+	SpoolDir             string
+	SpoolMaxSegmentBytes int64
+	SpoolMaxTotalBytes   int64
+	SpoolRetryInterval   time.Duration
 }
 
-// LoadConfig loads the configuration from environment variables
-func LoadConfig() *Config {
-	// Default values
-	config := &Config{
+// DefaultConfig returns the configuration used when no other source sets a value
+func DefaultConfig() *Config {
+	return &Config{
 		ServerPort:     "8090",
 		GRPCServerAddr: "127.0.0.1:50051",
+		SendMode:       SendModeUnary,
 		SensorConfig: domain.SensorConfig{
 			SensorType:     "temperature",
 			MinValue:       0.0,
 			MaxValue:       100.0,
 			GenerationRate: 1000 * time.Millisecond,
+			Signal: domain.SignalConfig{
+				Model: domain.SignalUniform,
+			},
 		},
+		LogLevel:             "info",
+		SpoolDir:             "spool",
+		SpoolMaxSegmentBytes: 8 << 20,
+		SpoolMaxTotalBytes:   512 << 20,
+		SpoolRetryInterval:   5 * time.Second,
 	}
+}
+
+// Validate checks the configuration and reports every problem found
+func (c *Config) Validate() error {
+	var errs []error
 
-	return config
+	if err := validatePort(c.ServerPort); err != nil {
+		errs = append(errs, fmt.Errorf("server_port: %w", err))
+	}
+	if c.GRPCServerAddr == "" {
+		errs = append(errs, errors.New("grpc_server_addr: must not be empty"))
+	}
+	if c.SendMode != SendModeUnary && c.SendMode != SendModeStream {
+		errs = append(errs, fmt.Errorf("send_mode: must be %s or %s, got %q", SendModeUnary, SendModeStream, c.SendMode))
+	}
+	if c.SensorConfig.SensorType == "" {
+		errs = append(errs, errors.New("sensor_type: must not be empty"))
+	}
+	if c.SensorConfig.MinValue >= c.SensorConfig.MaxValue {
+		errs = append(errs, fmt.Errorf("min_value: must be less than max_value (%g >= %g)", c.SensorConfig.MinValue, c.SensorConfig.MaxValue))
+	}
+	if c.SensorConfig.GenerationRate < 100*time.Millisecond {
+		errs = append(errs, fmt.Errorf("generation_rate: must be at least 100ms, got %s", c.SensorConfig.GenerationRate))
+	}
+	if err := validateSignal(c.SensorConfig.Signal); err != nil {
+		errs = append(errs, fmt.Errorf("signal: %w", err))
+	}
+	if _, err := parseLogLevel(c.LogLevel); err != nil {
+		errs = append(errs, fmt.Errorf("log_level: %w", err))
+	}
+	if c.SpoolDir != "" {
+		if c.SpoolMaxSegmentBytes <= 0 {
+			errs = append(errs, errors.New("spool_max_segment_bytes: must be positive"))
+		}
+		if c.SpoolMaxTotalBytes < c.SpoolMaxSegmentBytes {
+			errs = append(errs, errors.New("spool_max_total_bytes: must be at least spool_max_segment_bytes"))
+		}
+		if c.SpoolRetryInterval <= 0 {
+			errs = append(errs, errors.New("spool_retry_interval: must be positive"))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// SlogLevel returns the configured log level
+func (c *Config) SlogLevel() slog.Level {
+	level, _ := parseLogLevel(c.LogLevel)
+	return level
+}
+
+// validateSignal checks that a signal configuration and its components use known models
+func validateSignal(signal domain.SignalConfig) error {
+	switch signal.Model {
+	case "", domain.SignalUniform, domain.SignalSine, domain.SignalRandomWalk, domain.SignalStep, domain.SignalGaussian:
+		return nil
+	case domain.SignalComposite:
+		if len(signal.Components) == 0 {
+			return errors.New("composite model requires at least one component")
+		}
+		for _, component := range signal.Components {
+			if err := validateSignal(component); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown model %q", signal.Model)
+	}
+}
+
+// validatePort checks that a port is a number between 1 and 65535
+func validatePort(port string) error {
+	n, err := strconv.Atoi(port)
+	if err != nil || n < 1 || n > 65535 {
+		return fmt.Errorf("invalid port %q", port)
+	}
+	return nil
+}
+
+// parseLogLevel converts a log level name to its slog level
+func parseLogLevel(name string) (slog.Level, error) {
+	switch strings.ToLower(name) {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "info", "":
+		return slog.LevelInfo, nil
+	case "warn":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	default:
+		return slog.LevelInfo, fmt.Errorf("unknown log level %q", name)
+	}
 }
@@ -0,0 +1,187 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// setting describes one configuration value. The key names it in config files and,
+// with underscores replaced by dashes, on the command line.
+type setting struct {
+	key        string
+	env        string
+	usage      string
+	secret     bool
+	reloadable bool
+	set        func(cfg *Config, value string) error
+	get        func(cfg *Config) interface{}
+}
+
+// settings lists every value that can be set from a file, the environment or a flag
+var settings = []setting{
+	{
+		key: "server_port", env: "SERVER_PORT", usage: "HTTP server port",
+		set: func(cfg *Config, v string) error { cfg.ServerPort = v; return nil },
+		get: func(cfg *Config) interface{} { return cfg.ServerPort },
+	},
+	{
+		key: "grpc_server_addr", env: "GRPC_SERVER_ADDR", usage: "address of microservice-b's gRPC server",
+		set: func(cfg *Config, v string) error { cfg.GRPCServerAddr = v; return nil },
+		get: func(cfg *Config) interface{} { return cfg.GRPCServerAddr },
+	},
+	{
+		key: "api_key", env: "API_KEY", usage: "API key presented to microservice-b", secret: true,
+		set: func(cfg *Config, v string) error { cfg.APIKey = v; return nil },
+		get: func(cfg *Config) interface{} { return cfg.APIKey },
+	},
+	{
+		key: "send_mode", env: "SEND_MODE", usage: "how readings are sent: unary or stream",
+		set: func(cfg *Config, v string) error { cfg.SendMode = v; return nil },
+		get: func(cfg *Config) interface{} { return cfg.SendMode },
+	},
+	{
+		key: "log_level", env: "LOG_LEVEL", usage: "log level: debug, info, warn or error", reloadable: true,
+		set: func(cfg *Config, v string) error { cfg.LogLevel = v; return nil },
+		get: func(cfg *Config) interface{} { return cfg.LogLevel },
+	},
+	{
+		key: "sensor_type", env: "SENSOR_TYPE", usage: "sensor type of generated readings",
+		set: func(cfg *Config, v string) error { cfg.SensorConfig.SensorType = v; return nil },
+		get: func(cfg *Config) interface{} { return cfg.SensorConfig.SensorType },
+	},
+	{
+		key: "min_value", env: "MIN_VALUE", usage: "minimum generated value",
+		set: func(cfg *Config, v string) error { return setFloat(&cfg.SensorConfig.MinValue, v) },
+		get: func(cfg *Config) interface{} { return cfg.SensorConfig.MinValue },
+	},
+	{
+		key: "max_value", env: "MAX_VALUE", usage: "maximum generated value",
+		set: func(cfg *Config, v string) error { return setFloat(&cfg.SensorConfig.MaxValue, v) },
+		get: func(cfg *Config) interface{} { return cfg.SensorConfig.MaxValue },
+	},
+	{
+		key: "generation_rate", env: "GENERATION_RATE", usage: "interval between readings (duration or milliseconds)", reloadable: true,
+		set: func(cfg *Config, v string) error { return setDuration(&cfg.SensorConfig.GenerationRate, v) },
+		get: func(cfg *Config) interface{} { return cfg.SensorConfig.GenerationRate.String() },
+	},
+	{
+		key: "signal_model", env: "SIGNAL_MODEL", usage: "signal model: uniform, sine, random_walk, step, gaussian or composite",
+		set: func(cfg *Config, v string) error { cfg.SensorConfig.Signal.Model = v; return nil },
+		get: func(cfg *Config) interface{} { return cfg.SensorConfig.Signal.Model },
+	},
+	{
+		key: "signal_baseline", env: "SIGNAL_BASELINE", usage: "signal baseline value",
+		set: func(cfg *Config, v string) error { return setFloat(&cfg.SensorConfig.Signal.Baseline, v) },
+		get: func(cfg *Config) interface{} { return cfg.SensorConfig.Signal.Baseline },
+	},
+	{
+		key: "signal_amplitude", env: "SIGNAL_AMPLITUDE", usage: "sine signal amplitude",
+		set: func(cfg *Config, v string) error { return setFloat(&cfg.SensorConfig.Signal.Amplitude, v) },
+		get: func(cfg *Config) interface{} { return cfg.SensorConfig.Signal.Amplitude },
+	},
+	{
+		key: "signal_period", env: "SIGNAL_PERIOD", usage: "sine signal period",
+		set: func(cfg *Config, v string) error { return setDuration(&cfg.SensorConfig.Signal.Period, v) },
+		get: func(cfg *Config) interface{} { return cfg.SensorConfig.Signal.Period.String() },
+	},
+	{
+		key: "signal_phase", env: "SIGNAL_PHASE", usage: "sine signal phase offset",
+		set: func(cfg *Config, v string) error { return setDuration(&cfg.SensorConfig.Signal.Phase, v) },
+		get: func(cfg *Config) interface{} { return cfg.SensorConfig.Signal.Phase.String() },
+	},
+	{
+		key: "signal_step_size", env: "SIGNAL_STEP_SIZE", usage: "maximum random walk step",
+		set: func(cfg *Config, v string) error { return setFloat(&cfg.SensorConfig.Signal.StepSize, v) },
+		get: func(cfg *Config) interface{} { return cfg.SensorConfig.Signal.StepSize },
+	},
+	{
+		key: "signal_std_dev", env: "SIGNAL_STD_DEV", usage: "Gaussian noise standard deviation",
+		set: func(cfg *Config, v string) error { return setFloat(&cfg.SensorConfig.Signal.StdDev, v) },
+		get: func(cfg *Config) interface{} { return cfg.SensorConfig.Signal.StdDev },
+	},
+	{
+		key: "signal_interval", env: "SIGNAL_INTERVAL", usage: "interval between step signal changes",
+		set: func(cfg *Config, v string) error { return setDuration(&cfg.SensorConfig.Signal.Interval, v) },
+		get: func(cfg *Config) interface{} { return cfg.SensorConfig.Signal.Interval.String() },
+	},
+	{
+		key: "spool_dir", env: "SPOOL_DIR", usage: "directory for buffering unsent readings; empty disables buffering",
+		set: func(cfg *Config, v string) error { cfg.SpoolDir = v; return nil },
+		get: func(cfg *Config) interface{} { return cfg.SpoolDir },
+	},
+	{
+		key: "spool_max_segment_bytes", env: "SPOOL_MAX_SEGMENT_BYTES", usage: "maximum size of one spool segment",
+		set: func(cfg *Config, v string) error { return setInt64(&cfg.SpoolMaxSegmentBytes, v) },
+		get: func(cfg *Config) interface{} { return cfg.SpoolMaxSegmentBytes },
+	},
+	{
+		key: "spool_max_total_bytes", env: "SPOOL_MAX_TOTAL_BYTES", usage: "maximum total size of the spool",
+		set: func(cfg *Config, v string) error { return setInt64(&cfg.SpoolMaxTotalBytes, v) },
+		get: func(cfg *Config) interface{} { return cfg.SpoolMaxTotalBytes },
+	},
+	{
+		key: "spool_retry_interval", env: "SPOOL_RETRY_INTERVAL", usage: "interval between attempts to replay buffered readings",
+		set: func(cfg *Config, v string) error { return setDuration(&cfg.SpoolRetryInterval, v) },
+		get: func(cfg *Config) interface{} { return cfg.SpoolRetryInterval.String() },
+	},
+}
+
+// findSetting returns the setting with the given key
+func findSetting(key string) (setting, bool) {
+	for _, s := range settings {
+		if s.key == key {
+			return s, true
+		}
+	}
+	return setting{}, false
+}
+
+// flagName returns the command-line flag name of a setting
+func (s setting) flagName() string {
+	return strings.ReplaceAll(s.key, "_", "-")
+}
+
+// setFloat parses a floating point setting
+func setFloat(target *float64, value string) error {
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return fmt.Errorf("invalid number %q", value)
+	}
+	*target = f
+	return nil
+}
+
+// setInt64 parses an integer setting
+func setInt64(target *int64, value string) error {
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid integer %q", value)
+	}
+	*target = n
+	return nil
+}
+
+// setDuration parses a duration setting given either as a Go duration such as "1.5s"
+// or as a plain number of milliseconds
+func setDuration(target *time.Duration, value string) error {
+	d, err := parseDuration(value)
+	if err != nil {
+		return err
+	}
+	*target = d
+	return nil
+}
+
+// parseDuration parses a Go duration string or a plain number of milliseconds
+func parseDuration(value string) (time.Duration, error) {
+	if ms, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Duration(ms) * time.Millisecond, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration %q", value)
+	}
+	return d, nil
+}
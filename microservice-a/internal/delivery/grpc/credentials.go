@@ -0,0 +1,31 @@
+package grpc
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// apiKeyCredentials attaches an API key to the metadata of every call
+type apiKeyCredentials string
+
+// GetRequestMetadata returns the metadata carrying the API key
+func (k apiKeyCredentials) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	return map[string]string{"x-api-key": string(k)}, nil
+}
+
+// RequireTransportSecurity reports that the key may be sent over plaintext connections
+func (k apiKeyCredentials) RequireTransportSecurity() bool {
+	return false
+}
+
+// dialOptions returns the options for connecting to microservice-b, presenting apiKey
+// on every call if it is set
+func dialOptions(apiKey string) []grpc.DialOption {
+	opts := []grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())}
+	if apiKey != "" {
+		opts = append(opts, grpc.WithPerRPCCredentials(apiKeyCredentials(apiKey)))
+	}
+	return opts
+}
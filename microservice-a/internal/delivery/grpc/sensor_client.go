@@ -2,7 +2,9 @@ package grpc
 
 import (
 	"context"
+	"fmt"
 	"log"
+	"log/slog"
 	"time"
 
 	pb "sensor_project/proto/sensor_project/proto/sensor"
@@ -10,7 +12,8 @@ import (
 	"sensor_project/microservice-a/internal/domain"
 
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 // SensorClient implements the SensorSender interface using gRPC
@@ -20,9 +23,9 @@ type SensorClient struct {
 }
 
 // NewSensorClient creates a new gRPC client for sending sensor data
-func NewSensorClient(serverAddr string) (*SensorClient, error) {
+func NewSensorClient(serverAddr, apiKey string) (*SensorClient, error) {
 	// Set up a connection to the server
-	conn, err := grpc.Dial(serverAddr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	conn, err := grpc.Dial(serverAddr, dialOptions(apiKey)...)
 	if err != nil {
 		return nil, err
 	}
@@ -39,27 +42,27 @@ func (c *SensorClient) SendSensorData(data *domain.SensorData) error {
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
 	defer cancel()
 
-	// Convert domain model to protobuf message
-	pbData := &pb.SensorData{
-		SensorValue: float32(data.SensorValue),
-		SensorType:  data.SensorType,
-		Id1:         data.ID1,
-		Id2:         int32(data.ID2),
-		Timestamp:   time.Now().UnixMilli(),
-	}
-
 	// Send the data
-	resp, err := c.client.SendSensorData(ctx, pbData)
+	resp, err := c.client.SendSensorData(ctx, toProtoSensorData(data))
 	if err != nil {
 		log.Printf("Failed to send sensor data: %v", err)
-		return err
+		return rejection(err)
 	}
 
-	log.Println("Response from [MICROSERVICE - B] : ", resp)
+	slog.Debug("Response from [MICROSERVICE - B]", "response", resp)
 	if !resp.Success {
 		log.Printf("Server rejected sensor data: %s", resp.Message)
 	}
+	if resp.Duplicate {
+		// An earlier attempt was stored even though it timed out here
+		slog.Debug("Server already stored sensor data", "reading_id", data.ReadingID)
+	}
+
+	return nil
+}
 
+// Flush returns immediately; every sent reading was confirmed by its own call
+func (c *SensorClient) Flush() error {
 	return nil
 }
 
@@ -67,3 +70,25 @@ func (c *SensorClient) SendSensorData(data *domain.SensorData) error {
 func (c *SensorClient) Close() error {
 	return c.conn.Close()
 }
+
+// rejection wraps errors whose status code means the server will refuse the call however
+// often it is retried in domain.ErrRejected
+func rejection(err error) error {
+	switch status.Code(err) {
+	case codes.InvalidArgument, codes.PermissionDenied, codes.Unauthenticated:
+		return fmt.Errorf("%w: %w", domain.ErrRejected, err)
+	}
+	return err
+}
+
+// toProtoSensorData converts a domain sensor reading to its protobuf message
+func toProtoSensorData(data *domain.SensorData) *pb.SensorData {
+	return &pb.SensorData{
+		ReadingId:   data.ReadingID,
+		SensorValue: float32(data.SensorValue),
+		SensorType:  data.SensorType,
+		Id1:         data.ID1,
+		Id2:         int32(data.ID2),
+		Timestamp:   data.Timestamp,
+	}
+}
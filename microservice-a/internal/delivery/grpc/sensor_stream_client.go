@@ -0,0 +1,212 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"log/slog"
+	"sync"
+	"time"
+
+	pb "sensor_project/proto/sensor_project/proto/sensor"
+
+	"sensor_project/microservice-a/internal/domain"
+
+	"google.golang.org/grpc"
+)
+
+// streamCloseTimeout bounds how long Close waits for the server's final response
+const streamCloseTimeout = 5 * time.Second
+
+// The server confirms the readings of a stream only when it is closed, so a stream is
+// closed and reopened once it carries confirmStreamReadings unconfirmed readings or has
+// been open for confirmStreamInterval
+const (
+	confirmStreamReadings = 500
+	confirmStreamInterval = 10 * time.Second
+)
+
+// SensorStreamClient implements the SensorSender interface over a client stream that
+// is closed and reopened periodically to have the server confirm the readings sent on
+// it. When a stream breaks, every reading it did not confirm is sent again on a new
+// one; reading IDs keep the server from storing any twice. Readings that cannot be
+// sent again are returned in a domain.UndeliveredError.
+type SensorStreamClient struct {
+	client      pb.SensorServiceClient
+	conn        *grpc.ClientConn
+	stream      pb.SensorService_StreamSensorDataClient
+	cancel      context.CancelFunc
+	openedAt    time.Time
+	unconfirmed []*domain.SensorData // oldest first
+	sent        int                  // unconfirmed readings sent on the open stream
+	mu          sync.Mutex
+}
+
+// NewSensorStreamClient creates a new gRPC client that streams sensor data
+func NewSensorStreamClient(serverAddr, apiKey string) (*SensorStreamClient, error) {
+	// Set up a connection to the server
+	conn, err := grpc.Dial(serverAddr, dialOptions(apiKey)...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SensorStreamClient{
+		client: pb.NewSensorServiceClient(conn),
+		conn:   conn,
+	}, nil
+}
+
+// SendSensorData sends a single sensor reading on the open stream. If the stream is
+// broken it is reopened and the unconfirmed readings are sent once more on the new
+// stream; if that fails too they are all returned in a domain.UndeliveredError.
+func (c *SensorStreamClient) SendSensorData(data *domain.SensorData) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.unconfirmed = append(c.unconfirmed, data)
+	return c.deliver(false)
+}
+
+// Flush closes the open stream to have the server confirm the readings sent so far,
+// resending them on a new stream if it is broken. Readings that stay unconfirmed are
+// returned in a domain.UndeliveredError.
+func (c *SensorStreamClient) Flush() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.unconfirmed) == 0 {
+		return nil
+	}
+	return c.deliver(true)
+}
+
+// deliver sends the unconfirmed readings and has the server confirm them when forced
+// to or when they are due, retrying once on a new stream
+func (c *SensorStreamClient) deliver(force bool) error {
+	var lastErr error
+	for attempt := 0; attempt < 2; attempt++ {
+		err := c.sendUnconfirmed()
+		if err == nil && (force || len(c.unconfirmed) >= confirmStreamReadings || time.Since(c.openedAt) >= confirmStreamInterval) {
+			err = c.confirm()
+		}
+		if err == nil {
+			return nil
+		}
+
+		c.resetStream()
+		lastErr = rejection(err)
+		if errors.Is(lastErr, domain.ErrRejected) {
+			// A new stream would be refused the same way
+			break
+		}
+		log.Printf("Sensor data stream broken, reconnecting: %v", err)
+	}
+
+	undelivered := c.unconfirmed
+	c.unconfirmed = nil
+	return &domain.UndeliveredError{Readings: undelivered, Err: lastErr}
+}
+
+// Close finishes the open stream, if any, and closes the gRPC connection. Readings the
+// server does not confirm are returned in a domain.UndeliveredError.
+func (c *SensorStreamClient) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var err error
+	if c.stream != nil {
+		done := make(chan error, 1)
+		go func(stream pb.SensorService_StreamSensorDataClient) {
+			resp, err := stream.CloseAndRecv()
+			if err == nil {
+				slog.Debug("Response from [MICROSERVICE - B]", "response", resp)
+			}
+			done <- err
+		}(c.stream)
+
+		select {
+		case err = <-done:
+		case <-time.After(streamCloseTimeout):
+			err = errors.New("timed out waiting for sensor data stream to close")
+		}
+		if err == nil {
+			c.unconfirmed = c.unconfirmed[c.sent:]
+		}
+		c.resetStream()
+	}
+
+	if err != nil && len(c.unconfirmed) > 0 {
+		err = &domain.UndeliveredError{Readings: c.unconfirmed, Err: rejection(err)}
+		c.unconfirmed = nil
+	}
+	if closeErr := c.conn.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// sendUnconfirmed sends the unconfirmed readings not yet sent on the open stream,
+// opening a stream first if none is open
+func (c *SensorStreamClient) sendUnconfirmed() error {
+	if c.stream == nil {
+		if err := c.openStream(); err != nil {
+			return err
+		}
+	}
+
+	for ; c.sent < len(c.unconfirmed); c.sent++ {
+		err := c.stream.Send(toProtoSensorData(c.unconfirmed[c.sent]))
+		if err == nil {
+			continue
+		}
+		// Send only reports io.EOF on a broken stream; the actual status comes from CloseAndRecv
+		if err == io.EOF {
+			if _, recvErr := c.stream.CloseAndRecv(); recvErr != nil {
+				err = recvErr
+			}
+		}
+		return err
+	}
+	return nil
+}
+
+// confirm closes the open stream and waits for the server to confirm that the readings
+// sent on it were stored. The next send opens a new stream.
+func (c *SensorStreamClient) confirm() error {
+	resp, err := c.stream.CloseAndRecv()
+	if err != nil {
+		return err
+	}
+	slog.Debug("Response from [MICROSERVICE - B]", "response", resp)
+
+	c.unconfirmed = c.unconfirmed[c.sent:]
+	c.resetStream()
+	return nil
+}
+
+// openStream starts a new client stream that lives until it breaks or the client is closed
+func (c *SensorStreamClient) openStream() error {
+	ctx, cancel := context.WithCancel(context.Background())
+	stream, err := c.client.StreamSensorData(ctx)
+	if err != nil {
+		cancel()
+		return err
+	}
+
+	c.stream = stream
+	c.cancel = cancel
+	c.openedAt = time.Now()
+	c.sent = 0
+	return nil
+}
+
+// resetStream discards the current stream so the next send opens a new one
+func (c *SensorStreamClient) resetStream() {
+	if c.cancel != nil {
+		c.cancel()
+	}
+	c.stream = nil
+	c.cancel = nil
+	c.sent = 0
+}
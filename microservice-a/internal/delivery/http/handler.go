@@ -4,6 +4,7 @@ import (
 	"net/http"
 	"time"
 
+	"sensor_project/microservice-a/internal/config"
 	"sensor_project/microservice-a/internal/domain"
 
 	"github.com/labstack/echo/v4"
@@ -15,11 +16,16 @@ type FrequencyRequest struct {
 
 type Handler struct {
 	generator domain.SensorGenerator
+	buffer    domain.SensorBuffer
+	config    *config.Manager
 }
 
-func NewHandler(generator domain.SensorGenerator) *Handler {
+// NewHandler creates a new HTTP handler; buffer may be nil when buffering is disabled
+func NewHandler(generator domain.SensorGenerator, buffer domain.SensorBuffer, configManager *config.Manager) *Handler {
 	return &Handler{
 		generator: generator,
+		buffer:    buffer,
+		config:    configManager,
 	}
 }
 
@@ -30,17 +36,27 @@ func (h *Handler) SetupRoutes(e *echo.Echo) {
 }
 
 func (h *Handler) HealthCheck(c echo.Context) error {
-	return c.JSON(http.StatusOK, map[string]string{
+	response := map[string]interface{}{
 		"status": "ok",
 		"type":   h.generator.GetSensorType(),
-	})
+	}
+	if h.buffer != nil {
+		response["buffer"] = h.buffer.Stats()
+	}
+	return c.JSON(http.StatusOK, response)
 }
 
 func (h *Handler) GetConfig(c echo.Context) error {
-	return c.JSON(http.StatusOK, map[string]interface{}{
+	response := map[string]interface{}{
 		"sensor_type":        h.generator.GetSensorType(),
 		"generation_rate_ms": h.generator.GetGenerationRate().Milliseconds(),
-	})
+		"signal_model":       h.generator.GetSignalModel(),
+		"settings":           h.config.Current().Redacted(),
+	}
+	if h.buffer != nil {
+		response["buffer"] = h.buffer.Stats()
+	}
+	return c.JSON(http.StatusOK, response)
 }
 
 func (h *Handler) SetFrequency(c echo.Context) error {
@@ -1,11 +1,20 @@
 package domain
 
 import (
+	"errors"
+	"fmt"
 	"time"
 )
 
-// SensorData represents a single sensor reading
+// ErrRejected is returned by a sender when the server permanently refuses a reading,
+// for example because the API key is revoked or may not send it; sending it again
+// would fail the same way
+var ErrRejected = errors.New("rejected by server")
+
+// SensorData represents a single sensor reading. ReadingID identifies the reading so
+// the server stores it only once however often it is sent.
 type SensorData struct {
+	ReadingID   string  `json:"reading_id,omitempty"`
 	SensorValue float64 `json:"sensor_value"`
 	SensorType  string  `json:"sensor_type"`
 	ID1         string  `json:"id1"`
@@ -19,6 +28,36 @@ type SensorConfig struct {
 	MinValue       float64       `json:"min_value"`
 	MaxValue       float64       `json:"max_value"`
 	GenerationRate time.Duration `json:"generation_rate_ms"`
+	Signal         SignalConfig  `json:"signal"`
+}
+
+// Signal model names accepted by SignalConfig.Model
+const (
+	SignalUniform    = "uniform"
+	SignalSine       = "sine"
+	SignalRandomWalk = "random_walk"
+	SignalStep       = "step"
+	SignalGaussian   = "gaussian"
+	SignalComposite  = "composite"
+)
+
+// SignalConfig selects the model used to generate sensor values and its parameters.
+// Fields that do not apply to the selected model are ignored.
+type SignalConfig struct {
+	Model      string         `json:"model"`
+	Baseline   float64        `json:"baseline"`
+	Amplitude  float64        `json:"amplitude"`
+	Period     time.Duration  `json:"period"`
+	Phase      time.Duration  `json:"phase"`
+	StepSize   float64        `json:"step_size"`
+	StdDev     float64        `json:"std_dev"`
+	Interval   time.Duration  `json:"interval"`
+	Components []SignalConfig `json:"components,omitempty"`
+}
+
+// SignalModel produces the value of a simulated signal at a point in time
+type SignalModel interface {
+	Value(t time.Time) float64
 }
 
 // SensorGenerator defines the interface for generating sensor data
@@ -27,10 +66,54 @@ type SensorGenerator interface {
 	SetGenerationRate(rate time.Duration)
 	GetGenerationRate() time.Duration
 	GetSensorType() string
+	GetSignalModel() string
 }
 
-// SensorSender defines the interface for sending sensor data
+// SensorSender defines the interface for sending sensor data. A sender may accept a
+// reading before the server confirms it; Flush waits until every accepted reading is
+// confirmed.
 type SensorSender interface {
 	SendSensorData(data *SensorData) error
+	Flush() error
+	Close() error
+}
+
+// UndeliveredError is returned by a sender that cannot confirm the delivery of readings
+// it accepted earlier, besides the one being sent. Readings lists all of them, oldest
+// first, so they can be buffered and resent.
+type UndeliveredError struct {
+	Readings []*SensorData
+	Err      error
+}
+
+// Error describes the failure that left the readings undelivered
+func (e *UndeliveredError) Error() string {
+	return fmt.Sprintf("%d readings undelivered: %v", len(e.Readings), e.Err)
+}
+
+// Unwrap returns the failure that left the readings undelivered
+func (e *UndeliveredError) Unwrap() error {
+	return e.Err
+}
+
+// BufferStats describes the readings waiting in the outbound buffer
+type BufferStats struct {
+	Depth           int   `json:"depth"`
+	SizeBytes       int64 `json:"size_bytes"`
+	OldestTimestamp int64 `json:"oldest_timestamp"`
+	OldestAgeMs     int64 `json:"oldest_age_ms"`
+}
+
+// SensorBuffer defines the interface for durably buffering readings that could not be sent
+type SensorBuffer interface {
+	// Append adds a reading to the end of the buffer
+	Append(data *SensorData) error
+	// Peek returns up to max of the oldest readings without removing them
+	Peek(max int) ([]*SensorData, error)
+	// Ack removes the n oldest readings from the buffer
+	Ack(n int) error
+	// Depth returns the number of buffered readings; it is cheap enough to call per reading
+	Depth() int
+	Stats() BufferStats
 	Close() error
 }
@@ -0,0 +1,507 @@
+package spool
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"sensor_project/microservice-a/internal/domain"
+)
+
+const (
+	segmentSuffix    = ".seg"
+	cursorFileName   = "cursor"
+	recordHeaderSize = 8 // 4-byte payload length followed by 4-byte CRC32 of the payload
+	maxRecordBytes   = 1 << 20
+)
+
+// segment is one append-only file of the spool
+type segment struct {
+	id      uint64
+	size    int64
+	records int // unread records in the segment
+}
+
+// FileSpool implements the SensorBuffer interface as an append-only log of segment
+// files on disk. Every record is checksummed and synced before Append returns, and
+// the read position is kept in a cursor file so buffered readings survive restarts.
+type FileSpool struct {
+	dir             string
+	maxSegmentBytes int64
+	maxTotalBytes   int64
+	segments        []*segment // oldest first; the last one is open for writing
+	writer          *os.File
+	readOffset      int64        // offset of the next unread record in segments[0]
+	depth           atomic.Int64 // changed under mu, read without it by Depth
+	mu              sync.Mutex
+}
+
+// NewFileSpool opens or creates a spool in dir. Segments are rotated once they reach
+// maxSegmentBytes, and the oldest segments are dropped when the spool would exceed
+// maxTotalBytes.
+func NewFileSpool(dir string, maxSegmentBytes, maxTotalBytes int64) (*FileSpool, error) {
+	if maxSegmentBytes <= 0 || maxTotalBytes < maxSegmentBytes {
+		return nil, fmt.Errorf("invalid spool limits: segment %d bytes, total %d bytes", maxSegmentBytes, maxTotalBytes)
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	s := &FileSpool{
+		dir:             dir,
+		maxSegmentBytes: maxSegmentBytes,
+		maxTotalBytes:   maxTotalBytes,
+	}
+
+	if err := s.recover(); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// Append writes a reading to the end of the spool and syncs it to disk
+func (s *FileSpool) Append(data *domain.SensorData) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	record := make([]byte, recordHeaderSize+len(payload))
+	binary.BigEndian.PutUint32(record[0:4], uint32(len(payload)))
+	binary.BigEndian.PutUint32(record[4:8], crc32.ChecksumIEEE(payload))
+	copy(record[recordHeaderSize:], payload)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	active := s.segments[len(s.segments)-1]
+	if active.size > 0 && active.size+int64(len(record)) > s.maxSegmentBytes {
+		if err := s.rotate(); err != nil {
+			return err
+		}
+		active = s.segments[len(s.segments)-1]
+	}
+
+	if _, err := s.writer.Write(record); err != nil {
+		return err
+	}
+	if err := s.writer.Sync(); err != nil {
+		return err
+	}
+
+	active.size += int64(len(record))
+	active.records++
+	s.depth.Add(1)
+
+	return s.enforceSizeLimit()
+}
+
+// Peek returns up to max of the oldest buffered readings without removing them
+func (s *FileSpool) Peek(max int) ([]*domain.SensorData, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var results []*domain.SensorData
+	offset := s.readOffset
+	for _, seg := range s.segments {
+		if len(results) >= max {
+			break
+		}
+
+		f, err := os.Open(s.segmentPath(seg.id))
+		if err != nil {
+			return nil, err
+		}
+
+		for len(results) < max && offset < seg.size {
+			payload, next, err := readRecordAt(f, offset)
+			if err != nil {
+				f.Close()
+				return nil, err
+			}
+
+			var data domain.SensorData
+			if err := json.Unmarshal(payload, &data); err != nil {
+				f.Close()
+				return nil, err
+			}
+			results = append(results, &data)
+			offset = next
+		}
+
+		f.Close()
+		offset = 0
+	}
+
+	return results, nil
+}
+
+// Ack removes the n oldest readings, deleting segments once they are fully consumed
+func (s *FileSpool) Ack(n int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for ; n > 0 && s.depth.Load() > 0; n-- {
+		if err := s.skipConsumedHead(); err != nil {
+			return err
+		}
+		head := s.segments[0]
+
+		f, err := os.Open(s.segmentPath(head.id))
+		if err != nil {
+			return err
+		}
+		_, next, err := readRecordAt(f, s.readOffset)
+		f.Close()
+		if err != nil {
+			return err
+		}
+
+		s.readOffset = next
+		head.records--
+		s.depth.Add(-1)
+	}
+
+	if err := s.skipConsumedHead(); err != nil {
+		return err
+	}
+
+	return s.writeCursor()
+}
+
+// Depth returns the number of buffered readings without touching the disk
+func (s *FileSpool) Depth() int {
+	return int(s.depth.Load())
+}
+
+// Stats reports the number of buffered readings, their size and the age of the oldest one
+func (s *FileSpool) Stats() domain.BufferStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stats := domain.BufferStats{Depth: s.Depth()}
+	for _, seg := range s.segments {
+		stats.SizeBytes += seg.size
+	}
+	stats.SizeBytes -= s.readOffset
+
+	if stats.Depth == 0 {
+		return stats
+	}
+
+	// The oldest unread record may sit in a later segment if the head is fully read
+	offset := s.readOffset
+	for _, seg := range s.segments {
+		if seg.records == 0 {
+			offset = 0
+			continue
+		}
+
+		f, err := os.Open(s.segmentPath(seg.id))
+		if err != nil {
+			return stats
+		}
+		payload, _, err := readRecordAt(f, offset)
+		f.Close()
+		if err != nil {
+			return stats
+		}
+
+		var data domain.SensorData
+		if err := json.Unmarshal(payload, &data); err == nil && data.Timestamp > 0 {
+			stats.OldestTimestamp = data.Timestamp
+			stats.OldestAgeMs = time.Now().UnixMilli() - data.Timestamp
+		}
+		break
+	}
+
+	return stats
+}
+
+// Close closes the segment currently open for writing
+func (s *FileSpool) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.writer.Close()
+}
+
+// recover rebuilds the spool state from the files in the spool directory, truncating
+// any partially written record left behind by a crash
+func (s *FileSpool) recover() error {
+	ids, err := s.listSegments()
+	if err != nil {
+		return err
+	}
+
+	cursorID, cursorOffset, err := s.readCursor()
+	if err != nil {
+		log.Printf("Ignoring unreadable spool cursor, replaying from the oldest segment: %v", err)
+		cursorID, cursorOffset = 0, 0
+	}
+
+	for _, id := range ids {
+		// Segments before the cursor were fully consumed but not yet removed
+		if id < cursorID {
+			if err := os.Remove(s.segmentPath(id)); err != nil {
+				return err
+			}
+			continue
+		}
+
+		from := int64(0)
+		if id == cursorID {
+			from = cursorOffset
+		}
+
+		size, records, err := s.scanSegment(id, from)
+		if err != nil {
+			return err
+		}
+
+		seg := &segment{id: id, size: size, records: records}
+		if len(s.segments) == 0 {
+			s.readOffset = min(from, size)
+		}
+		s.segments = append(s.segments, seg)
+		s.depth.Add(int64(records))
+	}
+
+	if len(s.segments) == 0 {
+		s.segments = append(s.segments, &segment{id: 1})
+		s.readOffset = 0
+	}
+
+	active := s.segments[len(s.segments)-1]
+	writer, err := os.OpenFile(s.segmentPath(active.id), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	s.writer = writer
+
+	if depth := s.Depth(); depth > 0 {
+		log.Printf("Recovered %d buffered sensor readings from %s", depth, s.dir)
+	}
+
+	return nil
+}
+
+// scanSegment validates every record in a segment, truncating the file at the first
+// torn or corrupt record. It returns the valid size and the number of records at or
+// after the from offset.
+func (s *FileSpool) scanSegment(id uint64, from int64) (int64, int, error) {
+	f, err := os.OpenFile(s.segmentPath(id), os.O_RDWR, 0o644)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	var offset int64
+	records := 0
+	for offset < info.Size() {
+		_, next, err := readRecordAt(f, offset)
+		if err != nil {
+			log.Printf("Truncating spool segment %d at offset %d: %v", id, offset, err)
+			if err := f.Truncate(offset); err != nil {
+				return 0, 0, err
+			}
+			if err := f.Sync(); err != nil {
+				return 0, 0, err
+			}
+			break
+		}
+
+		if offset >= from {
+			records++
+		}
+		offset = next
+	}
+
+	return offset, records, nil
+}
+
+// rotate closes the active segment and starts a new one
+func (s *FileSpool) rotate() error {
+	if err := s.writer.Close(); err != nil {
+		return err
+	}
+
+	seg := &segment{id: s.segments[len(s.segments)-1].id + 1}
+	writer, err := os.OpenFile(s.segmentPath(seg.id), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+
+	s.segments = append(s.segments, seg)
+	s.writer = writer
+	return nil
+}
+
+// enforceSizeLimit drops the oldest segments while the spool is over its size limit
+func (s *FileSpool) enforceSizeLimit() error {
+	var total int64
+	for _, seg := range s.segments {
+		total += seg.size
+	}
+
+	dropped := 0
+	for total > s.maxTotalBytes && len(s.segments) > 1 {
+		head := s.segments[0]
+		total -= head.size
+		dropped += head.records
+		s.depth.Add(-int64(head.records))
+		if err := s.dropHead(); err != nil {
+			return err
+		}
+	}
+
+	if dropped > 0 {
+		log.Printf("Spool size limit of %d bytes reached, dropped %d oldest buffered readings", s.maxTotalBytes, dropped)
+		return s.writeCursor()
+	}
+
+	return nil
+}
+
+// skipConsumedHead removes fully read segments that are no longer being written to
+func (s *FileSpool) skipConsumedHead() error {
+	for len(s.segments) > 1 && s.readOffset >= s.segments[0].size {
+		if err := s.dropHead(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// dropHead removes the oldest segment and moves the read position to the next one
+func (s *FileSpool) dropHead() error {
+	if err := os.Remove(s.segmentPath(s.segments[0].id)); err != nil {
+		return err
+	}
+	s.segments = s.segments[1:]
+	s.readOffset = 0
+	return nil
+}
+
+// listSegments returns the IDs of the segment files in the spool directory in order
+func (s *FileSpool) listSegments() ([]uint64, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []uint64
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, segmentSuffix) {
+			continue
+		}
+		id, err := strconv.ParseUint(strings.TrimSuffix(name, segmentSuffix), 10, 64)
+		if err != nil {
+			continue
+		}
+		ids = append(ids, id)
+	}
+
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids, nil
+}
+
+// readCursor loads the persisted read position; a missing cursor means nothing was read yet
+func (s *FileSpool) readCursor() (uint64, int64, error) {
+	content, err := os.ReadFile(filepath.Join(s.dir, cursorFileName))
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, 0, nil
+	}
+	if err != nil {
+		return 0, 0, err
+	}
+
+	var id uint64
+	var offset int64
+	if _, err := fmt.Sscanf(string(content), "%d %d", &id, &offset); err != nil {
+		return 0, 0, err
+	}
+	return id, offset, nil
+}
+
+// writeCursor atomically persists the read position by writing a temporary file and
+// renaming it over the old cursor. If the rename is lost in a crash the older cursor is
+// used and some readings are resent, which is preferable to losing them.
+func (s *FileSpool) writeCursor() error {
+	path := filepath.Join(s.dir, cursorFileName)
+	tmpPath := path + ".tmp"
+
+	f, err := os.Create(tmpPath)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(f, "%d %d\n", s.segments[0].id, s.readOffset); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmpPath, path)
+}
+
+// segmentPath returns the file path of the segment with the given ID
+func (s *FileSpool) segmentPath(id uint64) string {
+	return filepath.Join(s.dir, fmt.Sprintf("%020d%s", id, segmentSuffix))
+}
+
+// readRecordAt reads and verifies the record at offset, returning its payload and the
+// offset of the following record
+func readRecordAt(f *os.File, offset int64) ([]byte, int64, error) {
+	header := make([]byte, recordHeaderSize)
+	if _, err := f.ReadAt(header, offset); err != nil {
+		if err == io.EOF {
+			return nil, 0, io.ErrUnexpectedEOF
+		}
+		return nil, 0, err
+	}
+
+	length := binary.BigEndian.Uint32(header[0:4])
+	checksum := binary.BigEndian.Uint32(header[4:8])
+	if length > maxRecordBytes {
+		return nil, 0, fmt.Errorf("record length %d exceeds limit", length)
+	}
+
+	payload := make([]byte, length)
+	if _, err := f.ReadAt(payload, offset+recordHeaderSize); err != nil {
+		if err == io.EOF {
+			return nil, 0, io.ErrUnexpectedEOF
+		}
+		return nil, 0, err
+	}
+
+	if crc32.ChecksumIEEE(payload) != checksum {
+		return nil, 0, fmt.Errorf("checksum mismatch")
+	}
+
+	return payload, offset + recordHeaderSize + int64(length), nil
+}
@@ -0,0 +1,224 @@
+package spool
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"sensor_project/microservice-a/internal/domain"
+)
+
+// appendReadings appends readings with reading IDs r0 to r(n-1) and returns the offset
+// of the end of each record in the segment
+func appendReadings(t *testing.T, s *FileSpool, n int) []int64 {
+	t.Helper()
+	var ends []int64
+	for i := 0; i < n; i++ {
+		if err := s.Append(&domain.SensorData{ReadingID: fmt.Sprintf("r%d", i), SensorType: "temperature", ID1: "A"}); err != nil {
+			t.Fatalf("append: %v", err)
+		}
+		ends = append(ends, s.segments[len(s.segments)-1].size)
+	}
+	return ends
+}
+
+// peekIDs returns the reading IDs of every buffered reading in order
+func peekIDs(t *testing.T, s *FileSpool) []string {
+	t.Helper()
+	readings, err := s.Peek(1000)
+	if err != nil {
+		t.Fatalf("peek: %v", err)
+	}
+	ids := make([]string, len(readings))
+	for i, data := range readings {
+		ids[i] = data.ReadingID
+	}
+	return ids
+}
+
+func TestFileSpoolRecoversDamagedSegment(t *testing.T) {
+	tests := []struct {
+		name string
+		// damage changes the segment file, given the end offsets of its 5 records
+		damage func(t *testing.T, path string, ends []int64)
+		// ack is the number of readings acknowledged before the damage
+		ack     int
+		wantIDs []string
+	}{
+		{
+			name:    "intact",
+			damage:  func(*testing.T, string, []int64) {},
+			wantIDs: []string{"r0", "r1", "r2", "r3", "r4"},
+		},
+		{
+			name: "torn header",
+			damage: func(t *testing.T, path string, ends []int64) {
+				appendBytes(t, path, []byte{0, 0, 0})
+			},
+			wantIDs: []string{"r0", "r1", "r2", "r3", "r4"},
+		},
+		{
+			name: "torn payload",
+			damage: func(t *testing.T, path string, ends []int64) {
+				truncate(t, path, ends[4]-3)
+			},
+			wantIDs: []string{"r0", "r1", "r2", "r3"},
+		},
+		{
+			name: "corrupt last record",
+			damage: func(t *testing.T, path string, ends []int64) {
+				flipByte(t, path, ends[4]-1)
+			},
+			wantIDs: []string{"r0", "r1", "r2", "r3"},
+		},
+		{
+			name: "corrupt record drops the ones after it",
+			damage: func(t *testing.T, path string, ends []int64) {
+				flipByte(t, path, ends[2]-1)
+			},
+			wantIDs: []string{"r0", "r1"},
+		},
+		{
+			name: "oversized length",
+			damage: func(t *testing.T, path string, ends []int64) {
+				writeAt(t, path, ends[3], []byte{0xff, 0xff, 0xff, 0xff})
+			},
+			wantIDs: []string{"r0", "r1", "r2", "r3"},
+		},
+		{
+			name: "corrupt record after the cursor",
+			damage: func(t *testing.T, path string, ends []int64) {
+				flipByte(t, path, ends[3]-1)
+			},
+			ack:     2,
+			wantIDs: []string{"r2"},
+		},
+		{
+			name: "unreadable cursor replays from the start",
+			damage: func(t *testing.T, path string, ends []int64) {
+				if err := os.WriteFile(filepath.Join(filepath.Dir(path), cursorFileName), []byte("garbage"), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			},
+			ack:     2,
+			wantIDs: []string{"r0", "r1", "r2", "r3", "r4"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			s, err := NewFileSpool(dir, 1<<20, 1<<22)
+			if err != nil {
+				t.Fatal(err)
+			}
+			ends := appendReadings(t, s, 5)
+			if err := s.Ack(tt.ack); err != nil {
+				t.Fatal(err)
+			}
+			path := s.segmentPath(s.segments[0].id)
+			if err := s.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			tt.damage(t, path, ends)
+
+			s, err = NewFileSpool(dir, 1<<20, 1<<22)
+			if err != nil {
+				t.Fatalf("recover: %v", err)
+			}
+			defer s.Close()
+
+			if got := s.Depth(); got != len(tt.wantIDs) {
+				t.Errorf("Depth() = %d, want %d", got, len(tt.wantIDs))
+			}
+			if got := peekIDs(t, s); fmt.Sprint(got) != fmt.Sprint(tt.wantIDs) {
+				t.Errorf("Peek() = %v, want %v", got, tt.wantIDs)
+			}
+
+			// Readings appended after recovery follow the recovered ones
+			if err := s.Append(&domain.SensorData{ReadingID: "new"}); err != nil {
+				t.Fatal(err)
+			}
+			want := append(append([]string{}, tt.wantIDs...), "new")
+			if got := peekIDs(t, s); fmt.Sprint(got) != fmt.Sprint(want) {
+				t.Errorf("Peek() after append = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestFileSpoolDepth(t *testing.T) {
+	s, err := NewFileSpool(t.TempDir(), 1<<20, 1<<22)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	steps := []struct {
+		appendN, ackN int
+		want          int
+	}{
+		{appendN: 3, want: 3},
+		{ackN: 1, want: 2},
+		{appendN: 2, want: 4},
+		{ackN: 10, want: 0},
+	}
+	for i, step := range steps {
+		appendReadings(t, s, step.appendN)
+		if err := s.Ack(step.ackN); err != nil {
+			t.Fatal(err)
+		}
+		if got := s.Depth(); got != step.want {
+			t.Errorf("step %d: Depth() = %d, want %d", i, got, step.want)
+		}
+		if got := s.Stats().Depth; got != step.want {
+			t.Errorf("step %d: Stats().Depth = %d, want %d", i, got, step.want)
+		}
+	}
+}
+
+// truncate cuts a file to size bytes
+func truncate(t *testing.T, path string, size int64) {
+	t.Helper()
+	if err := os.Truncate(path, size); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// flipByte inverts the byte at offset
+func flipByte(t *testing.T, path string, offset int64) {
+	t.Helper()
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeAt(t, path, offset, []byte{^content[offset]})
+}
+
+// writeAt overwrites the file at offset
+func writeAt(t *testing.T, path string, offset int64, b []byte) {
+	t.Helper()
+	f, err := os.OpenFile(path, os.O_WRONLY, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := f.WriteAt(b, offset); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// appendBytes adds bytes to the end of the file
+func appendBytes(t *testing.T, path string, b []byte) {
+	t.Helper()
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := f.Write(b); err != nil {
+		t.Fatal(err)
+	}
+}
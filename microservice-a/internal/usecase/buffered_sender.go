@@ -0,0 +1,205 @@
+package usecase
+
+import (
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"sensor_project/microservice-a/internal/domain"
+)
+
+// drainBatchSize is the number of buffered readings replayed per batch
+const drainBatchSize = 100
+
+// BufferedSender implements the SensorSender interface on top of another sender.
+// Readings that fail to send are appended to a durable buffer and replayed in order
+// once the server is reachable again. Readings the server rejects for good are logged
+// and dropped rather than retried.
+type BufferedSender struct {
+	sender        domain.SensorSender
+	buffer        domain.SensorBuffer
+	retryInterval time.Duration
+	mu            sync.Mutex
+}
+
+// NewBufferedSender creates a sender that spools failed readings into buffer
+func NewBufferedSender(sender domain.SensorSender, buffer domain.SensorBuffer, retryInterval time.Duration) *BufferedSender {
+	return &BufferedSender{
+		sender:        sender,
+		buffer:        buffer,
+		retryInterval: retryInterval,
+	}
+}
+
+// SendSensorData sends a reading, buffering it if it cannot be delivered, along with
+// any earlier readings the sender reports undelivered. While older readings are still
+// buffered, new readings are queued behind them to keep delivery in order.
+func (s *BufferedSender) SendSensorData(data *domain.SensorData) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.buffer.Depth() == 0 {
+		err := s.sender.SendSensorData(data)
+		if err == nil {
+			return nil
+		}
+		return s.keepUndelivered(err, undelivered(err, data))
+	}
+
+	return s.buffer.Append(data)
+}
+
+// Flush has the underlying sender confirm the readings it accepted, buffering the ones
+// it reports undelivered
+func (s *BufferedSender) Flush() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	err := s.sender.Flush()
+	var u *domain.UndeliveredError
+	if errors.As(err, &u) {
+		return s.keepUndelivered(err, u.Readings)
+	}
+	return err
+}
+
+// Run replays buffered readings every retry interval until stopChan is closed
+func (s *BufferedSender) Run(stopChan <-chan struct{}) {
+	ticker := time.NewTicker(s.retryInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stopChan:
+			return
+		case <-ticker.C:
+			if err := s.drain(); err != nil {
+				log.Printf("Failed to replay buffered sensor data: %v", err)
+			}
+		}
+	}
+}
+
+// Close closes the underlying sender and buffer, buffering the readings the sender
+// reports undelivered so they are sent after a restart
+func (s *BufferedSender) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	err := s.sender.Close()
+	var u *domain.UndeliveredError
+	if errors.As(err, &u) {
+		err = s.keepUndelivered(err, u.Readings)
+	}
+	if err != nil {
+		s.buffer.Close()
+		return err
+	}
+	return s.buffer.Close()
+}
+
+// drain sends buffered readings oldest first until the buffer is empty or a send fails
+func (s *BufferedSender) drain() error {
+	for {
+		done, err := s.drainBatch()
+		if err != nil || done {
+			return err
+		}
+	}
+}
+
+// drainBatch replays one batch of buffered readings, reporting whether draining should
+// stop. Readings leave the buffer only once the sender confirms them, so the ones a
+// failure leaves undelivered are still at its head for the next retry.
+func (s *BufferedSender) drainBatch() (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	batch, err := s.buffer.Peek(drainBatchSize)
+	if err != nil {
+		return true, err
+	}
+	if len(batch) == 0 {
+		return true, nil
+	}
+
+	sent := 0
+	var sendErr error
+	for _, data := range batch {
+		if sendErr = s.sender.SendSensorData(data); sendErr != nil {
+			break
+		}
+		sent++
+	}
+
+	// A failed send leaves the failed reading undelivered along with the earlier ones
+	// the sender reports; a failed flush leaves the ones it reports, or all of them
+	confirmed := sent
+	if sendErr != nil {
+		confirmed = sent + 1 - len(undelivered(sendErr, batch[sent]))
+	} else if sendErr = s.sender.Flush(); sendErr != nil {
+		confirmed = 0
+		var u *domain.UndeliveredError
+		if errors.As(sendErr, &u) {
+			confirmed = sent - len(u.Readings)
+		}
+	}
+	confirmed = max(confirmed, 0)
+
+	// Rejected readings are dropped along with the confirmed ones, so they do not block
+	// the readings behind them
+	if errors.Is(sendErr, domain.ErrRejected) {
+		tried := min(sent+1, len(batch))
+		dropRejected(sendErr, batch[confirmed:tried])
+		confirmed, sendErr = tried, nil
+	}
+
+	if confirmed > 0 {
+		if err := s.buffer.Ack(confirmed); err != nil {
+			return true, err
+		}
+		log.Printf("Replayed %d buffered sensor readings", confirmed)
+	}
+
+	// Leave the rest for the next retry while the server is still unreachable
+	return sendErr != nil, nil
+}
+
+// keepUndelivered appends the readings a failed send left undelivered to the buffer,
+// or drops them if the server rejected them
+func (s *BufferedSender) keepUndelivered(err error, readings []*domain.SensorData) error {
+	if errors.Is(err, domain.ErrRejected) {
+		dropRejected(err, readings)
+		return nil
+	}
+	log.Printf("Buffering sensor data after send failure: %v", err)
+	return s.appendAll(readings)
+}
+
+// appendAll appends readings to the buffer in order
+func (s *BufferedSender) appendAll(readings []*domain.SensorData) error {
+	for _, data := range readings {
+		if err := s.buffer.Append(data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// undelivered returns the readings a failed send of data left undelivered, ending with
+// data itself
+func undelivered(err error, data *domain.SensorData) []*domain.SensorData {
+	var u *domain.UndeliveredError
+	if errors.As(err, &u) && len(u.Readings) > 0 && u.Readings[len(u.Readings)-1] == data {
+		return u.Readings
+	}
+	return []*domain.SensorData{data}
+}
+
+// dropRejected logs readings the server rejected for good before they are discarded
+func dropRejected(err error, readings []*domain.SensorData) {
+	for _, data := range readings {
+		log.Printf("Dropping sensor data %+v: %v", *data, err)
+	}
+}
@@ -0,0 +1,199 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+
+	"sensor_project/microservice-a/internal/domain"
+)
+
+// fakeSender accepts readings like the stream client: they stay unconfirmed until a
+// flush, and a failure returns all of them undelivered
+type fakeSender struct {
+	unconfirmed []*domain.SensorData
+	confirmed   []string
+	failSend    string // reading ID whose send fails
+	failFlush   bool
+	reject      bool // failures are permanent rejections
+}
+
+func (f *fakeSender) SendSensorData(data *domain.SensorData) error {
+	f.unconfirmed = append(f.unconfirmed, data)
+	if data.ReadingID == f.failSend {
+		return f.fail()
+	}
+	return nil
+}
+
+func (f *fakeSender) Flush() error {
+	if f.failFlush {
+		return f.fail()
+	}
+	for _, data := range f.unconfirmed {
+		f.confirmed = append(f.confirmed, data.ReadingID)
+	}
+	f.unconfirmed = nil
+	return nil
+}
+
+func (f *fakeSender) Close() error {
+	return f.Flush()
+}
+
+func (f *fakeSender) fail() error {
+	undelivered := f.unconfirmed
+	f.unconfirmed = nil
+	err := errors.New("stream broken")
+	if f.reject {
+		err = fmt.Errorf("%w: permission denied", domain.ErrRejected)
+	}
+	return &domain.UndeliveredError{Readings: undelivered, Err: err}
+}
+
+// fakeBuffer keeps buffered readings in memory
+type fakeBuffer struct {
+	readings []*domain.SensorData
+}
+
+func (b *fakeBuffer) Append(data *domain.SensorData) error {
+	b.readings = append(b.readings, data)
+	return nil
+}
+
+func (b *fakeBuffer) Peek(max int) ([]*domain.SensorData, error) {
+	return slices.Clone(b.readings[:min(max, len(b.readings))]), nil
+}
+
+func (b *fakeBuffer) Ack(n int) error {
+	b.readings = b.readings[min(n, len(b.readings)):]
+	return nil
+}
+
+func (b *fakeBuffer) Depth() int                { return len(b.readings) }
+func (b *fakeBuffer) Stats() domain.BufferStats { return domain.BufferStats{Depth: len(b.readings)} }
+func (b *fakeBuffer) Close() error              { return nil }
+
+// bufferedIDs returns the reading IDs of the buffered readings in order
+func bufferedIDs(b *fakeBuffer) []string {
+	ids := make([]string, len(b.readings))
+	for i, data := range b.readings {
+		ids[i] = data.ReadingID
+	}
+	return ids
+}
+
+func TestBufferedSenderDrainAcksConfirmedReadings(t *testing.T) {
+	tests := []struct {
+		name          string
+		failSend      string
+		failFlush     bool
+		reject        bool
+		wantBuffered  []string
+		wantConfirmed []string
+	}{
+		{
+			name:          "confirmed batch",
+			wantBuffered:  []string{},
+			wantConfirmed: []string{"r0", "r1", "r2", "r3", "r4"},
+		},
+		{
+			name:         "failed send",
+			failSend:     "r3",
+			wantBuffered: []string{"r0", "r1", "r2", "r3", "r4"},
+		},
+		{
+			name:         "failed flush",
+			failFlush:    true,
+			wantBuffered: []string{"r0", "r1", "r2", "r3", "r4"},
+		},
+		{
+			name:          "rejected send drops the undelivered readings",
+			failSend:      "r3",
+			reject:        true,
+			wantBuffered:  []string{},
+			wantConfirmed: []string{"r4"},
+		},
+		{
+			name:         "rejected flush drops the batch",
+			failFlush:    true,
+			reject:       true,
+			wantBuffered: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sender := &fakeSender{failSend: tt.failSend, failFlush: tt.failFlush, reject: tt.reject}
+			buffer := &fakeBuffer{}
+			for i := 0; i < 5; i++ {
+				buffer.Append(&domain.SensorData{ReadingID: fmt.Sprintf("r%d", i)})
+			}
+			s := NewBufferedSender(sender, buffer, 0)
+
+			if err := s.drain(); err != nil {
+				t.Fatal(err)
+			}
+
+			// Unconfirmed readings stay at the head of the buffer, in order
+			if got := bufferedIDs(buffer); !slices.Equal(got, tt.wantBuffered) {
+				t.Errorf("buffered = %v, want %v", got, tt.wantBuffered)
+			}
+			if !slices.Equal(sender.confirmed, tt.wantConfirmed) {
+				t.Errorf("confirmed = %v, want %v", sender.confirmed, tt.wantConfirmed)
+			}
+		})
+	}
+}
+
+func TestBufferedSenderBuffersUndeliveredReadings(t *testing.T) {
+	sender := &fakeSender{failSend: "r2"}
+	buffer := &fakeBuffer{}
+	s := NewBufferedSender(sender, buffer, 0)
+
+	for i := 0; i < 4; i++ {
+		s.SendSensorData(&domain.SensorData{ReadingID: fmt.Sprintf("r%d", i)})
+	}
+
+	// The readings accepted before the failure are buffered ahead of the failed one,
+	// and later readings queue behind them
+	want := []string{"r0", "r1", "r2", "r3"}
+	if got := bufferedIDs(buffer); !slices.Equal(got, want) {
+		t.Fatalf("buffered = %v, want %v", got, want)
+	}
+
+	sender.failSend = ""
+	if err := s.drain(); err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(sender.confirmed, want) {
+		t.Errorf("confirmed = %v, want %v", sender.confirmed, want)
+	}
+	if buffer.Depth() != 0 {
+		t.Errorf("Depth() = %d after drain, want 0", buffer.Depth())
+	}
+}
+
+func TestBufferedSenderDropsRejectedReadings(t *testing.T) {
+	sender := &fakeSender{failSend: "r1", reject: true}
+	buffer := &fakeBuffer{}
+	s := NewBufferedSender(sender, buffer, 0)
+
+	for i := 0; i < 3; i++ {
+		if err := s.SendSensorData(&domain.SensorData{ReadingID: fmt.Sprintf("r%d", i)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Rejected readings are not buffered for a retry that would fail the same way
+	if buffer.Depth() != 0 {
+		t.Errorf("buffered = %v, want none", bufferedIDs(buffer))
+	}
+	if want := []string{"r2"}; !slices.Equal(sender.confirmed, want) {
+		t.Errorf("confirmed = %v, want %v", sender.confirmed, want)
+	}
+}
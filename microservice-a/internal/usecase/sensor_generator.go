@@ -1,6 +1,8 @@
 package usecase
 
 import (
+	crand "crypto/rand"
+	"fmt"
 	"math/rand"
 	"sync"
 	"time"
@@ -14,17 +16,31 @@ type DefaultSensorGenerator struct {
 	minValue       float64
 	maxValue       float64
 	generationRate time.Duration
+	signalModel    string
+	signal         domain.SignalModel
 	mu             sync.RWMutex
 }
 
 // NewSensorGenerator creates a new sensor generator with the given configuration
-func NewSensorGenerator(config domain.SensorConfig) domain.SensorGenerator {
+func NewSensorGenerator(config domain.SensorConfig) (domain.SensorGenerator, error) {
+	signal, err := NewSignalModel(config.Signal, config.MinValue, config.MaxValue)
+	if err != nil {
+		return nil, err
+	}
+
+	signalModel := config.Signal.Model
+	if signalModel == "" {
+		signalModel = domain.SignalUniform
+	}
+
 	return &DefaultSensorGenerator{
 		sensorType:     config.SensorType,
 		minValue:       config.MinValue,
 		maxValue:       config.MaxValue,
 		generationRate: config.GenerationRate,
-	}
+		signalModel:    signalModel,
+		signal:         signal,
+	}, nil
 }
 
 // GenerateSensorData generates a new sensor reading
@@ -32,8 +48,10 @@ func (g *DefaultSensorGenerator) GenerateSensorData() *domain.SensorData {
 	g.mu.RLock()
 	defer g.mu.RUnlock()
 
-	// Generate random sensor value between min and max
-	value := g.minValue + rand.Float64()*(g.maxValue-g.minValue)
+	now := time.Now()
+
+	// Sample the configured signal model and keep the value within min and max
+	value := clamp(g.signal.Value(now), g.minValue, g.maxValue)
 
 	// Generate random ID1 (capital letters)
 	id1 := string(rune('A' + rand.Intn(26)))
@@ -42,14 +60,24 @@ func (g *DefaultSensorGenerator) GenerateSensorData() *domain.SensorData {
 	id2 := rand.Intn(100)
 
 	return &domain.SensorData{
+		ReadingID:   newReadingID(),
 		SensorValue: value,
 		SensorType:  g.sensorType,
 		ID1:         id1,
 		ID2:         id2,
-		Timestamp:   time.Now().UnixMilli(),
+		Timestamp:   now.UnixMilli(),
 	}
 }
 
+// newReadingID returns a random (version 4) UUID identifying a reading
+func newReadingID() string {
+	var b [16]byte
+	crand.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40 // version 4
+	b[8] = b[8]&0x3f | 0x80 // RFC 4122 variant
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
 // SetGenerationRate updates the data generation rate
 func (g *DefaultSensorGenerator) SetGenerationRate(rate time.Duration) {
 	g.mu.Lock()
@@ -68,3 +96,8 @@ func (g *DefaultSensorGenerator) GetGenerationRate() time.Duration {
 func (g *DefaultSensorGenerator) GetSensorType() string {
 	return g.sensorType
 }
+
+// GetSignalModel returns the name of the configured signal model
+func (g *DefaultSensorGenerator) GetSignalModel() string {
+	return g.signalModel
+}
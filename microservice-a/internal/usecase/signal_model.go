@@ -0,0 +1,184 @@
+package usecase
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+
+	"sensor_project/microservice-a/internal/domain"
+)
+
+const (
+	defaultSinePeriod   = 24 * time.Hour
+	defaultStepInterval = time.Minute
+)
+
+// NewSignalModel builds the signal model selected by the configuration.
+// Values produced by stateful models stay within [minValue, maxValue].
+func NewSignalModel(config domain.SignalConfig, minValue, maxValue float64) (domain.SignalModel, error) {
+	switch config.Model {
+	case "", domain.SignalUniform:
+		return &UniformSignal{minValue: minValue, maxValue: maxValue}, nil
+	case domain.SignalSine:
+		period := config.Period
+		if period <= 0 {
+			period = defaultSinePeriod
+		}
+		return &SineSignal{
+			baseline:  config.Baseline,
+			amplitude: config.Amplitude,
+			period:    period,
+			phase:     config.Phase,
+		}, nil
+	case domain.SignalRandomWalk:
+		stepSize := config.StepSize
+		if stepSize <= 0 {
+			stepSize = (maxValue - minValue) / 100
+		}
+		return &RandomWalkSignal{
+			current:  clamp(config.Baseline, minValue, maxValue),
+			stepSize: stepSize,
+			minValue: minValue,
+			maxValue: maxValue,
+		}, nil
+	case domain.SignalStep:
+		interval := config.Interval
+		if interval <= 0 {
+			interval = defaultStepInterval
+		}
+		return &StepSignal{
+			level:    clamp(config.Baseline, minValue, maxValue),
+			interval: interval,
+			minValue: minValue,
+			maxValue: maxValue,
+		}, nil
+	case domain.SignalGaussian:
+		return &GaussianSignal{baseline: config.Baseline, stdDev: config.StdDev}, nil
+	case domain.SignalComposite:
+		if len(config.Components) == 0 {
+			return nil, fmt.Errorf("composite signal requires at least one component")
+		}
+		components := make([]domain.SignalModel, 0, len(config.Components))
+		for _, componentConfig := range config.Components {
+			component, err := NewSignalModel(componentConfig, minValue, maxValue)
+			if err != nil {
+				return nil, err
+			}
+			components = append(components, component)
+		}
+		return &CompositeSignal{components: components}, nil
+	default:
+		return nil, fmt.Errorf("unknown signal model %q", config.Model)
+	}
+}
+
+// UniformSignal produces independent values uniformly distributed between min and max
+type UniformSignal struct {
+	minValue float64
+	maxValue float64
+}
+
+// Value returns a random value between min and max
+func (s *UniformSignal) Value(_ time.Time) float64 {
+	return s.minValue + rand.Float64()*(s.maxValue-s.minValue)
+}
+
+// SineSignal produces a periodic signal, e.g. a daily temperature cycle
+type SineSignal struct {
+	baseline  float64
+	amplitude float64
+	period    time.Duration
+	phase     time.Duration
+}
+
+// Value returns the sine wave value at the given time
+func (s *SineSignal) Value(t time.Time) float64 {
+	elapsed := time.Duration(t.UnixNano()) + s.phase
+	angle := 2 * math.Pi * float64(elapsed%s.period) / float64(s.period)
+	return s.baseline + s.amplitude*math.Sin(angle)
+}
+
+// RandomWalkSignal produces a value that drifts by a bounded random step on every call
+type RandomWalkSignal struct {
+	current  float64
+	stepSize float64
+	minValue float64
+	maxValue float64
+	mu       sync.Mutex
+}
+
+// Value advances the walk by one step and returns the new value
+func (s *RandomWalkSignal) Value(_ time.Time) float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	next := s.current + (rand.Float64()*2-1)*s.stepSize
+
+	// Reflect off the bounds so the walk does not stick to them
+	if next > s.maxValue {
+		next = 2*s.maxValue - next
+	}
+	if next < s.minValue {
+		next = 2*s.minValue - next
+	}
+	s.current = clamp(next, s.minValue, s.maxValue)
+
+	return s.current
+}
+
+// StepSignal holds a level for a fixed interval and then jumps to a new random level
+type StepSignal struct {
+	level     float64
+	interval  time.Duration
+	changedAt time.Time
+	minValue  float64
+	maxValue  float64
+	mu        sync.Mutex
+}
+
+// Value returns the current level, changing it once the interval has elapsed
+func (s *StepSignal) Value(t time.Time) float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.changedAt.IsZero() {
+		s.changedAt = t
+	} else if t.Sub(s.changedAt) >= s.interval {
+		s.level = s.minValue + rand.Float64()*(s.maxValue-s.minValue)
+		s.changedAt = t
+	}
+
+	return s.level
+}
+
+// GaussianSignal produces normally distributed noise around a baseline
+type GaussianSignal struct {
+	baseline float64
+	stdDev   float64
+}
+
+// Value returns the baseline plus Gaussian noise
+func (s *GaussianSignal) Value(_ time.Time) float64 {
+	return s.baseline + rand.NormFloat64()*s.stdDev
+}
+
+// CompositeSignal sums the values of its components, e.g. a sine cycle plus Gaussian noise
+type CompositeSignal struct {
+	components []domain.SignalModel
+}
+
+// Value returns the sum of all component values at the given time
+func (s *CompositeSignal) Value(t time.Time) float64 {
+	var total float64
+	for _, component := range s.components {
+		total += component.Value(t)
+	}
+	return total
+}
+
+// clamp limits value to the range [minValue, maxValue]
+func clamp(value, minValue, maxValue float64) float64 {
+	return math.Max(minValue, math.Min(maxValue, value))
+}
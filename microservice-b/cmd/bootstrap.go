@@ -0,0 +1,61 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+
+	"sensor_project/microservice-b/internal/config"
+	"sensor_project/microservice-b/internal/domain"
+	"sensor_project/microservice-b/internal/repository/mysql"
+	"sensor_project/microservice-b/internal/usecase"
+)
+
+// bootstrapCommand is the command line argument running bootstrapAdmin instead of the
+// service
+const bootstrapCommand = "bootstrap-admin"
+
+// bootstrapAdmin creates the first admin from the ADMIN_USERNAME, ADMIN_EMAIL and
+// ADMIN_PASSWORD environment variables, using the database of the service's
+// configuration. It does nothing if an active admin already exists, so it is safe to
+// run on every deploy.
+func bootstrapAdmin(args []string) {
+	logger := log.New(os.Stdout, "[MICROSERVICE-B]", log.LstdFlags)
+
+	cfg, err := config.LoadConfig(args)
+	if err != nil {
+		logger.Fatalf("Failed to load configuration: %v", err)
+	}
+
+	db, err := connectToDatabase(cfg)
+	if err != nil {
+		logger.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer db.Close()
+
+	userRepo := mysql.NewMySQLUserRepository(db)
+	admins, err := userRepo.CountActiveWithRole(domain.RoleAdmin)
+	if err != nil {
+		logger.Fatalf("Failed to count admins: %v", err)
+	}
+	if admins > 0 {
+		logger.Println("An active admin already exists, nothing to do")
+		return
+	}
+
+	user := &domain.User{
+		Username: os.Getenv("ADMIN_USERNAME"),
+		Email:    os.Getenv("ADMIN_EMAIL"),
+		Roles:    []string{domain.RoleAdmin},
+	}
+
+	err = usecase.NewUserUseCase(userRepo).Create(user, os.Getenv("ADMIN_PASSWORD"))
+	if errors.Is(err, domain.ErrUserExists) {
+		err = fmt.Errorf("%w; give the existing user the admin role or choose another ADMIN_USERNAME and ADMIN_EMAIL", err)
+	}
+	if err != nil {
+		logger.Fatalf("Failed to create admin: %v", err)
+	}
+	logger.Printf("Created admin %s with ID %d", user.Username, user.ID)
+}
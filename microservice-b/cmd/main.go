@@ -5,10 +5,12 @@ import (
 	"database/sql"
 	"fmt"
 	"log"
+	"log/slog"
 	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
@@ -26,8 +28,23 @@ import (
 )
 
 func main() {
+	// Create the first admin and exit if asked to
+	if len(os.Args) > 1 && os.Args[1] == bootstrapCommand {
+		bootstrapAdmin(os.Args[2:])
+		return
+	}
+
 	// Load configuration
-	cfg := config.LoadConfig()
+	configManager, err := config.NewManager(os.Args[1:])
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+	cfg := configManager.Current()
+
+	// Set up leveled logging for the request and ingest paths
+	logLevel := new(slog.LevelVar)
+	logLevel.Set(cfg.SlogLevel())
+	slog.SetDefault(slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: logLevel})))
 
 	// Set up logger
 	logger := log.New(os.Stdout, "[MICROSERVICE-B]", log.LstdFlags)
@@ -43,17 +60,61 @@ func main() {
 
 	logger.Println("Database connection established")
 
-	// Initialize repository
+	// Initialize repositories
 	sensorRepo := mysql.NewMySQLSensorRepository(db)
+	sensorTypeRepo := mysql.NewMySQLSensorTypeRepository(db)
+	userRepo := mysql.NewMySQLUserRepository(db)
+	apiKeyRepo := mysql.NewMySQLAPIKeyRepository(db)
+	auditRepo := mysql.NewMySQLAuditRepository(db)
+	retentionRepo := mysql.NewMySQLRetentionRepository(db)
+
+	// Rollups are only read when they are kept up to date
+	var rollupRepo domain.RollupRepository
+	if cfg.RollupInterval > 0 {
+		rollupRepo = mysql.NewMySQLRollupRepository(db)
+	}
 
-	// Initialize use case
-	sensorUseCase := usecase.NewSensorDataUseCase(sensorRepo)
+	// Coalesce ingested readings into multi-row INSERTs; readings still queued at
+	// shutdown are written before the database is closed
+	writer := usecase.NewWritePipeline(sensorRepo, cfg.WriteBatchSize, cfg.WriteBatchDelay, cfg.WriteQueueSize, cfg.WriteWorkers, cfg.WriteQueueTimeout)
+	defer writer.Close()
+
+	// Readings keep the ingest time they were received at while they wait for the write
+	// pipeline, so they are stored up to the longest wait for room and a batch, plus the
+	// time allowed for in-flight inserts, after it
+	writeLag := cfg.RollupLag + cfg.WriteBatchDelay + cfg.WriteQueueTimeout
+
+	// Initialize use cases
+	feed := usecase.NewLiveFeed(cfg.StreamBufferSize)
+	sensorUseCase := usecase.NewSensorDataUseCase(sensorRepo, sensorTypeRepo, rollupRepo, feed, writer, cfg.IngestPolicy)
+	sensorTypeUseCase := usecase.NewSensorTypeUseCase(sensorTypeRepo)
+	retentionUseCase := usecase.NewRetentionUseCase(retentionRepo, cfg.RetentionInterval, cfg.RetentionBatchSize, cfg.RetentionBatchPause, cfg.DeleteGracePeriod)
+	importUseCase := usecase.NewImportUseCase(sensorRepo, sensorTypeRepo, cfg.IngestPolicy, cfg.ImportBatchSize)
+	deleteUseCase := usecase.NewDeleteUseCase(sensorRepo, cfg.DeleteMaxRows, cfg.DeleteBatchSize, cfg.DeleteGracePeriod)
+	streamUseCase := usecase.NewStreamUseCase(sensorRepo, feed, cfg.StreamReplayLimit, writeLag)
+	authUseCase := usecase.NewAuthUseCase(userRepo, cfg.JWTSecret, cfg.TokenExpiry, cfg.RefreshTokenExpiry)
+	apiKeyUseCase := usecase.NewAPIKeyUseCase(apiKeyRepo, userRepo)
+	userUseCase := usecase.NewUserUseCase(userRepo)
+	auditUseCase := usecase.NewAuditUseCase(auditRepo)
+
+	// Maintain rollups in the background until shutdown
+	stopChan := make(chan struct{})
+	defer close(stopChan)
+	if rollupRepo != nil {
+		rollupBuilder := usecase.NewRollupBuilder(rollupRepo, retentionRepo, cfg.RollupInterval, writeLag)
+		go rollupBuilder.Run(stopChan)
+	}
+
+	// Purge data past its retention in the background until shutdown
+	if cfg.RetentionInterval > 0 {
+		go retentionUseCase.Run(stopChan)
+	}
 
 	// Start gRPC server in a goroutine
-	go startGRPCServer(cfg, sensorUseCase, logger)
+	go startGRPCServer(cfg, sensorUseCase, sensorTypeUseCase, importUseCase, streamUseCase, authUseCase, apiKeyUseCase, logger)
 
 	// Start HTTP server
-	startHTTPServer(cfg, sensorUseCase, logger)
+	startHTTPServer(configManager, sensorUseCase, sensorTypeUseCase, retentionUseCase, importUseCase, deleteUseCase, streamUseCase, authUseCase, apiKeyUseCase, userUseCase, auditUseCase, logLevel, logger)
 }
 
 func connectToDatabase(cfg *config.Config) (*sql.DB, error) {
@@ -77,16 +138,25 @@ func connectToDatabase(cfg *config.Config) (*sql.DB, error) {
 	return db, nil
 }
 
-func startGRPCServer(cfg *config.Config, sensorUseCase domain.SensorDataUseCase, logger *log.Logger) {
+func startGRPCServer(cfg *config.Config, sensorUseCase domain.SensorDataUseCase, sensorTypeUseCase domain.SensorTypeUseCase, importUseCase domain.ImportUseCase, streamUseCase domain.StreamUseCase, authUseCase domain.AuthUseCase, apiKeyUseCase domain.APIKeyUseCase, logger *log.Logger) {
 	lis, err := net.Listen("tcp", fmt.Sprintf(":%s", cfg.GRPCPort))
 	if err != nil {
 		logger.Fatalf("Failed to listen for gRPC: %v", err)
 	}
 
-	grpcServer := grpcPkg.NewServer()
+	// Every call is authenticated, by API key or JWT, before it reaches the servers
+	authenticator := grpcDelivery.NewAuthenticator(authUseCase, apiKeyUseCase, cfg.RequireIngestAPIKey)
+	grpcServer := grpcPkg.NewServer(
+		grpcPkg.UnaryInterceptor(authenticator.UnaryInterceptor()),
+		grpcPkg.StreamInterceptor(authenticator.StreamInterceptor()),
+	)
 	// Use the delivery layer's gRPC server adapter which implements the generated interface
-	sensorServer := grpcDelivery.NewSensorServer(sensorUseCase)
+	sensorServer := grpcDelivery.NewSensorServer(sensorUseCase, streamUseCase, cfg.MaxIngestBatchSize)
 	sensorServer.RegisterServer(grpcServer)
+	sensorTypeServer := grpcDelivery.NewSensorTypeServer(sensorTypeUseCase)
+	sensorTypeServer.RegisterServer(grpcServer)
+	importServer := grpcDelivery.NewImportServer(importUseCase)
+	importServer.RegisterServer(grpcServer)
 
 	logger.Printf("gRPC server starting on port %s", cfg.GRPCPort)
 	if err := grpcServer.Serve(lis); err != nil {
@@ -94,7 +164,9 @@ func startGRPCServer(cfg *config.Config, sensorUseCase domain.SensorDataUseCase,
 	}
 }
 
-func startHTTPServer(cfg *config.Config, sensorUseCase domain.SensorDataUseCase, logger *log.Logger) {
+func startHTTPServer(configManager *config.Manager, sensorUseCase domain.SensorDataUseCase, sensorTypeUseCase domain.SensorTypeUseCase, retentionUseCase domain.RetentionUseCase, importUseCase domain.ImportUseCase, deleteUseCase domain.DeleteUseCase, streamUseCase domain.StreamUseCase, authUseCase domain.AuthUseCase, apiKeyUseCase domain.APIKeyUseCase, userUseCase domain.UserUseCase, auditUseCase domain.AuditUseCase, logLevel *slog.LevelVar, logger *log.Logger) {
+	cfg := configManager.Current()
+
 	// create Echo instance and wire up routes using the HTTP delivery layer
 	// Create Echo instance
 	e := echo.New()
@@ -103,10 +175,28 @@ func startHTTPServer(cfg *config.Config, sensorUseCase domain.SensorDataUseCase,
 	e.Use(middleware.Logger())
 	e.Use(middleware.Recover())
 	e.Use(middleware.CORS())
+	e.Use(httpDelivery.Authenticate(authUseCase, apiKeyUseCase))
 
 	// Initialize HTTP handler and setup routes
-	httpHandler := httpDelivery.NewHandler(sensorUseCase)
+	authHandler := httpDelivery.NewAuthHandler(authUseCase)
+	authHandler.SetupRoutes(e)
+	apiKeyHandler := httpDelivery.NewAPIKeyHandler(apiKeyUseCase)
+	apiKeyHandler.SetupRoutes(e)
+	userHandler := httpDelivery.NewUserHandler(userUseCase)
+	userHandler.SetupRoutes(e)
+	auditHandler := httpDelivery.NewAuditHandler(auditUseCase)
+	auditHandler.SetupRoutes(e)
+	httpHandler := httpDelivery.NewHandler(sensorUseCase, deleteUseCase, configManager)
 	httpHandler.SetupRoutes(e)
+	sensorTypeHandler := httpDelivery.NewSensorTypeHandler(sensorTypeUseCase)
+	sensorTypeHandler.SetupRoutes(e)
+	retentionHandler := httpDelivery.NewRetentionHandler(retentionUseCase)
+	retentionHandler.SetupRoutes(e)
+	importHandler := httpDelivery.NewImportHandler(importUseCase)
+	importHandler.SetupRoutes(e)
+	streamHandler := httpDelivery.NewStreamHandler(streamUseCase)
+	streamHandler.SetupRoutes(e)
+	e.Server.RegisterOnShutdown(streamHandler.Shutdown)
 
 	// Start server in a goroutine
 	go func() {
@@ -117,10 +207,16 @@ func startHTTPServer(cfg *config.Config, sensorUseCase domain.SensorDataUseCase,
 		}
 	}()
 
-	// Wait for interrupt signal to gracefully shut down the server
+	// Wait for interrupt signal to gracefully shut down the server, reloading the
+	// configuration on SIGHUP
 	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-	<-quit
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
+	for sig := range quit {
+		if sig != syscall.SIGHUP {
+			break
+		}
+		reloadConfig(configManager, logLevel, logger)
+	}
 
 	// Graceful shutdown
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
@@ -130,3 +226,20 @@ func startHTTPServer(cfg *config.Config, sensorUseCase domain.SensorDataUseCase,
 	}
 	logger.Println("Server gracefully stopped")
 }
+
+// reloadConfig re-reads the configuration and applies the settings that are safe to
+// change at runtime
+func reloadConfig(configManager *config.Manager, logLevel *slog.LevelVar, logger *log.Logger) {
+	cfg, restartRequired, err := configManager.Reload()
+	if err != nil {
+		logger.Printf("Failed to reload configuration, keeping current settings: %v", err)
+		return
+	}
+
+	logLevel.Set(cfg.SlogLevel())
+
+	if len(restartRequired) > 0 {
+		logger.Printf("Changes to %s take effect after a restart", strings.Join(restartRequired, ", "))
+	}
+	logger.Println("Configuration reloaded")
+}
@@ -1,9 +1,19 @@
 package config
 
 import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
 	"time"
+
+	"sensor_project/microservice-b/internal/domain"
 )
 
+// maxInsertBatchSize keeps a multi-row INSERT below MySQL's limit of 65535 placeholders
+const maxInsertBatchSize = 5000
+
 // Config holds the application configuration
 type Config struct {
 	ServerPort     string
@@ -14,30 +24,260 @@ type Config struct {
 	DBPassword     string
 	DBName         string
 	JWTSecret      string
-	TokenExpiry    time.Duration
 	MaxConnections int
+	LogLevel       string
+
+	// Lifetimes of the access tokens issued at login and of the refresh tokens that
+	// renew them
+	TokenExpiry        time.Duration
+	RefreshTokenExpiry time.Duration
+
+	// Whether readings sent over gRPC must carry an API key
+	RequireIngestAPIKey bool
+
+	// Most readings accepted by one SendSensorDataBatch call
+	MaxIngestBatchSize int
+
+	// Readings are written in multi-row INSERTs of up to WriteBatchSize rows, each
+	// written at most WriteBatchDelay after its first reading arrived, by WriteWorkers
+	// concurrent writers. At most WriteQueueSize readings wait to be written; further
+	// readings wait up to WriteQueueTimeout for room before they are refused.
+	WriteBatchSize    int
+	WriteBatchDelay   time.Duration
+	WriteWorkers      int
+	WriteQueueSize    int
+	WriteQueueTimeout time.Duration
+
+	// Rules for accepting readings at ingest
+	IngestPolicy domain.IngestPolicy
+
+	// Background maintenance of pre-computed rollups; a zero RollupInterval disables them
+	RollupInterval time.Duration
+	RollupLag      time.Duration
+
+	// Scheduled purging of data past its retention; a zero RetentionInterval disables it
+	RetentionInterval   time.Duration
+	RetentionBatchSize  int
+	RetentionBatchPause time.Duration
+
+	// Rows per multi-row INSERT when importing files
+	ImportBatchSize int
+
+	// Deletes by filter matching more than DeleteMaxRows records run in the background,
+	// DeleteBatchSize records per statement. Deleted records can be restored until the
+	// retention run purges them DeleteGracePeriod after their deletion.
+	DeleteMaxRows     int
+	DeleteBatchSize   int
+	DeleteGracePeriod time.Duration
+
+	// Live streams buffer StreamBufferSize readings per subscriber and replay at most
+	// StreamReplayLimit missed readings on resume
+	StreamBufferSize  int
+	StreamReplayLimit int
 }
 
-// LoadConfig loads the configuration from environment variables
-func LoadConfig() *Config {
-	// Default values
-	config := &Config{
+// DefaultConfig returns the configuration used when no other source sets a value.
+// Secrets have no defaults and must be provided through the file, environment or flags.
+func DefaultConfig() *Config {
+	return &Config{
 		ServerPort:     "8080",
 		GRPCPort:       "50051",
 		DBHost:         "mysql",
 		DBPort:         "3306",
 		DBUser:         "root",
-		DBPassword:     "Sameer@0998$B@",
 		DBName:         "sensor_data",
-		JWTSecret:      "default_jwt_secret",
-		TokenExpiry:    24 * time.Hour,
 		MaxConnections: 10,
+		LogLevel:       "info",
+
+		TokenExpiry:        24 * time.Hour,
+		RefreshTokenExpiry: 7 * 24 * time.Hour,
+
+		RequireIngestAPIKey: true,
+
+		MaxIngestBatchSize: 1000,
+
+		WriteBatchSize:    500,
+		WriteBatchDelay:   10 * time.Millisecond,
+		WriteWorkers:      4,
+		WriteQueueSize:    10000,
+		WriteQueueTimeout: 5 * time.Second,
+
+		IngestPolicy: domain.IngestPolicy{
+			EventTime: domain.EventTimePolicy{
+				ClockSkewTolerance: 30 * time.Second,
+				FutureAction:       domain.EventTimeReject,
+				PastAction:         domain.EventTimeReject,
+			},
+			UnknownSensorType: domain.UnknownTypeReject,
+		},
+		RollupInterval: time.Minute,
+		RollupLag:      10 * time.Second,
+
+		RetentionInterval:   time.Hour,
+		RetentionBatchSize:  1000,
+		RetentionBatchPause: 100 * time.Millisecond,
+
+		ImportBatchSize: 500,
+
+		DeleteMaxRows:     10000,
+		DeleteBatchSize:   1000,
+		DeleteGracePeriod: 7 * 24 * time.Hour,
+
+		StreamBufferSize:  256,
+		StreamReplayLimit: 10000,
+	}
+}
+
+// Validate checks the configuration and reports every problem found
+func (c *Config) Validate() error {
+	var errs []error
+
+	if err := validatePort(c.ServerPort); err != nil {
+		errs = append(errs, fmt.Errorf("server_port: %w", err))
+	}
+	if err := validatePort(c.GRPCPort); err != nil {
+		errs = append(errs, fmt.Errorf("grpc_port: %w", err))
+	}
+	if c.DBHost == "" {
+		errs = append(errs, errors.New("db_host: must not be empty"))
+	}
+	if err := validatePort(c.DBPort); err != nil {
+		errs = append(errs, fmt.Errorf("db_port: %w", err))
+	}
+	if c.DBUser == "" {
+		errs = append(errs, errors.New("db_user: must not be empty"))
+	}
+	if c.DBName == "" {
+		errs = append(errs, errors.New("db_name: must not be empty"))
+	}
+	if c.JWTSecret == "" {
+		errs = append(errs, errors.New("jwt_secret: must be set"))
+	}
+	if c.TokenExpiry <= 0 {
+		errs = append(errs, errors.New("token_expiry_hours: must be positive"))
+	}
+	if c.RefreshTokenExpiry <= 0 {
+		errs = append(errs, errors.New("refresh_token_expiry_hours: must be positive"))
+	}
+	if c.MaxConnections <= 0 {
+		errs = append(errs, errors.New("max_connections: must be positive"))
+	}
+	if c.MaxIngestBatchSize <= 0 {
+		errs = append(errs, errors.New("max_ingest_batch_size: must be positive"))
+	}
+	if c.WriteBatchSize <= 0 || c.WriteBatchSize > maxInsertBatchSize {
+		errs = append(errs, fmt.Errorf("write_batch_size: must be between 1 and %d", maxInsertBatchSize))
+	}
+	if c.WriteBatchDelay <= 0 {
+		errs = append(errs, errors.New("write_batch_delay: must be positive"))
+	}
+	if c.WriteWorkers <= 0 || c.WriteWorkers > c.MaxConnections {
+		errs = append(errs, errors.New("write_workers: must be positive and at most max_connections"))
+	}
+	if c.WriteQueueSize <= 0 {
+		errs = append(errs, errors.New("write_queue_size: must be positive"))
+	}
+	if c.WriteQueueTimeout < 0 {
+		errs = append(errs, errors.New("write_queue_timeout: must not be negative"))
+	}
+	if _, err := parseLogLevel(c.LogLevel); err != nil {
+		errs = append(errs, fmt.Errorf("log_level: %w", err))
+	}
+	if c.IngestPolicy.EventTime.ClockSkewTolerance < 0 {
+		errs = append(errs, errors.New("clock_skew_tolerance: must not be negative"))
+	}
+	if c.IngestPolicy.EventTime.MaxEventAge < 0 {
+		errs = append(errs, errors.New("max_event_age: must not be negative"))
+	}
+	if err := validateEventTimeAction(c.IngestPolicy.EventTime.FutureAction); err != nil {
+		errs = append(errs, fmt.Errorf("future_event_action: %w", err))
+	}
+	if err := validateEventTimeAction(c.IngestPolicy.EventTime.PastAction); err != nil {
+		errs = append(errs, fmt.Errorf("past_event_action: %w", err))
+	}
+	switch c.IngestPolicy.UnknownSensorType {
+	case domain.UnknownTypeReject, domain.UnknownTypeAutoRegister, domain.UnknownTypeQuarantine:
+	default:
+		errs = append(errs, fmt.Errorf("unknown_sensor_type_policy: must be %s, %s or %s, got %q",
+			domain.UnknownTypeReject, domain.UnknownTypeAutoRegister, domain.UnknownTypeQuarantine, c.IngestPolicy.UnknownSensorType))
+	}
+	if c.RollupInterval < 0 {
+		errs = append(errs, errors.New("rollup_interval: must not be negative"))
+	}
+	if c.RollupLag < 0 {
+		errs = append(errs, errors.New("rollup_lag: must not be negative"))
+	}
+	if c.RetentionInterval < 0 {
+		errs = append(errs, errors.New("retention_interval: must not be negative"))
+	}
+	if c.RetentionBatchSize <= 0 {
+		errs = append(errs, errors.New("retention_batch_size: must be positive"))
+	}
+	if c.RetentionBatchPause < 0 {
+		errs = append(errs, errors.New("retention_batch_pause: must not be negative"))
+	}
+	if c.ImportBatchSize <= 0 || c.ImportBatchSize > maxInsertBatchSize {
+		errs = append(errs, fmt.Errorf("import_batch_size: must be between 1 and %d", maxInsertBatchSize))
+	}
+	if c.DeleteMaxRows <= 0 {
+		errs = append(errs, errors.New("delete_max_rows: must be positive"))
+	}
+	if c.DeleteBatchSize <= 0 {
+		errs = append(errs, errors.New("delete_batch_size: must be positive"))
+	}
+	if c.DeleteGracePeriod < 0 {
+		errs = append(errs, errors.New("delete_grace_period: must not be negative"))
+	}
+	if c.StreamBufferSize <= 0 {
+		errs = append(errs, errors.New("stream_buffer_size: must be positive"))
+	}
+	if c.StreamReplayLimit < 0 {
+		errs = append(errs, errors.New("stream_replay_limit: must not be negative"))
 	}
 
-	return config
+	return errors.Join(errs...)
 }
 
 // GetDSN returns the database connection string
 func (c *Config) GetDSN() string {
 	return c.DBUser + ":" + c.DBPassword + "@tcp(" + c.DBHost + ":" + c.DBPort + ")/" + c.DBName + "?parseTime=true"
 }
+
+// SlogLevel returns the configured log level
+func (c *Config) SlogLevel() slog.Level {
+	level, _ := parseLogLevel(c.LogLevel)
+	return level
+}
+
+// validateEventTimeAction checks an action for readings outside the accepted time window
+func validateEventTimeAction(action string) error {
+	if action != domain.EventTimeReject && action != domain.EventTimeClamp {
+		return fmt.Errorf("must be %s or %s, got %q", domain.EventTimeReject, domain.EventTimeClamp, action)
+	}
+	return nil
+}
+
+// validatePort checks that a port is a number between 1 and 65535
+func validatePort(port string) error {
+	n, err := strconv.Atoi(port)
+	if err != nil || n < 1 || n > 65535 {
+		return fmt.Errorf("invalid port %q", port)
+	}
+	return nil
+}
+
+// parseLogLevel converts a log level name to its slog level
+func parseLogLevel(name string) (slog.Level, error) {
+	switch strings.ToLower(name) {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "info", "":
+		return slog.LevelInfo, nil
+	case "warn":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	default:
+		return slog.LevelInfo, fmt.Errorf("unknown log level %q", name)
+	}
+}
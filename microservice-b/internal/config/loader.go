@@ -0,0 +1,192 @@
+package config
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// redactedValue replaces secret values in configuration dumps
+const redactedValue = "********"
+
+// LoadConfig builds the configuration from defaults, an optional YAML or JSON config
+// file, environment variables and command-line flags, each overriding the previous.
+// The config file is given by the -config flag or the CONFIG_FILE environment variable.
+func LoadConfig(args []string) (*Config, error) {
+	cfg := DefaultConfig()
+
+	fs := flag.NewFlagSet("microservice-b", flag.ContinueOnError)
+	configFile := fs.String("config", os.Getenv("CONFIG_FILE"), "path to a YAML or JSON config file")
+	byFlag := make(map[string]setting, len(settings))
+	for _, s := range settings {
+		fs.String(s.flagName(), "", s.usage+" (env "+s.env+")")
+		byFlag[s.flagName()] = s
+	}
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	if *configFile != "" {
+		if err := loadFile(cfg, *configFile); err != nil {
+			return nil, fmt.Errorf("config file %s: %w", *configFile, err)
+		}
+	}
+
+	for _, s := range settings {
+		if value, ok := os.LookupEnv(s.env); ok {
+			if err := s.set(cfg, value); err != nil {
+				return nil, fmt.Errorf("environment variable %s: %w", s.env, err)
+			}
+		}
+	}
+
+	var flagErr error
+	fs.Visit(func(f *flag.Flag) {
+		s, ok := byFlag[f.Name]
+		if !ok || flagErr != nil {
+			return
+		}
+		if err := s.set(cfg, f.Value.String()); err != nil {
+			flagErr = fmt.Errorf("flag -%s: %w", f.Name, err)
+		}
+	})
+	if flagErr != nil {
+		return nil, flagErr
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid configuration:\n%w", err)
+	}
+
+	return cfg, nil
+}
+
+// Redacted returns every setting by key with secret values masked
+func (c *Config) Redacted() map[string]interface{} {
+	dump := make(map[string]interface{}, len(settings))
+	for _, s := range settings {
+		value := s.get(c)
+		if s.secret && fmt.Sprint(value) != "" {
+			value = redactedValue
+		}
+		dump[s.key] = value
+	}
+	return dump
+}
+
+// Manager holds the active configuration and reloads it on request
+type Manager struct {
+	args    []string
+	current *Config
+	mu      sync.RWMutex
+}
+
+// NewManager loads the configuration from the given command-line arguments
+func NewManager(args []string) (*Manager, error) {
+	cfg, err := LoadConfig(args)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Manager{
+		args:    args,
+		current: cfg,
+	}, nil
+}
+
+// Current returns the active configuration
+func (m *Manager) Current() *Config {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.current
+}
+
+// Reload loads the configuration again and applies only the settings that are safe to
+// change at runtime. It returns the new active configuration and the keys of changed
+// settings that only take effect after a restart.
+func (m *Manager) Reload() (*Config, []string, error) {
+	next, err := LoadConfig(m.args)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	applied := *m.current
+	var restartRequired []string
+	for _, s := range settings {
+		value := fmt.Sprint(s.get(next))
+		if value == fmt.Sprint(s.get(m.current)) {
+			continue
+		}
+		if !s.reloadable {
+			restartRequired = append(restartRequired, s.key)
+			continue
+		}
+		if err := s.set(&applied, value); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	m.current = &applied
+	return m.current, restartRequired, nil
+}
+
+// loadFile applies the settings in a YAML or JSON config file
+func loadFile(cfg *Config, path string) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	values := make(map[string]interface{})
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		err = json.Unmarshal(content, &values)
+	} else {
+		err = yaml.Unmarshal(content, &values)
+	}
+	if err != nil {
+		return err
+	}
+
+	// Apply keys in a stable order so errors are reported deterministically
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		value := values[key]
+		if value == nil {
+			continue
+		}
+
+		s, ok := findSetting(key)
+		if !ok {
+			return fmt.Errorf("unknown setting %q", key)
+		}
+		if err := s.set(cfg, formatFileValue(value)); err != nil {
+			return fmt.Errorf("%s: %w", key, err)
+		}
+	}
+
+	return nil
+}
+
+// formatFileValue converts a decoded file value to the string form settings parse
+func formatFileValue(value interface{}) string {
+	if f, ok := value.(float64); ok {
+		return strconv.FormatFloat(f, 'f', -1, 64)
+	}
+	return fmt.Sprint(value)
+}
@@ -0,0 +1,262 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// setting describes one configuration value. The key names it in config files and,
+// with underscores replaced by dashes, on the command line.
+type setting struct {
+	key        string
+	env        string
+	usage      string
+	secret     bool
+	reloadable bool
+	set        func(cfg *Config, value string) error
+	get        func(cfg *Config) interface{}
+}
+
+// settings lists every value that can be set from a file, the environment or a flag
+var settings = []setting{
+	{
+		key: "server_port", env: "SERVER_PORT", usage: "HTTP server port",
+		set: func(cfg *Config, v string) error { cfg.ServerPort = v; return nil },
+		get: func(cfg *Config) interface{} { return cfg.ServerPort },
+	},
+	{
+		key: "grpc_port", env: "GRPC_PORT", usage: "gRPC server port",
+		set: func(cfg *Config, v string) error { cfg.GRPCPort = v; return nil },
+		get: func(cfg *Config) interface{} { return cfg.GRPCPort },
+	},
+	{
+		key: "db_host", env: "DB_HOST", usage: "MySQL host",
+		set: func(cfg *Config, v string) error { cfg.DBHost = v; return nil },
+		get: func(cfg *Config) interface{} { return cfg.DBHost },
+	},
+	{
+		key: "db_port", env: "DB_PORT", usage: "MySQL port",
+		set: func(cfg *Config, v string) error { cfg.DBPort = v; return nil },
+		get: func(cfg *Config) interface{} { return cfg.DBPort },
+	},
+	{
+		key: "db_user", env: "DB_USER", usage: "MySQL user",
+		set: func(cfg *Config, v string) error { cfg.DBUser = v; return nil },
+		get: func(cfg *Config) interface{} { return cfg.DBUser },
+	},
+	{
+		key: "db_password", env: "DB_PASSWORD", usage: "MySQL password", secret: true,
+		set: func(cfg *Config, v string) error { cfg.DBPassword = v; return nil },
+		get: func(cfg *Config) interface{} { return cfg.DBPassword },
+	},
+	{
+		key: "db_name", env: "DB_NAME", usage: "MySQL database name",
+		set: func(cfg *Config, v string) error { cfg.DBName = v; return nil },
+		get: func(cfg *Config) interface{} { return cfg.DBName },
+	},
+	{
+		key: "jwt_secret", env: "JWT_SECRET", usage: "secret used to sign JWT tokens", secret: true,
+		set: func(cfg *Config, v string) error { cfg.JWTSecret = v; return nil },
+		get: func(cfg *Config) interface{} { return cfg.JWTSecret },
+	},
+	{
+		key: "token_expiry_hours", env: "TOKEN_EXPIRY_HOURS", usage: "JWT token lifetime in hours",
+		set: func(cfg *Config, v string) error {
+			hours, err := strconv.Atoi(v)
+			if err != nil {
+				return fmt.Errorf("invalid integer %q", v)
+			}
+			cfg.TokenExpiry = time.Duration(hours) * time.Hour
+			return nil
+		},
+		get: func(cfg *Config) interface{} { return int(cfg.TokenExpiry.Hours()) },
+	},
+	{
+		key: "refresh_token_expiry_hours", env: "REFRESH_TOKEN_EXPIRY_HOURS", usage: "JWT refresh token lifetime in hours",
+		set: func(cfg *Config, v string) error {
+			hours, err := strconv.Atoi(v)
+			if err != nil {
+				return fmt.Errorf("invalid integer %q", v)
+			}
+			cfg.RefreshTokenExpiry = time.Duration(hours) * time.Hour
+			return nil
+		},
+		get: func(cfg *Config) interface{} { return int(cfg.RefreshTokenExpiry.Hours()) },
+	},
+	{
+		key: "require_ingest_api_key", env: "REQUIRE_INGEST_API_KEY", usage: "whether readings sent over gRPC must carry an API key",
+		set: func(cfg *Config, v string) error {
+			required, err := strconv.ParseBool(v)
+			if err != nil {
+				return fmt.Errorf("invalid boolean %q", v)
+			}
+			cfg.RequireIngestAPIKey = required
+			return nil
+		},
+		get: func(cfg *Config) interface{} { return cfg.RequireIngestAPIKey },
+	},
+	{
+		key: "max_ingest_batch_size", env: "MAX_INGEST_BATCH_SIZE", usage: "most readings accepted by one SendSensorDataBatch call",
+		set: func(cfg *Config, v string) error { return setInt(&cfg.MaxIngestBatchSize, v) },
+		get: func(cfg *Config) interface{} { return cfg.MaxIngestBatchSize },
+	},
+	{
+		key: "write_batch_size", env: "WRITE_BATCH_SIZE", usage: "most readings written by one multi-row INSERT",
+		set: func(cfg *Config, v string) error { return setInt(&cfg.WriteBatchSize, v) },
+		get: func(cfg *Config) interface{} { return cfg.WriteBatchSize },
+	},
+	{
+		key: "write_batch_delay", env: "WRITE_BATCH_DELAY", usage: "longest a reading waits for more readings to be written with",
+		set: func(cfg *Config, v string) error { return setDuration(&cfg.WriteBatchDelay, v) },
+		get: func(cfg *Config) interface{} { return cfg.WriteBatchDelay.String() },
+	},
+	{
+		key: "write_workers", env: "WRITE_WORKERS", usage: "multi-row INSERTs of readings run concurrently",
+		set: func(cfg *Config, v string) error { return setInt(&cfg.WriteWorkers, v) },
+		get: func(cfg *Config) interface{} { return cfg.WriteWorkers },
+	},
+	{
+		key: "write_queue_size", env: "WRITE_QUEUE_SIZE", usage: "most readings waiting to be written before ingest slows down",
+		set: func(cfg *Config, v string) error { return setInt(&cfg.WriteQueueSize, v) },
+		get: func(cfg *Config) interface{} { return cfg.WriteQueueSize },
+	},
+	{
+		key: "write_queue_timeout", env: "WRITE_QUEUE_TIMEOUT", usage: "how long a reading waits for room in a full write queue before it is refused",
+		set: func(cfg *Config, v string) error { return setDuration(&cfg.WriteQueueTimeout, v) },
+		get: func(cfg *Config) interface{} { return cfg.WriteQueueTimeout.String() },
+	},
+	{
+		key: "max_connections", env: "MAX_CONNECTIONS", usage: "maximum open database connections",
+		set: func(cfg *Config, v string) error { return setInt(&cfg.MaxConnections, v) },
+		get: func(cfg *Config) interface{} { return cfg.MaxConnections },
+	},
+	{
+		key: "log_level", env: "LOG_LEVEL", usage: "log level: debug, info, warn or error", reloadable: true,
+		set: func(cfg *Config, v string) error { cfg.LogLevel = v; return nil },
+		get: func(cfg *Config) interface{} { return cfg.LogLevel },
+	},
+	{
+		key: "clock_skew_tolerance", env: "CLOCK_SKEW_TOLERANCE", usage: "how far event times may lead the ingest time",
+		set: func(cfg *Config, v string) error {
+			return setDuration(&cfg.IngestPolicy.EventTime.ClockSkewTolerance, v)
+		},
+		get: func(cfg *Config) interface{} { return cfg.IngestPolicy.EventTime.ClockSkewTolerance.String() },
+	},
+	{
+		key: "future_event_action", env: "FUTURE_EVENT_ACTION", usage: "action for readings from the future: reject or clamp",
+		set: func(cfg *Config, v string) error { cfg.IngestPolicy.EventTime.FutureAction = v; return nil },
+		get: func(cfg *Config) interface{} { return cfg.IngestPolicy.EventTime.FutureAction },
+	},
+	{
+		key: "max_event_age", env: "MAX_EVENT_AGE", usage: "oldest accepted event time relative to ingest; 0 disables the check",
+		set: func(cfg *Config, v string) error { return setDuration(&cfg.IngestPolicy.EventTime.MaxEventAge, v) },
+		get: func(cfg *Config) interface{} { return cfg.IngestPolicy.EventTime.MaxEventAge.String() },
+	},
+	{
+		key: "past_event_action", env: "PAST_EVENT_ACTION", usage: "action for readings older than max_event_age: reject or clamp",
+		set: func(cfg *Config, v string) error { cfg.IngestPolicy.EventTime.PastAction = v; return nil },
+		get: func(cfg *Config) interface{} { return cfg.IngestPolicy.EventTime.PastAction },
+	},
+	{
+		key: "unknown_sensor_type_policy", env: "UNKNOWN_SENSOR_TYPE_POLICY", usage: "action for readings of unregistered sensor types: reject, auto_register or quarantine",
+		set: func(cfg *Config, v string) error { cfg.IngestPolicy.UnknownSensorType = v; return nil },
+		get: func(cfg *Config) interface{} { return cfg.IngestPolicy.UnknownSensorType },
+	},
+	{
+		key: "rollup_interval", env: "ROLLUP_INTERVAL", usage: "interval between rollup refreshes; 0 disables rollups",
+		set: func(cfg *Config, v string) error { return setDuration(&cfg.RollupInterval, v) },
+		get: func(cfg *Config) interface{} { return cfg.RollupInterval.String() },
+	},
+	{
+		key: "rollup_lag", env: "ROLLUP_LAG", usage: "how long readings are left for in-flight inserts before being rolled up",
+		set: func(cfg *Config, v string) error { return setDuration(&cfg.RollupLag, v) },
+		get: func(cfg *Config) interface{} { return cfg.RollupLag.String() },
+	},
+	{
+		key: "retention_interval", env: "RETENTION_INTERVAL", usage: "interval between retention purge runs; 0 disables purging",
+		set: func(cfg *Config, v string) error { return setDuration(&cfg.RetentionInterval, v) },
+		get: func(cfg *Config) interface{} { return cfg.RetentionInterval.String() },
+	},
+	{
+		key: "retention_batch_size", env: "RETENTION_BATCH_SIZE", usage: "maximum rows deleted by one retention statement",
+		set: func(cfg *Config, v string) error { return setInt(&cfg.RetentionBatchSize, v) },
+		get: func(cfg *Config) interface{} { return cfg.RetentionBatchSize },
+	},
+	{
+		key: "retention_batch_pause", env: "RETENTION_BATCH_PAUSE", usage: "pause between retention delete batches",
+		set: func(cfg *Config, v string) error { return setDuration(&cfg.RetentionBatchPause, v) },
+		get: func(cfg *Config) interface{} { return cfg.RetentionBatchPause.String() },
+	},
+	{
+		key: "import_batch_size", env: "IMPORT_BATCH_SIZE", usage: "rows per multi-row INSERT when importing files",
+		set: func(cfg *Config, v string) error { return setInt(&cfg.ImportBatchSize, v) },
+		get: func(cfg *Config) interface{} { return cfg.ImportBatchSize },
+	},
+	{
+		key: "delete_max_rows", env: "DELETE_MAX_ROWS", usage: "most records a delete by filter removes within the request; larger deletes run in the background",
+		set: func(cfg *Config, v string) error { return setInt(&cfg.DeleteMaxRows, v) },
+		get: func(cfg *Config) interface{} { return cfg.DeleteMaxRows },
+	},
+	{
+		key: "delete_batch_size", env: "DELETE_BATCH_SIZE", usage: "maximum rows deleted by one statement of a background delete",
+		set: func(cfg *Config, v string) error { return setInt(&cfg.DeleteBatchSize, v) },
+		get: func(cfg *Config) interface{} { return cfg.DeleteBatchSize },
+	},
+	{
+		key: "delete_grace_period", env: "DELETE_GRACE_PERIOD", usage: "how long deleted records can be restored before the retention run purges them",
+		set: func(cfg *Config, v string) error { return setDuration(&cfg.DeleteGracePeriod, v) },
+		get: func(cfg *Config) interface{} { return cfg.DeleteGracePeriod.String() },
+	},
+	{
+		key: "stream_buffer_size", env: "STREAM_BUFFER_SIZE", usage: "readings buffered per live stream subscriber before it is disconnected",
+		set: func(cfg *Config, v string) error { return setInt(&cfg.StreamBufferSize, v) },
+		get: func(cfg *Config) interface{} { return cfg.StreamBufferSize },
+	},
+	{
+		key: "stream_replay_limit", env: "STREAM_REPLAY_LIMIT", usage: "most missed readings replayed when a live stream resumes",
+		set: func(cfg *Config, v string) error { return setInt(&cfg.StreamReplayLimit, v) },
+		get: func(cfg *Config) interface{} { return cfg.StreamReplayLimit },
+	},
+}
+
+// findSetting returns the setting with the given key
+func findSetting(key string) (setting, bool) {
+	for _, s := range settings {
+		if s.key == key {
+			return s, true
+		}
+	}
+	return setting{}, false
+}
+
+// flagName returns the command-line flag name of a setting
+func (s setting) flagName() string {
+	return strings.ReplaceAll(s.key, "_", "-")
+}
+
+// setInt parses an integer setting
+func setInt(target *int, value string) error {
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return fmt.Errorf("invalid integer %q", value)
+	}
+	*target = n
+	return nil
+}
+
+// setDuration parses a duration setting given either as a Go duration such as "1.5s"
+// or as a plain number of milliseconds
+func setDuration(target *time.Duration, value string) error {
+	if ms, err := strconv.ParseInt(value, 10, 64); err == nil {
+		*target = time.Duration(ms) * time.Millisecond
+		return nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return fmt.Errorf("invalid duration %q", value)
+	}
+	*target = d
+	return nil
+}
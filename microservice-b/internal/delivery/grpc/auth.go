@@ -0,0 +1,238 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"log"
+	"slices"
+	"strings"
+
+	"sensor_project/microservice-b/internal/domain"
+	pb "sensor_project/proto/sensor_project/proto/sensor"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// apiKeyMetadataKey is the metadata key clients pass their API key in
+const apiKeyMetadataKey = "x-api-key"
+
+// authorizationMetadataKey is the metadata key clients pass a bearer token in
+const authorizationMetadataKey = "authorization"
+
+// Access levels of gRPC methods, matching the REST route guards
+const (
+	// accessIngest lets through API keys and admins, and anyone when API keys are
+	// not required
+	accessIngest = iota
+	// accessImport lets through unscoped API keys and admins; the rows of an import are
+	// not checked against a key's scope
+	accessImport
+	// accessUser lets through users and admins
+	accessUser
+	// accessAdmin lets through admins only
+	accessAdmin
+)
+
+// methodAccess gives the access level of each gRPC method; methods missing from it are
+// refused, so a new method is only served once it is given one
+var methodAccess = map[string]int{
+	pb.SensorService_SendSensorData_FullMethodName:      accessIngest,
+	pb.SensorService_StreamSensorData_FullMethodName:    accessIngest,
+	pb.SensorService_SendSensorDataBatch_FullMethodName: accessIngest,
+	pb.SensorService_GetSensorData_FullMethodName:       accessUser,
+	pb.SensorService_QuerySensorData_FullMethodName:     accessUser,
+	pb.SensorService_Aggregate_FullMethodName:           accessUser,
+	pb.SensorService_Subscribe_FullMethodName:           accessUser,
+
+	pb.SensorTypeService_GetSensorType_FullMethodName:    accessUser,
+	pb.SensorTypeService_ListSensorTypes_FullMethodName:  accessUser,
+	pb.SensorTypeService_CreateSensorType_FullMethodName: accessAdmin,
+	pb.SensorTypeService_UpdateSensorType_FullMethodName: accessAdmin,
+	pb.SensorTypeService_DeleteSensorType_FullMethodName: accessAdmin,
+
+	pb.ImportService_ImportSensorData_FullMethodName: accessImport,
+	pb.ImportService_GetImportJob_FullMethodName:     accessImport,
+}
+
+// caller is the authenticated user of a call, and the API key they used if any
+type caller struct {
+	user *domain.User
+	key  *domain.APIKey
+}
+
+// callerContextKey is the context key the caller of a call is stored under
+type callerContextKey struct{}
+
+// Authenticator identifies the caller of every gRPC call from the API key in its
+// x-api-key metadata or else the bearer token in its authorization metadata, and
+// checks the caller against the access level of the method. As on REST, a scoped API
+// key acts as a plain user restricted to its scope.
+type Authenticator struct {
+	authUseCase   domain.AuthUseCase
+	apiKeyUseCase domain.APIKeyUseCase
+	requireAPIKey bool
+}
+
+// NewAuthenticator creates a gRPC authenticator. Ingest calls without credentials are
+// only accepted if requireAPIKey is not set.
+func NewAuthenticator(authUseCase domain.AuthUseCase, apiKeyUseCase domain.APIKeyUseCase, requireAPIKey bool) *Authenticator {
+	return &Authenticator{
+		authUseCase:   authUseCase,
+		apiKeyUseCase: apiKeyUseCase,
+		requireAPIKey: requireAPIKey,
+	}
+}
+
+// UnaryInterceptor returns the interceptor authenticating unary calls
+func (a *Authenticator) UnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, err := a.authenticate(ctx, info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamInterceptor returns the interceptor authenticating streaming calls
+func (a *Authenticator) StreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := a.authenticate(stream.Context(), info.FullMethod)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &authenticatedStream{ServerStream: stream, ctx: ctx})
+	}
+}
+
+// authenticatedStream is a server stream carrying the caller in its context
+type authenticatedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+// Context returns the stream's context with the caller
+func (s *authenticatedStream) Context() context.Context {
+	return s.ctx
+}
+
+// authenticate identifies the caller of a method and checks it against the method's
+// access level, returning a context carrying the caller
+func (a *Authenticator) authenticate(ctx context.Context, method string) (context.Context, error) {
+	access, ok := methodAccess[method]
+	if !ok {
+		log.Printf("Refusing gRPC method %s without an access level", method)
+		return nil, status.Error(codes.PermissionDenied, "method is not available")
+	}
+
+	c, err := a.identify(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	switch access {
+	case accessIngest:
+		if c == nil {
+			if a.requireAPIKey {
+				return nil, status.Error(codes.Unauthenticated, "an API key is required in the x-api-key metadata")
+			}
+			return ctx, nil
+		}
+		if c.key == nil && !c.hasRole(domain.RoleAdmin) {
+			return nil, status.Error(codes.PermissionDenied, "sending readings requires an API key or an admin")
+		}
+	case accessImport:
+		if c == nil {
+			return nil, status.Error(codes.Unauthenticated, "an API key or a bearer token is required")
+		}
+		if (c.key == nil || c.key.Scoped()) && !c.hasRole(domain.RoleAdmin) {
+			return nil, status.Error(codes.PermissionDenied, "importing readings requires an unscoped API key or an admin")
+		}
+	case accessUser:
+		if c == nil {
+			return nil, status.Error(codes.Unauthenticated, "authentication required")
+		}
+		if !c.hasRole(domain.RoleUser) && !c.hasRole(domain.RoleAdmin) {
+			return nil, status.Error(codes.PermissionDenied, "insufficient role")
+		}
+	case accessAdmin:
+		if c == nil {
+			return nil, status.Error(codes.Unauthenticated, "authentication required")
+		}
+		if !c.hasRole(domain.RoleAdmin) {
+			return nil, status.Error(codes.PermissionDenied, "insufficient role")
+		}
+	}
+
+	return context.WithValue(ctx, callerContextKey{}, c), nil
+}
+
+// identify returns the caller of a call, or nil if it carries no credentials
+func (a *Authenticator) identify(ctx context.Context) (*caller, error) {
+	if secret := firstMetadataValue(ctx, apiKeyMetadataKey); secret != "" {
+		key, user, err := a.apiKeyUseCase.Validate(secret)
+		if errors.Is(err, domain.ErrInvalidAPIKey) {
+			return nil, status.Error(codes.Unauthenticated, err.Error())
+		}
+		if err != nil {
+			log.Printf("Error validating API key: %v", err)
+			return nil, status.Error(codes.Internal, "failed to validate API key")
+		}
+
+		// A scoped key of a user who can read only reads within its scope, whatever
+		// else the user may do
+		if key.Scoped() {
+			scoped := *user
+			scoped.Roles = nil
+			if slices.Contains(user.Roles, domain.RoleUser) || slices.Contains(user.Roles, domain.RoleAdmin) {
+				scoped.Roles = []string{domain.RoleUser}
+			}
+			user = &scoped
+		}
+		return &caller{user: user, key: key}, nil
+	}
+
+	header := firstMetadataValue(ctx, authorizationMetadataKey)
+	if header == "" {
+		return nil, nil
+	}
+	scheme, token, ok := strings.Cut(header, " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return nil, status.Error(codes.Unauthenticated, "authorization metadata must be a bearer token")
+	}
+
+	user, err := a.authUseCase.ValidateToken(strings.TrimSpace(token))
+	if errors.Is(err, domain.ErrInvalidToken) {
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+	if err != nil {
+		log.Printf("Error validating token: %v", err)
+		return nil, status.Error(codes.Internal, "failed to validate token")
+	}
+	return &caller{user: user}, nil
+}
+
+// hasRole reports whether the caller has the given role
+func (c *caller) hasRole(role string) bool {
+	return c.user != nil && slices.Contains(c.user.Roles, role)
+}
+
+// callerAPIKey returns the API key a call was authenticated with, or nil if it was not
+// made with one
+func callerAPIKey(ctx context.Context) *domain.APIKey {
+	if c, ok := ctx.Value(callerContextKey{}).(*caller); ok && c != nil {
+		return c.key
+	}
+	return nil
+}
+
+// firstMetadataValue returns the first value of a metadata key of an incoming call
+func firstMetadataValue(ctx context.Context, key string) string {
+	if values := metadata.ValueFromIncomingContext(ctx, key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
@@ -0,0 +1,102 @@
+package grpc
+
+import (
+	"context"
+	"io"
+
+	"sensor_project/microservice-b/internal/domain"
+	pb "sensor_project/proto/sensor_project/proto/sensor"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ImportServer implements the ImportService gRPC server
+type ImportServer struct {
+	pb.UnimplementedImportServiceServer
+	importUseCase domain.ImportUseCase
+}
+
+// NewImportServer creates a new gRPC import server
+func NewImportServer(importUseCase domain.ImportUseCase) *ImportServer {
+	return &ImportServer{
+		importUseCase: importUseCase,
+	}
+}
+
+// RegisterServer registers the gRPC server to the provided gRPC server instance
+func (s *ImportServer) RegisterServer(grpcServer *grpc.Server) {
+	pb.RegisterImportServiceServer(grpcServer, s)
+}
+
+// ImportSensorData receives an import file in chunks and starts importing it once the
+// client closes the stream
+func (s *ImportServer) ImportSensorData(stream pb.ImportService_ImportSensorDataServer) error {
+	first, err := stream.Recv()
+	if err == io.EOF {
+		return status.Error(codes.InvalidArgument, "no import file received")
+	}
+	if err != nil {
+		return err
+	}
+
+	src := &chunkReader{stream: stream, buf: first.Data}
+	job, err := s.importUseCase.Start(first.Format, first.DryRun, src)
+	if err != nil {
+		return toStatusError(err)
+	}
+
+	return stream.SendAndClose(toProtoImportJob(job))
+}
+
+// GetImportJob returns the progress of an import job
+func (s *ImportServer) GetImportJob(ctx context.Context, req *pb.ImportJobID) (*pb.ImportJob, error) {
+	job := s.importUseCase.Get(req.Id)
+	if job == nil {
+		return nil, status.Error(codes.NotFound, "import job not found")
+	}
+	return toProtoImportJob(job), nil
+}
+
+// chunkReader reads an import file from the chunks of an ImportSensorData stream
+type chunkReader struct {
+	stream pb.ImportService_ImportSensorDataServer
+	buf    []byte
+}
+
+// Read copies the current chunk into p, receiving the next chunk once it is used up
+func (r *chunkReader) Read(p []byte) (int, error) {
+	for len(r.buf) == 0 {
+		chunk, err := r.stream.Recv()
+		if err != nil {
+			return 0, err
+		}
+		r.buf = chunk.Data
+	}
+
+	n := copy(p, r.buf)
+	r.buf = r.buf[n:]
+	return n, nil
+}
+
+// toProtoImportJob converts a domain import job to its protobuf message
+func toProtoImportJob(job *domain.ImportJob) *pb.ImportJob {
+	resp := &pb.ImportJob{
+		Id:              job.ID,
+		Status:          job.Status,
+		Format:          job.Format,
+		DryRun:          job.DryRun,
+		RowsRead:        job.RowsRead,
+		RowsImported:    job.RowsImported,
+		RowsRejected:    job.RowsRejected,
+		ErrorsTruncated: job.ErrorsTruncated,
+		Error:           job.Error,
+		CreatedAt:       job.CreatedAt,
+		FinishedAt:      job.FinishedAt,
+	}
+	for _, e := range job.Errors {
+		resp.Errors = append(resp.Errors, &pb.ImportRowError{Line: e.Line, Error: e.Error})
+	}
+	return resp
+}
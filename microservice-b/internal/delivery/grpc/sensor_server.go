@@ -2,25 +2,43 @@ package grpc
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"io"
 	"log"
+	"log/slog"
+	"sync"
 	"time"
 
 	"sensor_project/microservice-b/internal/domain"
 	pb "sensor_project/proto/sensor_project/proto/sensor"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 )
 
-// SensorServer implements the SensorService gRPC server
+// maxStreamPending limits the readings of one ingest stream waiting to be written
+const maxStreamPending = 1000
+
+// SensorServer implements the SensorService gRPC server. Calls are authenticated by the
+// Authenticator's interceptors; the API key a call was made with limits the readings
+// it may send and read to the key's scope. A batch may hold at most maxBatchSize
+// readings.
 type SensorServer struct {
 	pb.UnimplementedSensorServiceServer
 	sensorUseCase domain.SensorDataUseCase
+	streamUseCase domain.StreamUseCase
+	maxBatchSize  int
 }
 
 // NewSensorServer creates a new gRPC sensor server
-func NewSensorServer(sensorUseCase domain.SensorDataUseCase) *SensorServer {
+func NewSensorServer(sensorUseCase domain.SensorDataUseCase, streamUseCase domain.StreamUseCase, maxBatchSize int) *SensorServer {
 	return &SensorServer{
 		sensorUseCase: sensorUseCase,
+		streamUseCase: streamUseCase,
+		maxBatchSize:  maxBatchSize,
 	}
 }
 
@@ -31,55 +49,476 @@ func (s *SensorServer) RegisterServer(grpcServer *grpc.Server) {
 
 // SendSensorData handles incoming sensor data from Microservice A
 func (s *SensorServer) SendSensorData(ctx context.Context, req *pb.SensorData) (*pb.SensorResponse, error) {
-	log.Printf("Received sensor data: %+v", req)
+	slog.Debug("Received sensor data", "data", req)
 
-	// Convert protobuf message to domain model
-	sensorData := &domain.SensorData{
-		SensorValue: float64(req.SensorValue),
-		SensorType:  req.SensorType,
-		ID1:         req.Id1,
-		ID2:         int(req.Id2),
-		CreatedAt:   time.Now().UnixMilli(),
+	if err := checkScope(callerAPIKey(ctx), req); err != nil {
+		return nil, toStatusError(err)
 	}
 
-	// Store the sensor data
-	err := s.sensorUseCase.Store(sensorData)
-	if err != nil {
-		log.Printf("Error storing sensor data: %v", err)
+	// Store the sensor data; readings that fail validation are reported in the response
+	result, err := s.sensorUseCase.Store(toDomainReading(req))
+	if errors.Is(err, domain.ErrInvalidReading) {
+		slog.Debug("Rejected sensor data", "error", err)
 		return &pb.SensorResponse{
 			Success: false,
-			Message: "Failed to store sensor data",
-		}, err
+			Message: err.Error(),
+		}, nil
+	}
+	if err != nil {
+		log.Printf("Error storing sensor data: %v", err)
+		return nil, toStatusError(err)
 	}
 
-	return &pb.SensorResponse{
-		Success: true,
-		Message: "Sensor data stored successfully",
-	}, nil
+	return toProtoResponse(result), nil
 }
 
-// StreamSensorData handles streaming sensor data from Microservice A
+// StreamSensorData handles streaming sensor data from Microservice A. Readings are
+// written while the next ones are received. Readings outside the API key's scope or
+// failing validation are rejected one by one and counted in the response. The stream
+// ends with an error as soon as a reading fails to be stored otherwise, with
+// Unavailable once the write pipeline refuses one, so a successful response confirms
+// every reading sent on the stream and a client resends the readings of a failed one.
 func (s *SensorServer) StreamSensorData(stream pb.SensorService_StreamSensorDataServer) error {
+	key := callerAPIKey(stream.Context())
+	pending := make(chan domain.PendingStore, maxStreamPending)
+	failed := make(chan error, 1)
+	done := make(chan struct{})
+	stored, duplicates, rejected, outOfScope := 0, 0, 0, 0
+	go func() {
+		defer close(done)
+		for p := range pending {
+			result, err := p.Wait()
+			switch {
+			case isRejection(err):
+				// Resending a rejected reading would not change its outcome
+				rejected++
+			case err != nil:
+				if !isOverload(err) {
+					log.Printf("Error storing sensor data: %v", err)
+				}
+				select {
+				case failed <- err:
+				default:
+				}
+			case result.Status == domain.StoreStatusStored:
+				stored++
+			case result.Status == domain.StoreStatusDuplicate:
+				duplicates++
+			}
+		}
+	}()
+	finish := sync.OnceFunc(func() {
+		close(pending)
+		<-done
+	})
+	defer finish()
+
 	for {
 		req, err := stream.Recv()
+		if err == io.EOF {
+			// The client finished the stream; report how many readings were stored
+			finish()
+			select {
+			case err := <-failed:
+				return toStatusError(err)
+			default:
+			}
+			return stream.SendAndClose(&pb.SensorResponse{
+				Success: true,
+				Message: fmt.Sprintf("Stored %d sensor readings, %d duplicates, %d rejected", stored, duplicates, rejected+outOfScope),
+			})
+		}
 		if err != nil {
 			return err
 		}
+		if err := checkScope(key, req); err != nil {
+			slog.Debug("Rejected sensor data", "error", err)
+			outOfScope++
+			continue
+		}
 
-		// Convert protobuf message to domain model
-		sensorData := &domain.SensorData{
-			SensorValue: float64(req.SensorValue),
-			SensorType:  req.SensorType,
-			ID1:         req.Id1,
-			ID2:         int(req.Id2),
-			CreatedAt:   time.Now().UnixMilli(),
+		select {
+		case err := <-failed:
+			return toStatusError(err)
+		default:
 		}
+		pending <- s.sensorUseCase.StoreAsync(toDomainReading(req))
+	}
+}
 
-		// Store the sensor data
-		err = s.sensorUseCase.Store(sensorData)
-		if err != nil {
-			log.Printf("Error storing sensor data: %v", err)
+// SendSensorDataBatch stores the readings of a batch and reports the outcome of each.
+// Readings outside the API key's scope or failing validation are rejected one by one;
+// any other error fails the call, possibly after some of its readings were stored, so
+// the batch should be resent with the same reading IDs.
+func (s *SensorServer) SendSensorDataBatch(ctx context.Context, req *pb.SensorDataBatch) (*pb.SensorDataBatchResponse, error) {
+	key := callerAPIKey(ctx)
+	if len(req.Readings) > s.maxBatchSize {
+		return nil, status.Errorf(codes.InvalidArgument, "a batch may hold at most %d readings", s.maxBatchSize)
+	}
+
+	resp := &pb.SensorDataBatchResponse{Results: make([]*pb.SensorResponse, len(req.Readings))}
+	pending := make([]domain.PendingStore, len(req.Readings))
+	for i, reading := range req.Readings {
+		if err := checkScope(key, reading); err != nil {
+			resp.Results[i] = &pb.SensorResponse{Success: false, Message: err.Error()}
+			resp.Rejected++
+			continue
+		}
+		pending[i] = s.sensorUseCase.StoreAsync(toDomainReading(reading))
+	}
+
+	// Every reading is waited for, so none is still being written when the call fails
+	var failure error
+	for i, p := range pending {
+		if p == nil {
 			continue
 		}
+		result, err := p.Wait()
+		switch {
+		case isRejection(err):
+			resp.Results[i] = &pb.SensorResponse{Success: false, Message: err.Error()}
+			resp.Rejected++
+		case err != nil:
+			if failure == nil {
+				failure = err
+			}
+		default:
+			resp.Results[i] = toProtoResponse(result)
+			switch result.Status {
+			case domain.StoreStatusStored:
+				resp.Stored++
+			case domain.StoreStatusDuplicate:
+				resp.Duplicates++
+			case domain.StoreStatusQuarantined:
+				resp.Quarantined++
+			}
+		}
+	}
+	if failure != nil {
+		log.Printf("Error storing sensor data batch: %v", failure)
+		return nil, toStatusError(failure)
+	}
+
+	slog.Debug("Stored sensor data batch", "stored", resp.Stored, "duplicates", resp.Duplicates,
+		"quarantined", resp.Quarantined, "rejected", resp.Rejected)
+	return resp, nil
+}
+
+// toDomainReading converts a received protobuf reading to the domain model
+func toDomainReading(req *pb.SensorData) *domain.SensorData {
+	return &domain.SensorData{
+		SensorValue: float64(req.SensorValue),
+		SensorType:  req.SensorType,
+		ID1:         req.Id1,
+		ID2:         int(req.Id2),
+		EventTime:   req.Timestamp,
+		CreatedAt:   time.Now().UnixMilli(),
+		ReadingID:   req.ReadingId,
+	}
+}
+
+// toProtoResponse converts the outcome of storing an accepted reading to its response
+func toProtoResponse(result *domain.StoreResult) *pb.SensorResponse {
+	return &pb.SensorResponse{
+		Success:   true,
+		Message:   result.Message,
+		Duplicate: result.Status == domain.StoreStatusDuplicate,
+	}
+}
+
+// GetSensorData returns a stored reading by ID
+func (s *SensorServer) GetSensorData(ctx context.Context, req *pb.SensorDataID) (*pb.SensorReading, error) {
+	data, err := s.sensorUseCase.GetByID(req.Id, false)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	// Readings outside the scope of the call's API key are not disclosed
+	if key := callerAPIKey(ctx); data == nil || key != nil && !key.Allows(data.SensorType, data.ID1) {
+		return nil, status.Error(codes.NotFound, "sensor data not found")
+	}
+	return toProtoReading(data), nil
+}
+
+// QuerySensorData returns an offset or cursor page of the readings matching a filter
+func (s *SensorServer) QuerySensorData(ctx context.Context, req *pb.QuerySensorDataRequest) (*pb.QuerySensorDataResponse, error) {
+	filter, err := scopedFilter(ctx, req.Filter)
+	if err != nil {
+		return nil, err
+	}
+	for _, field := range req.Sort {
+		filter.Sort = append(filter.Sort, domain.SortField{Key: field.Key, Desc: field.Desc})
+	}
+	filter.Page = int(req.Page)
+	filter.PageSize = int(req.PageSize)
+
+	if req.UseCursor || req.Cursor != "" {
+		var cursor *domain.PageCursor
+		if req.Cursor != "" {
+			var err error
+			if cursor, err = domain.DecodePageCursor(req.Cursor); err != nil {
+				return nil, status.Error(codes.InvalidArgument, "invalid cursor")
+			}
+		}
+
+		page, err := s.sensorUseCase.GetPage(filter, cursor, req.IncludeTotal)
+		if err != nil {
+			return nil, toStatusError(err)
+		}
+
+		resp := &pb.QuerySensorDataResponse{
+			Data:       toProtoReadings(page.Data),
+			PageSize:   int32(filter.PageSize),
+			NextCursor: page.NextCursor.Encode(),
+			PrevCursor: page.PrevCursor.Encode(),
+		}
+		if page.Total != nil {
+			total := int32(*page.Total)
+			resp.Total = &total
+		}
+		return resp, nil
+	}
+
+	data, total, err := s.sensorUseCase.GetByFilter(filter)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	total32 := int32(total)
+	totalPages := int32((total + filter.PageSize - 1) / filter.PageSize)
+	return &pb.QuerySensorDataResponse{
+		Data:       toProtoReadings(data),
+		Total:      &total32,
+		Page:       int32(filter.Page),
+		PageSize:   int32(filter.PageSize),
+		TotalPages: &totalPages,
+	}, nil
+}
+
+// Aggregate groups the readings matching a filter into time buckets per sensor type
+// and device and computes the requested functions over each
+func (s *SensorServer) Aggregate(ctx context.Context, req *pb.AggregateRequest) (*pb.AggregateResponse, error) {
+	interval, ok := domain.AggregateIntervals[req.Interval]
+	if !ok {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid interval %q, expected 1m, 5m, 1h or 1d", req.Interval)
+	}
+
+	filter, err := scopedFilter(ctx, req.Filter)
+	if err != nil {
+		return nil, err
+	}
+	query := &domain.AggregateQuery{
+		Filter:    *filter,
+		Interval:  interval,
+		Functions: req.Functions,
+	}
+	result, err := s.sensorUseCase.Aggregate(query)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	resp := &pb.AggregateResponse{
+		Interval:  req.Interval,
+		Functions: query.Functions,
+		Truncated: result.Truncated,
+		Source:    result.Source,
+	}
+	for _, bucket := range result.Buckets {
+		resp.Buckets = append(resp.Buckets, &pb.AggregateBucket{
+			BucketStart: bucket.BucketStart,
+			SensorType:  bucket.SensorType,
+			Id1:         bucket.ID1,
+			Id2:         int32(bucket.ID2),
+			Values:      bucket.Values,
+		})
+	}
+	return resp, nil
+}
+
+// Subscribe streams the readings matching a filter as they are stored, after replaying
+// the ones the client may have missed since resume_after, which can repeat some it
+// already received. An incomplete replay is reported by the replay-truncated header
+// metadata. A client that falls behind is disconnected with
+// ResourceExhausted and should resubscribe from the last reading it received.
+func (s *SensorServer) Subscribe(req *pb.SubscribeRequest, stream pb.SensorService_SubscribeServer) error {
+	if req.ResumeAfter < 0 {
+		return status.Error(codes.InvalidArgument, "resume_after must not be negative")
+	}
+
+	filter, err := scopedFilter(stream.Context(), req.Filter)
+	if err != nil {
+		return err
+	}
+	replay, sub, err := s.streamUseCase.Subscribe(filter, req.ResumeAfter)
+	if err != nil {
+		return toStatusError(err)
+	}
+	defer sub.Close()
+
+	if replay.Truncated {
+		if err := stream.SendHeader(metadata.Pairs("replay-truncated", "true")); err != nil {
+			return err
+		}
+	}
+
+	// Readings stored while the replay was read can also arrive live
+	replayed := make(map[int64]struct{}, len(replay.Readings))
+	for _, data := range replay.Readings {
+		if err := stream.Send(toProtoReading(data)); err != nil {
+			return err
+		}
+		replayed[data.ID] = struct{}{}
+	}
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return status.FromContextError(stream.Context().Err()).Err()
+		case data, ok := <-sub.Readings():
+			if !ok {
+				if err := sub.Err(); err != nil {
+					return toStatusError(err)
+				}
+				return nil
+			}
+			if _, ok := replayed[data.ID]; ok {
+				continue
+			}
+			if err := stream.Send(toProtoReading(data)); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// toDomainFilter converts a protobuf filter to the domain filter; a nil filter matches
+// every reading
+func toDomainFilter(req *pb.SensorDataFilter) *domain.SensorDataFilter {
+	filter := &domain.SensorDataFilter{}
+	if req == nil {
+		return filter
+	}
+
+	filter.ID1 = req.Id1
+	for _, id2 := range req.Id2 {
+		filter.ID2 = append(filter.ID2, int(id2))
+	}
+	if req.Id2Min != nil {
+		id2Min := int(*req.Id2Min)
+		filter.ID2Min = &id2Min
+	}
+	if req.Id2Max != nil {
+		id2Max := int(*req.Id2Max)
+		filter.ID2Max = &id2Max
+	}
+	filter.SensorType = req.SensorType
+	filter.MinValue = req.MinValue
+	filter.MaxValue = req.MaxValue
+	if req.StartTime != nil {
+		startTime := time.UnixMilli(*req.StartTime)
+		filter.StartTime = &startTime
+	}
+	if req.EndTime != nil {
+		endTime := time.UnixMilli(*req.EndTime)
+		filter.EndTime = &endTime
+	}
+	filter.TimeField = req.TimeField
+	if req.QualityFlags != nil {
+		flags := int(*req.QualityFlags)
+		filter.QualityFlags = &flags
+	}
+	if req.AsOf != nil {
+		asOf := time.UnixMilli(*req.AsOf)
+		filter.AsOf = &asOf
+	}
+	return filter
+}
+
+// scopedFilter converts a protobuf filter to the domain filter, limited to the scope of
+// the API key the call was made with
+func scopedFilter(ctx context.Context, req *pb.SensorDataFilter) (*domain.SensorDataFilter, error) {
+	filter := toDomainFilter(req)
+	if key := callerAPIKey(ctx); key != nil {
+		if err := key.Restrict(filter); err != nil {
+			return nil, toStatusError(err)
+		}
+	}
+	return filter, nil
+}
+
+// toProtoReading converts a stored domain reading to its protobuf message
+func toProtoReading(data *domain.SensorData) *pb.SensorReading {
+	return &pb.SensorReading{
+		Id:           data.ID,
+		SensorValue:  data.SensorValue,
+		SensorType:   data.SensorType,
+		Id1:          data.ID1,
+		Id2:          int32(data.ID2),
+		EventTime:    data.EventTime,
+		CreatedAt:    data.CreatedAt,
+		QualityFlags: int32(data.QualityFlags),
+		Quality:      domain.QualityFlagList(data.QualityFlags),
+		Revisions:    int32(data.Revisions),
+		ReadingId:    data.ReadingID,
+	}
+}
+
+// toProtoReadings converts stored domain readings to protobuf messages
+func toProtoReadings(data []*domain.SensorData) []*pb.SensorReading {
+	readings := make([]*pb.SensorReading, 0, len(data))
+	for _, item := range data {
+		readings = append(readings, toProtoReading(item))
+	}
+	return readings
+}
+
+// checkScope rejects a reading outside the scope of the API key it was sent with
+func checkScope(key *domain.APIKey, req *pb.SensorData) error {
+	if key != nil && !key.Allows(req.SensorType, req.Id1) {
+		return fmt.Errorf("%w: sensor type %q, id1 %q", domain.ErrOutOfScope, req.SensorType, req.Id1)
+	}
+	return nil
+}
+
+// isRejection reports whether an error rejects a single reading of a batch
+func isRejection(err error) bool {
+	return errors.Is(err, domain.ErrInvalidReading) ||
+		errors.Is(err, domain.ErrEventTimeInFuture) ||
+		errors.Is(err, domain.ErrEventTimeTooOld) ||
+		errors.Is(err, domain.ErrUnknownSensorType)
+}
+
+// isOverload reports whether the write pipeline refused a reading
+func isOverload(err error) bool {
+	return errors.Is(err, domain.ErrWriteQueueFull) || errors.Is(err, domain.ErrWriteQueueClosed)
+}
+
+// toStatusError maps use case errors to gRPC status errors. Unexpected errors are only
+// logged, so clients never see their details.
+func toStatusError(err error) error {
+	switch {
+	case errors.Is(err, domain.ErrInvalidInput),
+		errors.Is(err, domain.ErrInvalidReading),
+		errors.Is(err, domain.ErrEventTimeInFuture),
+		errors.Is(err, domain.ErrEventTimeTooOld),
+		errors.Is(err, domain.ErrUnknownSensorType):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, domain.ErrSensorTypeNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, domain.ErrSensorTypeExists):
+		return status.Error(codes.AlreadyExists, err.Error())
+	case errors.Is(err, domain.ErrSensorTypeInUse):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, domain.ErrSubscriberTooSlow):
+		return status.Error(codes.ResourceExhausted, err.Error())
+	case errors.Is(err, domain.ErrInvalidAPIKey):
+		return status.Error(codes.Unauthenticated, err.Error())
+	case errors.Is(err, domain.ErrOutOfScope):
+		return status.Error(codes.PermissionDenied, err.Error())
+	case isOverload(err):
+		return status.Error(codes.Unavailable, err.Error())
+	default:
+		log.Printf("Internal error serving gRPC call: %v", err)
+		return status.Error(codes.Internal, "internal error")
 	}
 }
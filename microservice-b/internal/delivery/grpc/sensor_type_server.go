@@ -0,0 +1,119 @@
+package grpc
+
+import (
+	"context"
+
+	"sensor_project/microservice-b/internal/domain"
+	pb "sensor_project/proto/sensor_project/proto/sensor"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// SensorTypeServer implements the SensorTypeService gRPC server
+type SensorTypeServer struct {
+	pb.UnimplementedSensorTypeServiceServer
+	sensorTypeUseCase domain.SensorTypeUseCase
+}
+
+// NewSensorTypeServer creates a new gRPC sensor type server
+func NewSensorTypeServer(sensorTypeUseCase domain.SensorTypeUseCase) *SensorTypeServer {
+	return &SensorTypeServer{
+		sensorTypeUseCase: sensorTypeUseCase,
+	}
+}
+
+// RegisterServer registers the gRPC server to the provided gRPC server instance
+func (s *SensorTypeServer) RegisterServer(grpcServer *grpc.Server) {
+	pb.RegisterSensorTypeServiceServer(grpcServer, s)
+}
+
+// CreateSensorType registers a new sensor type
+func (s *SensorTypeServer) CreateSensorType(ctx context.Context, req *pb.SensorType) (*pb.SensorType, error) {
+	sensorType := fromProtoSensorType(req)
+	if err := s.sensorTypeUseCase.Create(sensorType); err != nil {
+		return nil, toStatusError(err)
+	}
+	return toProtoSensorType(sensorType), nil
+}
+
+// GetSensorType returns a sensor type by ID
+func (s *SensorTypeServer) GetSensorType(ctx context.Context, req *pb.SensorTypeID) (*pb.SensorType, error) {
+	sensorType, err := s.sensorTypeUseCase.GetByID(int(req.Id))
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	if sensorType == nil {
+		return nil, status.Error(codes.NotFound, domain.ErrSensorTypeNotFound.Error())
+	}
+	return toProtoSensorType(sensorType), nil
+}
+
+// ListSensorTypes returns all sensor types
+func (s *SensorTypeServer) ListSensorTypes(ctx context.Context, req *pb.ListSensorTypesRequest) (*pb.ListSensorTypesResponse, error) {
+	sensorTypes, err := s.sensorTypeUseCase.List()
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	resp := &pb.ListSensorTypesResponse{}
+	for _, sensorType := range sensorTypes {
+		resp.SensorTypes = append(resp.SensorTypes, toProtoSensorType(sensorType))
+	}
+	return resp, nil
+}
+
+// UpdateSensorType replaces the fields of an existing sensor type
+func (s *SensorTypeServer) UpdateSensorType(ctx context.Context, req *pb.SensorType) (*pb.SensorType, error) {
+	sensorType := fromProtoSensorType(req)
+	if err := s.sensorTypeUseCase.Update(int(req.Id), sensorType); err != nil {
+		return nil, toStatusError(err)
+	}
+	return toProtoSensorType(sensorType), nil
+}
+
+// DeleteSensorType removes a sensor type that has no readings
+func (s *SensorTypeServer) DeleteSensorType(ctx context.Context, req *pb.SensorTypeID) (*pb.SensorResponse, error) {
+	if err := s.sensorTypeUseCase.Delete(int(req.Id)); err != nil {
+		return nil, toStatusError(err)
+	}
+	return &pb.SensorResponse{
+		Success: true,
+		Message: "Sensor type deleted successfully",
+	}, nil
+}
+
+// fromProtoSensorType converts a protobuf sensor type to the domain model
+func fromProtoSensorType(req *pb.SensorType) *domain.SensorType {
+	return &domain.SensorType{
+		ID:               int(req.Id),
+		Name:             req.Name,
+		Unit:             req.Unit,
+		Description:      req.Description,
+		MinValue:         req.MinValue,
+		MaxValue:         req.MaxValue,
+		MaxRateOfChange:  req.MaxRateOfChange,
+		ID1Pattern:       req.Id1Pattern,
+		ID2Pattern:       req.Id2Pattern,
+		ValidationAction: req.ValidationAction,
+	}
+}
+
+// toProtoSensorType converts a domain sensor type to its protobuf message
+func toProtoSensorType(sensorType *domain.SensorType) *pb.SensorType {
+	return &pb.SensorType{
+		Id:               int32(sensorType.ID),
+		Name:             sensorType.Name,
+		Unit:             sensorType.Unit,
+		Description:      sensorType.Description,
+		MinValue:         sensorType.MinValue,
+		MaxValue:         sensorType.MaxValue,
+		MaxRateOfChange:  sensorType.MaxRateOfChange,
+		Id1Pattern:       sensorType.ID1Pattern,
+		Id2Pattern:       sensorType.ID2Pattern,
+		ValidationAction: sensorType.ValidationAction,
+		CreatedAt:        sensorType.CreatedAt,
+		UpdatedAt:        sensorType.UpdatedAt,
+	}
+}
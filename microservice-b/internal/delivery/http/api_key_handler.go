@@ -0,0 +1,159 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"sensor_project/microservice-b/internal/domain"
+
+	"github.com/labstack/echo/v4"
+)
+
+// APIKeyHandler handles HTTP requests for managing API keys
+type APIKeyHandler struct {
+	apiKeyUseCase domain.APIKeyUseCase
+}
+
+// NewAPIKeyHandler creates a new API key HTTP handler
+func NewAPIKeyHandler(apiKeyUseCase domain.APIKeyUseCase) *APIKeyHandler {
+	return &APIKeyHandler{
+		apiKeyUseCase: apiKeyUseCase,
+	}
+}
+
+// SetupRoutes configures the HTTP routes. API keys are managed by admins logged in with
+// a token; a request made with an API key cannot manage keys.
+func (h *APIKeyHandler) SetupRoutes(e *echo.Echo) {
+	api := e.Group("/api")
+
+	// API key routes
+	api.POST("/api-keys", h.CreateAPIKey, requireAdmin, rejectAPIKeys)
+	api.GET("/api-keys", h.ListAPIKeys, requireAdmin, rejectAPIKeys)
+	api.DELETE("/api-keys/:id", h.RevokeAPIKey, requireAdmin, rejectAPIKeys)
+}
+
+// CreateAPIKey issues a new API key
+// @Summary Create API key
+// @Description Issue an API key acting as user_id (default: the requesting admin), sent in the X-API-Key header or x-api-key gRPC metadata. A key limited to sensor_types or devices (id1 values) may only send readings within them, and only read them. The key is only returned in this response.
+// @Tags api-keys
+// @Accept json
+// @Produce json
+// @Param api_key body CreateAPIKeyRequest true "API key"
+// @Success 201 {object} APIKeyResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /api/api-keys [post]
+func (h *APIKeyHandler) CreateAPIKey(c echo.Context) error {
+	req := new(CreateAPIKeyRequest)
+	if err := c.Bind(req); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid request format"})
+	}
+
+	userID := req.UserID
+	if userID == 0 {
+		userID = currentUser(c).ID
+	}
+
+	key, err := h.apiKeyUseCase.Create(&domain.APIKey{
+		Name:        req.Name,
+		UserID:      userID,
+		SensorTypes: req.SensorTypes,
+		Devices:     req.Devices,
+		ExpiresAt:   req.ExpiresAt,
+	})
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidInput) {
+			return c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		}
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to create API key"})
+	}
+
+	return c.JSON(http.StatusCreated, toAPIKeyResponse(key))
+}
+
+// ListAPIKeys lists API keys
+// @Summary List API keys
+// @Description List API keys, newest first, without their secrets
+// @Tags api-keys
+// @Produce json
+// @Param user_id query int false "Only keys acting as this user"
+// @Success 200 {array} APIKeyResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /api/api-keys [get]
+func (h *APIKeyHandler) ListAPIKeys(c echo.Context) error {
+	// Without user_id every key is listed
+	var userID int
+	if value := c.QueryParam("user_id"); value != "" {
+		var err error
+		if userID, err = strconv.Atoi(value); err != nil || userID <= 0 {
+			return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid user_id"})
+		}
+	}
+
+	keys, err := h.apiKeyUseCase.List(userID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to retrieve API keys"})
+	}
+
+	results := make([]APIKeyResponse, 0, len(keys))
+	for _, key := range keys {
+		results = append(results, toAPIKeyResponse(key))
+	}
+
+	return c.JSON(http.StatusOK, results)
+}
+
+// RevokeAPIKey revokes an API key
+// @Summary Revoke API key
+// @Description Revoke an API key; it stops working immediately
+// @Tags api-keys
+// @Produce json
+// @Param id path int true "API key ID"
+// @Success 200 {object} SuccessResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /api/api-keys/{id} [delete]
+func (h *APIKeyHandler) RevokeAPIKey(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid ID format"})
+	}
+
+	if err := h.apiKeyUseCase.Revoke(id, 0); err != nil {
+		if errors.Is(err, domain.ErrAPIKeyNotFound) {
+			return c.JSON(http.StatusNotFound, ErrorResponse{Error: "API key not found"})
+		}
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to revoke API key"})
+	}
+
+	return c.JSON(http.StatusOK, SuccessResponse{
+		Success: true,
+		Message: "API key revoked successfully",
+	})
+}
+
+// toAPIKeyResponse converts a domain API key to the response model
+func toAPIKeyResponse(key *domain.APIKey) APIKeyResponse {
+	response := APIKeyResponse{
+		ID:          key.ID,
+		Name:        key.Name,
+		Key:         key.Key,
+		Prefix:      key.Prefix,
+		UserID:      key.UserID,
+		SensorTypes: key.SensorTypes,
+		Devices:     key.Devices,
+		CreatedAt:   formatMillis(key.CreatedAt.UnixMilli()),
+	}
+	if key.ExpiresAt != nil {
+		response.ExpiresAt = formatMillis(key.ExpiresAt.UnixMilli())
+	}
+	if key.RevokedAt != nil {
+		response.RevokedAt = formatMillis(key.RevokedAt.UnixMilli())
+	}
+	return response
+}
@@ -0,0 +1,160 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"sensor_project/microservice-b/internal/domain"
+
+	"github.com/labstack/echo/v4"
+)
+
+// AuditHandler handles HTTP requests for the audit log of changes to sensor data
+type AuditHandler struct {
+	auditUseCase domain.AuditUseCase
+}
+
+// NewAuditHandler creates a new audit HTTP handler
+func NewAuditHandler(auditUseCase domain.AuditUseCase) *AuditHandler {
+	return &AuditHandler{
+		auditUseCase: auditUseCase,
+	}
+}
+
+// SetupRoutes configures the HTTP routes
+func (h *AuditHandler) SetupRoutes(e *echo.Echo) {
+	api := e.Group("/api")
+
+	// Audit routes
+	api.GET("/audit", h.ListAuditEntries, requireAdmin)
+}
+
+// ListAuditEntries lists audit log entries
+// @Summary List audit log
+// @Description List the audit log of updates and deletes of sensor data, newest first, one entry per reading changed. Pass next_before_id of a page as before_id to get the next page.
+// @Tags audit
+// @Produce json
+// @Param user_id query int false "Only changes made by this user"
+// @Param operation query string false "Only this operation: update, delete, delete_by_filter or restore"
+// @Param sensor_data_id query int false "Only changes to this reading"
+// @Param start_time query string false "Only changes made at or after this time (RFC3339)"
+// @Param end_time query string false "Only changes made at or before this time (RFC3339)"
+// @Param before_id query int false "Only entries older than this entry"
+// @Param limit query int false "Maximum number of entries (default 100, max 1000)"
+// @Success 200 {object} AuditPageResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /api/audit [get]
+func (h *AuditHandler) ListAuditEntries(c echo.Context) error {
+	filter, err := parseAuditFilter(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+	}
+
+	entries, err := h.auditUseCase.List(filter)
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidInput) {
+			return c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		}
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to retrieve audit log"})
+	}
+
+	response := AuditPageResponse{Entries: make([]AuditEntryResponse, 0, len(entries))}
+	for _, entry := range entries {
+		response.Entries = append(response.Entries, toAuditEntryResponse(entry))
+	}
+	// A full page may be followed by older entries
+	if len(entries) > 0 && len(entries) == filter.Limit {
+		response.NextBeforeID = entries[len(entries)-1].ID
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+// parseAuditFilter parses the audit log filter from query parameters
+func parseAuditFilter(c echo.Context) (*domain.AuditFilter, error) {
+	filter := &domain.AuditFilter{
+		Operation: c.QueryParam("operation"),
+	}
+
+	userID, err := queryInt(c, "user_id")
+	if err != nil {
+		return nil, err
+	}
+	if userID != nil {
+		filter.ActorUserID = *userID
+	}
+	limit, err := queryInt(c, "limit")
+	if err != nil {
+		return nil, err
+	}
+	if limit != nil {
+		filter.Limit = *limit
+	}
+
+	if filter.SensorDataID, err = queryInt64(c, "sensor_data_id"); err != nil {
+		return nil, err
+	}
+	if filter.BeforeID, err = queryInt64(c, "before_id"); err != nil {
+		return nil, err
+	}
+
+	if value := c.QueryParam("start_time"); value != "" {
+		startTime, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid start_time %q", value)
+		}
+		filter.StartTime = &startTime
+	}
+	if value := c.QueryParam("end_time"); value != "" {
+		endTime, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid end_time %q", value)
+		}
+		filter.EndTime = &endTime
+	}
+
+	return filter, nil
+}
+
+// queryInt64 parses an optional 64-bit integer query parameter, 0 when absent
+func queryInt64(c echo.Context, name string) (int64, error) {
+	value := c.QueryParam(name)
+	if value == "" {
+		return 0, nil
+	}
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s %q", name, value)
+	}
+	return n, nil
+}
+
+// toAuditEntryResponse converts a domain audit entry to the response model
+func toAuditEntryResponse(entry *domain.AuditEntry) AuditEntryResponse {
+	response := AuditEntryResponse{
+		ID: entry.ID,
+		Actor: ActorResponse{
+			UserID:   entry.Actor.UserID,
+			Username: entry.Actor.Username,
+			APIKeyID: entry.Actor.APIKeyID,
+		},
+		Operation:    entry.Operation,
+		SensorDataID: entry.SensorDataID,
+		Filter:       entry.Filter,
+		CreatedAt:    formatMillis(entry.CreatedAt.UnixMilli()),
+	}
+	if entry.Before != nil {
+		before := toSensorDataResponse(entry.Before)
+		response.Before = &before
+	}
+	if entry.After != nil {
+		after := toSensorDataResponse(entry.After)
+		response.After = &after
+	}
+	return response
+}
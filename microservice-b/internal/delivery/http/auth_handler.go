@@ -0,0 +1,112 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"sensor_project/microservice-b/internal/domain"
+
+	"github.com/labstack/echo/v4"
+)
+
+// AuthHandler handles HTTP requests for logging in and refreshing tokens
+type AuthHandler struct {
+	authUseCase domain.AuthUseCase
+}
+
+// NewAuthHandler creates a new auth HTTP handler
+func NewAuthHandler(authUseCase domain.AuthUseCase) *AuthHandler {
+	return &AuthHandler{
+		authUseCase: authUseCase,
+	}
+}
+
+// SetupRoutes configures the HTTP routes
+func (h *AuthHandler) SetupRoutes(e *echo.Echo) {
+	api := e.Group("/api")
+
+	// Auth routes
+	api.POST("/auth/login", h.Login)
+	api.POST("/auth/refresh", h.Refresh)
+}
+
+// Login issues tokens for a username and password
+// @Summary Log in
+// @Description Exchange a username and password for a bearer access token, sent as "Authorization: Bearer <token>", and a refresh token
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body LoginRequest true "Credentials"
+// @Success 200 {object} TokenResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/auth/login [post]
+func (h *AuthHandler) Login(c echo.Context) error {
+	req := new(LoginRequest)
+	if err := c.Bind(req); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid request format"})
+	}
+	if req.Username == "" || req.Password == "" {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "username and password are required"})
+	}
+
+	user, err := h.authUseCase.Authenticate(req.Username, req.Password)
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidCredentials) {
+			return c.JSON(http.StatusUnauthorized, ErrorResponse{Error: err.Error()})
+		}
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to log in"})
+	}
+
+	tokens, err := h.authUseCase.GenerateTokens(user)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to issue tokens"})
+	}
+
+	return c.JSON(http.StatusOK, toTokenResponse(tokens))
+}
+
+// Refresh exchanges a refresh token for new tokens
+// @Summary Refresh tokens
+// @Description Exchange a refresh token for a new access token and refresh token; the new access token carries the user's current roles
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body RefreshRequest true "Refresh token"
+// @Success 200 {object} TokenResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/auth/refresh [post]
+func (h *AuthHandler) Refresh(c echo.Context) error {
+	req := new(RefreshRequest)
+	if err := c.Bind(req); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid request format"})
+	}
+	if req.RefreshToken == "" {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "refresh_token is required"})
+	}
+
+	tokens, err := h.authUseCase.Refresh(req.RefreshToken)
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidToken) {
+			return unauthorized(c, err.Error())
+		}
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to refresh tokens"})
+	}
+
+	return c.JSON(http.StatusOK, toTokenResponse(tokens))
+}
+
+// toTokenResponse converts issued tokens to the response model
+func toTokenResponse(tokens *domain.AuthTokens) TokenResponse {
+	return TokenResponse{
+		AccessToken:      tokens.AccessToken,
+		TokenType:        "Bearer",
+		ExpiresIn:        int64(time.Until(tokens.AccessExpiresAt).Round(time.Second).Seconds()),
+		RefreshToken:     tokens.RefreshToken,
+		RefreshExpiresAt: formatMillis(tokens.RefreshExpiresAt.UnixMilli()),
+	}
+}
@@ -1,11 +1,22 @@
 package http
 
 import (
+	"bufio"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
 	"net/http"
+	"slices"
 	"strconv"
+	"strings"
 	"time"
 
+	"sensor_project/microservice-b/internal/config"
 	"sensor_project/microservice-b/internal/domain"
+	"sensor_project/microservice-b/internal/export"
 
 	"github.com/labstack/echo/v4"
 )
@@ -13,12 +24,16 @@ import (
 // Handler handles HTTP requests for the sensor data API
 type Handler struct {
 	sensorUseCase domain.SensorDataUseCase
+	deleteUseCase domain.DeleteUseCase
+	config        *config.Manager
 }
 
 // NewHandler creates a new HTTP handler
-func NewHandler(sensorUseCase domain.SensorDataUseCase) *Handler {
+func NewHandler(sensorUseCase domain.SensorDataUseCase, deleteUseCase domain.DeleteUseCase, configManager *config.Manager) *Handler {
 	return &Handler{
 		sensorUseCase: sensorUseCase,
+		deleteUseCase: deleteUseCase,
+		config:        configManager,
 	}
 }
 
@@ -27,15 +42,24 @@ func (h *Handler) SetupRoutes(e *echo.Echo) {
 	// Health check
 	e.GET("/health", h.HealthCheck)
 
+	// Active configuration
+	e.GET("/config", h.GetConfig, requireAdmin)
+
 	// API routes
 	api := e.Group("/api")
 
 	// Sensor data routes
-	api.GET("/sensor-data/:id", h.GetSensorDataByID)
-	api.GET("/sensor-data", h.GetSensorDataByFilter)
-	api.PUT("/sensor-data/:id", h.UpdateSensorData)
-	api.DELETE("/sensor-data/:id", h.DeleteSensorData)
-	api.DELETE("/sensor-data", h.DeleteSensorDataByFilter)
+	api.GET("/sensor-data/aggregate", h.GetSensorDataAggregate, requireUser)
+	api.GET("/sensor-data/export", h.ExportSensorData, requireUser)
+	api.GET("/sensor-data/delete-jobs/:id", h.GetDeleteJob, requireAdmin)
+	api.GET("/sensor-data/deletions", h.ListDeletions, requireAdmin)
+	api.POST("/sensor-data/deletions/:batch_id/restore", h.RestoreDeletion, requireAdmin)
+	api.GET("/sensor-data/:id", h.GetSensorDataByID, requireUser)
+	api.GET("/sensor-data/:id/history", h.GetSensorDataHistory, requireUser)
+	api.GET("/sensor-data", h.GetSensorDataByFilter, requireUser)
+	api.PUT("/sensor-data/:id", h.UpdateSensorData, requireAdmin)
+	api.DELETE("/sensor-data/:id", h.DeleteSensorData, requireAdmin)
+	api.DELETE("/sensor-data", h.DeleteSensorDataByFilter, requireAdmin)
 }
 
 // HealthCheck handles health check requests
@@ -51,13 +75,27 @@ func (h *Handler) HealthCheck(c echo.Context) error {
 	})
 }
 
+// GetConfig returns the active configuration
+// @Summary Get configuration
+// @Description Dump the active configuration with secrets redacted
+// @Tags config
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Router /config [get]
+func (h *Handler) GetConfig(c echo.Context) error {
+	return c.JSON(http.StatusOK, h.config.Current().Redacted())
+}
+
 // GetSensorDataByID retrieves a sensor data record by ID
 // @Summary Get sensor data by ID
 // @Description Retrieve a single sensor data record by its ID
 // @Tags sensor-data
 // @Produce json
 // @Param id path int true "Sensor Data ID"
+// @Param include_deleted query bool false "Include deleted records (admin only)"
 // @Success 200 {object} SensorDataResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
 // @Failure 404 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Security ApiKeyAuth
@@ -68,22 +106,80 @@ func (h *Handler) GetSensorDataByID(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid ID format"})
 	}
 
-	data, err := h.sensorUseCase.GetByID(id)
+	includeDeleted, err := queryIncludeDeleted(c)
+	if err != nil {
+		return includeDeletedError(c, err)
+	}
+
+	data, err := h.sensorUseCase.GetByID(id, includeDeleted)
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to retrieve sensor data"})
 	}
 
-	if data == nil {
+	// Readings outside the scope of the request's API key are not disclosed
+	if key := currentAPIKey(c); data == nil || key != nil && !key.Allows(data.SensorType, data.ID1) {
 		return c.JSON(http.StatusNotFound, ErrorResponse{Error: "Sensor data not found"})
 	}
 
-	return c.JSON(http.StatusOK, SensorDataResponse{
-		ID:          data.ID,
-		SensorValue: data.SensorValue,
-		SensorType:  data.SensorType,
-		ID1:         data.ID1,
-		ID2:         data.ID2,
-	})
+	return c.JSON(http.StatusOK, toSensorDataResponse(data))
+}
+
+// GetSensorDataHistory retrieves every value a sensor data record has had
+// @Summary Get sensor data history
+// @Description Retrieve a sensor data record with every value it has had, oldest first: revision 0 is the measured value, followed by each correction with its reason code
+// @Tags sensor-data
+// @Produce json
+// @Param id path int true "Sensor Data ID"
+// @Param include_deleted query bool false "Include deleted records (admin only)"
+// @Success 200 {object} SensorDataHistoryResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /api/sensor-data/{id}/history [get]
+func (h *Handler) GetSensorDataHistory(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid ID format"})
+	}
+
+	includeDeleted, err := queryIncludeDeleted(c)
+	if err != nil {
+		return includeDeletedError(c, err)
+	}
+
+	data, revisions, err := h.sensorUseCase.GetHistory(id, includeDeleted)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to retrieve sensor data history"})
+	}
+
+	// Readings outside the scope of the request's API key are not disclosed
+	if key := currentAPIKey(c); data == nil || key != nil && !key.Allows(data.SensorType, data.ID1) {
+		return c.JSON(http.StatusNotFound, ErrorResponse{Error: "Sensor data not found"})
+	}
+
+	response := SensorDataHistoryResponse{
+		SensorData: toSensorDataResponse(data),
+		Revisions:  make([]SensorDataRevisionResponse, 0, len(revisions)),
+	}
+	for _, revision := range revisions {
+		result := SensorDataRevisionResponse{
+			Revision:    revision.Revision,
+			SensorValue: revision.SensorValue,
+			Reason:      revision.Reason,
+			CreatedAt:   formatMillis(revision.CreatedAt),
+		}
+		if revision.Revision > 0 {
+			result.Actor = &ActorResponse{
+				UserID:   revision.Actor.UserID,
+				Username: revision.Actor.Username,
+				APIKeyID: revision.Actor.APIKeyID,
+			}
+		}
+		response.Revisions = append(response.Revisions, result)
+	}
+	return c.JSON(http.StatusOK, response)
 }
 
 // GetSensorDataByFilter retrieves sensor data records based on filter criteria
@@ -91,15 +187,29 @@ func (h *Handler) GetSensorDataByID(c echo.Context) error {
 // @Description Retrieve sensor data records based on filter criteria with pagination
 // @Tags sensor-data
 // @Produce json
-// @Param id1 query string false "ID1 filter"
-// @Param id2 query int false "ID2 filter"
-// @Param sensor_type query string false "Sensor type filter"
+// @Param id1 query string false "Comma-separated ID1 values"
+// @Param id2 query string false "Comma-separated ID2 values"
+// @Param id2_min query int false "Minimum ID2"
+// @Param id2_max query int false "Maximum ID2"
+// @Param sensor_type query string false "Comma-separated sensor types"
+// @Param min_value query number false "Minimum sensor value"
+// @Param max_value query number false "Maximum sensor value"
 // @Param start_time query string false "Start time filter (RFC3339 format)"
 // @Param end_time query string false "End time filter (RFC3339 format)"
+// @Param time_field query string false "Time to filter and order by: event_time (default) or created_at"
+// @Param quality_flags query string false "Readings with any of these quality flags, as a bitmask or comma-separated names; 0 selects readings without flags"
+// @Param sort query string false "Comma-separated sort keys value, time or id, each optionally suffixed :asc or :desc (default: time:desc); not with cursor pagination"
+// @Param fields query string false "Comma-separated response fields (default: all)"
 // @Param page query int false "Page number (default: 1)"
 // @Param page_size query int false "Page size (default: 10, max: 100)"
+// @Param pagination query string false "offset (default) or cursor; a cursor parameter implies cursor"
+// @Param cursor query string false "next_cursor or prev_cursor of a previous cursor page"
+// @Param include_total query bool false "Count matching records in cursor pagination"
+// @Param as_of query string false "Reproduce the results as they were at this time (RFC3339 format)"
+// @Param include_deleted query bool false "Include deleted records (admin only)"
 // @Success 200 {object} PaginatedResponse
 // @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Security ApiKeyAuth
 // @Router /api/sensor-data [get]
@@ -108,6 +218,28 @@ func (h *Handler) GetSensorDataByFilter(c echo.Context) error {
 	if err != nil {
 		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
 	}
+	if filter.IncludeDeleted, err = queryIncludeDeleted(c); err != nil {
+		return includeDeletedError(c, err)
+	}
+	if filter.AsOf, err = queryTime(c, "as_of"); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+	}
+
+	fields, err := parseFields(c.QueryParam("fields"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+	}
+
+	switch c.QueryParam("pagination") {
+	case "", "offset":
+		if c.QueryParam("cursor") != "" {
+			return h.getSensorDataPage(c, filter, fields)
+		}
+	case "cursor":
+		return h.getSensorDataPage(c, filter, fields)
+	default:
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid pagination, expected offset or cursor"})
+	}
 
 	data, total, err := h.sensorUseCase.GetByFilter(filter)
 	if err != nil {
@@ -117,27 +249,247 @@ func (h *Handler) GetSensorDataByFilter(c echo.Context) error {
 	// Convert domain models to response models
 	var results []SensorDataResponse
 	for _, item := range data {
-		results = append(results, SensorDataResponse{
-			ID:          item.ID,
-			SensorValue: item.SensorValue,
-			SensorType:  item.SensorType,
-			ID1:         item.ID1,
-			ID2:         item.ID2,
-		})
+		result := toSensorDataResponse(item)
+		result.fields = fields
+		results = append(results, result)
 	}
 
+	totalPages := (total + filter.PageSize - 1) / filter.PageSize
 	return c.JSON(http.StatusOK, PaginatedResponse{
 		Data:       results,
-		Total:      total,
+		Total:      &total,
 		Page:       filter.Page,
 		PageSize:   filter.PageSize,
-		TotalPages: (total + filter.PageSize - 1) / filter.PageSize,
+		TotalPages: &totalPages,
+	})
+}
+
+// getSensorDataPage responds with a cursor page of sensor data records, newest first
+// by ingest time and limited to the given fields
+func (h *Handler) getSensorDataPage(c echo.Context, filter *domain.SensorDataFilter, fields []string) error {
+	var cursor *domain.PageCursor
+	if value := c.QueryParam("cursor"); value != "" {
+		var err error
+		if cursor, err = domain.DecodePageCursor(value); err != nil {
+			return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid cursor"})
+		}
+	}
+
+	includeTotal := false
+	if value := c.QueryParam("include_total"); value != "" {
+		var err error
+		if includeTotal, err = strconv.ParseBool(value); err != nil {
+			return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid include_total value"})
+		}
+	}
+
+	page, err := h.sensorUseCase.GetPage(filter, cursor, includeTotal)
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidInput) {
+			return c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		}
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to retrieve sensor data"})
+	}
+
+	results := make([]SensorDataResponse, 0, len(page.Data))
+	for _, item := range page.Data {
+		result := toSensorDataResponse(item)
+		result.fields = fields
+		results = append(results, result)
+	}
+
+	return c.JSON(http.StatusOK, PaginatedResponse{
+		Data:       results,
+		Total:      page.Total,
+		PageSize:   filter.PageSize,
+		NextCursor: page.NextCursor.Encode(),
+		PrevCursor: page.PrevCursor.Encode(),
 	})
 }
 
+// GetSensorDataAggregate aggregates sensor data into time buckets
+// @Summary Aggregate sensor data
+// @Description Group filtered sensor data into time buckets per sensor type and device and compute aggregate functions
+// @Tags sensor-data
+// @Produce json
+// @Param interval query string true "Bucket interval: 1m, 5m, 1h or 1d"
+// @Param functions query string false "Comma-separated functions: min, max, avg, count, sum, stddev, first, last (default: count,avg,min,max)"
+// @Param id1 query string false "Comma-separated ID1 values"
+// @Param id2 query string false "Comma-separated ID2 values"
+// @Param id2_min query int false "Minimum ID2"
+// @Param id2_max query int false "Maximum ID2"
+// @Param sensor_type query string false "Comma-separated sensor types"
+// @Param min_value query number false "Minimum sensor value"
+// @Param max_value query number false "Maximum sensor value"
+// @Param start_time query string false "Start time filter (RFC3339 format)"
+// @Param end_time query string false "End time filter (RFC3339 format)"
+// @Param time_field query string false "Time to filter and bucket by: event_time (default) or created_at"
+// @Param quality_flags query string false "Readings with any of these quality flags, as a bitmask or comma-separated names; 0 selects readings without flags"
+// @Param as_of query string false "Reproduce the results as they were at this time (RFC3339 format)"
+// @Success 200 {object} AggregateResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /api/sensor-data/aggregate [get]
+func (h *Handler) GetSensorDataAggregate(c echo.Context) error {
+	filter, err := parseFilterFromQuery(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+	}
+	if filter.AsOf, err = queryTime(c, "as_of"); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+	}
+
+	intervalName := c.QueryParam("interval")
+	interval, ok := domain.AggregateIntervals[intervalName]
+	if !ok {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: fmt.Sprintf("invalid interval %q, expected 1m, 5m, 1h or 1d", intervalName)})
+	}
+
+	query := &domain.AggregateQuery{
+		Filter:   *filter,
+		Interval: interval,
+	}
+	if functions := c.QueryParam("functions"); functions != "" {
+		for _, fn := range strings.Split(functions, ",") {
+			query.Functions = append(query.Functions, strings.TrimSpace(fn))
+		}
+	}
+
+	result, err := h.sensorUseCase.Aggregate(query)
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidInput) {
+			return c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		}
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to aggregate sensor data"})
+	}
+
+	results := make([]AggregateBucketResponse, 0, len(result.Buckets))
+	for _, bucket := range result.Buckets {
+		results = append(results, AggregateBucketResponse{
+			BucketStart: formatMillis(bucket.BucketStart),
+			SensorType:  bucket.SensorType,
+			ID1:         bucket.ID1,
+			ID2:         bucket.ID2,
+			Values:      bucket.Values,
+		})
+	}
+
+	return c.JSON(http.StatusOK, AggregateResponse{
+		Interval:  intervalName,
+		Functions: query.Functions,
+		Data:      results,
+		Truncated: result.Truncated,
+		Source:    result.Source,
+	})
+}
+
+// ExportSensorData streams all sensor data records matching the filters as a file
+// @Summary Export sensor data
+// @Description Stream every filtered sensor data record, oldest first unless sorted otherwise, as CSV, NDJSON or Parquet. The response is gzip-compressed when the client accepts it.
+// @Tags sensor-data
+// @Produce text/csv,application/x-ndjson,application/vnd.apache.parquet
+// @Param format query string false "Export format: csv (default), ndjson or parquet"
+// @Param columns query string false "Comma-separated columns to export (default: all)"
+// @Param id1 query string false "Comma-separated ID1 values"
+// @Param id2 query string false "Comma-separated ID2 values"
+// @Param id2_min query int false "Minimum ID2"
+// @Param id2_max query int false "Maximum ID2"
+// @Param sensor_type query string false "Comma-separated sensor types"
+// @Param min_value query number false "Minimum sensor value"
+// @Param max_value query number false "Maximum sensor value"
+// @Param start_time query string false "Start time filter (RFC3339 format)"
+// @Param end_time query string false "End time filter (RFC3339 format)"
+// @Param time_field query string false "Time to filter and order by: event_time (default) or created_at"
+// @Param quality_flags query string false "Readings with any of these quality flags, as a bitmask or comma-separated names; 0 selects readings without flags"
+// @Param sort query string false "Comma-separated sort keys value, time or id, each optionally suffixed :asc or :desc (default: time:asc)"
+// @Param as_of query string false "Reproduce the results as they were at this time (RFC3339 format)"
+// @Param include_deleted query bool false "Include deleted records (admin only)"
+// @Success 200 {file} file
+// @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /api/sensor-data/export [get]
+func (h *Handler) ExportSensorData(c echo.Context) error {
+	filter, err := parseFilterFromQuery(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+	}
+	if filter.IncludeDeleted, err = queryIncludeDeleted(c); err != nil {
+		return includeDeletedError(c, err)
+	}
+	if filter.AsOf, err = queryTime(c, "as_of"); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+	}
+
+	format := c.QueryParam("format")
+	if format == "" {
+		format = export.FormatCSV
+	}
+	contentType, ok := export.ContentTypes[format]
+	if !ok {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: fmt.Sprintf("invalid format %q, expected %s, %s or %s", format, export.FormatCSV, export.FormatNDJSON, export.FormatParquet)})
+	}
+
+	columns, err := export.ParseColumns(c.QueryParam("columns"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+	}
+
+	res := c.Response()
+	header := res.Header()
+	header.Set(echo.HeaderContentType, contentType)
+	header.Set(echo.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="sensor-data-%s.%s"`, time.Now().UTC().Format("20060102T150405Z"), format))
+	header.Add(echo.HeaderVary, echo.HeaderAcceptEncoding)
+
+	// Nothing reaches the client until the buffer first fills, so errors before then
+	// can still be reported as a normal error response
+	var out io.Writer = res
+	var gz *gzip.Writer
+	if strings.Contains(c.Request().Header.Get(echo.HeaderAcceptEncoding), "gzip") {
+		header.Set(echo.HeaderContentEncoding, "gzip")
+		gz = gzip.NewWriter(res)
+		out = gz
+	}
+	buf := bufio.NewWriterSize(out, 64*1024)
+
+	writer, err := export.NewWriter(format, buf, columns)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+	}
+
+	err = h.sensorUseCase.Export(filter, writer.Write)
+	if err == nil {
+		err = writer.Close()
+	}
+	if err == nil {
+		err = buf.Flush()
+	}
+	if err == nil && gz != nil {
+		err = gz.Close()
+	}
+
+	if err != nil {
+		if !res.Committed {
+			header.Del(echo.HeaderContentType)
+			header.Del(echo.HeaderContentDisposition)
+			header.Del(echo.HeaderContentEncoding)
+			return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to export sensor data"})
+		}
+
+		// Abort the connection so the client sees an incomplete download rather than a
+		// truncated file that looks complete
+		log.Printf("Sensor data export failed after %d bytes: %v", res.Size, err)
+		panic(http.ErrAbortHandler)
+	}
+
+	return nil
+}
+
 // UpdateSensorData updates a sensor data record
 // @Summary Update sensor data
-// @Description Update a sensor data record by ID
+// @Description Correct the value of a sensor data record by ID with a reason code: calibration, sensor_fault, data_entry or other. The measured value and earlier corrections are kept in the record's history.
 // @Tags sensor-data
 // @Accept json
 // @Produce json
@@ -156,7 +508,7 @@ func (h *Handler) UpdateSensorData(c echo.Context) error {
 	}
 
 	// Check if the record exists
-	data, err := h.sensorUseCase.GetByID(id)
+	data, err := h.sensorUseCase.GetByID(id, false)
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to retrieve sensor data"})
 	}
@@ -174,11 +526,18 @@ func (h *Handler) UpdateSensorData(c echo.Context) error {
 	// Create the update model
 	update := &domain.SensorDataUpdate{
 		SensorValue: &req.SensorValue,
+		Reason:      req.Reason,
 	}
 
 	// Update the record
-	err = h.sensorUseCase.Update(id, update)
+	err = h.sensorUseCase.Update(id, update, currentActor(c))
 	if err != nil {
+		if errors.Is(err, domain.ErrSensorDataNotFound) {
+			return c.JSON(http.StatusNotFound, ErrorResponse{Error: "Sensor data not found"})
+		}
+		if errors.Is(err, domain.ErrInvalidInput) {
+			return c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		}
 		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to update sensor data"})
 	}
 
@@ -190,12 +549,13 @@ func (h *Handler) UpdateSensorData(c echo.Context) error {
 
 // DeleteSensorData deletes a sensor data record by ID
 // @Summary Delete sensor data
-// @Description Delete a sensor data record by ID
+// @Description Mark a sensor data record deleted. It can be restored through its deletion batch until it is purged after delete_grace_period.
 // @Tags sensor-data
 // @Produce json
 // @Param id path int true "Sensor Data ID"
-// @Success 200 {object} SuccessResponse
+// @Success 200 {object} DeleteResponse
 // @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Security ApiKeyAuth
 // @Router /api/sensor-data/{id} [delete]
@@ -205,26 +565,34 @@ func (h *Handler) DeleteSensorData(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid ID format"})
 	}
 
-	err = h.sensorUseCase.Delete(id)
+	batchID, err := h.sensorUseCase.Delete(id, currentActor(c))
+	if errors.Is(err, domain.ErrSensorDataNotFound) {
+		return c.JSON(http.StatusNotFound, ErrorResponse{Error: "Sensor data not found"})
+	}
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to delete sensor data"})
 	}
 
-	return c.JSON(http.StatusOK, SuccessResponse{
+	return c.JSON(http.StatusOK, DeleteResponse{
 		Success: true,
 		Message: "Sensor data deleted successfully",
+		BatchID: batchID,
 	})
 }
 
 // DeleteSensorDataByFilter deletes sensor data records based on filter criteria
 // @Summary Delete sensor data by filter
-// @Description Delete sensor data records based on filter criteria
+// @Description Mark sensor data records deleted based on filter criteria. An empty filter is refused unless all=true is given by an admin. Deletes matching more than delete_max_rows records run in the background in chunks; poll the returned job for progress. The deleted records form a deletion batch, identified by the job ID for background deletes, that can be restored until it is purged after delete_grace_period.
 // @Tags sensor-data
 // @Accept json
 // @Produce json
 // @Param filter body FilterRequest true "Filter criteria"
+// @Param dry_run query bool false "Only count the matching records"
+// @Param all query bool false "Allow an empty filter to delete every record (admin only)"
 // @Success 200 {object} DeleteResponse
+// @Success 202 {object} DeleteJobResponse
 // @Failure 400 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Security ApiKeyAuth
 // @Router /api/sensor-data [delete]
@@ -234,47 +602,189 @@ func (h *Handler) DeleteSensorDataByFilter(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid request format"})
 	}
 
+	if err := validateTimeField(req.TimeField); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+	}
+
+	opts := domain.DeleteOptions{Actor: currentActor(c)}
+	var err error
+	if opts.DryRun, err = queryBool(c, "dry_run"); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+	}
+	if opts.All, err = queryBool(c, "all"); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+	}
+	if opts.All && !hasRole(c, domain.RoleAdmin) {
+		return c.JSON(http.StatusForbidden, ErrorResponse{Error: "Deleting all sensor data requires the admin role"})
+	}
+
 	filter := &domain.SensorDataFilter{
-		ID1:        req.ID1,
-		ID2:        req.ID2,
-		SensorType: req.SensorType,
-		StartTime:  req.StartTime,
-		EndTime:    req.EndTime,
+		ID1:          req.ID1,
+		ID2:          req.ID2,
+		ID2Min:       req.ID2Min,
+		ID2Max:       req.ID2Max,
+		SensorType:   req.SensorType,
+		MinValue:     req.MinValue,
+		MaxValue:     req.MaxValue,
+		StartTime:    req.StartTime,
+		EndTime:      req.EndTime,
+		TimeField:    req.TimeField,
+		QualityFlags: req.QualityFlags,
 	}
 
-	count, err := h.sensorUseCase.DeleteByFilter(filter)
+	result, err := h.deleteUseCase.DeleteByFilter(filter, opts)
 	if err != nil {
+		if errors.Is(err, domain.ErrEmptyFilter) {
+			return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "An empty filter would delete all sensor data; pass all=true to confirm"})
+		}
 		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to delete sensor data"})
 	}
 
+	if result.Job != nil {
+		c.Response().Header().Set(echo.HeaderLocation, "/api/sensor-data/delete-jobs/"+result.Job.ID)
+		return c.JSON(http.StatusAccepted, toDeleteJobResponse(result.Job))
+	}
+
+	message := "Sensor data deleted successfully"
+	if result.DryRun {
+		message = "Dry run, no sensor data deleted"
+	}
 	return c.JSON(http.StatusOK, DeleteResponse{
 		Success:     true,
-		Message:     "Sensor data deleted successfully",
-		DeletedRows: count,
+		Message:     message,
+		DeletedRows: int(result.Deleted),
+		MatchedRows: int(result.Matched),
+		DryRun:      result.DryRun,
+		BatchID:     result.BatchID,
 	})
 }
 
+// ListDeletions lists the deletion batches that can still be restored
+// @Summary List deletion batches
+// @Description List the batches of deleted sensor data records that have not been purged yet, most recent first
+// @Tags sensor-data
+// @Produce json
+// @Success 200 {array} DeletionBatchResponse
+// @Failure 500 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /api/sensor-data/deletions [get]
+func (h *Handler) ListDeletions(c echo.Context) error {
+	batches, err := h.deleteUseCase.ListDeletions()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to list deletion batches"})
+	}
+
+	results := make([]DeletionBatchResponse, 0, len(batches))
+	for _, batch := range batches {
+		results = append(results, DeletionBatchResponse{
+			ID:        batch.ID,
+			Rows:      batch.Rows,
+			DeletedAt: formatMillis(batch.DeletedAt),
+			PurgeAt:   formatMillis(batch.PurgeAt),
+		})
+	}
+	return c.JSON(http.StatusOK, results)
+}
+
+// RestoreDeletion restores the sensor data records of a deletion batch
+// @Summary Restore deletion batch
+// @Description Restore the sensor data records of a deletion batch that have not been purged yet. Restoring the batch of a running delete job only restores the records deleted so far.
+// @Tags sensor-data
+// @Produce json
+// @Param batch_id path string true "Deletion batch ID"
+// @Success 200 {object} RestoreResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /api/sensor-data/deletions/{batch_id}/restore [post]
+func (h *Handler) RestoreDeletion(c echo.Context) error {
+	restored, err := h.deleteUseCase.Restore(c.Param("batch_id"), currentActor(c))
+	if err != nil {
+		if errors.Is(err, domain.ErrDeletionBatchNotFound) {
+			return c.JSON(http.StatusNotFound, ErrorResponse{Error: "Deletion batch not found"})
+		}
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to restore sensor data"})
+	}
+
+	return c.JSON(http.StatusOK, RestoreResponse{
+		Success:      true,
+		Message:      "Sensor data restored successfully",
+		RestoredRows: restored,
+	})
+}
+
+// GetDeleteJob reports the progress of a delete by filter running in the background
+// @Summary Get delete job
+// @Description Retrieve the progress of a delete by filter running in the background. Finished jobs are kept for 24 hours.
+// @Tags sensor-data
+// @Produce json
+// @Param id path string true "Delete job ID"
+// @Success 200 {object} DeleteJobResponse
+// @Failure 404 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /api/sensor-data/delete-jobs/{id} [get]
+func (h *Handler) GetDeleteJob(c echo.Context) error {
+	job := h.deleteUseCase.GetJob(c.Param("id"))
+	if job == nil {
+		return c.JSON(http.StatusNotFound, ErrorResponse{Error: "Delete job not found"})
+	}
+
+	return c.JSON(http.StatusOK, toDeleteJobResponse(job))
+}
+
+// errIncludeDeletedForbidden is returned by queryIncludeDeleted for non-admins
+var errIncludeDeletedForbidden = errors.New("including deleted sensor data requires the admin role")
+
+// queryIncludeDeleted parses the include_deleted query parameter, which only admins may set
+func queryIncludeDeleted(c echo.Context) (bool, error) {
+	includeDeleted, err := queryBool(c, "include_deleted")
+	if err != nil {
+		return false, err
+	}
+	if includeDeleted && !hasRole(c, domain.RoleAdmin) {
+		return false, errIncludeDeletedForbidden
+	}
+	return includeDeleted, nil
+}
+
+// includeDeletedError responds to an error of queryIncludeDeleted
+func includeDeletedError(c echo.Context, err error) error {
+	if errors.Is(err, errIncludeDeletedForbidden) {
+		return c.JSON(http.StatusForbidden, ErrorResponse{Error: "Including deleted sensor data requires the admin role"})
+	}
+	return c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+}
+
 // parseFilterFromQuery parses filter parameters from the query string
 func parseFilterFromQuery(c echo.Context) (*domain.SensorDataFilter, error) {
 	filter := &domain.SensorDataFilter{}
 
-	// Parse ID1
-	if id1 := c.QueryParam("id1"); id1 != "" {
-		filter.ID1 = &id1
-	}
-
-	// Parse ID2
-	if id2Str := c.QueryParam("id2"); id2Str != "" {
-		id2, err := strconv.Atoi(id2Str)
+	// Parse series filters; each takes comma-separated or repeated values
+	filter.ID1 = queryList(c, "id1")
+	for _, value := range queryList(c, "id2") {
+		id2, err := strconv.Atoi(value)
 		if err != nil {
-			return nil, err
+			return nil, fmt.Errorf("invalid id2 %q", value)
 		}
-		filter.ID2 = &id2
+		filter.ID2 = append(filter.ID2, id2)
+	}
+	filter.SensorType = queryList(c, "sensor_type")
+
+	// Parse id2 range
+	var err error
+	if filter.ID2Min, err = queryInt(c, "id2_min"); err != nil {
+		return nil, err
+	}
+	if filter.ID2Max, err = queryInt(c, "id2_max"); err != nil {
+		return nil, err
 	}
 
-	// Parse sensor type
-	if sensorType := c.QueryParam("sensor_type"); sensorType != "" {
-		filter.SensorType = &sensorType
+	// Parse value bounds
+	if filter.MinValue, err = queryFloat(c, "min_value"); err != nil {
+		return nil, err
+	}
+	if filter.MaxValue, err = queryFloat(c, "max_value"); err != nil {
+		return nil, err
 	}
 
 	// Parse start time
@@ -295,6 +805,26 @@ func parseFilterFromQuery(c echo.Context) (*domain.SensorDataFilter, error) {
 		filter.EndTime = &endTime
 	}
 
+	// Parse time field
+	filter.TimeField = c.QueryParam("time_field")
+	if err := validateTimeField(filter.TimeField); err != nil {
+		return nil, err
+	}
+
+	// Parse quality flags
+	if qualityStr := c.QueryParam("quality_flags"); qualityStr != "" {
+		flags, err := parseQualityFlags(qualityStr)
+		if err != nil {
+			return nil, err
+		}
+		filter.QualityFlags = &flags
+	}
+
+	// Parse sort
+	if filter.Sort, err = parseSort(c.QueryParam("sort")); err != nil {
+		return nil, err
+	}
+
 	// Parse pagination
 	page, _ := strconv.Atoi(c.QueryParam("page"))
 	if page <= 0 {
@@ -311,5 +841,203 @@ func parseFilterFromQuery(c echo.Context) (*domain.SensorDataFilter, error) {
 	}
 	filter.PageSize = pageSize
 
+	// Limit requests made with a scoped API key to its scope
+	if err := restrictToKeyScope(c, filter); err != nil {
+		return nil, err
+	}
+
 	return filter, nil
 }
+
+// queryList returns the values of a query parameter given as comma-separated lists,
+// repeated parameters, or both
+func queryList(c echo.Context, name string) []string {
+	var values []string
+	for _, param := range c.QueryParams()[name] {
+		for _, value := range strings.Split(param, ",") {
+			if value = strings.TrimSpace(value); value != "" {
+				values = append(values, value)
+			}
+		}
+	}
+	return values
+}
+
+// queryInt parses an optional integer query parameter
+func queryInt(c echo.Context, name string) (*int, error) {
+	value := c.QueryParam(name)
+	if value == "" {
+		return nil, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s %q", name, value)
+	}
+	return &n, nil
+}
+
+// queryBool parses an optional boolean query parameter, false when absent
+func queryBool(c echo.Context, name string) (bool, error) {
+	value := c.QueryParam(name)
+	if value == "" {
+		return false, nil
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("invalid %s %q", name, value)
+	}
+	return b, nil
+}
+
+// queryTime parses an optional RFC3339 time query parameter
+func queryTime(c echo.Context, name string) (*time.Time, error) {
+	value := c.QueryParam(name)
+	if value == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s %q, expected RFC3339", name, value)
+	}
+	return &t, nil
+}
+
+// queryFloat parses an optional number query parameter
+func queryFloat(c echo.Context, name string) (*float64, error) {
+	value := c.QueryParam(name)
+	if value == "" {
+		return nil, nil
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+		return nil, fmt.Errorf("invalid %s %q", name, value)
+	}
+	return &f, nil
+}
+
+// parseSort parses a sort order given as comma-separated keys, each optionally
+// followed by :asc (the default) or :desc
+func parseSort(value string) ([]domain.SortField, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	var sort []domain.SortField
+	for _, part := range strings.Split(value, ",") {
+		key, direction, _ := strings.Cut(strings.TrimSpace(part), ":")
+		switch key {
+		case domain.SortValue, domain.SortTime, domain.SortID:
+		default:
+			return nil, fmt.Errorf("invalid sort key %q, expected %s, %s or %s", key, domain.SortValue, domain.SortTime, domain.SortID)
+		}
+
+		field := domain.SortField{Key: key}
+		switch direction {
+		case "", "asc":
+		case "desc":
+			field.Desc = true
+		default:
+			return nil, fmt.Errorf("invalid sort direction %q, expected asc or desc", direction)
+		}
+		sort = append(sort, field)
+	}
+	return sort, nil
+}
+
+// parseFields parses a comma-separated projection of sensor data response fields; an
+// empty value selects every field
+func parseFields(value string) ([]string, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	var fields []string
+	for _, name := range strings.Split(value, ",") {
+		name = strings.TrimSpace(name)
+		if !slices.Contains(sensorDataFields, name) {
+			return nil, fmt.Errorf("invalid field %q, expected one of %s", name, strings.Join(sensorDataFields, ", "))
+		}
+		if !slices.Contains(fields, name) {
+			fields = append(fields, name)
+		}
+	}
+	return fields, nil
+}
+
+// validateTimeField checks that a requested time field is one sensor data can be filtered by
+func validateTimeField(timeField string) error {
+	switch timeField {
+	case "", domain.TimeFieldEvent, domain.TimeFieldIngest:
+		return nil
+	default:
+		return fmt.Errorf("invalid time_field %q, expected %s or %s", timeField, domain.TimeFieldEvent, domain.TimeFieldIngest)
+	}
+}
+
+// parseQualityFlags parses a quality flag bitmask given as a number or as
+// comma-separated flag names
+func parseQualityFlags(value string) (int, error) {
+	if flags, err := strconv.Atoi(value); err == nil {
+		return flags, nil
+	}
+
+	flags := 0
+	for _, name := range strings.Split(value, ",") {
+		name = strings.TrimSpace(name)
+		found := false
+		for _, f := range domain.QualityFlagNames {
+			if f.Name == name {
+				flags |= f.Flag
+				found = true
+				break
+			}
+		}
+		if !found {
+			return 0, fmt.Errorf("invalid quality flag %q", name)
+		}
+	}
+	return flags, nil
+}
+
+// toSensorDataResponse converts a domain sensor reading to its response model
+func toSensorDataResponse(data *domain.SensorData) SensorDataResponse {
+	response := SensorDataResponse{
+		ID:           data.ID,
+		ReadingID:    data.ReadingID,
+		SensorValue:  data.SensorValue,
+		SensorType:   data.SensorType,
+		ID1:          data.ID1,
+		ID2:          data.ID2,
+		EventTime:    formatMillis(data.EventTime),
+		CreatedAt:    formatMillis(data.CreatedAt),
+		QualityFlags: data.QualityFlags,
+		Quality:      domain.QualityFlagList(data.QualityFlags),
+		Revisions:    data.Revisions,
+		BatchID:      data.DeletionBatchID,
+	}
+	if data.DeletedAt != 0 {
+		response.DeletedAt = formatMillis(data.DeletedAt)
+	}
+	return response
+}
+
+// toDeleteJobResponse converts a domain delete job to its response model
+func toDeleteJobResponse(job *domain.DeleteJob) DeleteJobResponse {
+	response := DeleteJobResponse{
+		ID:          job.ID,
+		Status:      job.Status,
+		MatchedRows: job.Matched,
+		DeletedRows: job.Deleted,
+		Error:       job.Error,
+		CreatedAt:   formatMillis(job.CreatedAt),
+	}
+	if job.FinishedAt != 0 {
+		response.FinishedAt = formatMillis(job.FinishedAt)
+	}
+	return response
+}
+
+// formatMillis formats a Unix millisecond timestamp as RFC3339
+func formatMillis(ms int64) string {
+	return time.UnixMilli(ms).UTC().Format(time.RFC3339Nano)
+}
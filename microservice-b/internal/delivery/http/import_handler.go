@@ -0,0 +1,140 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"sensor_project/microservice-b/internal/domain"
+	"sensor_project/microservice-b/internal/export"
+
+	"github.com/labstack/echo/v4"
+)
+
+// ImportHandler handles HTTP requests for bulk imports of sensor data
+type ImportHandler struct {
+	importUseCase domain.ImportUseCase
+}
+
+// NewImportHandler creates a new import HTTP handler
+func NewImportHandler(importUseCase domain.ImportUseCase) *ImportHandler {
+	return &ImportHandler{
+		importUseCase: importUseCase,
+	}
+}
+
+// SetupRoutes configures the HTTP routes
+func (h *ImportHandler) SetupRoutes(e *echo.Echo) {
+	api := e.Group("/api")
+
+	// Import routes
+	api.POST("/sensor-data/import", h.ImportSensorData, requireAdmin)
+	api.GET("/sensor-data/import/:id", h.GetImportJob, requireAdmin)
+}
+
+// ImportSensorData starts a bulk import of sensor data from an uploaded file
+// @Summary Import sensor data
+// @Description Upload a CSV or NDJSON file of readings. Every row is validated against the rules of its sensor type and valid rows are inserted in batches in the background; poll the returned job for progress and per-row errors.
+// @Tags sensor-data
+// @Accept multipart/form-data
+// @Produce json
+// @Param file formData file true "CSV or NDJSON file"
+// @Param format query string false "File format: csv or ndjson (default: from the file extension)"
+// @Param dry_run query bool false "Only validate the rows"
+// @Success 202 {object} ImportJobResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /api/sensor-data/import [post]
+func (h *ImportHandler) ImportSensorData(c echo.Context) error {
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "A file upload in the \"file\" field is required"})
+	}
+
+	format := c.QueryParam("format")
+	if format == "" {
+		format = importFormat(fileHeader.Filename)
+	}
+
+	dryRun := false
+	if value := c.QueryParam("dry_run"); value != "" {
+		if dryRun, err = strconv.ParseBool(value); err != nil {
+			return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid dry_run value"})
+		}
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Failed to read uploaded file"})
+	}
+	defer file.Close()
+
+	job, err := h.importUseCase.Start(format, dryRun, file)
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidInput) {
+			return c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		}
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to start import"})
+	}
+
+	c.Response().Header().Set(echo.HeaderLocation, "/api/sensor-data/import/"+job.ID)
+	return c.JSON(http.StatusAccepted, toImportJobResponse(job))
+}
+
+// GetImportJob reports the progress of an import job
+// @Summary Get import job
+// @Description Retrieve the progress and per-row error report of an import job. Finished jobs are kept for 24 hours.
+// @Tags sensor-data
+// @Produce json
+// @Param id path string true "Import job ID"
+// @Success 200 {object} ImportJobResponse
+// @Failure 404 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /api/sensor-data/import/{id} [get]
+func (h *ImportHandler) GetImportJob(c echo.Context) error {
+	job := h.importUseCase.Get(c.Param("id"))
+	if job == nil {
+		return c.JSON(http.StatusNotFound, ErrorResponse{Error: "Import job not found"})
+	}
+
+	return c.JSON(http.StatusOK, toImportJobResponse(job))
+}
+
+// importFormat infers an import format from a file name
+func importFormat(filename string) string {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".ndjson", ".jsonl":
+		return export.FormatNDJSON
+	default:
+		return export.FormatCSV
+	}
+}
+
+// toImportJobResponse converts a domain import job to its response model
+func toImportJobResponse(job *domain.ImportJob) ImportJobResponse {
+	rowErrors := make([]ImportRowErrorResponse, 0, len(job.Errors))
+	for _, e := range job.Errors {
+		rowErrors = append(rowErrors, ImportRowErrorResponse{Line: e.Line, Error: e.Error})
+	}
+
+	response := ImportJobResponse{
+		ID:              job.ID,
+		Status:          job.Status,
+		Format:          job.Format,
+		DryRun:          job.DryRun,
+		RowsRead:        job.RowsRead,
+		RowsImported:    job.RowsImported,
+		RowsRejected:    job.RowsRejected,
+		Errors:          rowErrors,
+		ErrorsTruncated: job.ErrorsTruncated,
+		Error:           job.Error,
+		CreatedAt:       formatMillis(job.CreatedAt),
+	}
+	if job.FinishedAt != 0 {
+		response.FinishedAt = formatMillis(job.FinishedAt)
+	}
+	return response
+}
@@ -1,5 +1,171 @@
 package http
 
-// Implementation of authentication and authorisation using jwt token
-// give roles based on user-type
-// check the roles in the middleware before allowing access to certain routes
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+
+	"sensor_project/microservice-b/internal/domain"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Echo context keys the authenticated user, and the API key they used, are stored under
+const (
+	userContextKey   = "user"
+	apiKeyContextKey = "api_key"
+)
+
+// apiKeyHeader is the header machine clients pass their API key in
+const apiKeyHeader = "X-API-Key"
+
+// Route guards: reads are open to every user, changes and deletes only to admins
+var (
+	requireUser  = requireRole(domain.RoleUser, domain.RoleAdmin)
+	requireAdmin = requireRole(domain.RoleAdmin)
+)
+
+// Authenticate returns middleware that identifies the user of a request from the API key
+// in its X-API-Key header or else the bearer token in its Authorization header. Requests
+// with neither continue anonymously and are turned away by the routes that require a
+// role; requests with an invalid or expired credential are rejected.
+func Authenticate(authUseCase domain.AuthUseCase, apiKeyUseCase domain.APIKeyUseCase) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if secret := c.Request().Header.Get(apiKeyHeader); secret != "" {
+				key, user, err := apiKeyUseCase.Validate(secret)
+				if err != nil {
+					if errors.Is(err, domain.ErrInvalidAPIKey) {
+						return unauthorized(c, err.Error())
+					}
+					return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to validate API key"})
+				}
+
+				// A scoped key of a user who can read only reads within its scope, whatever
+				// else the user may do
+				if key.Scoped() {
+					scoped := *user
+					scoped.Roles = nil
+					if slices.Contains(user.Roles, domain.RoleUser) || slices.Contains(user.Roles, domain.RoleAdmin) {
+						scoped.Roles = []string{domain.RoleUser}
+					}
+					user = &scoped
+				}
+				c.Set(userContextKey, user)
+				c.Set(apiKeyContextKey, key)
+				return next(c)
+			}
+
+			header := c.Request().Header.Get(echo.HeaderAuthorization)
+			if header == "" {
+				return next(c)
+			}
+
+			scheme, token, ok := strings.Cut(header, " ")
+			if !ok || !strings.EqualFold(scheme, "Bearer") {
+				return unauthorized(c, "Authorization header must be a bearer token")
+			}
+
+			user, err := authUseCase.ValidateToken(strings.TrimSpace(token))
+			if err != nil {
+				if errors.Is(err, domain.ErrInvalidToken) {
+					return unauthorized(c, err.Error())
+				}
+				return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to validate token"})
+			}
+
+			c.Set(userContextKey, user)
+			return next(c)
+		}
+	}
+}
+
+// requireRole returns middleware that only lets through authenticated users with at
+// least one of the given roles
+func requireRole(roles ...string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if currentUser(c) == nil {
+				return unauthorized(c, "Authentication required")
+			}
+			for _, role := range roles {
+				if hasRole(c, role) {
+					return next(c)
+				}
+			}
+			return c.JSON(http.StatusForbidden, ErrorResponse{Error: "Insufficient role"})
+		}
+	}
+}
+
+// requireLogin is middleware that only lets through authenticated users, whatever
+// their roles
+func requireLogin(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if currentUser(c) == nil {
+			return unauthorized(c, "Authentication required")
+		}
+		return next(c)
+	}
+}
+
+// rejectAPIKeys is middleware refusing requests made with an API key, for routes that
+// administer accounts and credentials
+func rejectAPIKeys(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if currentAPIKey(c) != nil {
+			return c.JSON(http.StatusForbidden, ErrorResponse{Error: "API keys cannot administer users or API keys"})
+		}
+		return next(c)
+	}
+}
+
+// unauthorized responds with 401 and a challenge for a bearer token
+func unauthorized(c echo.Context, message string) error {
+	c.Response().Header().Set(echo.HeaderWWWAuthenticate, "Bearer")
+	return c.JSON(http.StatusUnauthorized, ErrorResponse{Error: message})
+}
+
+// currentUser returns the authenticated user of a request, or nil if there is none
+func currentUser(c echo.Context) *domain.User {
+	user, _ := c.Get(userContextKey).(*domain.User)
+	return user
+}
+
+// currentAPIKey returns the API key a request was authenticated with, or nil if it was
+// not made with one
+func currentAPIKey(c echo.Context) *domain.APIKey {
+	key, _ := c.Get(apiKeyContextKey).(*domain.APIKey)
+	return key
+}
+
+// currentActor returns the user, and the API key if any, a request changing sensor data
+// is recorded in the audit log as made by
+func currentActor(c echo.Context) domain.Actor {
+	var actor domain.Actor
+	if user := currentUser(c); user != nil {
+		actor.UserID = user.ID
+		actor.Username = user.Username
+	}
+	if key := currentAPIKey(c); key != nil {
+		actor.APIKeyID = key.ID
+	}
+	return actor
+}
+
+// restrictToKeyScope limits a filter to the sensor types and devices of the scoped API
+// key a request was made with. Asking for readings outside the scope is an error.
+func restrictToKeyScope(c echo.Context, filter *domain.SensorDataFilter) error {
+	key := currentAPIKey(c)
+	if key == nil {
+		return nil
+	}
+	return key.Restrict(filter)
+}
+
+// hasRole reports whether the authenticated user of a request has the given role
+func hasRole(c echo.Context, role string) bool {
+	user := currentUser(c)
+	return user != nil && slices.Contains(user.Roles, role)
+}
@@ -1,6 +1,9 @@
 package http
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
 	"time"
 )
 
@@ -15,50 +18,414 @@ type SuccessResponse struct {
 	Message string `json:"message"`
 }
 
-// DeleteResponse represents a response for delete operations
+// DeleteResponse represents a response for delete operations. Deletes by filter also
+// report the records matched, which a dry run only counts.
 type DeleteResponse struct {
 	Success     bool   `json:"success"`
 	Message     string `json:"message"`
 	DeletedRows int    `json:"deleted_rows"`
+	MatchedRows int    `json:"matched_rows,omitempty"`
+	DryRun      bool   `json:"dry_run,omitempty"`
+	BatchID     string `json:"deletion_batch_id,omitempty"`
+}
+
+// DeleteJobResponse represents the progress of a delete by filter running in the
+// background
+type DeleteJobResponse struct {
+	ID          string `json:"id"`
+	Status      string `json:"status"`
+	MatchedRows int64  `json:"matched_rows"`
+	DeletedRows int64  `json:"deleted_rows"`
+	Error       string `json:"error,omitempty"`
+	CreatedAt   string `json:"created_at"`
+	FinishedAt  string `json:"finished_at,omitempty"`
 }
 
 // UserResponse represents user information in responses
 type UserResponse struct {
-	ID       int      `json:"id"`
+	ID         int      `json:"id"`
+	Username   string   `json:"username"`
+	Email      string   `json:"email"`
+	Roles      []string `json:"roles"`
+	Disabled   bool     `json:"disabled"`
+	DisabledAt string   `json:"disabled_at,omitempty"`
+	CreatedAt  string   `json:"created_at"`
+}
+
+// CreateUserRequest represents a request to create a user with the given roles
+type CreateUserRequest struct {
 	Username string   `json:"username"`
 	Email    string   `json:"email"`
+	Password string   `json:"password"`
 	Roles    []string `json:"roles"`
 }
 
-// SensorDataResponse represents a sensor data record in responses
+// UpdateUserRequest represents a request to update the account of a user. Fields left
+// out are unchanged.
+type UpdateUserRequest struct {
+	Username *string `json:"username"`
+	Email    *string `json:"email"`
+}
+
+// PasswordRequest represents a request to reset the password of a user
+type PasswordRequest struct {
+	Password string `json:"password"`
+}
+
+// CreateAPIKeyRequest represents a request to issue an API key. The key acts as UserID,
+// or the requesting user if it is 0, and is limited to the given sensor types and
+// devices (ID1 values) if any are given.
+type CreateAPIKeyRequest struct {
+	Name        string     `json:"name"`
+	UserID      int        `json:"user_id"`
+	SensorTypes []string   `json:"sensor_types"`
+	Devices     []string   `json:"devices"`
+	ExpiresAt   *time.Time `json:"expires_at"`
+}
+
+// APIKeyResponse represents an API key in responses. Key is only returned when the key
+// is issued.
+type APIKeyResponse struct {
+	ID          int      `json:"id"`
+	Name        string   `json:"name"`
+	Key         string   `json:"key,omitempty"`
+	Prefix      string   `json:"prefix"`
+	UserID      int      `json:"user_id"`
+	SensorTypes []string `json:"sensor_types,omitempty"`
+	Devices     []string `json:"devices,omitempty"`
+	ExpiresAt   string   `json:"expires_at,omitempty"`
+	RevokedAt   string   `json:"revoked_at,omitempty"`
+	CreatedAt   string   `json:"created_at"`
+}
+
+// LoginRequest represents a request to log in with a username and password
+type LoginRequest struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// RefreshRequest represents a request to exchange a refresh token for new tokens
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// TokenResponse represents the tokens issued at login or refresh. ExpiresIn is the
+// lifetime of the access token in seconds.
+type TokenResponse struct {
+	AccessToken      string `json:"access_token"`
+	TokenType        string `json:"token_type"`
+	ExpiresIn        int64  `json:"expires_in"`
+	RefreshToken     string `json:"refresh_token"`
+	RefreshExpiresAt string `json:"refresh_expires_at"`
+}
+
+// SensorDataResponse represents a sensor data record in responses. A record limited to
+// a projection of its fields only encodes those fields, in the projection's order.
+// Deleted records, returned only to admins asking for them, carry when and in which
+// deletion batch they were deleted.
 type SensorDataResponse struct {
-	ID          int64   `json:"id"`
-	SensorValue float64 `json:"sensor_value"`
-	SensorType  string  `json:"sensor_type"`
-	ID1         string  `json:"id1"`
-	ID2         int     `json:"id2"`
-	CreatedAt   string  `json:"created_at"`
+	ID           int64    `json:"id"`
+	ReadingID    string   `json:"reading_id,omitempty"`
+	SensorValue  float64  `json:"sensor_value"`
+	SensorType   string   `json:"sensor_type"`
+	ID1          string   `json:"id1"`
+	ID2          int      `json:"id2"`
+	EventTime    string   `json:"event_time"`
+	CreatedAt    string   `json:"created_at"`
+	QualityFlags int      `json:"quality_flags"`
+	Quality      []string `json:"quality"`
+	Revisions    int      `json:"revisions"`
+	DeletedAt    string   `json:"deleted_at,omitempty"`
+	BatchID      string   `json:"deletion_batch_id,omitempty"`
+
+	fields []string
 }
 
-// PaginatedResponse represents a paginated response
+// sensorDataFields are the fields of SensorDataResponse a projection can select
+var sensorDataFields = []string{"id", "reading_id", "sensor_value", "sensor_type", "id1", "id2", "event_time", "created_at", "quality_flags", "quality", "revisions"}
+
+// MarshalJSON encodes the record, limited to its projected fields if it has any
+func (r SensorDataResponse) MarshalJSON() ([]byte, error) {
+	type plain SensorDataResponse
+	b, err := json.Marshal(plain(r))
+	if err != nil || len(r.fields) == 0 {
+		return b, err
+	}
+
+	var all map[string]json.RawMessage
+	if err := json.Unmarshal(b, &all); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, name := range r.fields {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, _ := json.Marshal(name)
+		buf.Write(key)
+		buf.WriteByte(':')
+		// Fields left out when empty are projected as null
+		value, ok := all[name]
+		if !ok {
+			value = json.RawMessage("null")
+		}
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// SensorDataRevisionResponse represents one value a sensor data record has had.
+// Revision 0 is the measured value and has no reason or actor.
+type SensorDataRevisionResponse struct {
+	Revision    int            `json:"revision"`
+	SensorValue float64        `json:"sensor_value"`
+	Reason      string         `json:"reason,omitempty"`
+	Actor       *ActorResponse `json:"actor,omitempty"`
+	CreatedAt   string         `json:"created_at"`
+}
+
+// SensorDataHistoryResponse represents a sensor data record with every value it has
+// had, oldest first
+type SensorDataHistoryResponse struct {
+	SensorData SensorDataResponse           `json:"sensor_data"`
+	Revisions  []SensorDataRevisionResponse `json:"revisions"`
+}
+
+// DeletionBatchResponse represents the records deleted together by one delete, which
+// can be restored until PurgeAt
+type DeletionBatchResponse struct {
+	ID        string `json:"id"`
+	Rows      int64  `json:"rows"`
+	DeletedAt string `json:"deleted_at"`
+	PurgeAt   string `json:"purge_at"`
+}
+
+// RestoreResponse represents the result of restoring a deletion batch
+type RestoreResponse struct {
+	Success      bool   `json:"success"`
+	Message      string `json:"message"`
+	RestoredRows int    `json:"restored_rows"`
+}
+
+// PaginatedResponse represents a paginated response. Offset pages set Page and
+// TotalPages; cursor pages set the cursors of the neighbouring pages that exist, and
+// Total only when requested.
 type PaginatedResponse struct {
 	Data       []SensorDataResponse `json:"data"`
-	Total      int                  `json:"total"`
-	Page       int                  `json:"page"`
+	Total      *int                 `json:"total,omitempty"`
+	Page       int                  `json:"page,omitempty"`
 	PageSize   int                  `json:"page_size"`
-	TotalPages int                  `json:"total_pages"`
+	TotalPages *int                 `json:"total_pages,omitempty"`
+	NextCursor string               `json:"next_cursor,omitempty"`
+	PrevCursor string               `json:"prev_cursor,omitempty"`
+}
+
+// ActorResponse represents the user, and the API key they used if any, who made a change
+type ActorResponse struct {
+	UserID   int    `json:"user_id"`
+	Username string `json:"username"`
+	APIKeyID int    `json:"api_key_id,omitempty"`
+}
+
+// AuditEntryResponse represents a change to one reading recorded in the audit log.
+// Filter is the filter of a delete by filter.
+type AuditEntryResponse struct {
+	ID           int64               `json:"id"`
+	Actor        ActorResponse       `json:"actor"`
+	Operation    string              `json:"operation"`
+	SensorDataID int64               `json:"sensor_data_id"`
+	Filter       json.RawMessage     `json:"filter,omitempty" swaggertype:"object"`
+	Before       *SensorDataResponse `json:"before,omitempty"`
+	After        *SensorDataResponse `json:"after,omitempty"`
+	CreatedAt    string              `json:"created_at"`
+}
+
+// AuditPageResponse represents a page of the audit log. NextBeforeID is set when older
+// entries may follow.
+type AuditPageResponse struct {
+	Entries      []AuditEntryResponse `json:"entries"`
+	NextBeforeID int64                `json:"next_before_id,omitempty"`
+}
+
+// StreamMessage is a message of a live sensor data stream: a reading, a notice that the
+// replay was truncated, or an error ending the stream
+type StreamMessage struct {
+	Type    string              `json:"type"`
+	Reading *SensorDataResponse `json:"reading,omitempty"`
+	Error   string              `json:"error,omitempty"`
+}
+
+// SensorTypeRequest represents a request to create or update a sensor type
+type SensorTypeRequest struct {
+	Name             string   `json:"name"`
+	Unit             string   `json:"unit"`
+	Description      string   `json:"description"`
+	MinValue         *float64 `json:"min_value"`
+	MaxValue         *float64 `json:"max_value"`
+	MaxRateOfChange  *float64 `json:"max_rate_of_change"`
+	ID1Pattern       string   `json:"id1_pattern"`
+	ID2Pattern       string   `json:"id2_pattern"`
+	ValidationAction string   `json:"validation_action"`
+}
+
+// SensorTypeResponse represents a sensor type in responses
+type SensorTypeResponse struct {
+	ID               int      `json:"id"`
+	Name             string   `json:"name"`
+	Unit             string   `json:"unit"`
+	Description      string   `json:"description"`
+	MinValue         *float64 `json:"min_value"`
+	MaxValue         *float64 `json:"max_value"`
+	MaxRateOfChange  *float64 `json:"max_rate_of_change"`
+	ID1Pattern       string   `json:"id1_pattern"`
+	ID2Pattern       string   `json:"id2_pattern"`
+	ValidationAction string   `json:"validation_action"`
+	CreatedAt        string   `json:"created_at"`
+	UpdatedAt        string   `json:"updated_at"`
+}
+
+// AggregateBucketResponse represents the aggregated values of one bucket in responses
+type AggregateBucketResponse struct {
+	BucketStart string             `json:"bucket_start"`
+	SensorType  string             `json:"sensor_type"`
+	ID1         string             `json:"id1"`
+	ID2         int                `json:"id2"`
+	Values      map[string]float64 `json:"values"`
+}
+
+// AggregateResponse represents the result of an aggregation
+type AggregateResponse struct {
+	Interval  string                    `json:"interval"`
+	Functions []string                  `json:"functions"`
+	Data      []AggregateBucketResponse `json:"data"`
+	Truncated bool                      `json:"truncated"`
+	Source    string                    `json:"source"`
+}
+
+// RetentionPolicyRequest represents a request to set the retention policy of a sensor
+// type. Retentions are durations such as "30d" or "12h"; empty or "0" keeps data forever.
+type RetentionPolicyRequest struct {
+	RawRetention          string `json:"raw_retention"`
+	MinuteRollupRetention string `json:"minute_rollup_retention"`
+	HourRollupRetention   string `json:"hour_rollup_retention"`
+	DayRollupRetention    string `json:"day_rollup_retention"`
+}
+
+// RetentionPolicyResponse represents a retention policy in responses
+type RetentionPolicyResponse struct {
+	SensorType            string `json:"sensor_type"`
+	RawRetention          string `json:"raw_retention"`
+	MinuteRollupRetention string `json:"minute_rollup_retention"`
+	HourRollupRetention   string `json:"hour_rollup_retention"`
+	DayRollupRetention    string `json:"day_rollup_retention"`
+	CreatedAt             string `json:"created_at"`
+	UpdatedAt             string `json:"updated_at"`
+}
+
+// RetentionPurgeResponse represents the rows purged for one sensor type and target, or
+// for the deleted target across sensor types
+type RetentionPurgeResponse struct {
+	SensorType string `json:"sensor_type,omitempty"`
+	Target     string `json:"target"`
+	Cutoff     string `json:"cutoff"`
+	Rows       int64  `json:"rows"`
+}
+
+// RetentionReportResponse represents the report of a retention run or preview
+type RetentionReportResponse struct {
+	DryRun     bool                     `json:"dry_run"`
+	StartedAt  string                   `json:"started_at"`
+	FinishedAt string                   `json:"finished_at"`
+	Purged     []RetentionPurgeResponse `json:"purged"`
+	TotalRows  int64                    `json:"total_rows"`
+	Error      string                   `json:"error,omitempty"`
+}
+
+// ImportJobResponse represents the state of a bulk import job
+type ImportJobResponse struct {
+	ID              string                   `json:"id"`
+	Status          string                   `json:"status"`
+	Format          string                   `json:"format"`
+	DryRun          bool                     `json:"dry_run"`
+	RowsRead        int64                    `json:"rows_read"`
+	RowsImported    int64                    `json:"rows_imported"`
+	RowsRejected    int64                    `json:"rows_rejected"`
+	Errors          []ImportRowErrorResponse `json:"errors"`
+	ErrorsTruncated bool                     `json:"errors_truncated"`
+	Error           string                   `json:"error,omitempty"`
+	CreatedAt       string                   `json:"created_at"`
+	FinishedAt      string                   `json:"finished_at,omitempty"`
+}
+
+// ImportRowErrorResponse represents a rejected row of an import file
+type ImportRowErrorResponse struct {
+	Line  int64  `json:"line"`
+	Error string `json:"error"`
 }
 
 // UpdateSensorDataRequest represents a request to update sensor data
 type UpdateSensorDataRequest struct {
 	SensorValue float64 `json:"sensor_value"`
+	Reason      string  `json:"reason"`
 }
 
-// FilterRequest represents filter criteria for querying or deleting sensor data
+// FilterRequest represents filter criteria for querying or deleting sensor data. id1,
+// id2 and sensor_type take a single value or a list of values to match any of.
 type FilterRequest struct {
-	ID1        *string    `json:"id1"`
-	ID2        *int       `json:"id2"`
-	SensorType *string    `json:"sensor_type"`
-	StartTime  *time.Time `json:"start_time"`
-	EndTime    *time.Time `json:"end_time"`
+	ID1          StringList `json:"id1" swaggertype:"array,string"`
+	ID2          IntList    `json:"id2" swaggertype:"array,integer"`
+	ID2Min       *int       `json:"id2_min"`
+	ID2Max       *int       `json:"id2_max"`
+	SensorType   StringList `json:"sensor_type" swaggertype:"array,string"`
+	MinValue     *float64   `json:"min_value"`
+	MaxValue     *float64   `json:"max_value"`
+	StartTime    *time.Time `json:"start_time"`
+	EndTime      *time.Time `json:"end_time"`
+	TimeField    string     `json:"time_field"`
+	QualityFlags *int       `json:"quality_flags"`
+}
+
+// StringList is a list of strings that can also be given as a single string
+type StringList []string
+
+// UnmarshalJSON decodes a string or an array of strings
+func (l *StringList) UnmarshalJSON(b []byte) error {
+	if string(b) == "null" {
+		return nil
+	}
+	var value string
+	if err := json.Unmarshal(b, &value); err == nil {
+		*l = StringList{value}
+		return nil
+	}
+	var values []string
+	if err := json.Unmarshal(b, &values); err != nil {
+		return errors.New("expected a string or an array of strings")
+	}
+	*l = values
+	return nil
+}
+
+// IntList is a list of integers that can also be given as a single integer
+type IntList []int
+
+// UnmarshalJSON decodes an integer or an array of integers
+func (l *IntList) UnmarshalJSON(b []byte) error {
+	if string(b) == "null" {
+		return nil
+	}
+	var value int
+	if err := json.Unmarshal(b, &value); err == nil {
+		*l = IntList{value}
+		return nil
+	}
+	var values []int
+	if err := json.Unmarshal(b, &values); err != nil {
+		return errors.New("expected an integer or an array of integers")
+	}
+	*l = values
+	return nil
 }
@@ -0,0 +1,269 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"sensor_project/microservice-b/internal/domain"
+
+	"github.com/labstack/echo/v4"
+)
+
+// day is the unit of the "d" suffix accepted in retention durations
+const day = 24 * time.Hour
+
+// RetentionHandler handles HTTP requests for retention policies and purge reports
+type RetentionHandler struct {
+	retentionUseCase domain.RetentionUseCase
+}
+
+// NewRetentionHandler creates a new retention HTTP handler
+func NewRetentionHandler(retentionUseCase domain.RetentionUseCase) *RetentionHandler {
+	return &RetentionHandler{
+		retentionUseCase: retentionUseCase,
+	}
+}
+
+// SetupRoutes configures the HTTP routes
+func (h *RetentionHandler) SetupRoutes(e *echo.Echo) {
+	api := e.Group("/api")
+
+	// Retention routes
+	api.GET("/retention/policies", h.ListRetentionPolicies, requireUser)
+	api.GET("/retention/policies/:sensor_type", h.GetRetentionPolicy, requireUser)
+	api.PUT("/retention/policies/:sensor_type", h.SaveRetentionPolicy, requireAdmin)
+	api.DELETE("/retention/policies/:sensor_type", h.DeleteRetentionPolicy, requireAdmin)
+	api.GET("/retention/preview", h.PreviewRetention, requireUser)
+	api.GET("/retention/last-run", h.GetLastRetentionRun, requireUser)
+}
+
+// ListRetentionPolicies retrieves all retention policies
+// @Summary List retention policies
+// @Description Retrieve the retention policies of all sensor types
+// @Tags retention
+// @Produce json
+// @Success 200 {array} RetentionPolicyResponse
+// @Failure 500 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /api/retention/policies [get]
+func (h *RetentionHandler) ListRetentionPolicies(c echo.Context) error {
+	policies, err := h.retentionUseCase.List()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to retrieve retention policies"})
+	}
+
+	results := make([]RetentionPolicyResponse, 0, len(policies))
+	for _, policy := range policies {
+		results = append(results, toRetentionPolicyResponse(policy))
+	}
+
+	return c.JSON(http.StatusOK, results)
+}
+
+// GetRetentionPolicy retrieves the retention policy of a sensor type
+// @Summary Get retention policy
+// @Description Retrieve the retention policy of a sensor type
+// @Tags retention
+// @Produce json
+// @Param sensor_type path string true "Sensor type"
+// @Success 200 {object} RetentionPolicyResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /api/retention/policies/{sensor_type} [get]
+func (h *RetentionHandler) GetRetentionPolicy(c echo.Context) error {
+	policy, err := h.retentionUseCase.Get(c.Param("sensor_type"))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to retrieve retention policy"})
+	}
+
+	if policy == nil {
+		return c.JSON(http.StatusNotFound, ErrorResponse{Error: "Retention policy not found"})
+	}
+
+	return c.JSON(http.StatusOK, toRetentionPolicyResponse(policy))
+}
+
+// SaveRetentionPolicy creates or replaces the retention policy of a sensor type
+// @Summary Set retention policy
+// @Description Create or replace how long raw readings and rollups of a sensor type are kept
+// @Tags retention
+// @Accept json
+// @Produce json
+// @Param sensor_type path string true "Sensor type"
+// @Param policy body RetentionPolicyRequest true "Retention policy"
+// @Success 200 {object} RetentionPolicyResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /api/retention/policies/{sensor_type} [put]
+func (h *RetentionHandler) SaveRetentionPolicy(c echo.Context) error {
+	req := new(RetentionPolicyRequest)
+	if err := c.Bind(req); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid request format"})
+	}
+
+	policy := &domain.RetentionPolicy{SensorType: c.Param("sensor_type")}
+	retentions := []struct {
+		name   string
+		value  string
+		target *time.Duration
+	}{
+		{"raw_retention", req.RawRetention, &policy.RawRetention},
+		{"minute_rollup_retention", req.MinuteRollupRetention, &policy.MinuteRollupRetention},
+		{"hour_rollup_retention", req.HourRollupRetention, &policy.HourRollupRetention},
+		{"day_rollup_retention", req.DayRollupRetention, &policy.DayRollupRetention},
+	}
+	for _, r := range retentions {
+		d, err := parseRetention(r.value)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, ErrorResponse{Error: fmt.Sprintf("%s: %v", r.name, err)})
+		}
+		*r.target = d
+	}
+
+	if err := h.retentionUseCase.Save(policy); err != nil {
+		switch {
+		case errors.Is(err, domain.ErrInvalidInput):
+			return c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		case errors.Is(err, domain.ErrSensorTypeNotFound):
+			return c.JSON(http.StatusNotFound, ErrorResponse{Error: "Sensor type not found"})
+		default:
+			return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to save retention policy"})
+		}
+	}
+
+	return c.JSON(http.StatusOK, toRetentionPolicyResponse(policy))
+}
+
+// DeleteRetentionPolicy removes the retention policy of a sensor type
+// @Summary Delete retention policy
+// @Description Remove the retention policy of a sensor type so its data is kept forever
+// @Tags retention
+// @Produce json
+// @Param sensor_type path string true "Sensor type"
+// @Success 200 {object} SuccessResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /api/retention/policies/{sensor_type} [delete]
+func (h *RetentionHandler) DeleteRetentionPolicy(c echo.Context) error {
+	if err := h.retentionUseCase.Delete(c.Param("sensor_type")); err != nil {
+		if errors.Is(err, domain.ErrRetentionPolicyNotFound) {
+			return c.JSON(http.StatusNotFound, ErrorResponse{Error: "Retention policy not found"})
+		}
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to delete retention policy"})
+	}
+
+	return c.JSON(http.StatusOK, SuccessResponse{
+		Success: true,
+		Message: "Retention policy deleted successfully",
+	})
+}
+
+// PreviewRetention reports what the next retention run would remove
+// @Summary Preview retention run
+// @Description Count the rows the next retention run would delete, without deleting anything
+// @Tags retention
+// @Produce json
+// @Success 200 {object} RetentionReportResponse
+// @Failure 500 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /api/retention/preview [get]
+func (h *RetentionHandler) PreviewRetention(c echo.Context) error {
+	report, err := h.retentionUseCase.Preview()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to preview retention run"})
+	}
+
+	return c.JSON(http.StatusOK, toRetentionReportResponse(report))
+}
+
+// GetLastRetentionRun reports what the last retention run removed
+// @Summary Get last retention run
+// @Description Retrieve the report of the last scheduled retention run
+// @Tags retention
+// @Produce json
+// @Success 200 {object} RetentionReportResponse
+// @Failure 404 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /api/retention/last-run [get]
+func (h *RetentionHandler) GetLastRetentionRun(c echo.Context) error {
+	report := h.retentionUseCase.LastReport()
+	if report == nil {
+		return c.JSON(http.StatusNotFound, ErrorResponse{Error: "No retention run has completed yet"})
+	}
+
+	return c.JSON(http.StatusOK, toRetentionReportResponse(report))
+}
+
+// parseRetention parses a retention given as a Go duration or a number of days with a
+// "d" suffix; an empty value keeps data forever
+func parseRetention(value string) (time.Duration, error) {
+	if value == "" {
+		return 0, nil
+	}
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, fmt.Errorf("invalid retention %q", value)
+		}
+		return time.Duration(n) * day, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid retention %q", value)
+	}
+	return d, nil
+}
+
+// formatRetention formats a retention in whole days where possible
+func formatRetention(d time.Duration) string {
+	if d == 0 {
+		return "0"
+	}
+	if d%day == 0 {
+		return fmt.Sprintf("%dd", d/day)
+	}
+	return d.String()
+}
+
+// toRetentionPolicyResponse converts a domain retention policy to its response model
+func toRetentionPolicyResponse(policy *domain.RetentionPolicy) RetentionPolicyResponse {
+	return RetentionPolicyResponse{
+		SensorType:            policy.SensorType,
+		RawRetention:          formatRetention(policy.RawRetention),
+		MinuteRollupRetention: formatRetention(policy.MinuteRollupRetention),
+		HourRollupRetention:   formatRetention(policy.HourRollupRetention),
+		DayRollupRetention:    formatRetention(policy.DayRollupRetention),
+		CreatedAt:             formatMillis(policy.CreatedAt),
+		UpdatedAt:             formatMillis(policy.UpdatedAt),
+	}
+}
+
+// toRetentionReportResponse converts a domain retention report to its response model
+func toRetentionReportResponse(report *domain.RetentionReport) RetentionReportResponse {
+	purged := make([]RetentionPurgeResponse, 0, len(report.Purged))
+	for _, p := range report.Purged {
+		purged = append(purged, RetentionPurgeResponse{
+			SensorType: p.SensorType,
+			Target:     p.Target,
+			Cutoff:     formatMillis(p.Cutoff),
+			Rows:       p.Rows,
+		})
+	}
+
+	return RetentionReportResponse{
+		DryRun:     report.DryRun,
+		StartedAt:  formatMillis(report.StartedAt),
+		FinishedAt: formatMillis(report.FinishedAt),
+		Purged:     purged,
+		TotalRows:  report.TotalRows,
+		Error:      report.Error,
+	}
+}
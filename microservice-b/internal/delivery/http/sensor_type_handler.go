@@ -0,0 +1,225 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"sensor_project/microservice-b/internal/domain"
+
+	"github.com/labstack/echo/v4"
+)
+
+// SensorTypeHandler handles HTTP requests for the sensor type API
+type SensorTypeHandler struct {
+	sensorTypeUseCase domain.SensorTypeUseCase
+}
+
+// NewSensorTypeHandler creates a new sensor type HTTP handler
+func NewSensorTypeHandler(sensorTypeUseCase domain.SensorTypeUseCase) *SensorTypeHandler {
+	return &SensorTypeHandler{
+		sensorTypeUseCase: sensorTypeUseCase,
+	}
+}
+
+// SetupRoutes configures the HTTP routes
+func (h *SensorTypeHandler) SetupRoutes(e *echo.Echo) {
+	api := e.Group("/api")
+
+	// Sensor type routes
+	api.GET("/sensor-types", h.ListSensorTypes, requireUser)
+	api.POST("/sensor-types", h.CreateSensorType, requireAdmin)
+	api.GET("/sensor-types/:id", h.GetSensorType, requireUser)
+	api.PUT("/sensor-types/:id", h.UpdateSensorType, requireAdmin)
+	api.DELETE("/sensor-types/:id", h.DeleteSensorType, requireAdmin)
+}
+
+// ListSensorTypes retrieves all sensor types
+// @Summary List sensor types
+// @Description Retrieve all registered sensor types
+// @Tags sensor-types
+// @Produce json
+// @Success 200 {array} SensorTypeResponse
+// @Failure 500 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /api/sensor-types [get]
+func (h *SensorTypeHandler) ListSensorTypes(c echo.Context) error {
+	sensorTypes, err := h.sensorTypeUseCase.List()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to retrieve sensor types"})
+	}
+
+	results := make([]SensorTypeResponse, 0, len(sensorTypes))
+	for _, sensorType := range sensorTypes {
+		results = append(results, toSensorTypeResponse(sensorType))
+	}
+
+	return c.JSON(http.StatusOK, results)
+}
+
+// CreateSensorType registers a new sensor type
+// @Summary Create sensor type
+// @Description Register a new sensor type with its unit and validation rules
+// @Tags sensor-types
+// @Accept json
+// @Produce json
+// @Param sensor_type body SensorTypeRequest true "Sensor type"
+// @Success 201 {object} SensorTypeResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /api/sensor-types [post]
+func (h *SensorTypeHandler) CreateSensorType(c echo.Context) error {
+	req := new(SensorTypeRequest)
+	if err := c.Bind(req); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid request format"})
+	}
+
+	sensorType := req.toDomain()
+	if err := h.sensorTypeUseCase.Create(sensorType); err != nil {
+		return sensorTypeError(c, err, "Failed to create sensor type")
+	}
+
+	return c.JSON(http.StatusCreated, toSensorTypeResponse(sensorType))
+}
+
+// GetSensorType retrieves a sensor type by ID
+// @Summary Get sensor type by ID
+// @Description Retrieve a single sensor type by its ID
+// @Tags sensor-types
+// @Produce json
+// @Param id path int true "Sensor Type ID"
+// @Success 200 {object} SensorTypeResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /api/sensor-types/{id} [get]
+func (h *SensorTypeHandler) GetSensorType(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid ID format"})
+	}
+
+	sensorType, err := h.sensorTypeUseCase.GetByID(id)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to retrieve sensor type"})
+	}
+
+	if sensorType == nil {
+		return c.JSON(http.StatusNotFound, ErrorResponse{Error: "Sensor type not found"})
+	}
+
+	return c.JSON(http.StatusOK, toSensorTypeResponse(sensorType))
+}
+
+// UpdateSensorType updates a sensor type
+// @Summary Update sensor type
+// @Description Replace the name, unit, description and validation rules of a sensor type
+// @Tags sensor-types
+// @Accept json
+// @Produce json
+// @Param id path int true "Sensor Type ID"
+// @Param sensor_type body SensorTypeRequest true "Sensor type"
+// @Success 200 {object} SensorTypeResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /api/sensor-types/{id} [put]
+func (h *SensorTypeHandler) UpdateSensorType(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid ID format"})
+	}
+
+	req := new(SensorTypeRequest)
+	if err := c.Bind(req); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid request format"})
+	}
+
+	sensorType := req.toDomain()
+	if err := h.sensorTypeUseCase.Update(id, sensorType); err != nil {
+		return sensorTypeError(c, err, "Failed to update sensor type")
+	}
+
+	return c.JSON(http.StatusOK, toSensorTypeResponse(sensorType))
+}
+
+// DeleteSensorType deletes a sensor type
+// @Summary Delete sensor type
+// @Description Delete a sensor type that has no sensor data
+// @Tags sensor-types
+// @Produce json
+// @Param id path int true "Sensor Type ID"
+// @Success 200 {object} SuccessResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /api/sensor-types/{id} [delete]
+func (h *SensorTypeHandler) DeleteSensorType(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid ID format"})
+	}
+
+	if err := h.sensorTypeUseCase.Delete(id); err != nil {
+		return sensorTypeError(c, err, "Failed to delete sensor type")
+	}
+
+	return c.JSON(http.StatusOK, SuccessResponse{
+		Success: true,
+		Message: "Sensor type deleted successfully",
+	})
+}
+
+// sensorTypeError writes the response for a failed sensor type operation
+func sensorTypeError(c echo.Context, err error, fallback string) error {
+	switch {
+	case errors.Is(err, domain.ErrInvalidInput):
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+	case errors.Is(err, domain.ErrSensorTypeNotFound):
+		return c.JSON(http.StatusNotFound, ErrorResponse{Error: "Sensor type not found"})
+	case errors.Is(err, domain.ErrSensorTypeExists):
+		return c.JSON(http.StatusConflict, ErrorResponse{Error: "Sensor type already exists"})
+	case errors.Is(err, domain.ErrSensorTypeInUse):
+		return c.JSON(http.StatusConflict, ErrorResponse{Error: "Sensor type has sensor data and cannot be deleted"})
+	default:
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: fallback})
+	}
+}
+
+// toDomain converts a sensor type request to the domain model
+func (r *SensorTypeRequest) toDomain() *domain.SensorType {
+	return &domain.SensorType{
+		Name:             r.Name,
+		Unit:             r.Unit,
+		Description:      r.Description,
+		MinValue:         r.MinValue,
+		MaxValue:         r.MaxValue,
+		MaxRateOfChange:  r.MaxRateOfChange,
+		ID1Pattern:       r.ID1Pattern,
+		ID2Pattern:       r.ID2Pattern,
+		ValidationAction: r.ValidationAction,
+	}
+}
+
+// toSensorTypeResponse converts a domain sensor type to its response model
+func toSensorTypeResponse(sensorType *domain.SensorType) SensorTypeResponse {
+	return SensorTypeResponse{
+		ID:               sensorType.ID,
+		Name:             sensorType.Name,
+		Unit:             sensorType.Unit,
+		Description:      sensorType.Description,
+		MinValue:         sensorType.MinValue,
+		MaxValue:         sensorType.MaxValue,
+		MaxRateOfChange:  sensorType.MaxRateOfChange,
+		ID1Pattern:       sensorType.ID1Pattern,
+		ID2Pattern:       sensorType.ID2Pattern,
+		ValidationAction: sensorType.ValidationAction,
+		CreatedAt:        formatMillis(sensorType.CreatedAt),
+		UpdatedAt:        formatMillis(sensorType.UpdatedAt),
+	}
+}
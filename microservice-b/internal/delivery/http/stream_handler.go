@@ -0,0 +1,285 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"sensor_project/microservice-b/internal/domain"
+
+	"github.com/labstack/echo/v4"
+	"golang.org/x/net/websocket"
+)
+
+// streamKeepalive is how long an idle event stream waits before sending a comment, so
+// proxies do not close the connection
+const streamKeepalive = 15 * time.Second
+
+// errReplayTruncated tells a resuming client that it missed more readings than were replayed
+const errReplayTruncated = "more readings were missed than can be replayed; query them by time"
+
+// StreamHandler handles live streams of sensor data over Server-Sent Events and WebSocket
+type StreamHandler struct {
+	streamUseCase domain.StreamUseCase
+
+	// ctx is cancelled on shutdown to end the open streams
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// NewStreamHandler creates a new stream HTTP handler
+func NewStreamHandler(streamUseCase domain.StreamUseCase) *StreamHandler {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &StreamHandler{
+		streamUseCase: streamUseCase,
+		ctx:           ctx,
+		cancel:        cancel,
+	}
+}
+
+// SetupRoutes configures the HTTP routes
+func (h *StreamHandler) SetupRoutes(e *echo.Echo) {
+	api := e.Group("/api")
+
+	// Live stream routes
+	api.GET("/sensor-data/stream", h.StreamSensorData, requireUser)
+	api.GET("/sensor-data/ws", h.StreamSensorDataWebSocket, requireUser)
+}
+
+// Shutdown ends every open stream so the server can shut down
+func (h *StreamHandler) Shutdown() {
+	h.cancel()
+}
+
+// StreamSensorData streams sensor data records as Server-Sent Events as they are stored
+// @Summary Stream sensor data
+// @Description Push every stored reading matching the filters as a "reading" event whose id is its resume token. A reconnecting client sends the last id in Last-Event-ID (or resume) and first receives the matching readings it may have missed, which can repeat readings received shortly before; drop repeats by id. If there were more than can be replayed, a "truncated" event follows the replay and the rest must be queried. A client that falls behind receives an "error" event and is disconnected.
+// @Tags sensor-data
+// @Produce text/event-stream
+// @Param id1 query string false "Comma-separated ID1 values"
+// @Param id2 query string false "Comma-separated ID2 values"
+// @Param id2_min query int false "Minimum ID2"
+// @Param id2_max query int false "Maximum ID2"
+// @Param sensor_type query string false "Comma-separated sensor types"
+// @Param min_value query number false "Minimum sensor value"
+// @Param max_value query number false "Maximum sensor value"
+// @Param start_time query string false "Start time filter (RFC3339 format)"
+// @Param end_time query string false "End time filter (RFC3339 format)"
+// @Param time_field query string false "Time to filter by: event_time (default) or created_at"
+// @Param quality_flags query string false "Readings with any of these quality flags, as a bitmask or comma-separated names; 0 selects readings without flags"
+// @Param resume query int false "Resume token of the last reading received"
+// @Param Last-Event-ID header int false "Resume token of the last reading received"
+// @Success 200 {object} StreamMessage
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /api/sensor-data/stream [get]
+func (h *StreamHandler) StreamSensorData(c echo.Context) error {
+	filter, resumeAfter, err := parseStreamRequest(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+	}
+
+	replay, sub, err := h.streamUseCase.Subscribe(filter, resumeAfter)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to subscribe to sensor data"})
+	}
+	defer sub.Close()
+
+	res := c.Response()
+	header := res.Header()
+	header.Set(echo.HeaderContentType, "text/event-stream")
+	header.Set(echo.HeaderCacheControl, "no-cache")
+	header.Set(echo.HeaderConnection, "keep-alive")
+	header.Set("X-Accel-Buffering", "no")
+	res.WriteHeader(http.StatusOK)
+	res.Flush()
+
+	writeEvent := func(id, event string, payload interface{}) error {
+		b, err := json.Marshal(payload)
+		if err != nil {
+			return err
+		}
+		if id != "" {
+			fmt.Fprintf(res, "id: %s\n", id)
+		}
+		if _, err := fmt.Fprintf(res, "event: %s\ndata: %s\n\n", event, b); err != nil {
+			return err
+		}
+		res.Flush()
+		return nil
+	}
+
+	ctx, cancel := h.streamContext(c.Request().Context())
+	defer cancel()
+
+	err = streamReadings(ctx, replay, sub,
+		func(data *domain.SensorData) error {
+			return writeEvent(strconv.FormatInt(data.ID, 10), "reading", toSensorDataResponse(data))
+		},
+		func() error {
+			return writeEvent("", "truncated", ErrorResponse{Error: errReplayTruncated})
+		},
+		func() error {
+			if _, err := io.WriteString(res, ": keepalive\n\n"); err != nil {
+				return err
+			}
+			res.Flush()
+			return nil
+		})
+	if errors.Is(err, domain.ErrSubscriberTooSlow) {
+		writeEvent("", "error", ErrorResponse{Error: err.Error()})
+	}
+
+	return nil
+}
+
+// StreamSensorDataWebSocket streams sensor data records over a WebSocket as they are stored
+// @Summary Stream sensor data over WebSocket
+// @Description Upgrade to a WebSocket that receives a JSON message per stored reading matching the filters, {"type": "reading", "reading": {...}}; the reading's id is its resume token. A reconnecting client passes the last id as resume and first receives the matching readings it may have missed, which can repeat readings received shortly before; drop repeats by id. If there were more than can be replayed, {"type": "truncated", "error": "..."} follows the replay and the rest must be queried. A client that falls behind receives {"type": "error", "error": "..."} and is disconnected.
+// @Tags sensor-data
+// @Param id1 query string false "Comma-separated ID1 values"
+// @Param id2 query string false "Comma-separated ID2 values"
+// @Param id2_min query int false "Minimum ID2"
+// @Param id2_max query int false "Maximum ID2"
+// @Param sensor_type query string false "Comma-separated sensor types"
+// @Param min_value query number false "Minimum sensor value"
+// @Param max_value query number false "Maximum sensor value"
+// @Param start_time query string false "Start time filter (RFC3339 format)"
+// @Param end_time query string false "End time filter (RFC3339 format)"
+// @Param time_field query string false "Time to filter by: event_time (default) or created_at"
+// @Param quality_flags query string false "Readings with any of these quality flags, as a bitmask or comma-separated names; 0 selects readings without flags"
+// @Param resume query int false "Resume token of the last reading received"
+// @Success 101 {object} StreamMessage
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /api/sensor-data/ws [get]
+func (h *StreamHandler) StreamSensorDataWebSocket(c echo.Context) error {
+	filter, resumeAfter, err := parseStreamRequest(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+	}
+
+	// Subscribe before upgrading so failures can still be reported as a normal response
+	replay, sub, err := h.streamUseCase.Subscribe(filter, resumeAfter)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to subscribe to sensor data"})
+	}
+	defer sub.Close()
+
+	server := websocket.Server{
+		Handler: func(ws *websocket.Conn) {
+			defer ws.Close()
+
+			ctx, cancel := h.streamContext(c.Request().Context())
+			defer cancel()
+
+			// Messages from the client are ignored; reading only notices it going away
+			go func() {
+				io.Copy(io.Discard, ws)
+				cancel()
+			}()
+
+			err := streamReadings(ctx, replay, sub,
+				func(data *domain.SensorData) error {
+					reading := toSensorDataResponse(data)
+					return websocket.JSON.Send(ws, StreamMessage{Type: "reading", Reading: &reading})
+				},
+				func() error {
+					return websocket.JSON.Send(ws, StreamMessage{Type: "truncated", Error: errReplayTruncated})
+				}, nil)
+			if errors.Is(err, domain.ErrSubscriberTooSlow) {
+				websocket.JSON.Send(ws, StreamMessage{Type: "error", Error: err.Error()})
+			}
+		},
+	}
+	server.ServeHTTP(c.Response(), c.Request())
+
+	return nil
+}
+
+// streamContext returns a context for a stream that is also cancelled on shutdown
+func (h *StreamHandler) streamContext(parent context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(parent)
+	stop := context.AfterFunc(h.ctx, cancel)
+	return ctx, func() {
+		stop()
+		cancel()
+	}
+}
+
+// parseStreamRequest parses the filters of a stream request and its resume token, taken
+// from the resume parameter or else the Last-Event-ID header sent by reconnecting
+// event sources
+func parseStreamRequest(c echo.Context) (*domain.SensorDataFilter, int64, error) {
+	filter, err := parseFilterFromQuery(c)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	token := c.QueryParam("resume")
+	if token == "" {
+		token = c.Request().Header.Get("Last-Event-ID")
+	}
+	if token == "" {
+		return filter, 0, nil
+	}
+
+	resumeAfter, err := strconv.ParseInt(token, 10, 64)
+	if err != nil || resumeAfter < 0 {
+		return nil, 0, fmt.Errorf("invalid resume token %q", token)
+	}
+	return filter, resumeAfter, nil
+}
+
+// streamReadings sends the replayed readings, calling truncated after them if the replay
+// is incomplete, and then the live ones until the context is done or the subscription
+// ends, skipping live readings that were already replayed. A non-nil keepalive is called
+// whenever nothing was sent for streamKeepalive.
+func streamReadings(ctx context.Context, replay *domain.StreamReplay, sub domain.FeedSubscription, send func(*domain.SensorData) error, truncated, keepalive func() error) error {
+	replayed := make(map[int64]struct{}, len(replay.Readings))
+	for _, data := range replay.Readings {
+		if err := send(data); err != nil {
+			return err
+		}
+		replayed[data.ID] = struct{}{}
+	}
+	if replay.Truncated {
+		if err := truncated(); err != nil {
+			return err
+		}
+	}
+
+	ticker := time.NewTicker(streamKeepalive)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case data, ok := <-sub.Readings():
+			if !ok {
+				return sub.Err()
+			}
+			if _, ok := replayed[data.ID]; ok {
+				continue
+			}
+			if err := send(data); err != nil {
+				return err
+			}
+			ticker.Reset(streamKeepalive)
+		case <-ticker.C:
+			if keepalive != nil {
+				if err := keepalive(); err != nil {
+					return err
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,382 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"sensor_project/microservice-b/internal/domain"
+
+	"github.com/labstack/echo/v4"
+)
+
+// UserHandler handles HTTP requests for the current user and for administering users
+// and their roles
+type UserHandler struct {
+	userUseCase domain.UserUseCase
+}
+
+// NewUserHandler creates a new user HTTP handler
+func NewUserHandler(userUseCase domain.UserUseCase) *UserHandler {
+	return &UserHandler{
+		userUseCase: userUseCase,
+	}
+}
+
+// SetupRoutes configures the HTTP routes. Users are administered by admins logged in
+// with a token; a request made with an API key cannot administer users.
+func (h *UserHandler) SetupRoutes(e *echo.Echo) {
+	api := e.Group("/api")
+
+	// Current user route
+	api.GET("/me", h.GetMe, requireLogin)
+
+	// User administration routes
+	api.POST("/users", h.CreateUser, requireAdmin, rejectAPIKeys)
+	api.GET("/users", h.ListUsers, requireAdmin, rejectAPIKeys)
+	api.GET("/users/:id", h.GetUser, requireAdmin, rejectAPIKeys)
+	api.PUT("/users/:id", h.UpdateUser, requireAdmin, rejectAPIKeys)
+	api.DELETE("/users/:id", h.DeleteUser, requireAdmin, rejectAPIKeys)
+	api.POST("/users/:id/disable", h.DisableUser, requireAdmin, rejectAPIKeys)
+	api.POST("/users/:id/enable", h.EnableUser, requireAdmin, rejectAPIKeys)
+	api.POST("/users/:id/password", h.ResetPassword, requireAdmin, rejectAPIKeys)
+	api.PUT("/users/:id/roles/:role", h.AddRole, requireAdmin, rejectAPIKeys)
+	api.DELETE("/users/:id/roles/:role", h.RemoveRole, requireAdmin, rejectAPIKeys)
+}
+
+// GetMe returns the authenticated user
+// @Summary Get current user
+// @Description Get the user a request is authenticated as. With a scoped API key the roles are those the key grants.
+// @Tags users
+// @Produce json
+// @Success 200 {object} UserResponse
+// @Failure 401 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /api/me [get]
+func (h *UserHandler) GetMe(c echo.Context) error {
+	return c.JSON(http.StatusOK, toUserResponse(currentUser(c)))
+}
+
+// CreateUser creates a user
+// @Summary Create user
+// @Description Create a user with a password of 8 to 72 bytes and the given roles
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param user body CreateUserRequest true "User"
+// @Success 201 {object} UserResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /api/users [post]
+func (h *UserHandler) CreateUser(c echo.Context) error {
+	req := new(CreateUserRequest)
+	if err := c.Bind(req); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid request format"})
+	}
+
+	user := &domain.User{
+		Username: req.Username,
+		Email:    req.Email,
+		Roles:    req.Roles,
+	}
+	if err := h.userUseCase.Create(user, req.Password); err != nil {
+		return userError(c, err, "Failed to create user")
+	}
+
+	return c.JSON(http.StatusCreated, toUserResponse(user))
+}
+
+// ListUsers lists all users
+// @Summary List users
+// @Description List all users ordered by username
+// @Tags users
+// @Produce json
+// @Success 200 {array} UserResponse
+// @Failure 500 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /api/users [get]
+func (h *UserHandler) ListUsers(c echo.Context) error {
+	users, err := h.userUseCase.List()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to retrieve users"})
+	}
+
+	results := make([]UserResponse, 0, len(users))
+	for _, user := range users {
+		results = append(results, toUserResponse(user))
+	}
+
+	return c.JSON(http.StatusOK, results)
+}
+
+// GetUser returns a user by ID
+// @Summary Get user
+// @Description Get a user by ID
+// @Tags users
+// @Produce json
+// @Param id path int true "User ID"
+// @Success 200 {object} UserResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /api/users/{id} [get]
+func (h *UserHandler) GetUser(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid ID format"})
+	}
+
+	user, err := h.userUseCase.GetByID(id)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to retrieve user"})
+	}
+	if user == nil {
+		return c.JSON(http.StatusNotFound, ErrorResponse{Error: "User not found"})
+	}
+
+	return c.JSON(http.StatusOK, toUserResponse(user))
+}
+
+// UpdateUser updates the username and email of a user
+// @Summary Update user
+// @Description Change the username and email of a user; fields left out are unchanged
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param id path int true "User ID"
+// @Param user body UpdateUserRequest true "User"
+// @Success 200 {object} UserResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /api/users/{id} [put]
+func (h *UserHandler) UpdateUser(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid ID format"})
+	}
+
+	req := new(UpdateUserRequest)
+	if err := c.Bind(req); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid request format"})
+	}
+
+	user, err := h.userUseCase.Update(id, &domain.UserUpdate{
+		Username: req.Username,
+		Email:    req.Email,
+	})
+	if err != nil {
+		return userError(c, err, "Failed to update user")
+	}
+
+	return c.JSON(http.StatusOK, toUserResponse(user))
+}
+
+// DeleteUser deletes a user
+// @Summary Delete user
+// @Description Delete a user with their roles and API keys. The last active admin cannot be deleted.
+// @Tags users
+// @Produce json
+// @Param id path int true "User ID"
+// @Success 200 {object} SuccessResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /api/users/{id} [delete]
+func (h *UserHandler) DeleteUser(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid ID format"})
+	}
+
+	if err := h.userUseCase.Delete(id); err != nil {
+		return userError(c, err, "Failed to delete user")
+	}
+
+	return c.JSON(http.StatusOK, SuccessResponse{
+		Success: true,
+		Message: "User deleted successfully",
+	})
+}
+
+// DisableUser disables a user
+// @Summary Disable user
+// @Description Disable a user; they can no longer log in, and their tokens and API keys stop working. The last active admin cannot be disabled.
+// @Tags users
+// @Produce json
+// @Param id path int true "User ID"
+// @Success 200 {object} UserResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /api/users/{id}/disable [post]
+func (h *UserHandler) DisableUser(c echo.Context) error {
+	return h.setDisabled(c, true)
+}
+
+// EnableUser re-enables a disabled user
+// @Summary Enable user
+// @Description Re-enable a disabled user
+// @Tags users
+// @Produce json
+// @Param id path int true "User ID"
+// @Success 200 {object} UserResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /api/users/{id}/enable [post]
+func (h *UserHandler) EnableUser(c echo.Context) error {
+	return h.setDisabled(c, false)
+}
+
+// ResetPassword replaces the password of a user
+// @Summary Reset password
+// @Description Replace the password of a user with one of 8 to 72 bytes
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param id path int true "User ID"
+// @Param password body PasswordRequest true "New password"
+// @Success 200 {object} SuccessResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /api/users/{id}/password [post]
+func (h *UserHandler) ResetPassword(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid ID format"})
+	}
+
+	req := new(PasswordRequest)
+	if err := c.Bind(req); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid request format"})
+	}
+
+	if err := h.userUseCase.ResetPassword(id, req.Password); err != nil {
+		return userError(c, err, "Failed to reset password")
+	}
+
+	return c.JSON(http.StatusOK, SuccessResponse{
+		Success: true,
+		Message: "Password reset successfully",
+	})
+}
+
+// AddRole assigns a role to a user
+// @Summary Assign role
+// @Description Assign a role (admin or user) to a user; assigning a role the user has is a no-op
+// @Tags users
+// @Produce json
+// @Param id path int true "User ID"
+// @Param role path string true "Role name"
+// @Success 200 {object} UserResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /api/users/{id}/roles/{role} [put]
+func (h *UserHandler) AddRole(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid ID format"})
+	}
+
+	user, err := h.userUseCase.AddRole(id, c.Param("role"))
+	if err != nil {
+		return userError(c, err, "Failed to assign role")
+	}
+
+	return c.JSON(http.StatusOK, toUserResponse(user))
+}
+
+// RemoveRole unassigns a role from a user
+// @Summary Unassign role
+// @Description Unassign a role from a user; the last active admin cannot lose the admin role
+// @Tags users
+// @Produce json
+// @Param id path int true "User ID"
+// @Param role path string true "Role name"
+// @Success 200 {object} UserResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security ApiKeyAuth
+// @Router /api/users/{id}/roles/{role} [delete]
+func (h *UserHandler) RemoveRole(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid ID format"})
+	}
+
+	user, err := h.userUseCase.RemoveRole(id, c.Param("role"))
+	if err != nil {
+		return userError(c, err, "Failed to unassign role")
+	}
+
+	return c.JSON(http.StatusOK, toUserResponse(user))
+}
+
+// setDisabled disables or re-enables the user named in the path
+func (h *UserHandler) setDisabled(c echo.Context, disabled bool) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid ID format"})
+	}
+
+	user, err := h.userUseCase.SetDisabled(id, disabled)
+	if err != nil {
+		return userError(c, err, "Failed to update user")
+	}
+
+	return c.JSON(http.StatusOK, toUserResponse(user))
+}
+
+// userError maps user administration errors to HTTP responses
+func userError(c echo.Context, err error, fallback string) error {
+	switch {
+	case errors.Is(err, domain.ErrInvalidInput):
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+	case errors.Is(err, domain.ErrRoleNotFound):
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Unknown role"})
+	case errors.Is(err, domain.ErrUserNotFound):
+		return c.JSON(http.StatusNotFound, ErrorResponse{Error: "User not found"})
+	case errors.Is(err, domain.ErrUserExists):
+		return c.JSON(http.StatusConflict, ErrorResponse{Error: "Username or email already in use"})
+	case errors.Is(err, domain.ErrLastAdmin):
+		return c.JSON(http.StatusConflict, ErrorResponse{Error: err.Error()})
+	default:
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: fallback})
+	}
+}
+
+// toUserResponse converts a domain user to the response model
+func toUserResponse(user *domain.User) UserResponse {
+	response := UserResponse{
+		ID:        user.ID,
+		Username:  user.Username,
+		Email:     user.Email,
+		Roles:     user.Roles,
+		Disabled:  user.DisabledAt != nil,
+		CreatedAt: formatMillis(user.CreatedAt.UnixMilli()),
+	}
+	if response.Roles == nil {
+		response.Roles = []string{}
+	}
+	if user.DisabledAt != nil {
+		response.DisabledAt = formatMillis(user.DisabledAt.UnixMilli())
+	}
+	return response
+}
@@ -0,0 +1,72 @@
+package domain
+
+import (
+	"errors"
+)
+
+// ErrInvalidInput is wrapped by errors describing a request that failed validation
+var ErrInvalidInput = errors.New("invalid input")
+
+// Errors returned when a reading is rejected at ingest
+var (
+	ErrEventTimeInFuture = errors.New("event time is too far in the future")
+	ErrEventTimeTooOld   = errors.New("event time is too far in the past")
+	ErrUnknownSensorType = errors.New("unknown sensor type")
+	ErrInvalidReading    = errors.New("invalid reading")
+)
+
+// ErrDuplicateReading is returned when storing a reading whose reading ID is already
+// stored
+var ErrDuplicateReading = errors.New("reading is already stored")
+
+// ErrSensorDataNotFound is returned when a sensor data record does not exist or is
+// already deleted
+var ErrSensorDataNotFound = errors.New("sensor data not found")
+
+// Errors returned when the write pipeline cannot take a reading
+var (
+	ErrWriteQueueFull   = errors.New("write queue is full, the database is falling behind")
+	ErrWriteQueueClosed = errors.New("write queue is closed")
+)
+
+// Errors returned by sensor type operations
+var (
+	ErrSensorTypeNotFound = errors.New("sensor type not found")
+	ErrSensorTypeExists   = errors.New("sensor type already exists")
+	ErrSensorTypeInUse    = errors.New("sensor type has sensor data")
+)
+
+// ErrRetentionPolicyNotFound is returned when a sensor type has no retention policy
+var ErrRetentionPolicyNotFound = errors.New("retention policy not found")
+
+// ErrEmptyFilter is returned when a delete by filter would match every record without
+// being asked to
+var ErrEmptyFilter = errors.New("filter is empty and would delete every record")
+
+// ErrDeletionBatchNotFound is returned when no deleted records are left to restore in
+// a deletion batch
+var ErrDeletionBatchNotFound = errors.New("deletion batch not found")
+
+// ErrSubscriberTooSlow ends a live subscription whose reader fell too far behind
+var ErrSubscriberTooSlow = errors.New("subscriber fell too far behind")
+
+// Errors returned by authentication
+var (
+	ErrInvalidCredentials = errors.New("invalid username or password")
+	ErrInvalidToken       = errors.New("invalid or expired token")
+	ErrInvalidAPIKey      = errors.New("invalid, expired or revoked API key")
+)
+
+// Errors returned by user administration
+var (
+	ErrUserNotFound = errors.New("user not found")
+	ErrUserExists   = errors.New("username or email already in use")
+	ErrRoleNotFound = errors.New("role not found")
+	ErrLastAdmin    = errors.New("the last active admin cannot be removed")
+)
+
+// ErrAPIKeyNotFound is returned when an API key does not exist or belongs to another user
+var ErrAPIKeyNotFound = errors.New("API key not found")
+
+// ErrOutOfScope is returned when an API key is used for a reading outside its scope
+var ErrOutOfScope = errors.New("reading is outside the API key's scope")
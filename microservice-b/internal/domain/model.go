@@ -1,87 +1,805 @@
 package domain
 
 import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
 	"time"
 )
 
-// SensorData represents a single sensor reading
+// SensorData represents a single sensor reading. EventTime is when the device took the
+// reading and CreatedAt is when microservice-b received it, both in Unix milliseconds.
+// QualityFlags records the validation rules the reading broke but was stored anyway.
+// A deleted reading has the time it was deleted and the ID of the deletion batch that
+// can restore it. Revisions counts the corrections made to the measured value.
+// ReadingID is the optional ID the client gave the reading to make retries idempotent.
 type SensorData struct {
-	ID          int64   `json:"id"`
-	SensorValue float64 `json:"sensor_value"`
-	SensorType  string  `json:"sensor_type"`
-	ID1         string  `json:"id1"`
-	ID2         int     `json:"id2"`
-	CreatedAt   int64   `json:"created_at"`
+	ID              int64   `json:"id"`
+	ReadingID       string  `json:"reading_id,omitempty"`
+	SensorValue     float64 `json:"sensor_value"`
+	SensorType      string  `json:"sensor_type"`
+	ID1             string  `json:"id1"`
+	ID2             int     `json:"id2"`
+	EventTime       int64   `json:"event_time"`
+	CreatedAt       int64   `json:"created_at"`
+	QualityFlags    int     `json:"quality_flags"`
+	DeletedAt       int64   `json:"deleted_at,omitempty"`
+	DeletionBatchID string  `json:"deletion_batch_id,omitempty"`
+	Revisions       int     `json:"revisions,omitempty"`
+}
+
+// MaxID1Length matches the size of the sensor_data.id1 column
+const MaxID1Length = 10
+
+// MaxReadingIDLength matches the size of the sensor_data.reading_id column, which fits
+// a UUID or a ULID
+const MaxReadingIDLength = 36
+
+// Quality flags set on stored readings, combined as a bitmask
+const (
+	QualityOutOfRange      = 1 << iota // value outside the sensor type's range
+	QualityClamped                     // value was clamped to the allowed range or rate
+	QualityRateExceeded                // value changed faster than the sensor type allows
+	QualityPatternMismatch             // id1 or id2 does not match the sensor type's pattern
+)
+
+// QualityFlagNames maps each quality flag to its name in the API
+var QualityFlagNames = []struct {
+	Flag int
+	Name string
+}{
+	{QualityOutOfRange, "out_of_range"},
+	{QualityClamped, "clamped"},
+	{QualityRateExceeded, "rate_exceeded"},
+	{QualityPatternMismatch, "pattern_mismatch"},
+}
+
+// QualityFlagList returns the names of the flags set in a quality bitmask
+func QualityFlagList(flags int) []string {
+	names := []string{}
+	for _, f := range QualityFlagNames {
+		if flags&f.Flag != 0 {
+			names = append(names, f.Name)
+		}
+	}
+	return names
 }
 
-// SensorDataFilter represents filter criteria for querying sensor data
+// Time fields that sensor data can be filtered and ordered by
+const (
+	TimeFieldEvent  = "event_time"
+	TimeFieldIngest = "created_at"
+)
+
+// SensorDataFilter represents filter criteria for querying sensor data. Multi-valued
+// criteria match any of their values; empty ones match everything. TimeField selects
+// which time StartTime, EndTime and ordering apply to.
 type SensorDataFilter struct {
-	ID1        *string    `json:"id1"`
-	ID2        *int       `json:"id2"`
-	SensorType *string    `json:"sensor_type"`
+	ID1        []string   `json:"id1"`
+	ID2        []int      `json:"id2"`
+	ID2Min     *int       `json:"id2_min"`
+	ID2Max     *int       `json:"id2_max"`
+	SensorType []string   `json:"sensor_type"`
+	MinValue   *float64   `json:"min_value"`
+	MaxValue   *float64   `json:"max_value"`
 	StartTime  *time.Time `json:"start_time"`
 	EndTime    *time.Time `json:"end_time"`
+	TimeField  string     `json:"time_field"`
 	Page       int        `json:"page"`
 	PageSize   int        `json:"page_size"`
+
+	// Sort orders the results by the given keys in turn; empty means the default order
+	Sort []SortField `json:"sort"`
+
+	// QualityFlags matches readings with any of the given flags set; zero matches
+	// only readings without flags
+	QualityFlags *int `json:"quality_flags"`
+
+	// IncludeDeleted also matches deleted readings that have not been purged yet
+	IncludeDeleted bool `json:"include_deleted"`
+
+	// AsOf, if set, matches the readings as they were at that time: ingested by then,
+	// with the value they had then and deleted only if they had been deleted by then
+	AsOf *time.Time `json:"as_of,omitempty"`
+}
+
+// Sort keys of sensor data: the reading's value, its time selected by TimeField, and
+// its record id
+const (
+	SortValue = "value"
+	SortTime  = "time"
+	SortID    = "id"
+)
+
+// SortField is one key of a sort order
+type SortField struct {
+	Key  string `json:"key"`
+	Desc bool   `json:"desc"`
 }
 
-// SensorDataUpdate represents fields that can be updated
+// PageCursor is a position in the newest-first (created_at, id) order of sensor data,
+// used for keyset pagination. Before selects the newer rows preceding the position
+// instead of the older rows following it.
+type PageCursor struct {
+	CreatedAt int64 `json:"created_at"`
+	ID        int64 `json:"id"`
+	Before    bool  `json:"before"`
+}
+
+// Encode encodes the cursor as an opaque URL-safe string; a nil cursor is empty
+func (c *PageCursor) Encode() string {
+	if c == nil {
+		return ""
+	}
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// DecodePageCursor decodes a page cursor produced by Encode
+func DecodePageCursor(value string) (*PageCursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+	var cursor PageCursor
+	if err := json.Unmarshal(b, &cursor); err != nil {
+		return nil, err
+	}
+	return &cursor, nil
+}
+
+// SensorDataPage is a page of sensor data in keyset pagination. A nil cursor means
+// there are no rows in that direction; Total is only set when requested.
+type SensorDataPage struct {
+	Data       []*SensorData `json:"data"`
+	NextCursor *PageCursor   `json:"next_cursor"`
+	PrevCursor *PageCursor   `json:"prev_cursor"`
+	Total      *int          `json:"total"`
+}
+
+// Aggregation functions supported per bucket
+const (
+	AggregateMin    = "min"
+	AggregateMax    = "max"
+	AggregateAvg    = "avg"
+	AggregateCount  = "count"
+	AggregateSum    = "sum"
+	AggregateStdDev = "stddev"
+	AggregateFirst  = "first"
+	AggregateLast   = "last"
+)
+
+// AggregateIntervals lists the bucket intervals aggregations can use, by name
+var AggregateIntervals = map[string]time.Duration{
+	"1m": time.Minute,
+	"5m": 5 * time.Minute,
+	"1h": time.Hour,
+	"1d": 24 * time.Hour,
+}
+
+// AggregateQuery selects sensor data with Filter, groups it into buckets of Interval
+// per sensor type and device, and computes Functions over each bucket. Buckets are
+// aligned to the Unix epoch on the filter's time field.
+type AggregateQuery struct {
+	Filter    SensorDataFilter `json:"filter"`
+	Interval  time.Duration    `json:"interval"`
+	Functions []string         `json:"functions"`
+	Limit     int              `json:"limit"`
+}
+
+// AggregateBucket holds the aggregated values of one bucket of one sensor type and
+// device, keyed by function. BucketStart is in Unix milliseconds.
+type AggregateBucket struct {
+	BucketStart int64              `json:"bucket_start"`
+	SensorType  string             `json:"sensor_type"`
+	ID1         string             `json:"id1"`
+	ID2         int                `json:"id2"`
+	Values      map[string]float64 `json:"values"`
+}
+
+// Sources an aggregation can be computed from
+const (
+	AggregateSourceRaw    = "raw"
+	AggregateSourceRollup = "rollup_"
+)
+
+// AggregateResult holds the buckets of an aggregation. Truncated reports that more
+// buckets matched than were returned, and Source names the data the buckets were
+// computed from: raw readings, or a rollup such as rollup_1h.
+type AggregateResult struct {
+	Buckets   []*AggregateBucket `json:"buckets"`
+	Truncated bool               `json:"truncated"`
+	Source    string             `json:"source"`
+}
+
+// RollupResolutions lists the pre-computed rollup bucket sizes from finest to coarsest.
+// The finest is built from raw readings and each other from the one before it.
+var RollupResolutions = []time.Duration{time.Minute, time.Hour, 24 * time.Hour}
+
+// RollupSeries identifies the readings of one sensor in the rollups
+type RollupSeries struct {
+	SensorTypeID int    `json:"sensor_type_id"`
+	ID1          string `json:"id1"`
+	ID2          int    `json:"id2"`
+}
+
+// RollupChange marks a finest-resolution rollup bucket whose readings changed
+type RollupChange struct {
+	RollupSeries
+	BucketStart int64 `json:"bucket_start"`
+}
+
+// RollupMerge is a run of rollup buckets of one series in [From, To) whose source
+// retention may have purged part of, so the readings ingested into them are added to the
+// buckets instead of rebuilding them
+type RollupMerge struct {
+	RollupSeries
+	Resolution time.Duration `json:"resolution"`
+	From       int64         `json:"from"`
+	To         int64         `json:"to"`
+}
+
+// Actions for readings whose event time falls outside the accepted window
+const (
+	EventTimeReject = "reject"
+	EventTimeClamp  = "clamp"
+)
+
+// IngestPolicy defines how readings are checked when they are stored
+type IngestPolicy struct {
+	EventTime         EventTimePolicy `json:"event_time"`
+	UnknownSensorType string          `json:"unknown_sensor_type"`
+}
+
+// Actions for readings whose sensor type is not registered
+const (
+	UnknownTypeReject       = "reject"
+	UnknownTypeAutoRegister = "auto_register"
+	UnknownTypeQuarantine   = "quarantine"
+)
+
+// Outcomes of storing a reading
+const (
+	StoreStatusStored      = "stored"
+	StoreStatusQuarantined = "quarantined"
+	StoreStatusDuplicate   = "duplicate" // a reading with the same reading ID is already stored
+)
+
+// StoreResult describes what happened to a reading that was accepted at ingest
+type StoreResult struct {
+	Status       string `json:"status"`
+	Message      string `json:"message"`
+	QualityFlags int    `json:"quality_flags"`
+}
+
+// EventTimePolicy defines which device timestamps are accepted at ingest.
+// Readings more than ClockSkewTolerance in the future are handled by FutureAction,
+// and readings older than MaxEventAge (zero means no limit) by PastAction.
+type EventTimePolicy struct {
+	ClockSkewTolerance time.Duration `json:"clock_skew_tolerance"`
+	FutureAction       string        `json:"future_action"`
+	MaxEventAge        time.Duration `json:"max_event_age"`
+	PastAction         string        `json:"past_action"`
+}
+
+// SensorType describes a kind of sensor and the rules its readings are validated
+// against. A nil MinValue or MaxValue leaves that side of the range open, a nil
+// MaxRateOfChange (units per second) disables the rate check, and empty patterns
+// accept any id1 or id2. ValidationAction decides what happens to a reading that
+// breaks a rule.
+type SensorType struct {
+	ID               int      `json:"id"`
+	Name             string   `json:"name"`
+	Unit             string   `json:"unit"`
+	Description      string   `json:"description"`
+	MinValue         *float64 `json:"min_value"`
+	MaxValue         *float64 `json:"max_value"`
+	MaxRateOfChange  *float64 `json:"max_rate_of_change"`
+	ID1Pattern       string   `json:"id1_pattern"`
+	ID2Pattern       string   `json:"id2_pattern"`
+	ValidationAction string   `json:"validation_action"`
+	CreatedAt        int64    `json:"created_at"`
+	UpdatedAt        int64    `json:"updated_at"`
+}
+
+// Actions for readings that break a sensor type's validation rules. Clamp applies to
+// range and rate violations; pattern mismatches are flagged instead.
+const (
+	ValidationReject = "reject"
+	ValidationClamp  = "clamp"
+	ValidationFlag   = "flag"
+)
+
+// RetentionPolicy defines how long the raw readings and each rollup resolution of a
+// sensor type are kept, measured from event time. A zero retention keeps data forever.
+type RetentionPolicy struct {
+	SensorTypeID          int           `json:"sensor_type_id"`
+	SensorType            string        `json:"sensor_type"`
+	RawRetention          time.Duration `json:"raw_retention"`
+	MinuteRollupRetention time.Duration `json:"minute_rollup_retention"`
+	HourRollupRetention   time.Duration `json:"hour_rollup_retention"`
+	DayRollupRetention    time.Duration `json:"day_rollup_retention"`
+	CreatedAt             int64         `json:"created_at"`
+	UpdatedAt             int64         `json:"updated_at"`
+}
+
+// Retentions maps each data resolution to its retention; resolution zero is raw readings
+func (p *RetentionPolicy) Retentions() map[time.Duration]time.Duration {
+	return map[time.Duration]time.Duration{
+		0:              p.RawRetention,
+		time.Minute:    p.MinuteRollupRetention,
+		time.Hour:      p.HourRollupRetention,
+		24 * time.Hour: p.DayRollupRetention,
+	}
+}
+
+// RetentionTargetDeleted is the retention target of deleted readings
+const RetentionTargetDeleted = "deleted"
+
+// RetentionPurge reports the rows of one sensor type and resolution that a retention
+// run removed, or would remove in a dry run. Target is raw or a rollup such as rollup_1h,
+// or deleted for the deleted readings of every sensor type past their grace period.
+type RetentionPurge struct {
+	SensorType string `json:"sensor_type"`
+	Target     string `json:"target"`
+	Cutoff     int64  `json:"cutoff"`
+	Rows       int64  `json:"rows"`
+}
+
+// RetentionReport summarizes one retention run. Times are in Unix milliseconds.
+type RetentionReport struct {
+	DryRun     bool             `json:"dry_run"`
+	StartedAt  int64            `json:"started_at"`
+	FinishedAt int64            `json:"finished_at"`
+	Purged     []RetentionPurge `json:"purged"`
+	TotalRows  int64            `json:"total_rows"`
+	Error      string           `json:"error,omitempty"`
+}
+
+// Statuses of background import and delete jobs
+const (
+	JobStatusRunning   = "running"
+	JobStatusCompleted = "completed"
+	JobStatusFailed    = "failed"
+)
+
+// ImportRowError reports why a row of an import file was rejected. Line is the line of
+// the file the row starts on.
+type ImportRowError struct {
+	Line  int64  `json:"line"`
+	Error string `json:"error"`
+}
+
+// ImportJob tracks a bulk import of readings from a file. In a dry run rows are only
+// validated and RowsImported counts the rows that would have been imported. Times are
+// in Unix milliseconds.
+type ImportJob struct {
+	ID              string           `json:"id"`
+	Status          string           `json:"status"`
+	Format          string           `json:"format"`
+	DryRun          bool             `json:"dry_run"`
+	RowsRead        int64            `json:"rows_read"`
+	RowsImported    int64            `json:"rows_imported"`
+	RowsRejected    int64            `json:"rows_rejected"`
+	Errors          []ImportRowError `json:"errors"`
+	ErrorsTruncated bool             `json:"errors_truncated"`
+	Error           string           `json:"error,omitempty"`
+	CreatedAt       int64            `json:"created_at"`
+	FinishedAt      int64            `json:"finished_at,omitempty"`
+}
+
+// DeleteOptions controls a delete by filter. All allows an empty filter to delete every
+// record; DryRun only counts the matching records. Actor is recorded in the audit log
+// as having made the delete.
+type DeleteOptions struct {
+	All    bool
+	DryRun bool
+	Actor  Actor
+}
+
+// DeleteResult reports a delete by filter. Deletes matching more records than can be
+// deleted in one request run in the background as Job. BatchID restores the deleted
+// records; it is the ID of Job for a delete in the background.
+type DeleteResult struct {
+	Matched int64      `json:"matched"`
+	Deleted int64      `json:"deleted"`
+	DryRun  bool       `json:"dry_run"`
+	BatchID string     `json:"batch_id,omitempty"`
+	Job     *DeleteJob `json:"job,omitempty"`
+}
+
+// DeletionBatch summarizes the records deleted together by one delete, which can be
+// restored until they are purged. Times are in Unix milliseconds: DeletedAt is when
+// the first record was deleted and PurgeAt when purging starts.
+type DeletionBatch struct {
+	ID        string `json:"id"`
+	Rows      int64  `json:"rows"`
+	DeletedAt int64  `json:"deleted_at"`
+	PurgeAt   int64  `json:"purge_at"`
+}
+
+// DeleteJob tracks a delete by filter running in the background in chunks. Times are
+// in Unix milliseconds.
+type DeleteJob struct {
+	ID         string `json:"id"`
+	Status     string `json:"status"`
+	Matched    int64  `json:"matched"`
+	Deleted    int64  `json:"deleted"`
+	Error      string `json:"error,omitempty"`
+	CreatedAt  int64  `json:"created_at"`
+	FinishedAt int64  `json:"finished_at,omitempty"`
+}
+
+// SensorDataUpdate represents a correction of a reading's value and its reason code
 type SensorDataUpdate struct {
 	SensorValue *float64 `json:"sensor_value"`
+	Reason      string   `json:"reason"`
+}
+
+// Reason codes of corrections
+const (
+	CorrectionCalibration = "calibration"  // the sensor was miscalibrated
+	CorrectionSensorFault = "sensor_fault" // the sensor reported a faulty value
+	CorrectionDataEntry   = "data_entry"   // the value was entered or transmitted wrongly
+	CorrectionOther       = "other"
+)
+
+// CorrectionReasons lists the valid reason codes of corrections
+var CorrectionReasons = []string{CorrectionCalibration, CorrectionSensorFault, CorrectionDataEntry, CorrectionOther}
+
+// SensorDataRevision is one value a reading has had. Revision 0 is the measured value,
+// stored at CreatedAt without a reason or actor; each correction adds the next
+// revision. Times are in Unix milliseconds.
+type SensorDataRevision struct {
+	Revision    int     `json:"revision"`
+	SensorValue float64 `json:"sensor_value"`
+	Reason      string  `json:"reason,omitempty"`
+	Actor       Actor   `json:"actor"`
+	CreatedAt   int64   `json:"created_at"`
+}
+
+// Actor identifies who changed sensor data: a user, and the API key they used if any
+type Actor struct {
+	UserID   int    `json:"user_id"`
+	Username string `json:"username"`
+	APIKeyID int    `json:"api_key_id,omitempty"`
+}
+
+// Operations recorded in the audit log
+const (
+	AuditUpdate         = "update"
+	AuditDelete         = "delete"
+	AuditDeleteByFilter = "delete_by_filter"
+	AuditRestore        = "restore"
+)
+
+// AuditEntry records a change to one reading: who made it and when, the operation, and
+// the reading before and after the change. Filter is the JSON filter of a delete by
+// filter.
+type AuditEntry struct {
+	ID           int64           `json:"id"`
+	Actor        Actor           `json:"actor"`
+	Operation    string          `json:"operation"`
+	SensorDataID int64           `json:"sensor_data_id"`
+	Filter       json.RawMessage `json:"filter,omitempty"`
+	Before       *SensorData     `json:"before,omitempty"`
+	After        *SensorData     `json:"after,omitempty"`
+	CreatedAt    time.Time       `json:"created_at"`
+}
+
+// AuditFilter selects audit entries, newest first. Zero fields match every entry;
+// BeforeID pages back through the entries older than the one given.
+type AuditFilter struct {
+	ActorUserID  int        `json:"actor_user_id"`
+	Operation    string     `json:"operation"`
+	SensorDataID int64      `json:"sensor_data_id"`
+	StartTime    *time.Time `json:"start_time"`
+	EndTime      *time.Time `json:"end_time"`
+	BeforeID     int64      `json:"before_id"`
+	Limit        int        `json:"limit"`
 }
 
-// User represents a user in the system
+// Roles a user can be given. Users read data; admins also change and delete it.
+const (
+	RoleAdmin = "admin"
+	RoleUser  = "user"
+)
+
+// User represents a user in the system. A disabled user cannot log in or use tokens
+// and API keys issued to them.
 type User struct {
-	ID           int       `json:"id"`
-	Username     string    `json:"username"`
-	PasswordHash string    `json:"-"`
-	Email        string    `json:"email"`
-	Roles        []string  `json:"roles"`
-	CreatedAt    time.Time `json:"created_at"`
-	UpdatedAt    time.Time `json:"updated_at"`
+	ID           int        `json:"id"`
+	Username     string     `json:"username"`
+	PasswordHash string     `json:"-"`
+	Email        string     `json:"email"`
+	Roles        []string   `json:"roles"`
+	DisabledAt   *time.Time `json:"disabled_at,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
 }
 
-// APIKey represents an API key for authentication
+// UserUpdate represents the account fields of a user that can be updated
+type UserUpdate struct {
+	Username *string `json:"username"`
+	Email    *string `json:"email"`
+}
+
+// APIKey is a long-lived credential for machine clients and devices that acts as the
+// user it was issued for. A key scoped to sensor types or devices (ID1 values) may only
+// send and read readings within its scope. Key is only set when the key is issued;
+// just its hash is stored.
 type APIKey struct {
-	ID        int       `json:"id"`
-	Key       string    `json:"key"`
-	UserID    int       `json:"user_id"`
-	ExpiresAt time.Time `json:"expires_at"`
-	CreatedAt time.Time `json:"created_at"`
+	ID          int
+	Key         string
+	Prefix      string
+	UserID      int
+	Name        string
+	SensorTypes []string
+	Devices     []string
+	ExpiresAt   *time.Time
+	RevokedAt   *time.Time
+	CreatedAt   time.Time
+}
+
+// Scoped reports whether the key is limited to some sensor types or devices
+func (k *APIKey) Scoped() bool {
+	return len(k.SensorTypes) > 0 || len(k.Devices) > 0
+}
+
+// Allows reports whether a reading of the sensor type and device is within the key's scope
+func (k *APIKey) Allows(sensorType, id1 string) bool {
+	return (len(k.SensorTypes) == 0 || slices.Contains(k.SensorTypes, sensorType)) &&
+		(len(k.Devices) == 0 || slices.Contains(k.Devices, id1))
+}
+
+// Restrict limits a filter to the key's scope: a filter asking for no sensor types or
+// devices gets those of the scope, and asking for any outside it is an error
+func (k *APIKey) Restrict(filter *SensorDataFilter) error {
+	var err error
+	if filter.SensorType, err = restrictToScope(filter.SensorType, k.SensorTypes, "sensor_type"); err != nil {
+		return err
+	}
+	filter.ID1, err = restrictToScope(filter.ID1, k.Devices, "id1")
+	return err
+}
+
+// restrictToScope returns the requested values of a filter, or the scope if none were
+// requested. An empty scope allows any value.
+func restrictToScope(requested, scope []string, name string) ([]string, error) {
+	if len(scope) == 0 {
+		return requested, nil
+	}
+	if len(requested) == 0 {
+		return scope, nil
+	}
+	for _, value := range requested {
+		if !slices.Contains(scope, value) {
+			return nil, fmt.Errorf("%w: %s %q", ErrOutOfScope, name, value)
+		}
+	}
+	return requested, nil
+}
+
+// Active reports whether the key is neither revoked nor expired at the given time
+func (k *APIKey) Active(now time.Time) bool {
+	return k.RevokedAt == nil && (k.ExpiresAt == nil || now.Before(*k.ExpiresAt))
+}
+
+// AuthTokens are the tokens issued to a user at login. The access token authenticates
+// requests until AccessExpiresAt; the refresh token can be exchanged for new tokens
+// until RefreshExpiresAt.
+type AuthTokens struct {
+	AccessToken      string
+	AccessExpiresAt  time.Time
+	RefreshToken     string
+	RefreshExpiresAt time.Time
 }
 
 // SensorDataRepository defines the interface for sensor data storage
 type SensorDataRepository interface {
 	Store(data *SensorData) error
-	GetByID(id int64) (*SensorData, error)
+	StoreBatch(data []*SensorData) error
+	GetByID(id int64, includeDeleted bool) (*SensorData, error)
+	GetByReadingID(readingID string) (*SensorData, error)
+	GetIDsByReadingID(readingIDs []string) (map[string]int64, error)
 	GetByFilter(filter *SensorDataFilter) ([]*SensorData, int, error)
-	Update(id int64, update *SensorDataUpdate) error
-	Delete(id int64) error
-	DeleteByFilter(filter *SensorDataFilter) (int, error)
+	GetByCursor(filter *SensorDataFilter, cursor *PageCursor, limit int) ([]*SensorData, error)
+	Count(filter *SensorDataFilter) (int, error)
+	MaxID() (int64, error)
+	Stream(filter *SensorDataFilter, fn func(*SensorData) error) error
+	Update(id int64, update *SensorDataUpdate, actor Actor) error
+	GetRevisions(id int64) ([]*SensorDataRevision, error)
+	Delete(id int64, batchID string, actor Actor) error
+	DeleteByFilter(filter *SensorDataFilter, batchID string, actor Actor) (int, error)
+	DeleteBatch(filter *SensorDataFilter, maxID int64, limit int, batchID string, actor Actor) (int, error)
+	Restore(batchID string, actor Actor) (int, error)
+	ListDeletions() ([]*DeletionBatch, error)
+	GetAfterID(filter *SensorDataFilter, afterID, overlapMs int64, limit int) ([]*SensorData, error)
+	Quarantine(data *SensorData, reason string) error
+	Aggregate(query *AggregateQuery) ([]*AggregateBucket, error)
+}
+
+// AuditRepository defines the interface for reading the audit log. Entries are written
+// by SensorDataRepository in the transaction of the change they record.
+type AuditRepository interface {
+	List(filter *AuditFilter) ([]*AuditEntry, error)
+}
+
+// RollupRepository defines the interface for pre-computed rollup storage. The watermark
+// is the ingest time up to which readings are reflected in the rollups.
+type RollupRepository interface {
+	GetWatermark() (int64, error)
+	GetChanges(since, until int64) ([]RollupChange, int64, error)
+	Rebuild(resolution, source time.Duration, series RollupSeries, from, to int64) error
+	Advance(since, watermark, markID int64, merges []RollupMerge) error
+	Aggregate(query *AggregateQuery, resolution time.Duration) ([]*AggregateBucket, error)
+	GetPendingBuckets(filter *SensorDataFilter, interval time.Duration, watermark int64) ([]int64, error)
+}
+
+// RetentionRepository defines the interface for retention policy storage and purging.
+// A zero resolution addresses raw readings, any other the rollups of that resolution.
+type RetentionRepository interface {
+	List() ([]*RetentionPolicy, error)
+	GetBySensorType(sensorType string) (*RetentionPolicy, error)
+	Save(policy *RetentionPolicy) error
+	Delete(sensorType string) error
+	CountExpired(resolution time.Duration, sensorTypeID int, cutoff int64) (int64, error)
+	PurgeExpired(resolution time.Duration, sensorTypeID int, cutoff int64, limit int) (int64, error)
+	CountDeleted(cutoff int64) (int64, error)
+	PurgeDeleted(cutoff int64, limit int) (int64, error)
+}
+
+// SensorTypeRepository defines the interface for sensor type storage
+type SensorTypeRepository interface {
+	Create(sensorType *SensorType) error
+	GetByID(id int) (*SensorType, error)
+	GetByName(name string) (*SensorType, error)
+	List() ([]*SensorType, error)
+	Update(sensorType *SensorType) error
+	Delete(id int) error
 }
 
 // UserRepository defines the interface for user storage
 type UserRepository interface {
+	Create(user *User) error
 	GetByID(id int) (*User, error)
 	GetByUsername(username string) (*User, error)
-	GetByAPIKey(key string) (*User, error)
-	CreateAPIKey(userID int, expiresAt time.Time) (*APIKey, error)
+	List() ([]*User, error)
+	Update(user *User) error
+	SetPassword(id int, passwordHash string) error
+	SetDisabled(id int, disabledAt *time.Time) error
+	Delete(id int) error
+	AddRole(id int, role string) error
+	RemoveRole(id int, role string) error
+	CountActiveWithRole(role string) (int, error)
+}
+
+// APIKeyRepository defines the interface for API key storage. Keys are looked up by
+// the hash of their secret.
+type APIKeyRepository interface {
+	Create(key *APIKey, hash string) error
+	GetByID(id int) (*APIKey, error)
+	GetByHash(hash string) (*APIKey, error)
+	List(userID int) ([]*APIKey, error)
+	Revoke(id int, at time.Time) error
 }
 
 // SensorDataUseCase defines the interface for sensor data business logic
 type SensorDataUseCase interface {
-	Store(data *SensorData) error
-	GetByID(id int64) (*SensorData, error)
+	Store(data *SensorData) (*StoreResult, error)
+	StoreAsync(data *SensorData) PendingStore
+	GetByID(id int64, includeDeleted bool) (*SensorData, error)
 	GetByFilter(filter *SensorDataFilter) ([]*SensorData, int, error)
-	Update(id int64, update *SensorDataUpdate) error
-	Delete(id int64) error
-	DeleteByFilter(filter *SensorDataFilter) (int, error)
+	GetPage(filter *SensorDataFilter, cursor *PageCursor, includeTotal bool) (*SensorDataPage, error)
+	Export(filter *SensorDataFilter, fn func(*SensorData) error) error
+	Update(id int64, update *SensorDataUpdate, actor Actor) error
+	GetHistory(id int64, includeDeleted bool) (*SensorData, []*SensorDataRevision, error)
+	Delete(id int64, actor Actor) (string, error)
+	Aggregate(query *AggregateQuery) (*AggregateResult, error)
+}
+
+// PendingStore is a reading handed to the write pipeline. Wait blocks until it has been
+// written and reports the outcome as Store does.
+type PendingStore interface {
+	Wait() (*StoreResult, error)
+}
+
+// SensorFeed delivers readings to live subscribers as they are stored
+type SensorFeed interface {
+	Publish(data *SensorData)
+	Subscribe(filter *SensorDataFilter) FeedSubscription
+}
+
+// FeedSubscription receives the readings matching its filter that are published after
+// it was made. Readings is closed when the subscription ends; Err then reports why, or
+// nil if it was closed by the subscriber.
+type FeedSubscription interface {
+	Readings() <-chan *SensorData
+	Err() error
+	Close()
+}
+
+// StreamReplay holds the readings a resuming subscriber may have missed, in id order.
+// Truncated reports that there were more than could be replayed; the rest are only
+// available through queries.
+type StreamReplay struct {
+	Readings  []*SensorData
+	Truncated bool
+}
+
+// StreamUseCase defines the interface for live streams of sensor data
+type StreamUseCase interface {
+	Subscribe(filter *SensorDataFilter, resumeAfter int64) (*StreamReplay, FeedSubscription, error)
+}
+
+// DeleteUseCase defines the interface for deleting sensor data by filter and restoring
+// deleted sensor data
+type DeleteUseCase interface {
+	DeleteByFilter(filter *SensorDataFilter, opts DeleteOptions) (*DeleteResult, error)
+	GetJob(id string) *DeleteJob
+	ListDeletions() ([]*DeletionBatch, error)
+	Restore(batchID string, actor Actor) (int, error)
+}
+
+// AuditUseCase defines the interface for querying the audit log
+type AuditUseCase interface {
+	List(filter *AuditFilter) ([]*AuditEntry, error)
+}
+
+// SensorTypeUseCase defines the interface for sensor type business logic
+type SensorTypeUseCase interface {
+	Create(sensorType *SensorType) error
+	GetByID(id int) (*SensorType, error)
+	List() ([]*SensorType, error)
+	Update(id int, sensorType *SensorType) error
+	Delete(id int) error
+}
+
+// RetentionUseCase defines the interface for retention policy management and purging
+type RetentionUseCase interface {
+	List() ([]*RetentionPolicy, error)
+	Get(sensorType string) (*RetentionPolicy, error)
+	Save(policy *RetentionPolicy) error
+	Delete(sensorType string) error
+	Preview() (*RetentionReport, error)
+	Purge() (*RetentionReport, error)
+	LastReport() *RetentionReport
+}
+
+// ImportUseCase defines the interface for bulk imports of readings from files
+type ImportUseCase interface {
+	Start(format string, dryRun bool, src io.Reader) (*ImportJob, error)
+	Get(id string) *ImportJob
 }
 
 // AuthUseCase defines the interface for authentication business logic
 type AuthUseCase interface {
 	Authenticate(username, password string) (*User, error)
-	ValidateAPIKey(key string) (*User, error)
-	GenerateToken(user *User) (string, error)
+	GenerateTokens(user *User) (*AuthTokens, error)
+	Refresh(refreshToken string) (*AuthTokens, error)
 	ValidateToken(token string) (*User, error)
 }
+
+// UserUseCase defines the interface for user and role administration
+type UserUseCase interface {
+	Create(user *User, password string) error
+	GetByID(id int) (*User, error)
+	List() ([]*User, error)
+	Update(id int, update *UserUpdate) (*User, error)
+	SetDisabled(id int, disabled bool) (*User, error)
+	Delete(id int) error
+	AddRole(id int, role string) (*User, error)
+	RemoveRole(id int, role string) (*User, error)
+	ResetPassword(id int, password string) error
+}
+
+// APIKeyUseCase defines the interface for issuing, revoking and checking API keys.
+// List and Revoke are limited to the keys of userID, or every key if it is 0.
+type APIKeyUseCase interface {
+	Create(key *APIKey) (*APIKey, error)
+	List(userID int) ([]*APIKey, error)
+	Revoke(id, userID int) error
+	Validate(key string) (*APIKey, *User, error)
+}
@@ -0,0 +1,61 @@
+package domain
+
+import (
+	"encoding/base64"
+	"testing"
+)
+
+func TestPageCursorRoundTrip(t *testing.T) {
+	tests := []struct {
+		name   string
+		cursor PageCursor
+	}{
+		{"zero", PageCursor{}},
+		{"after", PageCursor{CreatedAt: 1735689600000, ID: 42}},
+		{"before", PageCursor{CreatedAt: 1735689600000, ID: 42, Before: true}},
+		{"large id", PageCursor{CreatedAt: 1, ID: 1<<62 + 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			encoded := tt.cursor.Encode()
+			if _, err := base64.RawURLEncoding.DecodeString(encoded); err != nil {
+				t.Fatalf("Encode() = %q, not URL-safe base64: %v", encoded, err)
+			}
+			decoded, err := DecodePageCursor(encoded)
+			if err != nil {
+				t.Fatalf("DecodePageCursor(%q): %v", encoded, err)
+			}
+			if *decoded != tt.cursor {
+				t.Errorf("DecodePageCursor(Encode()) = %+v, want %+v", *decoded, tt.cursor)
+			}
+		})
+	}
+}
+
+func TestPageCursorEncodeNil(t *testing.T) {
+	var cursor *PageCursor
+	if got := cursor.Encode(); got != "" {
+		t.Errorf("nil cursor Encode() = %q, want empty", got)
+	}
+}
+
+func TestDecodePageCursorInvalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+	}{
+		{"not base64", "not a cursor!"},
+		{"padded base64", base64.URLEncoding.EncodeToString([]byte(`{"id":1}`))},
+		{"not JSON", base64.RawURLEncoding.EncodeToString([]byte("created_at=1"))},
+		{"wrong types", base64.RawURLEncoding.EncodeToString([]byte(`{"created_at":"yesterday"}`))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if cursor, err := DecodePageCursor(tt.value); err == nil {
+				t.Errorf("DecodePageCursor(%q) = %+v, want an error", tt.value, cursor)
+			}
+		})
+	}
+}
@@ -0,0 +1,223 @@
+package export
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"sensor_project/microservice-b/internal/domain"
+)
+
+// Export formats
+const (
+	FormatCSV     = "csv"
+	FormatNDJSON  = "ndjson"
+	FormatParquet = "parquet"
+)
+
+// ContentTypes maps each export format to its MIME type
+var ContentTypes = map[string]string{
+	FormatCSV:     "text/csv; charset=utf-8",
+	FormatNDJSON:  "application/x-ndjson",
+	FormatParquet: "application/vnd.apache.parquet",
+}
+
+// columnKind is the type of an exported column's values
+type columnKind int
+
+const (
+	kindInt64 columnKind = iota
+	kindInt32
+	kindDouble
+	kindString
+	kindTimestamp // Unix milliseconds, written as RFC3339 in text formats
+)
+
+// Column is a sensor data field that can be exported
+type Column struct {
+	Name  string
+	kind  columnKind
+	value func(*domain.SensorData) interface{}
+}
+
+// Columns lists every exportable column in its default order
+var Columns = []Column{
+	{"id", kindInt64, func(d *domain.SensorData) interface{} { return d.ID }},
+	{"sensor_value", kindDouble, func(d *domain.SensorData) interface{} { return d.SensorValue }},
+	{"sensor_type", kindString, func(d *domain.SensorData) interface{} { return d.SensorType }},
+	{"id1", kindString, func(d *domain.SensorData) interface{} { return d.ID1 }},
+	{"id2", kindInt32, func(d *domain.SensorData) interface{} { return int32(d.ID2) }},
+	{"event_time", kindTimestamp, func(d *domain.SensorData) interface{} { return d.EventTime }},
+	{"created_at", kindTimestamp, func(d *domain.SensorData) interface{} { return d.CreatedAt }},
+	{"quality_flags", kindInt32, func(d *domain.SensorData) interface{} { return int32(d.QualityFlags) }},
+}
+
+// ParseColumns selects columns from a comma-separated list of names; an empty list
+// selects all columns
+func ParseColumns(value string) ([]Column, error) {
+	if value == "" {
+		return Columns, nil
+	}
+
+	var columns []Column
+	seen := make(map[string]bool)
+	for _, name := range strings.Split(value, ",") {
+		name = strings.TrimSpace(name)
+		if seen[name] {
+			return nil, fmt.Errorf("duplicate column %q", name)
+		}
+		seen[name] = true
+
+		found := false
+		for _, col := range Columns {
+			if col.Name == name {
+				columns = append(columns, col)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("invalid column %q", name)
+		}
+	}
+	return columns, nil
+}
+
+// Writer encodes sensor data records one at a time. Close writes any buffered records
+// and the format's trailer but does not close the underlying writer.
+type Writer interface {
+	Write(data *domain.SensorData) error
+	Close() error
+}
+
+// NewWriter creates a writer for the given format that writes the selected columns to w
+func NewWriter(format string, w io.Writer, columns []Column) (Writer, error) {
+	switch format {
+	case FormatCSV:
+		return newCSVWriter(w, columns), nil
+	case FormatNDJSON:
+		return newNDJSONWriter(w, columns), nil
+	case FormatParquet:
+		return newParquetWriter(w, columns), nil
+	default:
+		return nil, fmt.Errorf("invalid format %q, expected %s, %s or %s", format, FormatCSV, FormatNDJSON, FormatParquet)
+	}
+}
+
+// csvWriter writes a header row followed by one row per record
+type csvWriter struct {
+	w       *csv.Writer
+	columns []Column
+	header  bool
+	record  []string
+}
+
+func newCSVWriter(w io.Writer, columns []Column) *csvWriter {
+	return &csvWriter{
+		w:       csv.NewWriter(w),
+		columns: columns,
+		record:  make([]string, len(columns)),
+	}
+}
+
+// Write writes a record, preceded by the header row on the first call
+func (c *csvWriter) Write(data *domain.SensorData) error {
+	if !c.header {
+		if err := c.writeHeader(); err != nil {
+			return err
+		}
+	}
+
+	for i, col := range c.columns {
+		c.record[i] = formatText(col, data)
+	}
+	return c.w.Write(c.record)
+}
+
+// Close writes the header row if no record was written and flushes buffered rows
+func (c *csvWriter) Close() error {
+	if !c.header {
+		if err := c.writeHeader(); err != nil {
+			return err
+		}
+	}
+
+	c.w.Flush()
+	return c.w.Error()
+}
+
+func (c *csvWriter) writeHeader() error {
+	for i, col := range c.columns {
+		c.record[i] = col.Name
+	}
+	c.header = true
+	return c.w.Write(c.record)
+}
+
+// ndjsonWriter writes one JSON object per line with keys in column order
+type ndjsonWriter struct {
+	w       *bufio.Writer
+	columns []Column
+}
+
+func newNDJSONWriter(w io.Writer, columns []Column) *ndjsonWriter {
+	return &ndjsonWriter{
+		w:       bufio.NewWriter(w),
+		columns: columns,
+	}
+}
+
+// Write writes a record as a single line
+func (n *ndjsonWriter) Write(data *domain.SensorData) error {
+	n.w.WriteByte('{')
+	for i, col := range n.columns {
+		if i > 0 {
+			n.w.WriteByte(',')
+		}
+		n.w.WriteString(strconv.Quote(col.Name))
+		n.w.WriteByte(':')
+
+		value := formatText(col, data)
+		switch col.kind {
+		case kindString, kindTimestamp:
+			quoted, err := json.Marshal(value)
+			if err != nil {
+				return err
+			}
+			n.w.Write(quoted)
+		default:
+			n.w.WriteString(value)
+		}
+	}
+	_, err := n.w.WriteString("}\n")
+	return err
+}
+
+// Close flushes buffered lines
+func (n *ndjsonWriter) Close() error {
+	return n.w.Flush()
+}
+
+// formatText formats a column of a record for the text formats
+func formatText(col Column, data *domain.SensorData) string {
+	switch v := col.value(data).(type) {
+	case int64:
+		if col.kind == kindTimestamp {
+			return time.UnixMilli(v).UTC().Format(time.RFC3339Nano)
+		}
+		return strconv.FormatInt(v, 10)
+	case int32:
+		return strconv.FormatInt(int64(v), 10)
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	case string:
+		return v
+	default:
+		return fmt.Sprint(v)
+	}
+}
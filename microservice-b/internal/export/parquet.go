@@ -0,0 +1,325 @@
+package export
+
+import (
+	"encoding/binary"
+	"io"
+	"math"
+
+	"sensor_project/microservice-b/internal/domain"
+)
+
+// parquetRowGroupRows is the number of records buffered before a row group is written,
+// bounding the writer's memory regardless of the export size
+const parquetRowGroupRows = 64 * 1024
+
+// parquetMagic starts and ends every Parquet file
+const parquetMagic = "PAR1"
+
+// Parquet physical types, converted types and enums used by the writer
+const (
+	parquetInt32     = 1
+	parquetInt64     = 2
+	parquetDouble    = 5
+	parquetByteArray = 6
+
+	parquetUTF8            = 0
+	parquetTimestampMillis = 9
+
+	parquetRequired     = 0
+	parquetPlain        = 0
+	parquetRLE          = 3
+	parquetDataPage     = 0
+	parquetUncompressed = 0
+)
+
+// parquetChunk locates a column chunk written to the file
+type parquetChunk struct {
+	offset int64
+	size   int64
+	values int64
+}
+
+// parquetRowGroup describes a written row group for the file footer
+type parquetRowGroup struct {
+	rows   int64
+	chunks []parquetChunk
+}
+
+// parquetWriter writes records as a Parquet file with one required, PLAIN-encoded and
+// uncompressed column per selected field. Records are buffered per column and written
+// as a row group of a single data page per column every parquetRowGroupRows records;
+// only the row group locations are kept until Close writes the footer.
+type parquetWriter struct {
+	w       io.Writer
+	columns []Column
+	pages   [][]byte
+	rows    int64
+	offset  int64
+	groups  []parquetRowGroup
+	err     error
+}
+
+func newParquetWriter(w io.Writer, columns []Column) *parquetWriter {
+	return &parquetWriter{
+		w:       w,
+		columns: columns,
+		pages:   make([][]byte, len(columns)),
+	}
+}
+
+// Write buffers a record, writing a row group once enough records are buffered
+func (p *parquetWriter) Write(data *domain.SensorData) error {
+	for i, col := range p.columns {
+		page := p.pages[i]
+		switch v := col.value(data).(type) {
+		case int64:
+			page = binary.LittleEndian.AppendUint64(page, uint64(v))
+		case int32:
+			page = binary.LittleEndian.AppendUint32(page, uint32(v))
+		case float64:
+			page = binary.LittleEndian.AppendUint64(page, math.Float64bits(v))
+		case string:
+			page = binary.LittleEndian.AppendUint32(page, uint32(len(v)))
+			page = append(page, v...)
+		}
+		p.pages[i] = page
+	}
+
+	p.rows++
+	if p.rows >= parquetRowGroupRows {
+		return p.flushRowGroup()
+	}
+	return p.err
+}
+
+// Close writes the buffered records and the file footer
+func (p *parquetWriter) Close() error {
+	if p.rows > 0 {
+		if err := p.flushRowGroup(); err != nil {
+			return err
+		}
+	}
+	if p.offset == 0 {
+		p.write([]byte(parquetMagic))
+	}
+
+	footer := p.fileMetaData()
+	p.write(footer)
+	p.write(binary.LittleEndian.AppendUint32(nil, uint32(len(footer))))
+	p.write([]byte(parquetMagic))
+	return p.err
+}
+
+// flushRowGroup writes the buffered records as a row group
+func (p *parquetWriter) flushRowGroup() error {
+	if p.offset == 0 {
+		p.write([]byte(parquetMagic))
+	}
+
+	group := parquetRowGroup{rows: p.rows}
+	for i, page := range p.pages {
+		var t thriftWriter
+		t.writeI32(1, parquetDataPage)
+		t.writeI32(2, int32(len(page)))
+		t.writeI32(3, int32(len(page)))
+		t.beginStructField(5)
+		t.writeI32(1, int32(p.rows))
+		t.writeI32(2, parquetPlain)
+		t.writeI32(3, parquetRLE)
+		t.writeI32(4, parquetRLE)
+		t.endStruct()
+		t.endStruct()
+
+		chunk := parquetChunk{offset: p.offset, values: p.rows}
+		p.write(t.buf)
+		p.write(page)
+		chunk.size = p.offset - chunk.offset
+		group.chunks = append(group.chunks, chunk)
+
+		p.pages[i] = page[:0]
+	}
+
+	p.groups = append(p.groups, group)
+	p.rows = 0
+	return p.err
+}
+
+// fileMetaData encodes the file footer describing the schema and every row group
+func (p *parquetWriter) fileMetaData() []byte {
+	var t thriftWriter
+	var total int64
+	for _, group := range p.groups {
+		total += group.rows
+	}
+
+	t.writeI32(1, 1)
+	t.beginList(2, thriftStruct, len(p.columns)+1)
+	t.beginStruct()
+	t.writeString(4, "schema")
+	t.writeI32(5, int32(len(p.columns)))
+	t.endStruct()
+	for _, col := range p.columns {
+		physical, converted := parquetTypes(col.kind)
+		t.beginStruct()
+		t.writeI32(1, physical)
+		t.writeI32(3, parquetRequired)
+		t.writeString(4, col.Name)
+		if converted >= 0 {
+			t.writeI32(6, converted)
+		}
+		t.endStruct()
+	}
+	t.writeI64(3, total)
+
+	t.beginList(4, thriftStruct, len(p.groups))
+	for _, group := range p.groups {
+		var size int64
+		t.beginStruct()
+		t.beginList(1, thriftStruct, len(group.chunks))
+		for i, chunk := range group.chunks {
+			physical, _ := parquetTypes(p.columns[i].kind)
+			size += chunk.size
+			t.beginStruct()
+			t.writeI64(2, chunk.offset)
+			t.beginStructField(3)
+			t.writeI32(1, physical)
+			t.beginList(2, thriftI32, 2)
+			t.appendI32(parquetPlain)
+			t.appendI32(parquetRLE)
+			t.beginList(3, thriftBinary, 1)
+			t.appendString(p.columns[i].Name)
+			t.writeI32(4, parquetUncompressed)
+			t.writeI64(5, chunk.values)
+			t.writeI64(6, chunk.size)
+			t.writeI64(7, chunk.size)
+			t.writeI64(9, chunk.offset)
+			t.endStruct()
+			t.endStruct()
+		}
+		t.writeI64(2, size)
+		t.writeI64(3, group.rows)
+		t.endStruct()
+	}
+	t.writeString(6, "sensor_project microservice-b")
+	t.endStruct()
+
+	return t.buf
+}
+
+// write writes b to the underlying writer unless an earlier write failed
+func (p *parquetWriter) write(b []byte) {
+	if p.err != nil {
+		return
+	}
+	n, err := p.w.Write(b)
+	p.offset += int64(n)
+	p.err = err
+}
+
+// parquetTypes returns the physical and converted type of a column kind; a negative
+// converted type means none
+func parquetTypes(kind columnKind) (int32, int32) {
+	switch kind {
+	case kindInt32:
+		return parquetInt32, -1
+	case kindDouble:
+		return parquetDouble, -1
+	case kindString:
+		return parquetByteArray, parquetUTF8
+	case kindTimestamp:
+		return parquetInt64, parquetTimestampMillis
+	default:
+		return parquetInt64, -1
+	}
+}
+
+// Thrift compact protocol type ids
+const (
+	thriftI32    = 5
+	thriftI64    = 6
+	thriftBinary = 8
+	thriftList   = 9
+	thriftStruct = 12
+)
+
+// thriftWriter encodes the Parquet metadata structures with the Thrift compact protocol.
+// The writer starts inside the top-level struct, which endStruct closes.
+type thriftWriter struct {
+	buf    []byte
+	last   int16
+	parent []int16
+}
+
+// field writes a field header, as a delta from the previous field id where possible
+func (t *thriftWriter) field(id int16, typ byte) {
+	if delta := id - t.last; delta > 0 && delta <= 15 {
+		t.buf = append(t.buf, byte(delta)<<4|typ)
+	} else {
+		t.buf = append(t.buf, typ)
+		t.buf = binary.AppendUvarint(t.buf, zigzag(int64(id)))
+	}
+	t.last = id
+}
+
+func (t *thriftWriter) writeI32(id int16, v int32) {
+	t.field(id, thriftI32)
+	t.appendI32(v)
+}
+
+func (t *thriftWriter) writeI64(id int16, v int64) {
+	t.field(id, thriftI64)
+	t.buf = binary.AppendUvarint(t.buf, zigzag(v))
+}
+
+func (t *thriftWriter) writeString(id int16, s string) {
+	t.field(id, thriftBinary)
+	t.appendString(s)
+}
+
+// appendI32 writes an i32 list element
+func (t *thriftWriter) appendI32(v int32) {
+	t.buf = binary.AppendUvarint(t.buf, zigzag(int64(v)))
+}
+
+// appendString writes a binary list element
+func (t *thriftWriter) appendString(s string) {
+	t.buf = binary.AppendUvarint(t.buf, uint64(len(s)))
+	t.buf = append(t.buf, s...)
+}
+
+// beginList writes the header of a list field with n elements of the given type
+func (t *thriftWriter) beginList(id int16, elem byte, n int) {
+	t.field(id, thriftList)
+	if n < 15 {
+		t.buf = append(t.buf, byte(n)<<4|elem)
+		return
+	}
+	t.buf = append(t.buf, 0xf0|elem)
+	t.buf = binary.AppendUvarint(t.buf, uint64(n))
+}
+
+// beginStructField starts a struct-valued field
+func (t *thriftWriter) beginStructField(id int16) {
+	t.field(id, thriftStruct)
+	t.beginStruct()
+}
+
+// beginStruct starts a struct, either a field value or a list element
+func (t *thriftWriter) beginStruct() {
+	t.parent = append(t.parent, t.last)
+	t.last = 0
+}
+
+// endStruct closes the current struct
+func (t *thriftWriter) endStruct() {
+	t.buf = append(t.buf, 0)
+	if n := len(t.parent); n > 0 {
+		t.last = t.parent[n-1]
+		t.parent = t.parent[:n-1]
+	}
+}
+
+// zigzag maps signed integers to unsigned ones so small magnitudes encode compactly
+func zigzag(v int64) uint64 {
+	return uint64(v<<1) ^ uint64(v>>63)
+}
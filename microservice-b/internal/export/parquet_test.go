@@ -0,0 +1,344 @@
+package export
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"reflect"
+	"testing"
+
+	"sensor_project/microservice-b/internal/domain"
+)
+
+// thriftReader decodes the Thrift compact protocol into maps of field id to value for
+// structs, slices for lists, int64 for integers and strings for binaries
+type thriftReader struct {
+	t   *testing.T
+	buf []byte
+	pos int
+}
+
+func (r *thriftReader) byte() byte {
+	if r.pos >= len(r.buf) {
+		r.t.Fatalf("thrift: read past the end at %d", r.pos)
+	}
+	b := r.buf[r.pos]
+	r.pos++
+	return b
+}
+
+func (r *thriftReader) uvarint() uint64 {
+	v, n := binary.Uvarint(r.buf[r.pos:])
+	if n <= 0 {
+		r.t.Fatalf("thrift: invalid varint at %d", r.pos)
+	}
+	r.pos += n
+	return v
+}
+
+func (r *thriftReader) readStruct() map[int16]interface{} {
+	fields := make(map[int16]interface{})
+	var last int16
+	for {
+		header := r.byte()
+		if header == 0 {
+			return fields
+		}
+		id := last + int16(header>>4)
+		if header>>4 == 0 {
+			id = int16(unzigzag(r.uvarint()))
+		}
+		if _, ok := fields[id]; ok {
+			r.t.Fatalf("thrift: field %d repeated", id)
+		}
+		fields[id] = r.readValue(header & 0x0f)
+		last = id
+	}
+}
+
+func (r *thriftReader) readValue(typ byte) interface{} {
+	switch typ {
+	case thriftI32, thriftI64:
+		return unzigzag(r.uvarint())
+	case thriftBinary:
+		n := int(r.uvarint())
+		if r.pos+n > len(r.buf) {
+			r.t.Fatalf("thrift: binary of %d bytes past the end at %d", n, r.pos)
+		}
+		s := string(r.buf[r.pos : r.pos+n])
+		r.pos += n
+		return s
+	case thriftList:
+		header := r.byte()
+		n := int(header >> 4)
+		if n == 15 {
+			n = int(r.uvarint())
+		}
+		list := make([]interface{}, n)
+		for i := range list {
+			list[i] = r.readValue(header & 0x0f)
+		}
+		return list
+	case thriftStruct:
+		return r.readStruct()
+	default:
+		r.t.Fatalf("thrift: unexpected type %d at %d", typ, r.pos)
+		return nil
+	}
+}
+
+func unzigzag(v uint64) int64 {
+	return int64(v>>1) ^ -int64(v&1)
+}
+
+// field returns a field of a decoded struct, failing the test if it is missing or of
+// another type
+func field[T any](t *testing.T, s interface{}, id int16) T {
+	t.Helper()
+	fields, ok := s.(map[int16]interface{})
+	if !ok {
+		t.Fatalf("%v is not a struct", s)
+	}
+	v, ok := fields[id].(T)
+	if !ok {
+		t.Fatalf("field %d = %#v, want a %T", id, fields[id], *new(T))
+	}
+	return v
+}
+
+// readPlain decodes n PLAIN-encoded values of a column kind
+func readPlain(t *testing.T, page []byte, kind columnKind, n int) []interface{} {
+	t.Helper()
+	values := make([]interface{}, n)
+	for i := range values {
+		switch kind {
+		case kindInt32:
+			values[i] = int32(binary.LittleEndian.Uint32(page))
+			page = page[4:]
+		case kindDouble:
+			values[i] = math.Float64frombits(binary.LittleEndian.Uint64(page))
+			page = page[8:]
+		case kindString:
+			size := binary.LittleEndian.Uint32(page)
+			values[i] = string(page[4 : 4+size])
+			page = page[4+size:]
+		default:
+			values[i] = int64(binary.LittleEndian.Uint64(page))
+			page = page[8:]
+		}
+	}
+	if len(page) != 0 {
+		t.Fatalf("%d bytes left after %d values", len(page), n)
+	}
+	return values
+}
+
+// sampleRecords returns n records with values of both signs and varied string lengths
+func sampleRecords(n int) []*domain.SensorData {
+	types := []string{"temperature", "humidity", ""}
+	records := make([]*domain.SensorData, n)
+	for i := range records {
+		records[i] = &domain.SensorData{
+			ID:           int64(i) + 1<<40,
+			SensorValue:  float64(i)*0.25 - 1000,
+			SensorType:   types[i%len(types)],
+			ID1:          fmt.Sprintf("dev-%d", i%1000),
+			ID2:          i%7 - 3,
+			EventTime:    1735689600000 + int64(i),
+			CreatedAt:    1735689600000 - int64(i),
+			QualityFlags: i % 16,
+		}
+	}
+	return records
+}
+
+func TestParquetWriter(t *testing.T) {
+	subset, err := ParseColumns("sensor_value,id1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		columns   []Column
+		records   int
+		wantGroup []int64
+	}{
+		{"every column over several row groups", Columns, 2*parquetRowGroupRows + 100, []int64{parquetRowGroupRows, parquetRowGroupRows, 100}},
+		{"selected columns", subset, 3, []int64{3}},
+		{"no records", Columns, 0, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			records := sampleRecords(tt.records)
+			var buf bytes.Buffer
+			w := newParquetWriter(&buf, tt.columns)
+			for _, data := range records {
+				if err := w.Write(data); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := w.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			file := buf.Bytes()
+			if !bytes.HasPrefix(file, []byte(parquetMagic)) || !bytes.HasSuffix(file, []byte(parquetMagic)) {
+				t.Fatal("file does not start and end with the magic")
+			}
+			footerStart := len(file) - 8 - int(binary.LittleEndian.Uint32(file[len(file)-8:]))
+			r := &thriftReader{t: t, buf: file[footerStart : len(file)-8]}
+			meta := r.readStruct()
+			if r.pos != len(r.buf) {
+				t.Fatalf("footer has %d bytes after the metadata", len(r.buf)-r.pos)
+			}
+
+			// FileMetaData: version, schema, num_rows and row_groups
+			if v := field[int64](t, meta, 1); v != 1 {
+				t.Errorf("version = %d, want 1", v)
+			}
+			if rows := field[int64](t, meta, 3); rows != int64(tt.records) {
+				t.Errorf("num_rows = %d, want %d", rows, tt.records)
+			}
+			schema := field[[]interface{}](t, meta, 2)
+			if len(schema) != len(tt.columns)+1 {
+				t.Fatalf("schema has %d elements, want %d", len(schema), len(tt.columns)+1)
+			}
+			if children := field[int64](t, schema[0], 5); children != int64(len(tt.columns)) {
+				t.Errorf("root num_children = %d, want %d", children, len(tt.columns))
+			}
+			for i, col := range tt.columns {
+				physical, converted := parquetTypes(col.kind)
+				element := schema[i+1]
+				if name := field[string](t, element, 4); name != col.Name {
+					t.Errorf("schema element %d name = %q, want %q", i, name, col.Name)
+				}
+				if typ := field[int64](t, element, 1); typ != int64(physical) {
+					t.Errorf("column %s type = %d, want %d", col.Name, typ, physical)
+				}
+				if repetition := field[int64](t, element, 3); repetition != parquetRequired {
+					t.Errorf("column %s repetition = %d, want required", col.Name, repetition)
+				}
+				got, ok := element.(map[int16]interface{})[6]
+				if converted < 0 && ok || converted >= 0 && got != int64(converted) {
+					t.Errorf("column %s converted type = %v, want %d", col.Name, got, converted)
+				}
+			}
+
+			// Column chunks follow each other from the magic to the footer, each a data
+			// page header followed by the PLAIN-encoded values of its records
+			groups := field[[]interface{}](t, meta, 4)
+			if len(groups) != len(tt.wantGroup) {
+				t.Fatalf("%d row groups, want %d", len(groups), len(tt.wantGroup))
+			}
+			offset := int64(len(parquetMagic))
+			first := 0
+			for g, group := range groups {
+				rows := field[int64](t, group, 3)
+				if rows != tt.wantGroup[g] {
+					t.Errorf("row group %d has %d rows, want %d", g, rows, tt.wantGroup[g])
+				}
+				chunks := field[[]interface{}](t, group, 1)
+				if len(chunks) != len(tt.columns) {
+					t.Fatalf("row group %d has %d chunks, want %d", g, len(chunks), len(tt.columns))
+				}
+
+				groupStart := offset
+				for i, chunk := range chunks {
+					col := tt.columns[i]
+					physical, _ := parquetTypes(col.kind)
+					cm := field[map[int16]interface{}](t, chunk, 3)
+					size := field[int64](t, cm, 7)
+					if o, page := field[int64](t, chunk, 2), field[int64](t, cm, 9); o != offset || page != offset {
+						t.Fatalf("chunk %s of row group %d at %d with its page at %d, want %d", col.Name, g, o, page, offset)
+					}
+					if typ := field[int64](t, cm, 1); typ != int64(physical) {
+						t.Errorf("chunk %s type = %d, want %d", col.Name, typ, physical)
+					}
+					if path := field[[]interface{}](t, cm, 3); !reflect.DeepEqual(path, []interface{}{col.Name}) {
+						t.Errorf("chunk %s path = %v", col.Name, path)
+					}
+					if codec := field[int64](t, cm, 4); codec != parquetUncompressed {
+						t.Errorf("chunk %s codec = %d, want uncompressed", col.Name, codec)
+					}
+					if values := field[int64](t, cm, 5); values != rows {
+						t.Errorf("chunk %s has %d values, want %d", col.Name, values, rows)
+					}
+					if uncompressed := field[int64](t, cm, 6); uncompressed != size {
+						t.Errorf("chunk %s uncompressed size %d, compressed %d", col.Name, uncompressed, size)
+					}
+
+					chunkBytes := file[offset : offset+size]
+					pr := &thriftReader{t: t, buf: chunkBytes}
+					page := pr.readStruct()
+					pageSize := int64(len(chunkBytes) - pr.pos)
+					if typ := field[int64](t, page, 1); typ != parquetDataPage {
+						t.Errorf("chunk %s page type = %d, want a data page", col.Name, typ)
+					}
+					if field[int64](t, page, 2) != pageSize || field[int64](t, page, 3) != pageSize {
+						t.Errorf("chunk %s page sizes %d/%d, want %d", col.Name, field[int64](t, page, 2), field[int64](t, page, 3), pageSize)
+					}
+					dataPage := field[map[int16]interface{}](t, page, 5)
+					if n := field[int64](t, dataPage, 1); n != rows {
+						t.Errorf("chunk %s page has %d values, want %d", col.Name, n, rows)
+					}
+					if encoding := field[int64](t, dataPage, 2); encoding != parquetPlain {
+						t.Errorf("chunk %s page encoding = %d, want PLAIN", col.Name, encoding)
+					}
+
+					values := readPlain(t, chunkBytes[pr.pos:], col.kind, int(rows))
+					for j, v := range values {
+						if want := col.value(records[first+j]); v != want {
+							t.Fatalf("row %d column %s = %v, want %v", first+j, col.Name, v, want)
+						}
+					}
+					offset += size
+				}
+				if total := field[int64](t, group, 2); total != offset-groupStart {
+					t.Errorf("row group %d total size = %d, want %d", g, total, offset-groupStart)
+				}
+				first += int(rows)
+			}
+			if offset != int64(footerStart) {
+				t.Errorf("footer starts at %d, want %d after the last chunk", footerStart, offset)
+			}
+		})
+	}
+}
+
+func TestThriftWriterLongForms(t *testing.T) {
+	var w thriftWriter
+	w.writeI32(1, -7)
+	w.writeI64(40, math.MinInt64)  // field id delta above 15
+	w.beginList(41, thriftI32, 20) // 15 or more elements
+	for i := 0; i < 20; i++ {
+		w.appendI32(int32(i * -1000))
+	}
+	w.beginStructField(42)
+	w.writeString(3, "nested")
+	w.endStruct()
+	w.writeString(2, "back") // field id below the previous one
+	w.endStruct()
+
+	r := &thriftReader{t: t, buf: w.buf}
+	got := r.readStruct()
+	list := make([]interface{}, 20)
+	for i := range list {
+		list[i] = int64(i * -1000)
+	}
+	want := map[int16]interface{}{
+		1:  int64(-7),
+		40: int64(math.MinInt64),
+		41: list,
+		42: map[int16]interface{}{3: "nested"},
+		2:  "back",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("decoded %v, want %v", got, want)
+	}
+	if r.pos != len(w.buf) {
+		t.Errorf("%d bytes left after the struct", len(w.buf)-r.pos)
+	}
+}
@@ -0,0 +1,218 @@
+package export
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"sensor_project/microservice-b/internal/domain"
+)
+
+// maxNDJSONLine bounds the length of one NDJSON line
+const maxNDJSONLine = 1024 * 1024
+
+// importColumns are the columns a file must provide for its rows to be imported; other
+// exported columns are ignored so an export can be imported again
+var importColumns = []string{"sensor_value", "sensor_type", "id1", "id2", "event_time"}
+
+// RowError reports a row of an import file that could not be parsed. Reading can
+// continue with the next row.
+type RowError struct {
+	Line int64
+	Err  error
+}
+
+func (e *RowError) Error() string {
+	return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+}
+
+func (e *RowError) Unwrap() error {
+	return e.Err
+}
+
+// Reader decodes sensor readings from an import file one row at a time. Read returns
+// io.EOF after the last row and a *RowError for a row that cannot be parsed; any other
+// error ends the file. Line reports the line the last row read starts on.
+type Reader interface {
+	Read() (*domain.SensorData, error)
+	Line() int64
+}
+
+// NewReader creates a reader for an import file in the given format
+func NewReader(format string, r io.Reader) (Reader, error) {
+	switch format {
+	case FormatCSV:
+		return newCSVReader(r), nil
+	case FormatNDJSON:
+		return newNDJSONReader(r), nil
+	default:
+		return nil, fmt.Errorf("invalid format %q, expected %s or %s", format, FormatCSV, FormatNDJSON)
+	}
+}
+
+// csvReader reads rows of a CSV file whose first row names the columns
+type csvReader struct {
+	r       *csv.Reader
+	columns map[string]int
+	line    int64
+}
+
+func newCSVReader(r io.Reader) *csvReader {
+	reader := csv.NewReader(r)
+	reader.ReuseRecord = true
+	return &csvReader{r: reader}
+}
+
+// Read parses the next row, reading the header row first
+func (c *csvReader) Read() (*domain.SensorData, error) {
+	if c.columns == nil {
+		if err := c.readHeader(); err != nil {
+			return nil, err
+		}
+	}
+
+	record, err := c.r.Read()
+	if err != nil {
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			c.line = int64(parseErr.StartLine)
+			return nil, &RowError{Line: c.line, Err: parseErr.Err}
+		}
+		return nil, err
+	}
+	line, _ := c.r.FieldPos(0)
+	c.line = int64(line)
+
+	field := func(name string) string {
+		return strings.TrimSpace(record[c.columns[name]])
+	}
+
+	data := &domain.SensorData{
+		SensorType: field("sensor_type"),
+		ID1:        field("id1"),
+	}
+	if data.SensorValue, err = strconv.ParseFloat(field("sensor_value"), 64); err != nil {
+		return nil, &RowError{Line: c.line, Err: fmt.Errorf("invalid sensor_value %q", field("sensor_value"))}
+	}
+	if data.ID2, err = strconv.Atoi(field("id2")); err != nil {
+		return nil, &RowError{Line: c.line, Err: fmt.Errorf("invalid id2 %q", field("id2"))}
+	}
+	if data.EventTime, err = parseEventTime(field("event_time")); err != nil {
+		return nil, &RowError{Line: c.line, Err: err}
+	}
+	return data, nil
+}
+
+// Line returns the line the last row read starts on
+func (c *csvReader) Line() int64 {
+	return c.line
+}
+
+// readHeader maps the required columns to their positions in the header row
+func (c *csvReader) readHeader() error {
+	header, err := c.r.Read()
+	if err == io.EOF {
+		return errors.New("missing header row")
+	}
+	if err != nil {
+		return err
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.TrimSpace(name)] = i
+	}
+	for _, name := range importColumns {
+		if _, ok := columns[name]; !ok {
+			return fmt.Errorf("missing column %q", name)
+		}
+	}
+	c.columns = columns
+	return nil
+}
+
+// ndjsonRecord is one line of an NDJSON import file
+type ndjsonRecord struct {
+	SensorValue *float64        `json:"sensor_value"`
+	SensorType  string          `json:"sensor_type"`
+	ID1         string          `json:"id1"`
+	ID2         int             `json:"id2"`
+	EventTime   json.RawMessage `json:"event_time"`
+}
+
+// ndjsonReader reads one JSON object per line, skipping blank lines
+type ndjsonReader struct {
+	s    *bufio.Scanner
+	line int64
+}
+
+func newNDJSONReader(r io.Reader) *ndjsonReader {
+	s := bufio.NewScanner(r)
+	s.Buffer(make([]byte, 64*1024), maxNDJSONLine)
+	return &ndjsonReader{s: s}
+}
+
+// Read parses the next non-blank line
+func (n *ndjsonReader) Read() (*domain.SensorData, error) {
+	for n.s.Scan() {
+		n.line++
+		line := strings.TrimSpace(n.s.Text())
+		if line == "" {
+			continue
+		}
+
+		var record ndjsonRecord
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			return nil, &RowError{Line: n.line, Err: err}
+		}
+		if record.SensorValue == nil {
+			return nil, &RowError{Line: n.line, Err: errors.New("missing sensor_value")}
+		}
+
+		data := &domain.SensorData{
+			SensorValue: *record.SensorValue,
+			SensorType:  record.SensorType,
+			ID1:         record.ID1,
+			ID2:         record.ID2,
+		}
+
+		// Event times may be RFC3339 strings, as exported, or Unix milliseconds
+		eventTime := strings.Trim(string(record.EventTime), `"`)
+		var err error
+		if data.EventTime, err = parseEventTime(eventTime); err != nil {
+			return nil, &RowError{Line: n.line, Err: err}
+		}
+		return data, nil
+	}
+
+	if err := n.s.Err(); err != nil {
+		return nil, err
+	}
+	return nil, io.EOF
+}
+
+// Line returns the line the last row read is on
+func (n *ndjsonReader) Line() int64 {
+	return n.line
+}
+
+// parseEventTime parses an RFC3339 time or a number of Unix milliseconds
+func parseEventTime(value string) (int64, error) {
+	if value == "" || value == "null" {
+		return 0, errors.New("missing event_time")
+	}
+	if ms, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return ms, nil
+	}
+	t, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid event_time %q", value)
+	}
+	return t.UnixMilli(), nil
+}
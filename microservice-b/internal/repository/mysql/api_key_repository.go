@@ -0,0 +1,195 @@
+package mysql
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"sensor_project/microservice-b/internal/domain"
+)
+
+// apiKeyColumns lists the api_keys columns in the order scanAPIKey reads them
+const apiKeyColumns = `id, user_id, name, key_prefix, sensor_types, devices, expires_at, revoked_at, created_at`
+
+// MySQLAPIKeyRepository implements the APIKeyRepository interface
+type MySQLAPIKeyRepository struct {
+	db *sql.DB
+}
+
+// NewMySQLAPIKeyRepository creates a new MySQL API key repository
+func NewMySQLAPIKeyRepository(db *sql.DB) domain.APIKeyRepository {
+	return &MySQLAPIKeyRepository{
+		db: db,
+	}
+}
+
+// Create saves a new API key under the hash of its secret and sets its ID
+func (r *MySQLAPIKeyRepository) Create(key *domain.APIKey, hash string) error {
+	sensorTypes, err := encodeScope(key.SensorTypes)
+	if err != nil {
+		return err
+	}
+	devices, err := encodeScope(key.Devices)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO api_keys (user_id, name, key_prefix, key_hash, sensor_types, devices, expires_at, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	result, err := r.db.Exec(
+		query,
+		key.UserID,
+		key.Name,
+		key.Prefix,
+		hash,
+		sensorTypes,
+		devices,
+		nullableMillis(key.ExpiresAt),
+		key.CreatedAt.UnixMilli(),
+	)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	key.ID = int(id)
+	return nil
+}
+
+// GetByID retrieves an API key by ID
+func (r *MySQLAPIKeyRepository) GetByID(id int) (*domain.APIKey, error) {
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys WHERE id = ?`
+	return r.getOne(query, id)
+}
+
+// GetByHash retrieves an API key by the hash of its secret
+func (r *MySQLAPIKeyRepository) GetByHash(hash string) (*domain.APIKey, error) {
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys WHERE key_hash = ?`
+	return r.getOne(query, hash)
+}
+
+// List retrieves the API keys of a user, or of every user if userID is 0, newest first
+func (r *MySQLAPIKeyRepository) List(userID int) ([]*domain.APIKey, error) {
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys`
+	var args []interface{}
+	if userID != 0 {
+		query += ` WHERE user_id = ?`
+		args = append(args, userID)
+	}
+	query += ` ORDER BY created_at DESC, id DESC`
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := []*domain.APIKey{}
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
+
+// Revoke marks an API key revoked at the given time. Revoking a key again keeps the
+// original time.
+func (r *MySQLAPIKeyRepository) Revoke(id int, at time.Time) error {
+	query := `UPDATE api_keys SET revoked_at = COALESCE(revoked_at, ?) WHERE id = ?`
+	_, err := r.db.Exec(query, at.UnixMilli(), id)
+	return err
+}
+
+// getOne runs a query selecting at most one API key, returning nil if none matched
+func (r *MySQLAPIKeyRepository) getOne(query string, args ...interface{}) (*domain.APIKey, error) {
+	key, err := scanAPIKey(r.db.QueryRow(query, args...))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return key, err
+}
+
+// scanAPIKey reads an API key from a result row
+func scanAPIKey(row rowScanner) (*domain.APIKey, error) {
+	var key domain.APIKey
+	var sensorTypes, devices []byte
+	var expiresAt, revokedAt sql.NullInt64
+	var createdAt int64
+
+	err := row.Scan(
+		&key.ID,
+		&key.UserID,
+		&key.Name,
+		&key.Prefix,
+		&sensorTypes,
+		&devices,
+		&expiresAt,
+		&revokedAt,
+		&createdAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if key.SensorTypes, err = decodeScope(sensorTypes); err != nil {
+		return nil, err
+	}
+	if key.Devices, err = decodeScope(devices); err != nil {
+		return nil, err
+	}
+	key.ExpiresAt = timeFromNullMillis(expiresAt)
+	key.RevokedAt = timeFromNullMillis(revokedAt)
+	key.CreatedAt = time.UnixMilli(createdAt)
+	return &key, nil
+}
+
+// encodeScope encodes a scope list as a JSON array, or NULL if it is empty
+func encodeScope(values []string) (interface{}, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+	b, err := json.Marshal(values)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+// decodeScope decodes a scope list stored by encodeScope
+func decodeScope(b []byte) ([]string, error) {
+	if b == nil {
+		return nil, nil
+	}
+	var values []string
+	if err := json.Unmarshal(b, &values); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// nullableMillis converts an optional time to Unix milliseconds or NULL
+func nullableMillis(t *time.Time) interface{} {
+	if t == nil {
+		return nil
+	}
+	return t.UnixMilli()
+}
+
+// timeFromNullMillis converts nullable Unix milliseconds to an optional time
+func timeFromNullMillis(ms sql.NullInt64) *time.Time {
+	if !ms.Valid {
+		return nil
+	}
+	t := time.UnixMilli(ms.Int64)
+	return &t
+}
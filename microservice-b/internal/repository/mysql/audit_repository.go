@@ -0,0 +1,197 @@
+package mysql
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"sensor_project/microservice-b/internal/domain"
+)
+
+// auditReadingJSON builds the JSON of a reading of sensor_data sd joined to
+// sensor_types st, keyed like domain.SensorData
+const auditReadingJSON = `JSON_OBJECT(
+	'id', sd.id, 'reading_id', sd.reading_id, 'sensor_value', sd.sensor_value, 'sensor_type', st.name, 'id1', sd.id1,
+	'id2', sd.id2, 'event_time', sd.event_time, 'created_at', sd.created_at,
+	'quality_flags', sd.quality_flags, 'deleted_at', sd.deleted_at,
+	'deletion_batch_id', sd.deletion_batch_id, 'revisions', sd.revision)`
+
+// MySQLAuditRepository implements the AuditRepository interface
+type MySQLAuditRepository struct {
+	db *sql.DB
+}
+
+// NewMySQLAuditRepository creates a new MySQL audit repository
+func NewMySQLAuditRepository(db *sql.DB) domain.AuditRepository {
+	return &MySQLAuditRepository{
+		db: db,
+	}
+}
+
+// List retrieves the audit entries matching the filter, newest first
+func (r *MySQLAuditRepository) List(filter *domain.AuditFilter) ([]*domain.AuditEntry, error) {
+	var conditions []string
+	var args []interface{}
+
+	if filter.ActorUserID != 0 {
+		conditions = append(conditions, "actor_user_id = ?")
+		args = append(args, filter.ActorUserID)
+	}
+	if filter.Operation != "" {
+		conditions = append(conditions, "operation = ?")
+		args = append(args, filter.Operation)
+	}
+	if filter.SensorDataID != 0 {
+		conditions = append(conditions, "sensor_data_id = ?")
+		args = append(args, filter.SensorDataID)
+	}
+	if filter.StartTime != nil {
+		conditions = append(conditions, "created_at >= ?")
+		args = append(args, filter.StartTime.UnixMilli())
+	}
+	if filter.EndTime != nil {
+		conditions = append(conditions, "created_at <= ?")
+		args = append(args, filter.EndTime.UnixMilli())
+	}
+	if filter.BeforeID != 0 {
+		conditions = append(conditions, "id < ?")
+		args = append(args, filter.BeforeID)
+	}
+
+	whereClause := ""
+	if len(conditions) > 0 {
+		whereClause = "WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	query := fmt.Sprintf(`
+		SELECT id, actor_user_id, actor_username, actor_api_key_id, operation, sensor_data_id,
+			filter, before_value, after_value, created_at
+		FROM audit_log
+		%s
+		ORDER BY id DESC
+		LIMIT ?
+	`, whereClause)
+
+	rows, err := r.db.Query(query, append(args, filter.Limit)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []*domain.AuditEntry{}
+	for rows.Next() {
+		entry, err := scanAuditEntry(rows)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
+
+// recordAudit writes an audit entry for each reading matched by whereClause, with the
+// reading as it is now as the value before the change. The value after the change is
+// the reading with the JSON path and value pairs in after set, or NULL if after is nil.
+// It must run in the transaction of the change, before the change is made.
+func recordAudit(tx *sql.Tx, actor domain.Actor, operation string, filter *domain.SensorDataFilter, after []interface{}, whereClause string, args []interface{}) error {
+	var filterJSON interface{}
+	if filter != nil {
+		// Only the fields selecting readings are recorded
+		recorded := *filter
+		recorded.Page, recorded.PageSize, recorded.Sort = 0, 0, nil
+		b, err := json.Marshal(recorded)
+		if err != nil {
+			return err
+		}
+		filterJSON = string(b)
+	}
+
+	afterValue := "NULL"
+	var afterArgs []interface{}
+	if after != nil {
+		afterValue = "JSON_SET(" + auditReadingJSON + strings.Repeat(", ?, ?", len(after)/2) + ")"
+		afterArgs = after
+	}
+
+	actorUserID, actorAPIKeyID := actorIDs(actor)
+	query := fmt.Sprintf(`
+		INSERT INTO audit_log (actor_user_id, actor_username, actor_api_key_id, operation,
+			sensor_data_id, filter, before_value, after_value, created_at)
+		SELECT ?, ?, ?, ?, sd.id, CAST(? AS JSON), %s, %s, ?
+		FROM sensor_data sd
+		JOIN sensor_types st ON sd.sensor_type_id = st.id
+		%s
+	`, auditReadingJSON, afterValue, whereClause)
+
+	queryArgs := []interface{}{actorUserID, actor.Username, actorAPIKeyID, operation, filterJSON}
+	queryArgs = append(queryArgs, afterArgs...)
+	queryArgs = append(queryArgs, time.Now().UnixMilli())
+	queryArgs = append(queryArgs, args...)
+
+	_, err := tx.Exec(query, queryArgs...)
+	return err
+}
+
+// actorIDs returns the user and API key IDs of an actor as column values, NULL where
+// they are unset
+func actorIDs(actor domain.Actor) (userID, apiKeyID interface{}) {
+	if actor.UserID != 0 {
+		userID = actor.UserID
+	}
+	if actor.APIKeyID != 0 {
+		apiKeyID = actor.APIKeyID
+	}
+	return userID, apiKeyID
+}
+
+// scanAuditEntry reads an audit entry from a result row
+func scanAuditEntry(row rowScanner) (*domain.AuditEntry, error) {
+	var entry domain.AuditEntry
+	var actorUserID, actorAPIKeyID sql.NullInt64
+	var filter, before, after []byte
+	var createdAt int64
+
+	err := row.Scan(
+		&entry.ID,
+		&actorUserID,
+		&entry.Actor.Username,
+		&actorAPIKeyID,
+		&entry.Operation,
+		&entry.SensorDataID,
+		&filter,
+		&before,
+		&after,
+		&createdAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	entry.Actor.UserID = int(actorUserID.Int64)
+	entry.Actor.APIKeyID = int(actorAPIKeyID.Int64)
+	if filter != nil {
+		entry.Filter = json.RawMessage(filter)
+	}
+	if entry.Before, err = decodeAuditReading(before); err != nil {
+		return nil, err
+	}
+	if entry.After, err = decodeAuditReading(after); err != nil {
+		return nil, err
+	}
+	entry.CreatedAt = time.UnixMilli(createdAt)
+	return &entry, nil
+}
+
+// decodeAuditReading decodes a reading stored as JSON, or returns nil for NULL
+func decodeAuditReading(b []byte) (*domain.SensorData, error) {
+	if b == nil {
+		return nil, nil
+	}
+	var data domain.SensorData
+	if err := json.Unmarshal(b, &data); err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
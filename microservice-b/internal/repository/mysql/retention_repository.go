@@ -0,0 +1,223 @@
+package mysql
+
+import (
+	"database/sql"
+	"time"
+
+	"sensor_project/microservice-b/internal/domain"
+)
+
+// MySQLRetentionRepository implements the RetentionRepository interface
+type MySQLRetentionRepository struct {
+	db *sql.DB
+}
+
+// NewMySQLRetentionRepository creates a new MySQL retention repository
+func NewMySQLRetentionRepository(db *sql.DB) domain.RetentionRepository {
+	return &MySQLRetentionRepository{
+		db: db,
+	}
+}
+
+// List retrieves the retention policies of all sensor types ordered by sensor type
+func (r *MySQLRetentionRepository) List() ([]*domain.RetentionPolicy, error) {
+	query := `
+		SELECT rp.sensor_type_id, st.name, rp.raw_retention_ms, rp.minute_rollup_retention_ms,
+			rp.hour_rollup_retention_ms, rp.day_rollup_retention_ms, rp.created_at, rp.updated_at
+		FROM retention_policies rp
+		JOIN sensor_types st ON rp.sensor_type_id = st.id
+		ORDER BY st.name
+	`
+
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []*domain.RetentionPolicy
+	for rows.Next() {
+		policy, err := scanRetentionPolicy(rows)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, policy)
+	}
+
+	return results, rows.Err()
+}
+
+// GetBySensorType retrieves the retention policy of a sensor type; a missing policy yields nil
+func (r *MySQLRetentionRepository) GetBySensorType(sensorType string) (*domain.RetentionPolicy, error) {
+	query := `
+		SELECT rp.sensor_type_id, st.name, rp.raw_retention_ms, rp.minute_rollup_retention_ms,
+			rp.hour_rollup_retention_ms, rp.day_rollup_retention_ms, rp.created_at, rp.updated_at
+		FROM retention_policies rp
+		JOIN sensor_types st ON rp.sensor_type_id = st.id
+		WHERE st.name = ?
+	`
+
+	policy, err := scanRetentionPolicy(r.db.QueryRow(query, sensorType))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return policy, nil
+}
+
+// Save creates or replaces the retention policy of a sensor type and sets its IDs and
+// timestamps. It returns domain.ErrSensorTypeNotFound if the sensor type does not exist.
+func (r *MySQLRetentionRepository) Save(policy *domain.RetentionPolicy) error {
+	var sensorTypeID int
+	err := r.db.QueryRow(`SELECT id FROM sensor_types WHERE name = ?`, policy.SensorType).Scan(&sensorTypeID)
+	if err == sql.ErrNoRows {
+		return domain.ErrSensorTypeNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UnixMilli()
+	query := `
+		INSERT INTO retention_policies (sensor_type_id, raw_retention_ms, minute_rollup_retention_ms,
+			hour_rollup_retention_ms, day_rollup_retention_ms, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE
+			raw_retention_ms = VALUES(raw_retention_ms),
+			minute_rollup_retention_ms = VALUES(minute_rollup_retention_ms),
+			hour_rollup_retention_ms = VALUES(hour_rollup_retention_ms),
+			day_rollup_retention_ms = VALUES(day_rollup_retention_ms),
+			updated_at = VALUES(updated_at)
+	`
+
+	_, err = r.db.Exec(
+		query,
+		sensorTypeID,
+		policy.RawRetention.Milliseconds(),
+		policy.MinuteRollupRetention.Milliseconds(),
+		policy.HourRollupRetention.Milliseconds(),
+		policy.DayRollupRetention.Milliseconds(),
+		now,
+		now,
+	)
+	if err != nil {
+		return err
+	}
+
+	saved, err := r.GetBySensorType(policy.SensorType)
+	if err != nil {
+		return err
+	}
+	if saved == nil {
+		return domain.ErrSensorTypeNotFound
+	}
+	*policy = *saved
+	return nil
+}
+
+// Delete removes the retention policy of a sensor type
+func (r *MySQLRetentionRepository) Delete(sensorType string) error {
+	query := `
+		DELETE rp FROM retention_policies rp
+		JOIN sensor_types st ON rp.sensor_type_id = st.id
+		WHERE st.name = ?
+	`
+
+	result, err := r.db.Exec(query, sensorType)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return domain.ErrRetentionPolicyNotFound
+	}
+	return nil
+}
+
+// CountExpired counts the rows of a sensor type at a resolution that lie entirely before cutoff
+func (r *MySQLRetentionRepository) CountExpired(resolution time.Duration, sensorTypeID int, cutoff int64) (int64, error) {
+	var query string
+	var args []interface{}
+	if resolution == 0 {
+		query = `SELECT COUNT(*) FROM sensor_data WHERE sensor_type_id = ? AND event_time < ?`
+		args = []interface{}{sensorTypeID, cutoff}
+	} else {
+		query = `SELECT COUNT(*) FROM sensor_data_rollups WHERE resolution_ms = ? AND sensor_type_id = ? AND bucket_start <= ?`
+		args = []interface{}{resolution.Milliseconds(), sensorTypeID, cutoff - resolution.Milliseconds()}
+	}
+
+	var count int64
+	err := r.db.QueryRow(query, args...).Scan(&count)
+	return count, err
+}
+
+// PurgeExpired deletes up to limit rows of a sensor type at a resolution that lie
+// entirely before cutoff and returns how many were deleted. Rollup buckets are only
+// deleted once they end at or before cutoff.
+func (r *MySQLRetentionRepository) PurgeExpired(resolution time.Duration, sensorTypeID int, cutoff int64, limit int) (int64, error) {
+	var query string
+	var args []interface{}
+	if resolution == 0 {
+		query = `DELETE FROM sensor_data WHERE sensor_type_id = ? AND event_time < ? LIMIT ?`
+		args = []interface{}{sensorTypeID, cutoff, limit}
+	} else {
+		query = `DELETE FROM sensor_data_rollups WHERE resolution_ms = ? AND sensor_type_id = ? AND bucket_start <= ? LIMIT ?`
+		args = []interface{}{resolution.Milliseconds(), sensorTypeID, cutoff - resolution.Milliseconds(), limit}
+	}
+
+	result, err := r.db.Exec(query, args...)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// CountDeleted counts the sensor data records deleted before cutoff
+func (r *MySQLRetentionRepository) CountDeleted(cutoff int64) (int64, error) {
+	var count int64
+	err := r.db.QueryRow(`SELECT COUNT(*) FROM sensor_data WHERE deleted_at < ?`, cutoff).Scan(&count)
+	return count, err
+}
+
+// PurgeDeleted permanently removes up to limit sensor data records deleted before
+// cutoff and returns how many were removed
+func (r *MySQLRetentionRepository) PurgeDeleted(cutoff int64, limit int) (int64, error) {
+	result, err := r.db.Exec(`DELETE FROM sensor_data WHERE deleted_at < ? LIMIT ?`, cutoff, limit)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// scanRetentionPolicy reads a retention policy from a result row
+func scanRetentionPolicy(row rowScanner) (*domain.RetentionPolicy, error) {
+	var policy domain.RetentionPolicy
+	var raw, minute, hour, day int64
+
+	err := row.Scan(
+		&policy.SensorTypeID,
+		&policy.SensorType,
+		&raw,
+		&minute,
+		&hour,
+		&day,
+		&policy.CreatedAt,
+		&policy.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	policy.RawRetention = time.Duration(raw) * time.Millisecond
+	policy.MinuteRollupRetention = time.Duration(minute) * time.Millisecond
+	policy.HourRollupRetention = time.Duration(hour) * time.Millisecond
+	policy.DayRollupRetention = time.Duration(day) * time.Millisecond
+
+	return &policy, nil
+}
@@ -0,0 +1,328 @@
+package mysql
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"sensor_project/microservice-b/internal/domain"
+)
+
+// rollupColumns lists the sensor_data_rollups columns written by a rebuild
+const rollupColumns = `resolution_ms, sensor_type_id, id1, id2, bucket_start, reading_count, value_sum,
+	value_sum_sq, min_value, max_value, first_value, first_time, last_value, last_time`
+
+// rollupFromRaw aggregates the raw readings of a series in a time range into rollup rows;
+// callers may add conditions before grouping by rollupFromRawGroup
+const rollupFromRaw = `
+	SELECT ?, sensor_type_id, id1, id2, (event_time DIV ?) * ? AS bucket,
+		COUNT(*), SUM(sensor_value), SUM(sensor_value * sensor_value),
+		MIN(sensor_value), MAX(sensor_value),
+		SUBSTRING_INDEX(GROUP_CONCAT(sensor_value ORDER BY event_time, id), ',', 1) + 0, MIN(event_time),
+		SUBSTRING_INDEX(GROUP_CONCAT(sensor_value ORDER BY event_time DESC, id DESC), ',', 1) + 0, MAX(event_time)
+	FROM sensor_data
+	WHERE sensor_type_id = ? AND id1 = ? AND id2 = ? AND event_time >= ? AND event_time < ?
+		AND deleted_at IS NULL`
+
+const rollupFromRawGroup = `
+	GROUP BY sensor_type_id, id1, id2, bucket`
+
+// MySQLRollupRepository implements the RollupRepository interface
+type MySQLRollupRepository struct {
+	db *sql.DB
+}
+
+// NewMySQLRollupRepository creates a new MySQL rollup repository
+func NewMySQLRollupRepository(db *sql.DB) domain.RollupRepository {
+	return &MySQLRollupRepository{
+		db: db,
+	}
+}
+
+// GetWatermark returns the ingest time up to which readings are reflected in the
+// rollups. Before the first refresh it is just before the earliest reading, or zero
+// when there are no readings.
+func (r *MySQLRollupRepository) GetWatermark() (int64, error) {
+	var watermark int64
+	err := r.db.QueryRow(`SELECT watermark FROM rollup_state WHERE id = 1`).Scan(&watermark)
+	if err == nil {
+		return watermark, nil
+	}
+	if err != sql.ErrNoRows {
+		return 0, err
+	}
+
+	err = r.db.QueryRow(`SELECT COALESCE(MIN(created_at) - 1, 0) FROM sensor_data`).Scan(&watermark)
+	return watermark, err
+}
+
+// GetChanges returns the finest-resolution buckets holding readings ingested after
+// since and up to until, together with buckets marked dirty by updates and deletes.
+// It also returns the last dirty mark read, to be cleared by Advance.
+func (r *MySQLRollupRepository) GetChanges(since, until int64) ([]domain.RollupChange, int64, error) {
+	var markID int64
+	if err := r.db.QueryRow(`SELECT COALESCE(MAX(id), 0) FROM sensor_data_rollup_dirty`).Scan(&markID); err != nil {
+		return nil, 0, err
+	}
+
+	bucketMs := domain.RollupResolutions[0].Milliseconds()
+	query := `
+		SELECT DISTINCT sensor_type_id, id1, id2, (event_time DIV ?) * ?
+		FROM sensor_data
+		WHERE created_at > ? AND created_at <= ?
+		UNION
+		SELECT DISTINCT sensor_type_id, id1, id2, bucket_start
+		FROM sensor_data_rollup_dirty
+		WHERE id <= ?
+	`
+
+	rows, err := r.db.Query(query, bucketMs, bucketMs, since, until, markID)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var changes []domain.RollupChange
+	for rows.Next() {
+		var change domain.RollupChange
+		if err := rows.Scan(&change.SensorTypeID, &change.ID1, &change.ID2, &change.BucketStart); err != nil {
+			return nil, 0, err
+		}
+		changes = append(changes, change)
+	}
+
+	return changes, markID, rows.Err()
+}
+
+// Rebuild recomputes the rollup buckets of one series starting in [from, to) at the
+// given resolution. A zero source builds them from raw readings, otherwise from the
+// rollups of the source resolution. Buckets left without readings are removed.
+func (r *MySQLRollupRepository) Rebuild(resolution, source time.Duration, series domain.RollupSeries, from, to int64) error {
+	resolutionMs := resolution.Milliseconds()
+
+	var insert string
+	var args []interface{}
+	if source == 0 {
+		insert = `INSERT INTO sensor_data_rollups (` + rollupColumns + `)` + rollupFromRaw + rollupFromRawGroup
+		args = []interface{}{resolutionMs, resolutionMs, resolutionMs, series.SensorTypeID, series.ID1, series.ID2, from, to}
+	} else {
+		insert = `
+			INSERT INTO sensor_data_rollups (` + rollupColumns + `)
+			SELECT ?, sensor_type_id, id1, id2, (bucket_start DIV ?) * ? AS bucket,
+				SUM(reading_count), SUM(value_sum), SUM(value_sum_sq),
+				MIN(min_value), MAX(max_value),
+				SUBSTRING_INDEX(GROUP_CONCAT(first_value ORDER BY first_time), ',', 1) + 0, MIN(first_time),
+				SUBSTRING_INDEX(GROUP_CONCAT(last_value ORDER BY last_time DESC), ',', 1) + 0, MAX(last_time)
+			FROM sensor_data_rollups
+			WHERE resolution_ms = ? AND sensor_type_id = ? AND id1 = ? AND id2 = ? AND bucket_start >= ? AND bucket_start < ?
+			GROUP BY sensor_type_id, id1, id2, bucket
+		`
+		args = []interface{}{resolutionMs, resolutionMs, resolutionMs, source.Milliseconds(), series.SensorTypeID, series.ID1, series.ID2, from, to}
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+		DELETE FROM sensor_data_rollups
+		WHERE resolution_ms = ? AND sensor_type_id = ? AND id1 = ? AND id2 = ? AND bucket_start >= ? AND bucket_start < ?
+	`, resolutionMs, series.SensorTypeID, series.ID1, series.ID2, from, to)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(insert, args...); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Advance stores a new watermark and clears the dirty marks up to markID. In the same
+// transaction, so a failed refresh cannot add them twice, it adds the readings ingested
+// after since and up to the watermark to the buckets of merges.
+func (r *MySQLRollupRepository) Advance(since, watermark, markID int64, merges []domain.RollupMerge) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Assignments apply in order, so first_value and last_value compare against the
+	// bucket's times before they are updated
+	merge := `INSERT INTO sensor_data_rollups (` + rollupColumns + `)` + rollupFromRaw + `
+		AND created_at > ? AND created_at <= ?` + rollupFromRawGroup + `
+		ON DUPLICATE KEY UPDATE
+			reading_count = reading_count + VALUES(reading_count),
+			value_sum = value_sum + VALUES(value_sum),
+			value_sum_sq = value_sum_sq + VALUES(value_sum_sq),
+			min_value = LEAST(min_value, VALUES(min_value)),
+			max_value = GREATEST(max_value, VALUES(max_value)),
+			first_value = IF(VALUES(first_time) < first_time, VALUES(first_value), first_value),
+			first_time = LEAST(first_time, VALUES(first_time)),
+			last_value = IF(VALUES(last_time) >= last_time, VALUES(last_value), last_value),
+			last_time = GREATEST(last_time, VALUES(last_time))
+	`
+	for _, m := range merges {
+		resolutionMs := m.Resolution.Milliseconds()
+		_, err := tx.Exec(merge, resolutionMs, resolutionMs, resolutionMs, m.SensorTypeID, m.ID1, m.ID2, m.From, m.To, since, watermark)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO rollup_state (id, watermark) VALUES (1, ?)
+		ON DUPLICATE KEY UPDATE watermark = VALUES(watermark)
+	`, watermark)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(`DELETE FROM sensor_data_rollup_dirty WHERE id <= ?`, markID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// GetPendingBuckets returns the starts, in order, of the interval buckets in the filter's
+// time range holding readings of its series the rollups do not reflect yet: readings
+// ingested after watermark and readings in buckets marked dirty. The time range must be
+// set and aligned to the interval.
+func (r *MySQLRollupRepository) GetPendingBuckets(filter *domain.SensorDataFilter, interval time.Duration, watermark int64) ([]int64, error) {
+	from, to := filter.StartTime.UnixMilli(), filter.EndTime.UnixMilli()
+
+	readingConditions, readingArgs := seriesConditions(filter, "sd")
+	readingConditions = append(readingConditions, "sd.created_at > ?", "sd.event_time >= ?", "sd.event_time <= ?")
+	readingArgs = append(readingArgs, watermark, from, to)
+
+	dirtyConditions, dirtyArgs := seriesConditions(filter, "d")
+	dirtyConditions = append(dirtyConditions, "d.bucket_start >= ?", "d.bucket_start <= ?")
+	dirtyArgs = append(dirtyArgs, from, to)
+
+	intervalMs := interval.Milliseconds()
+	query := fmt.Sprintf(`
+		SELECT (sd.event_time DIV ?) * ? AS bucket
+		FROM sensor_data sd
+		JOIN sensor_types st ON sd.sensor_type_id = st.id
+		WHERE %s
+		UNION
+		SELECT (d.bucket_start DIV ?) * ?
+		FROM sensor_data_rollup_dirty d
+		JOIN sensor_types st ON d.sensor_type_id = st.id
+		WHERE %s
+		ORDER BY bucket
+	`, strings.Join(readingConditions, " AND "), strings.Join(dirtyConditions, " AND "))
+
+	args := append([]interface{}{intervalMs, intervalMs}, readingArgs...)
+	args = append(args, intervalMs, intervalMs)
+	args = append(args, dirtyArgs...)
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var buckets []int64
+	for rows.Next() {
+		var bucket int64
+		if err := rows.Scan(&bucket); err != nil {
+			return nil, err
+		}
+		buckets = append(buckets, bucket)
+	}
+
+	return buckets, rows.Err()
+}
+
+// Aggregate computes an aggregation from the rollups of the given resolution. The
+// query's interval must be a multiple of the resolution and its time range aligned to it.
+func (r *MySQLRollupRepository) Aggregate(query *domain.AggregateQuery, resolution time.Duration) ([]*domain.AggregateBucket, error) {
+	filter := query.Filter
+	conditions, args := seriesConditions(&filter, "ru")
+	conditions = append([]string{"ru.resolution_ms = ?"}, conditions...)
+	args = append([]interface{}{resolution.Milliseconds()}, args...)
+
+	if filter.StartTime != nil {
+		conditions = append(conditions, "ru.bucket_start >= ?")
+		args = append(args, filter.StartTime.UnixMilli())
+	}
+	if filter.EndTime != nil {
+		conditions = append(conditions, "ru.bucket_start <= ?")
+		args = append(args, filter.EndTime.UnixMilli())
+	}
+
+	intervalMs := query.Interval.Milliseconds()
+	sqlQuery := fmt.Sprintf(`
+		SELECT (ru.bucket_start DIV ?) * ? AS bucket_start, st.name, ru.id1, ru.id2,
+			SUM(ru.reading_count), SUM(ru.value_sum), SUM(ru.value_sum_sq),
+			MIN(ru.min_value), MAX(ru.max_value),
+			SUBSTRING_INDEX(GROUP_CONCAT(ru.first_value ORDER BY ru.first_time), ',', 1) + 0,
+			SUBSTRING_INDEX(GROUP_CONCAT(ru.last_value ORDER BY ru.last_time DESC), ',', 1) + 0
+		FROM sensor_data_rollups ru
+		JOIN sensor_types st ON ru.sensor_type_id = st.id
+		WHERE %s
+		GROUP BY bucket_start, st.name, ru.id1, ru.id2
+		ORDER BY bucket_start, st.name, ru.id1, ru.id2
+		LIMIT ?
+	`, strings.Join(conditions, " AND "))
+
+	args = append([]interface{}{intervalMs, intervalMs}, args...)
+	args = append(args, query.Limit)
+
+	rows, err := r.db.Query(sqlQuery, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []*domain.AggregateBucket
+	for rows.Next() {
+		var bucket domain.AggregateBucket
+		var count int64
+		var sum, sumSq, minValue, maxValue, first, last float64
+		err := rows.Scan(
+			&bucket.BucketStart,
+			&bucket.SensorType,
+			&bucket.ID1,
+			&bucket.ID2,
+			&count,
+			&sum,
+			&sumSq,
+			&minValue,
+			&maxValue,
+			&first,
+			&last,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		avg := sum / float64(count)
+		computed := map[string]float64{
+			domain.AggregateMin:    minValue,
+			domain.AggregateMax:    maxValue,
+			domain.AggregateAvg:    avg,
+			domain.AggregateCount:  float64(count),
+			domain.AggregateSum:    sum,
+			domain.AggregateStdDev: math.Sqrt(math.Max(sumSq/float64(count)-avg*avg, 0)),
+			domain.AggregateFirst:  first,
+			domain.AggregateLast:   last,
+		}
+
+		bucket.Values = make(map[string]float64, len(query.Functions))
+		for _, fn := range query.Functions {
+			bucket.Values[fn] = computed[fn]
+		}
+		results = append(results, &bucket)
+	}
+
+	return results, rows.Err()
+}
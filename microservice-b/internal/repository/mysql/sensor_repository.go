@@ -2,15 +2,28 @@ package mysql
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	"sensor_project/microservice-b/internal/domain"
 
-	_ "github.com/go-sql-driver/mysql"
+	mysqlDriver "github.com/go-sql-driver/mysql"
 )
 
-// MySQLSensorRepository implements the SensorDataRepository interface
+// streamChunkSize is the number of records Stream reads per query, so no connection is
+// held while a slow client takes its time
+const streamChunkSize = 1000
+
+// sensorDataColumns lists the columns of sensor_data sd joined to sensor_types st in the
+// order scanSensorData reads them
+const sensorDataColumns = `sd.id, sd.reading_id, sd.sensor_value, st.name, sd.id1, sd.id2, sd.event_time,
+	sd.created_at, sd.quality_flags, sd.deleted_at, sd.deletion_batch_id, sd.revision`
+
+// MySQLSensorRepository implements the SensorDataRepository interface. Deletes only mark
+// records deleted; they are excluded from reads unless asked for and purged by the
+// retention repository after a grace period.
 type MySQLSensorRepository struct {
 	db *sql.DB
 }
@@ -22,49 +35,187 @@ func NewMySQLSensorRepository(db *sql.DB) domain.SensorDataRepository {
 	}
 }
 
-// Store saves a sensor data record to the database
+// Store saves a sensor data record and sets its ID. It returns
+// domain.ErrUnknownSensorType if its sensor type is not registered, and
+// domain.ErrDuplicateReading with the ID of the stored record if a record with the same
+// reading ID is already stored.
 func (r *MySQLSensorRepository) Store(data *domain.SensorData) error {
 	query := `
-		INSERT INTO sensor_data (sensor_value, sensor_type_id, id1, id2, created_at)
-		SELECT ?, id, ?, ?, ?
+		INSERT INTO sensor_data (reading_id, sensor_value, sensor_type_id, id1, id2, event_time, created_at, quality_flags)
+		SELECT ?, ?, id, ?, ?, ?, ?, ?
 		FROM sensor_types
 		WHERE name = ?
 	`
 
-	_, err := r.db.Exec(
+	result, err := r.db.Exec(
 		query,
+		sql.NullString{String: data.ReadingID, Valid: data.ReadingID != ""},
 		data.SensorValue,
 		data.ID1,
 		data.ID2,
+		data.EventTime,
 		data.CreatedAt,
+		data.QualityFlags,
 		data.SensorType,
 	)
+	var mysqlErr *mysqlDriver.MySQLError
+	if errors.As(err, &mysqlErr) && mysqlErr.Number == errDuplicateEntry && data.ReadingID != "" {
+		stored, err := r.GetByReadingID(data.ReadingID)
+		if err != nil {
+			return err
+		}
+		if stored != nil {
+			data.ID = stored.ID
+		}
+		return domain.ErrDuplicateReading
+	}
+	if err != nil {
+		return err
+	}
 
-	return err
+	// The INSERT ... SELECT inserts nothing when the sensor type does not exist
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return domain.ErrUnknownSensorType
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	data.ID = id
+
+	return nil
 }
 
-// GetByID retrieves a sensor data record by ID
-func (r *MySQLSensorRepository) GetByID(id int64) (*domain.SensorData, error) {
-	fmt.Println("reached here ??")
+// StoreBatch saves sensor data records in a single multi-row INSERT and sets their
+// IDs, so either all or none of them are stored. It returns domain.ErrUnknownSensorType
+// if any sensor type is not registered and domain.ErrDuplicateReading if any reading ID
+// is already stored.
+func (r *MySQLSensorRepository) StoreBatch(data []*domain.SensorData) error {
+	if len(data) == 0 {
+		return nil
+	}
+
+	typeIDs, err := r.sensorTypeIDs(data)
+	if err != nil {
+		return err
+	}
+
+	// Sensor type IDs are resolved beforehand, because a subquery in the VALUES would
+	// make the INSERT a bulk insert, whose auto-increment IDs need not be consecutive
+	placeholders := make([]string, 0, len(data))
+	args := make([]interface{}, 0, len(data)*8)
+	for _, d := range data {
+		typeID, ok := typeIDs[strings.ToLower(d.SensorType)]
+		if !ok {
+			return fmt.Errorf("%w %q", domain.ErrUnknownSensorType, d.SensorType)
+		}
+		placeholders = append(placeholders, "(?, ?, ?, ?, ?, ?, ?, ?)")
+		args = append(args, sql.NullString{String: d.ReadingID, Valid: d.ReadingID != ""},
+			d.SensorValue, typeID, d.ID1, d.ID2, d.EventTime, d.CreatedAt, d.QualityFlags)
+	}
+
 	query := `
-		SELECT sd.id, sd.sensor_value, st.name, sd.id1, sd.id2, sd.created_at
+		INSERT INTO sensor_data (reading_id, sensor_value, sensor_type_id, id1, id2, event_time, created_at, quality_flags)
+		VALUES ` + strings.Join(placeholders, ", ")
+
+	result, err := r.db.Exec(query, args...)
+	var mysqlErr *mysqlDriver.MySQLError
+	if errors.As(err, &mysqlErr) {
+		switch mysqlErr.Number {
+		case errDuplicateEntry:
+			return domain.ErrDuplicateReading
+		case errNoReferencedRow:
+			// A sensor type was deleted since its ID was looked up
+			return domain.ErrUnknownSensorType
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	// A multi-row INSERT reports the ID of its first row and assigns consecutive IDs
+	firstID, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	for i, d := range data {
+		d.ID = firstID + int64(i)
+	}
+
+	return nil
+}
+
+// sensorTypeIDs returns the IDs of the registered sensor types of the records, by
+// lower-case name since names are compared case-insensitively
+func (r *MySQLSensorRepository) sensorTypeIDs(data []*domain.SensorData) (map[string]int, error) {
+	var names []interface{}
+	seen := make(map[string]bool)
+	for _, d := range data {
+		if !seen[d.SensorType] {
+			seen[d.SensorType] = true
+			names = append(names, d.SensorType)
+		}
+	}
+
+	rows, err := r.db.Query(`SELECT id, name FROM sensor_types WHERE `+inCondition("name", len(names)), names...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := make(map[string]int, len(names))
+	for rows.Next() {
+		var id int
+		var name string
+		if err := rows.Scan(&id, &name); err != nil {
+			return nil, err
+		}
+		ids[strings.ToLower(name)] = id
+	}
+
+	return ids, rows.Err()
+}
+
+// GetByID retrieves a sensor data record by ID, returning nil if it does not exist or
+// is deleted and includeDeleted is not set
+func (r *MySQLSensorRepository) GetByID(id int64, includeDeleted bool) (*domain.SensorData, error) {
+	query := `
+		SELECT ` + sensorDataColumns + `
 		FROM sensor_data sd
 		JOIN sensor_types st ON sd.sensor_type_id = st.id
 		WHERE sd.id = ?
 	`
+	if !includeDeleted {
+		query += " AND sd.deleted_at IS NULL"
+	}
 
-	var data domain.SensorData
-	err := r.db.QueryRow(query, id).Scan(
-		&data.ID,
-		&data.SensorValue,
-		&data.SensorType,
-		&data.ID1,
-		&data.ID2,
-		&data.CreatedAt,
-	)
+	data, err := scanSensorData(r.db.QueryRow(query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
 
-	fmt.Println("errror : ", err)
+	return data, nil
+}
 
+// GetByReadingID retrieves the sensor data record with a client-supplied reading ID,
+// including a deleted one, returning nil if it does not exist
+func (r *MySQLSensorRepository) GetByReadingID(readingID string) (*domain.SensorData, error) {
+	query := `
+		SELECT ` + sensorDataColumns + `
+		FROM sensor_data sd
+		JOIN sensor_types st ON sd.sensor_type_id = st.id
+		WHERE sd.reading_id = ?
+	`
+
+	data, err := scanSensorData(r.db.QueryRow(query, readingID))
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
@@ -72,37 +223,62 @@ func (r *MySQLSensorRepository) GetByID(id int64) (*domain.SensorData, error) {
 		return nil, err
 	}
 
-	return &data, nil
+	return data, nil
 }
 
-// GetByFilter retrieves sensor data records based on filter criteria
-func (r *MySQLSensorRepository) GetByFilter(filter *domain.SensorDataFilter) ([]*domain.SensorData, int, error) {
-	// Build the WHERE clause based on filter criteria
-	whereClause, args := r.buildWhereClause(filter)
+// GetIDsByReadingID returns the IDs of the sensor data records, including deleted ones,
+// stored under any of the given reading IDs, by reading ID
+func (r *MySQLSensorRepository) GetIDsByReadingID(readingIDs []string) (map[string]int64, error) {
+	ids := make(map[string]int64)
+	if len(readingIDs) == 0 {
+		return ids, nil
+	}
 
-	// Count total records matching the filter
-	countQuery := fmt.Sprintf(`
-		SELECT COUNT(*)
-		FROM sensor_data sd
-		JOIN sensor_types st ON sd.sensor_type_id = st.id
-		%s
-	`, whereClause)
+	args := make([]interface{}, 0, len(readingIDs))
+	for _, readingID := range readingIDs {
+		args = append(args, readingID)
+	}
 
-	var total int
-	err := r.db.QueryRow(countQuery, args...).Scan(&total)
+	rows, err := r.db.Query(`SELECT reading_id, id FROM sensor_data WHERE `+inCondition("reading_id", len(args)), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var readingID string
+		var id int64
+		if err := rows.Scan(&readingID, &id); err != nil {
+			return nil, err
+		}
+		ids[readingID] = id
+	}
+
+	return ids, rows.Err()
+}
+
+// GetByFilter retrieves sensor data records based on filter criteria, by default newest
+// first
+func (r *MySQLSensorRepository) GetByFilter(filter *domain.SensorDataFilter) ([]*domain.SensorData, int, error) {
+	// Count total records matching the filter
+	total, err := r.Count(filter)
 	if err != nil {
 		return nil, 0, err
 	}
 
+	// Build the FROM and WHERE clauses based on filter criteria
+	whereClause, args := r.buildWhereClause(filter)
+	fromClause, args := r.buildFromClause(filter, args)
+
 	// Build the main query with pagination
 	query := fmt.Sprintf(`
-		SELECT sd.id, sd.sensor_value, st.name, sd.id1, sd.id2, sd.created_at
-		FROM sensor_data sd
+		SELECT `+sensorDataColumns+`
+		FROM `+fromClause+`
 		JOIN sensor_types st ON sd.sensor_type_id = st.id
 		%s
-		ORDER BY sd.created_at DESC
+		ORDER BY %s
 		LIMIT ? OFFSET ?
-	`, whereClause)
+	`, whereClause, orderClause(filter, true))
 
 	// Add pagination parameters
 	offset := (filter.Page - 1) * filter.PageSize
@@ -118,102 +294,652 @@ func (r *MySQLSensorRepository) GetByFilter(filter *domain.SensorDataFilter) ([]
 	// Parse the results
 	var results []*domain.SensorData
 	for rows.Next() {
-		var data domain.SensorData
-		err := rows.Scan(
-			&data.ID,
-			&data.SensorValue,
-			&data.SensorType,
-			&data.ID1,
-			&data.ID2,
-			&data.CreatedAt,
-		)
+		data, err := scanSensorData(rows)
 		if err != nil {
 			return nil, 0, err
 		}
-		results = append(results, &data)
+		results = append(results, data)
 	}
 
 	return results, total, nil
 }
 
-// Update updates a sensor data record
-func (r *MySQLSensorRepository) Update(id int64, update *domain.SensorDataUpdate) error {
+// GetByCursor retrieves up to limit sensor data records matching the filter that follow
+// the cursor in newest-first (created_at, id) order, or precede it if cursor.Before is
+// set. Preceding rows are returned oldest first. A nil cursor starts at the newest row.
+func (r *MySQLSensorRepository) GetByCursor(filter *domain.SensorDataFilter, cursor *domain.PageCursor, limit int) ([]*domain.SensorData, error) {
+	whereClause, args := r.buildWhereClause(filter)
+
+	order := "DESC"
+	if cursor != nil {
+		// Expanded rather than a row comparison so MySQL uses a range scan on the index
+		comparison := "<"
+		if cursor.Before {
+			comparison = ">"
+			order = "ASC"
+		}
+		condition := fmt.Sprintf("(sd.created_at %[1]s ? OR (sd.created_at = ? AND sd.id %[1]s ?))", comparison)
+		if whereClause == "" {
+			whereClause = "WHERE " + condition
+		} else {
+			whereClause += " AND " + condition
+		}
+		args = append(args, cursor.CreatedAt, cursor.CreatedAt, cursor.ID)
+	}
+	fromClause, args := r.buildFromClause(filter, args)
+
+	query := fmt.Sprintf(`
+		SELECT `+sensorDataColumns+`
+		FROM `+fromClause+`
+		JOIN sensor_types st ON sd.sensor_type_id = st.id
+		%[1]s
+		ORDER BY sd.created_at %[2]s, sd.id %[2]s
+		LIMIT ?
+	`, whereClause, order)
+
+	rows, err := r.db.Query(query, append(args, limit)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []*domain.SensorData
+	for rows.Next() {
+		data, err := scanSensorData(rows)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, data)
+	}
+
+	return results, rows.Err()
+}
+
+// GetAfterID retrieves up to limit sensor data records matching the filter, in id order,
+// whose id is greater than afterID or, since concurrent writes commit out of id order,
+// lower but ingested at most overlapMs before the record afterID
+func (r *MySQLSensorRepository) GetAfterID(filter *domain.SensorDataFilter, afterID, overlapMs int64, limit int) ([]*domain.SensorData, error) {
+	condition := `(sd.id > ? OR sd.id < ? AND sd.created_at >= (
+		SELECT created_at - ? FROM sensor_data WHERE id = ?))`
+	whereClause, args := r.buildWhereClause(filter)
+	if whereClause == "" {
+		whereClause = "WHERE " + condition
+	} else {
+		whereClause += " AND " + condition
+	}
+	args = append(args, afterID, afterID, overlapMs, afterID)
+	fromClause, args := r.buildFromClause(filter, args)
+
+	query := fmt.Sprintf(`
+		SELECT `+sensorDataColumns+`
+		FROM `+fromClause+`
+		JOIN sensor_types st ON sd.sensor_type_id = st.id
+		%s
+		ORDER BY sd.id
+		LIMIT ?
+	`, whereClause)
+
+	rows, err := r.db.Query(query, append(args, limit)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []*domain.SensorData
+	for rows.Next() {
+		data, err := scanSensorData(rows)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, data)
+	}
+
+	return results, rows.Err()
+}
+
+// Count counts the sensor data records matching the filter
+func (r *MySQLSensorRepository) Count(filter *domain.SensorDataFilter) (int, error) {
+	whereClause, args := r.buildWhereClause(filter)
+	fromClause, args := r.buildFromClause(filter, args)
+
+	query := fmt.Sprintf(`
+		SELECT COUNT(*)
+		FROM `+fromClause+`
+		JOIN sensor_types st ON sd.sensor_type_id = st.id
+		%s
+	`, whereClause)
+
+	var total int
+	err := r.db.QueryRow(query, args...).Scan(&total)
+	return total, err
+}
+
+// MaxID returns the highest sensor data record id, or 0 if there are none
+func (r *MySQLSensorRepository) MaxID() (int64, error) {
+	var maxID int64
+	err := r.db.QueryRow(`SELECT COALESCE(MAX(id), 0) FROM sensor_data`).Scan(&maxID)
+	return maxID, err
+}
+
+// Stream passes every sensor data record matching the filter to fn in the filter's sort
+// order, by default ascending time. Records are read streamChunkSize at a time, each
+// chunk after the last record of the one before, and the connection is released before
+// they are passed on. It stops at the first error returned by fn.
+func (r *MySQLSensorRepository) Stream(filter *domain.SensorDataFilter, fn func(*domain.SensorData) error) error {
+	columns := sortColumns(filter, false)
+	var last *domain.SensorData
+	for {
+		chunk, err := r.streamChunk(filter, columns, last)
+		if err != nil {
+			return err
+		}
+		for _, data := range chunk {
+			if err := fn(data); err != nil {
+				return err
+			}
+		}
+		if len(chunk) < streamChunkSize {
+			return nil
+		}
+		last = chunk[len(chunk)-1]
+	}
+}
+
+// streamChunk reads up to streamChunkSize records matching the filter that follow last
+// in the order of the sort columns, or the first ones if last is nil
+func (r *MySQLSensorRepository) streamChunk(filter *domain.SensorDataFilter, columns []sortColumn, last *domain.SensorData) ([]*domain.SensorData, error) {
+	whereClause, args := r.buildWhereClause(filter)
+	if last != nil {
+		condition, keyArgs := keysetCondition(columns, last)
+		if whereClause == "" {
+			whereClause = "WHERE " + condition
+		} else {
+			whereClause += " AND " + condition
+		}
+		args = append(args, keyArgs...)
+	}
+	fromClause, args := r.buildFromClause(filter, args)
+
+	query := fmt.Sprintf(`
+		SELECT `+sensorDataColumns+`
+		FROM `+fromClause+`
+		JOIN sensor_types st ON sd.sensor_type_id = st.id
+		%s
+		ORDER BY %s
+		LIMIT ?
+	`, whereClause, joinSortColumns(columns))
+
+	rows, err := r.db.Query(query, append(args, streamChunkSize)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []*domain.SensorData
+	for rows.Next() {
+		data, err := scanSensorData(rows)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, data)
+	}
+	return results, rows.Err()
+}
+
+// Update corrects the value of a sensor data record that is not deleted, keeping the
+// measured value in raw_value and recording the correction as its next revision and in
+// the audit log. It returns domain.ErrSensorDataNotFound if there is no such record.
+func (r *MySQLSensorRepository) Update(id int64, update *domain.SensorDataUpdate, actor domain.Actor) error {
 	if update.SensorValue == nil {
 		return nil // Nothing to update
 	}
 
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Lock the record so concurrent corrections get consecutive revisions
+	var revision int
+	err = tx.QueryRow(`SELECT revision FROM sensor_data WHERE id = ? AND deleted_at IS NULL FOR UPDATE`, id).Scan(&revision)
+	if err == sql.ErrNoRows {
+		return domain.ErrSensorDataNotFound
+	}
+	if err != nil {
+		return err
+	}
+	revision++
+
+	whereClause := "WHERE sd.id = ?"
+	after := []interface{}{"$.sensor_value", *update.SensorValue, "$.revisions", revision}
+	if err := recordAudit(tx, actor, domain.AuditUpdate, nil, after, whereClause, []interface{}{id}); err != nil {
+		return err
+	}
+	if err := markRollupsDirty(tx, whereClause, []interface{}{id}); err != nil {
+		return err
+	}
+
 	query := `
 		UPDATE sensor_data
-		SET sensor_value = ?
+		SET raw_value = COALESCE(raw_value, sensor_value), sensor_value = ?, revision = ?
 		WHERE id = ?
 	`
 
-	_, err := r.db.Exec(query, *update.SensorValue, id)
-	return err
+	if _, err := tx.Exec(query, *update.SensorValue, revision, id); err != nil {
+		return err
+	}
+
+	actorUserID, actorAPIKeyID := actorIDs(actor)
+	query = `
+		INSERT INTO sensor_data_revisions (sensor_data_id, revision, sensor_value, reason,
+			actor_user_id, actor_username, actor_api_key_id, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	_, err = tx.Exec(query, id, revision, *update.SensorValue, update.Reason, actorUserID, actor.Username, actorAPIKeyID, time.Now().UnixMilli())
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
 }
 
-// Delete removes a sensor data record by ID
-func (r *MySQLSensorRepository) Delete(id int64) error {
-	query := `DELETE FROM sensor_data WHERE id = ?`
-	_, err := r.db.Exec(query, id)
-	return err
+// GetRevisions retrieves every value of a sensor data record in order: revision 0 with
+// the measured value and time of ingest, then each correction. It returns an empty list
+// if the record does not exist.
+func (r *MySQLSensorRepository) GetRevisions(id int64) ([]*domain.SensorDataRevision, error) {
+	query := `
+		SELECT 0 AS revision, COALESCE(raw_value, sensor_value), '', NULL, '', NULL, created_at
+		FROM sensor_data
+		WHERE id = ?
+		UNION ALL
+		SELECT revision, sensor_value, reason, actor_user_id, actor_username, actor_api_key_id, created_at
+		FROM sensor_data_revisions
+		WHERE sensor_data_id = ?
+		ORDER BY revision
+	`
+
+	rows, err := r.db.Query(query, id, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	revisions := []*domain.SensorDataRevision{}
+	for rows.Next() {
+		var revision domain.SensorDataRevision
+		var actorUserID, actorAPIKeyID sql.NullInt64
+		err := rows.Scan(
+			&revision.Revision,
+			&revision.SensorValue,
+			&revision.Reason,
+			&actorUserID,
+			&revision.Actor.Username,
+			&actorAPIKeyID,
+			&revision.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		revision.Actor.UserID = int(actorUserID.Int64)
+		revision.Actor.APIKeyID = int(actorAPIKeyID.Int64)
+		revisions = append(revisions, &revision)
+	}
+	return revisions, rows.Err()
+}
+
+// Delete marks a sensor data record deleted in the given deletion batch and records it
+// in the audit log. It returns domain.ErrSensorDataNotFound if there is no such record
+// left to delete.
+func (r *MySQLSensorRepository) Delete(id int64, batchID string, actor domain.Actor) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	whereClause := "WHERE sd.id = ? AND sd.deleted_at IS NULL"
+	deleted, err := softDelete(tx, domain.AuditDelete, nil, batchID, actor, whereClause, []interface{}{id})
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return domain.ErrSensorDataNotFound
+	}
+
+	return tx.Commit()
 }
 
-// DeleteByFilter removes sensor data records based on filter criteria
-func (r *MySQLSensorRepository) DeleteByFilter(filter *domain.SensorDataFilter) (int, error) {
+// DeleteByFilter marks the sensor data records matching the filter deleted in the given
+// deletion batch, records them in the audit log and returns how many were deleted
+func (r *MySQLSensorRepository) DeleteByFilter(filter *domain.SensorDataFilter, batchID string, actor domain.Actor) (int, error) {
 	// Build the WHERE clause based on filter criteria
 	whereClause, args := r.buildWhereClause(filter)
 
-	// Build the delete query
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	deleted, err := softDelete(tx, domain.AuditDeleteByFilter, filter, batchID, actor, whereClause, args)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	return deleted, nil
+}
+
+// DeleteBatch marks up to limit sensor data records matching the filter with an id up
+// to maxID deleted in the given deletion batch, lowest id first, records them in the
+// audit log and returns how many were deleted
+func (r *MySQLSensorRepository) DeleteBatch(filter *domain.SensorDataFilter, maxID int64, limit int, batchID string, actor domain.Actor) (int, error) {
+	whereClause, args := r.buildWhereClause(filter)
+	if whereClause == "" {
+		whereClause = "WHERE sd.id <= ?"
+	} else {
+		whereClause += " AND sd.id <= ?"
+	}
+	args = append(args, maxID)
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
 	query := fmt.Sprintf(`
-		DELETE sd FROM sensor_data sd
+		SELECT sd.id
+		FROM sensor_data sd
 		JOIN sensor_types st ON sd.sensor_type_id = st.id
 		%s
+		ORDER BY sd.id
+		LIMIT ?
+		FOR UPDATE
 	`, whereClause)
 
-	// Execute the query
-	result, err := r.db.Exec(query, args...)
+	rows, err := tx.Query(query, append(args, limit)...)
 	if err != nil {
 		return 0, err
 	}
+	var ids []interface{}
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+	if len(ids) == 0 {
+		return 0, nil
+	}
 
-	// Get the number of affected rows
+	idClause := "WHERE " + inCondition("sd.id", len(ids))
+	deleted, err := softDelete(tx, domain.AuditDeleteByFilter, filter, batchID, actor, idClause, ids)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	return deleted, nil
+}
+
+// Restore undeletes the records of a deletion batch that have not been purged yet,
+// records them in the audit log and returns how many were restored
+func (r *MySQLSensorRepository) Restore(batchID string, actor domain.Actor) (int, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	whereClause := "WHERE sd.deletion_batch_id = ?"
+	args := []interface{}{batchID}
+	after := []interface{}{"$.deleted_at", nil, "$.deletion_batch_id", nil}
+	if err := recordAudit(tx, actor, domain.AuditRestore, nil, after, whereClause, args); err != nil {
+		return 0, err
+	}
+	if err := markRollupsDirty(tx, whereClause, args); err != nil {
+		return 0, err
+	}
+
+	query := `
+		UPDATE sensor_data
+		SET deleted_at = NULL, deletion_batch_id = NULL
+		WHERE deletion_batch_id = ?
+	`
+
+	result, err := tx.Exec(query, batchID)
+	if err != nil {
+		return 0, err
+	}
 	affected, err := result.RowsAffected()
 	if err != nil {
 		return 0, err
 	}
 
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
 	return int(affected), nil
 }
 
+// ListDeletions summarizes the deletion batches with records left to restore, most
+// recent first. PurgeAt is left to the caller.
+func (r *MySQLSensorRepository) ListDeletions() ([]*domain.DeletionBatch, error) {
+	query := `
+		SELECT deletion_batch_id, COUNT(*), MIN(deleted_at)
+		FROM sensor_data
+		WHERE deletion_batch_id IS NOT NULL
+		GROUP BY deletion_batch_id
+		ORDER BY MIN(deleted_at) DESC
+	`
+
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	batches := []*domain.DeletionBatch{}
+	for rows.Next() {
+		var batch domain.DeletionBatch
+		if err := rows.Scan(&batch.ID, &batch.Rows, &batch.DeletedAt); err != nil {
+			return nil, err
+		}
+		batches = append(batches, &batch)
+	}
+	return batches, rows.Err()
+}
+
+// Quarantine saves a reading that could not be stored, together with the reason. It
+// returns domain.ErrDuplicateReading if a reading with the same reading ID is already
+// quarantined.
+func (r *MySQLSensorRepository) Quarantine(data *domain.SensorData, reason string) error {
+	query := `
+		INSERT INTO quarantined_sensor_data (reading_id, sensor_value, sensor_type, id1, id2, event_time, created_at, reason)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	_, err := r.db.Exec(
+		query,
+		sql.NullString{String: data.ReadingID, Valid: data.ReadingID != ""},
+		data.SensorValue,
+		data.SensorType,
+		data.ID1,
+		data.ID2,
+		data.EventTime,
+		data.CreatedAt,
+		reason,
+	)
+	var mysqlErr *mysqlDriver.MySQLError
+	if errors.As(err, &mysqlErr) && mysqlErr.Number == errDuplicateEntry {
+		return domain.ErrDuplicateReading
+	}
+
+	return err
+}
+
+// aggregateExpressions maps aggregate functions to their SQL. First and last take the
+// leading value of the bucket's readings ordered by time; GROUP_CONCAT truncation only
+// drops trailing values, so the leading one is always complete.
+var aggregateExpressions = map[string]string{
+	domain.AggregateMin:    "MIN(sd.sensor_value)",
+	domain.AggregateMax:    "MAX(sd.sensor_value)",
+	domain.AggregateAvg:    "AVG(sd.sensor_value)",
+	domain.AggregateCount:  "COUNT(*)",
+	domain.AggregateSum:    "SUM(sd.sensor_value)",
+	domain.AggregateStdDev: "STDDEV_POP(sd.sensor_value)",
+	domain.AggregateFirst:  "SUBSTRING_INDEX(GROUP_CONCAT(sd.sensor_value ORDER BY %[1]s ASC, sd.id ASC), ',', 1) + 0",
+	domain.AggregateLast:   "SUBSTRING_INDEX(GROUP_CONCAT(sd.sensor_value ORDER BY %[1]s DESC, sd.id DESC), ',', 1) + 0",
+}
+
+// Aggregate groups the filtered sensor data into time buckets per sensor type and
+// device and computes the requested functions in the database
+func (r *MySQLSensorRepository) Aggregate(query *domain.AggregateQuery) ([]*domain.AggregateBucket, error) {
+	whereClause, args := r.buildWhereClause(&query.Filter)
+	fromClause, args := r.buildFromClause(&query.Filter, args)
+	column := timeColumn(&query.Filter)
+	intervalMs := query.Interval.Milliseconds()
+
+	selects := make([]string, 0, len(query.Functions))
+	for _, fn := range query.Functions {
+		expr, ok := aggregateExpressions[fn]
+		if !ok {
+			return nil, fmt.Errorf("unknown aggregate function %q", fn)
+		}
+		if strings.Contains(expr, "%[1]s") {
+			expr = fmt.Sprintf(expr, column)
+		}
+		selects = append(selects, expr)
+	}
+
+	sqlQuery := fmt.Sprintf(`
+		SELECT (%[1]s DIV ?) * ? AS bucket_start, st.name, sd.id1, sd.id2, %[2]s
+		FROM `+fromClause+`
+		JOIN sensor_types st ON sd.sensor_type_id = st.id
+		%[3]s
+		GROUP BY bucket_start, st.name, sd.id1, sd.id2
+		ORDER BY bucket_start, st.name, sd.id1, sd.id2
+		LIMIT ?
+	`, column, strings.Join(selects, ", "), whereClause)
+
+	args = append([]interface{}{intervalMs, intervalMs}, args...)
+	args = append(args, query.Limit)
+
+	rows, err := r.db.Query(sqlQuery, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []*domain.AggregateBucket
+	values := make([]sql.NullFloat64, len(query.Functions))
+	for rows.Next() {
+		var bucket domain.AggregateBucket
+		dest := []interface{}{&bucket.BucketStart, &bucket.SensorType, &bucket.ID1, &bucket.ID2}
+		for i := range values {
+			dest = append(dest, &values[i])
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+
+		bucket.Values = make(map[string]float64, len(query.Functions))
+		for i, fn := range query.Functions {
+			if values[i].Valid {
+				bucket.Values[fn] = values[i].Float64
+			}
+		}
+		results = append(results, &bucket)
+	}
+
+	return results, rows.Err()
+}
+
+// buildFromClause returns the sensor_data sd source of a query on the filtered readings
+// and args with its arguments prepended. With filter.AsOf set, sd holds the readings
+// ingested by then with the value they had then, deleted only if they had been deleted
+// by then.
+func (r *MySQLSensorRepository) buildFromClause(filter *domain.SensorDataFilter, args []interface{}) (string, []interface{}) {
+	if filter.AsOf == nil {
+		return "sensor_data sd", args
+	}
+
+	// Revisions are numbered from 1 without gaps, so the latest one made by then is
+	// also the number of corrections made by then
+	fromClause := `(
+			SELECT d.id, d.reading_id, COALESCE(r.sensor_value, d.raw_value, d.sensor_value) AS sensor_value,
+				d.sensor_type_id, d.id1, d.id2, d.event_time, d.created_at, d.quality_flags,
+				IF(d.deleted_at <= ?, d.deleted_at, NULL) AS deleted_at,
+				IF(d.deleted_at <= ?, d.deletion_batch_id, NULL) AS deletion_batch_id,
+				COALESCE(r.revision, 0) AS revision
+			FROM sensor_data d
+			LEFT JOIN LATERAL (
+				SELECT revision, sensor_value
+				FROM sensor_data_revisions
+				WHERE sensor_data_id = d.id AND created_at <= ?
+				ORDER BY revision DESC
+				LIMIT 1
+			) r ON TRUE
+			WHERE d.created_at <= ?
+		) sd`
+
+	asOf := filter.AsOf.UnixMilli()
+	return fromClause, append([]interface{}{asOf, asOf, asOf, asOf}, args...)
+}
+
 // buildWhereClause constructs a WHERE clause based on filter criteria
 func (r *MySQLSensorRepository) buildWhereClause(filter *domain.SensorDataFilter) (string, []interface{}) {
-	var conditions []string
-	var args []interface{}
+	conditions, args := seriesConditions(filter, "sd")
+
+	if !filter.IncludeDeleted {
+		conditions = append(conditions, "sd.deleted_at IS NULL")
+	}
 
-	if filter.ID1 != nil {
-		conditions = append(conditions, "sd.id1 = ?")
-		args = append(args, *filter.ID1)
+	if filter.MinValue != nil {
+		conditions = append(conditions, "sd.sensor_value >= ?")
+		args = append(args, *filter.MinValue)
 	}
 
-	if filter.ID2 != nil {
-		conditions = append(conditions, "sd.id2 = ?")
-		args = append(args, *filter.ID2)
+	if filter.MaxValue != nil {
+		conditions = append(conditions, "sd.sensor_value <= ?")
+		args = append(args, *filter.MaxValue)
 	}
 
-	if filter.SensorType != nil {
-		conditions = append(conditions, "st.name = ?")
-		args = append(args, *filter.SensorType)
+	// A zero mask selects readings without quality flags
+	if filter.QualityFlags != nil {
+		if *filter.QualityFlags == 0 {
+			conditions = append(conditions, "sd.quality_flags = 0")
+		} else {
+			conditions = append(conditions, "sd.quality_flags & ? != 0")
+			args = append(args, *filter.QualityFlags)
+		}
 	}
 
+	// Times are stored as Unix milliseconds
 	if filter.StartTime != nil {
-		conditions = append(conditions, "sd.created_at >= ?")
-		args = append(args, *filter.StartTime)
+		conditions = append(conditions, timeColumn(filter)+" >= ?")
+		args = append(args, filter.StartTime.UnixMilli())
 	}
 
 	if filter.EndTime != nil {
-		conditions = append(conditions, "sd.created_at <= ?")
-		args = append(args, *filter.EndTime)
+		conditions = append(conditions, timeColumn(filter)+" <= ?")
+		args = append(args, filter.EndTime.UnixMilli())
 	}
 
 	if len(conditions) > 0 {
@@ -222,3 +948,213 @@ func (r *MySQLSensorRepository) buildWhereClause(filter *domain.SensorDataFilter
 
 	return "", args
 }
+
+// scanSensorData reads a sensor data record selected by sensorDataColumns from a
+// result row
+func scanSensorData(row rowScanner) (*domain.SensorData, error) {
+	var data domain.SensorData
+	var readingID, deletionBatchID sql.NullString
+	var deletedAt sql.NullInt64
+	err := row.Scan(
+		&data.ID,
+		&readingID,
+		&data.SensorValue,
+		&data.SensorType,
+		&data.ID1,
+		&data.ID2,
+		&data.EventTime,
+		&data.CreatedAt,
+		&data.QualityFlags,
+		&deletedAt,
+		&deletionBatchID,
+		&data.Revisions,
+	)
+	if err != nil {
+		return nil, err
+	}
+	data.ReadingID = readingID.String
+	data.DeletedAt = deletedAt.Int64
+	data.DeletionBatchID = deletionBatchID.String
+	return &data, nil
+}
+
+// softDelete marks the readings matched by whereClause deleted in a deletion batch,
+// recording them in the audit log and marking their rollups for recomputation, and
+// returns how many were marked. It must run in a transaction.
+func softDelete(tx *sql.Tx, operation string, filter *domain.SensorDataFilter, batchID string, actor domain.Actor, whereClause string, args []interface{}) (int, error) {
+	now := time.Now().UnixMilli()
+	after := []interface{}{"$.deleted_at", now, "$.deletion_batch_id", batchID}
+	if err := recordAudit(tx, actor, operation, filter, after, whereClause, args); err != nil {
+		return 0, err
+	}
+	if err := markRollupsDirty(tx, whereClause, args); err != nil {
+		return 0, err
+	}
+
+	query := fmt.Sprintf(`
+		UPDATE sensor_data sd
+		JOIN sensor_types st ON sd.sensor_type_id = st.id
+		SET sd.deleted_at = ?, sd.deletion_batch_id = ?
+		%s
+	`, whereClause)
+
+	result, err := tx.Exec(query, append([]interface{}{now, batchID}, args...)...)
+	if err != nil {
+		return 0, err
+	}
+	affected, err := result.RowsAffected()
+	return int(affected), err
+}
+
+// markRollupsDirty records the rollup buckets of the readings matched by whereClause so
+// the rollup builder recomputes them after the readings change
+func markRollupsDirty(tx *sql.Tx, whereClause string, args []interface{}) error {
+	query := fmt.Sprintf(`
+		INSERT INTO sensor_data_rollup_dirty (sensor_type_id, id1, id2, bucket_start)
+		SELECT DISTINCT sd.sensor_type_id, sd.id1, sd.id2, (sd.event_time DIV ?) * ?
+		FROM sensor_data sd
+		JOIN sensor_types st ON sd.sensor_type_id = st.id
+		%s
+	`, whereClause)
+
+	bucketMs := domain.RollupResolutions[0].Milliseconds()
+	_, err := tx.Exec(query, append([]interface{}{bucketMs, bucketMs}, args...)...)
+	return err
+}
+
+// seriesConditions returns the conditions selecting the sensor types and devices a
+// filter asks for, on a table with the given alias joined to sensor_types as st
+func seriesConditions(filter *domain.SensorDataFilter, alias string) ([]string, []interface{}) {
+	var conditions []string
+	var args []interface{}
+
+	if len(filter.ID1) > 0 {
+		conditions = append(conditions, inCondition(alias+".id1", len(filter.ID1)))
+		for _, id1 := range filter.ID1 {
+			args = append(args, id1)
+		}
+	}
+
+	if len(filter.ID2) > 0 {
+		conditions = append(conditions, inCondition(alias+".id2", len(filter.ID2)))
+		for _, id2 := range filter.ID2 {
+			args = append(args, id2)
+		}
+	}
+
+	if filter.ID2Min != nil {
+		conditions = append(conditions, alias+".id2 >= ?")
+		args = append(args, *filter.ID2Min)
+	}
+
+	if filter.ID2Max != nil {
+		conditions = append(conditions, alias+".id2 <= ?")
+		args = append(args, *filter.ID2Max)
+	}
+
+	if len(filter.SensorType) > 0 {
+		conditions = append(conditions, inCondition("st.name", len(filter.SensorType)))
+		for _, sensorType := range filter.SensorType {
+			args = append(args, sensorType)
+		}
+	}
+
+	return conditions, args
+}
+
+// inCondition returns a condition matching column against n placeholder values
+func inCondition(column string, n int) string {
+	if n == 1 {
+		return column + " = ?"
+	}
+	return column + " IN (" + strings.Repeat("?, ", n-1) + "?)"
+}
+
+// sortColumn is a column records are ordered by
+type sortColumn struct {
+	name string
+	desc bool
+}
+
+// orderClause returns the ORDER BY expressions for the filter's sort, or the time
+// column in the default direction when it has none. Ties are broken by id so pages
+// and streams have a stable order.
+func orderClause(filter *domain.SensorDataFilter, defaultDesc bool) string {
+	return joinSortColumns(sortColumns(filter, defaultDesc))
+}
+
+// sortColumns returns the columns of the filter's sort up to and including id, adding
+// id to break ties if the sort does not end with it
+func sortColumns(filter *domain.SensorDataFilter, defaultDesc bool) []sortColumn {
+	sort := filter.Sort
+	if len(sort) == 0 {
+		sort = []domain.SortField{{Key: domain.SortTime, Desc: defaultDesc}}
+	}
+
+	var columns []sortColumn
+	for _, field := range sort {
+		switch field.Key {
+		case domain.SortValue:
+			columns = append(columns, sortColumn{"sd.sensor_value", field.Desc})
+		case domain.SortID:
+			// id is unique, so nothing after it changes the order
+			return append(columns, sortColumn{"sd.id", field.Desc})
+		default:
+			columns = append(columns, sortColumn{timeColumn(filter), field.Desc})
+		}
+	}
+	return append(columns, sortColumn{"sd.id", sort[len(sort)-1].Desc})
+}
+
+// joinSortColumns returns the ORDER BY expressions for sort columns
+func joinSortColumns(columns []sortColumn) string {
+	parts := make([]string, len(columns))
+	for i, column := range columns {
+		parts[i] = column.name
+		if column.desc {
+			parts[i] += " DESC"
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// keysetCondition returns a condition matching the records that follow a record in the
+// order of the sort columns: those after it in the first column, or equal to it there
+// and after it in the next one, and so on
+func keysetCondition(columns []sortColumn, after *domain.SensorData) (string, []interface{}) {
+	var alternatives []string
+	var args, equalArgs []interface{}
+	var equal []string
+	for _, column := range columns {
+		var value interface{}
+		switch column.name {
+		case "sd.sensor_value":
+			value = after.SensorValue
+		case "sd.created_at":
+			value = after.CreatedAt
+		case "sd.event_time":
+			value = after.EventTime
+		default:
+			value = after.ID
+		}
+
+		comparison := " > ?"
+		if column.desc {
+			comparison = " < ?"
+		}
+		alternatives = append(alternatives, "("+strings.Join(append(equal, column.name+comparison), " AND ")+")")
+		args = append(append(args, equalArgs...), value)
+
+		equal = append(equal, column.name+" = ?")
+		equalArgs = append(equalArgs, value)
+	}
+	return "(" + strings.Join(alternatives, " OR ") + ")", args
+}
+
+// timeColumn returns the column the filter's time range and ordering apply to
+func timeColumn(filter *domain.SensorDataFilter) string {
+	if filter.TimeField == domain.TimeFieldIngest {
+		return "sd.created_at"
+	}
+	return "sd.event_time"
+}
@@ -0,0 +1,217 @@
+package mysql
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"sensor_project/microservice-b/internal/domain"
+
+	mysqlDriver "github.com/go-sql-driver/mysql"
+)
+
+// MySQL error numbers mapped to domain errors
+const (
+	errDuplicateEntry     = 1062
+	errRowIsReferenced    = 1451
+	errRowIsReferencedOld = 1217
+)
+
+// sensorTypeColumns lists the sensor_types columns in the order scanSensorType reads them
+const sensorTypeColumns = `id, name, unit, description, min_value, max_value, max_rate_of_change,
+	id1_pattern, id2_pattern, validation_action, created_at, updated_at`
+
+// MySQLSensorTypeRepository implements the SensorTypeRepository interface
+type MySQLSensorTypeRepository struct {
+	db *sql.DB
+}
+
+// NewMySQLSensorTypeRepository creates a new MySQL sensor type repository
+func NewMySQLSensorTypeRepository(db *sql.DB) domain.SensorTypeRepository {
+	return &MySQLSensorTypeRepository{
+		db: db,
+	}
+}
+
+// Create saves a new sensor type and sets its ID and timestamps
+func (r *MySQLSensorTypeRepository) Create(sensorType *domain.SensorType) error {
+	now := time.Now().UnixMilli()
+	query := `
+		INSERT INTO sensor_types (name, unit, description, min_value, max_value, max_rate_of_change,
+			id1_pattern, id2_pattern, validation_action, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	result, err := r.db.Exec(
+		query,
+		sensorType.Name,
+		sensorType.Unit,
+		sensorType.Description,
+		sensorType.MinValue,
+		sensorType.MaxValue,
+		sensorType.MaxRateOfChange,
+		sensorType.ID1Pattern,
+		sensorType.ID2Pattern,
+		sensorType.ValidationAction,
+		now,
+		now,
+	)
+	if err != nil {
+		return mapSensorTypeError(err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	sensorType.ID = int(id)
+	sensorType.CreatedAt = now
+	sensorType.UpdatedAt = now
+	return nil
+}
+
+// GetByID retrieves a sensor type by ID
+func (r *MySQLSensorTypeRepository) GetByID(id int) (*domain.SensorType, error) {
+	query := `SELECT ` + sensorTypeColumns + ` FROM sensor_types WHERE id = ?`
+	return r.getOne(query, id)
+}
+
+// GetByName retrieves a sensor type by name
+func (r *MySQLSensorTypeRepository) GetByName(name string) (*domain.SensorType, error) {
+	query := `SELECT ` + sensorTypeColumns + ` FROM sensor_types WHERE name = ?`
+	return r.getOne(query, name)
+}
+
+// List retrieves all sensor types ordered by name
+func (r *MySQLSensorTypeRepository) List() ([]*domain.SensorType, error) {
+	query := `SELECT ` + sensorTypeColumns + ` FROM sensor_types ORDER BY name`
+
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []*domain.SensorType
+	for rows.Next() {
+		sensorType, err := scanSensorType(rows)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, sensorType)
+	}
+
+	return results, rows.Err()
+}
+
+// Update replaces the fields of an existing sensor type
+func (r *MySQLSensorTypeRepository) Update(sensorType *domain.SensorType) error {
+	now := time.Now().UnixMilli()
+	query := `
+		UPDATE sensor_types
+		SET name = ?, unit = ?, description = ?, min_value = ?, max_value = ?, max_rate_of_change = ?,
+			id1_pattern = ?, id2_pattern = ?, validation_action = ?, updated_at = ?
+		WHERE id = ?
+	`
+
+	_, err := r.db.Exec(
+		query,
+		sensorType.Name,
+		sensorType.Unit,
+		sensorType.Description,
+		sensorType.MinValue,
+		sensorType.MaxValue,
+		sensorType.MaxRateOfChange,
+		sensorType.ID1Pattern,
+		sensorType.ID2Pattern,
+		sensorType.ValidationAction,
+		now,
+		sensorType.ID,
+	)
+	if err != nil {
+		return mapSensorTypeError(err)
+	}
+
+	sensorType.UpdatedAt = now
+	return nil
+}
+
+// Delete removes a sensor type by ID
+func (r *MySQLSensorTypeRepository) Delete(id int) error {
+	query := `DELETE FROM sensor_types WHERE id = ?`
+	_, err := r.db.Exec(query, id)
+	return mapSensorTypeError(err)
+}
+
+// getOne runs a query returning at most one sensor type; a missing row yields nil
+func (r *MySQLSensorTypeRepository) getOne(query string, arg interface{}) (*domain.SensorType, error) {
+	sensorType, err := scanSensorType(r.db.QueryRow(query, arg))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return sensorType, nil
+}
+
+// rowScanner is implemented by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanSensorType reads a sensor type from a result row
+func scanSensorType(row rowScanner) (*domain.SensorType, error) {
+	var sensorType domain.SensorType
+	var unit, description, id1Pattern, id2Pattern sql.NullString
+	var minValue, maxValue, maxRateOfChange sql.NullFloat64
+
+	err := row.Scan(
+		&sensorType.ID,
+		&sensorType.Name,
+		&unit,
+		&description,
+		&minValue,
+		&maxValue,
+		&maxRateOfChange,
+		&id1Pattern,
+		&id2Pattern,
+		&sensorType.ValidationAction,
+		&sensorType.CreatedAt,
+		&sensorType.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	sensorType.Unit = unit.String
+	sensorType.Description = description.String
+	sensorType.ID1Pattern = id1Pattern.String
+	sensorType.ID2Pattern = id2Pattern.String
+	if minValue.Valid {
+		sensorType.MinValue = &minValue.Float64
+	}
+	if maxValue.Valid {
+		sensorType.MaxValue = &maxValue.Float64
+	}
+	if maxRateOfChange.Valid {
+		sensorType.MaxRateOfChange = &maxRateOfChange.Float64
+	}
+
+	return &sensorType, nil
+}
+
+// mapSensorTypeError converts constraint violations to domain errors
+func mapSensorTypeError(err error) error {
+	var mysqlErr *mysqlDriver.MySQLError
+	if errors.As(err, &mysqlErr) {
+		switch mysqlErr.Number {
+		case errDuplicateEntry:
+			return domain.ErrSensorTypeExists
+		case errRowIsReferenced, errRowIsReferencedOld:
+			return domain.ErrSensorTypeInUse
+		}
+	}
+	return err
+}
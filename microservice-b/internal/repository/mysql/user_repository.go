@@ -0,0 +1,302 @@
+package mysql
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"sensor_project/microservice-b/internal/domain"
+
+	mysqlDriver "github.com/go-sql-driver/mysql"
+)
+
+// errNoReferencedRow is the MySQL error number for a foreign key pointing at a missing row
+const errNoReferencedRow = 1452
+
+// userColumns lists the users columns in the order scanUser reads them
+const userColumns = `id, username, password_hash, email, disabled_at, created_at, updated_at`
+
+// MySQLUserRepository implements the UserRepository interface
+type MySQLUserRepository struct {
+	db *sql.DB
+}
+
+// NewMySQLUserRepository creates a new MySQL user repository
+func NewMySQLUserRepository(db *sql.DB) domain.UserRepository {
+	return &MySQLUserRepository{
+		db: db,
+	}
+}
+
+// Create saves a new user with their roles and sets their ID and timestamps
+func (r *MySQLUserRepository) Create(user *domain.User) error {
+	now := time.Now()
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO users (username, password_hash, email, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?)
+	`
+
+	result, err := tx.Exec(query, user.Username, user.PasswordHash, user.Email, now.UnixMilli(), now.UnixMilli())
+	if err != nil {
+		return mapUserError(err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	for _, role := range user.Roles {
+		if err := addRole(tx, int(id), role); err != nil {
+			return err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	user.ID = int(id)
+	user.CreatedAt = now
+	user.UpdatedAt = now
+	return nil
+}
+
+// GetByID retrieves a user and their roles by ID
+func (r *MySQLUserRepository) GetByID(id int) (*domain.User, error) {
+	query := `SELECT ` + userColumns + ` FROM users WHERE id = ?`
+	return r.getOne(query, id)
+}
+
+// GetByUsername retrieves a user and their roles by username
+func (r *MySQLUserRepository) GetByUsername(username string) (*domain.User, error) {
+	query := `SELECT ` + userColumns + ` FROM users WHERE username = ?`
+	return r.getOne(query, username)
+}
+
+// List retrieves all users and their roles ordered by username
+func (r *MySQLUserRepository) List() ([]*domain.User, error) {
+	query := `SELECT ` + userColumns + ` FROM users ORDER BY username`
+
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := []*domain.User{}
+	byID := make(map[int]*domain.User)
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+		byID[user.ID] = user
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// Load the roles of every user in one query
+	roleQuery := `
+		SELECT user_roles.user_id, roles.name
+		FROM user_roles
+		JOIN roles ON roles.id = user_roles.role_id
+		ORDER BY roles.name
+	`
+
+	roleRows, err := r.db.Query(roleQuery)
+	if err != nil {
+		return nil, err
+	}
+	defer roleRows.Close()
+
+	for roleRows.Next() {
+		var userID int
+		var role string
+		if err := roleRows.Scan(&userID, &role); err != nil {
+			return nil, err
+		}
+		if user, ok := byID[userID]; ok {
+			user.Roles = append(user.Roles, role)
+		}
+	}
+	return users, roleRows.Err()
+}
+
+// Update saves the username and email of a user
+func (r *MySQLUserRepository) Update(user *domain.User) error {
+	now := time.Now()
+	query := `UPDATE users SET username = ?, email = ?, updated_at = ? WHERE id = ?`
+
+	if _, err := r.db.Exec(query, user.Username, user.Email, now.UnixMilli(), user.ID); err != nil {
+		return mapUserError(err)
+	}
+
+	user.UpdatedAt = now
+	return nil
+}
+
+// SetPassword replaces the password hash of a user
+func (r *MySQLUserRepository) SetPassword(id int, passwordHash string) error {
+	query := `UPDATE users SET password_hash = ?, updated_at = ? WHERE id = ?`
+	_, err := r.db.Exec(query, passwordHash, time.Now().UnixMilli(), id)
+	return err
+}
+
+// SetDisabled disables a user as of disabledAt, or enables them if it is nil
+func (r *MySQLUserRepository) SetDisabled(id int, disabledAt *time.Time) error {
+	query := `UPDATE users SET disabled_at = ?, updated_at = ? WHERE id = ?`
+	_, err := r.db.Exec(query, nullableMillis(disabledAt), time.Now().UnixMilli(), id)
+	return err
+}
+
+// Delete removes a user; their roles and API keys are removed with them
+func (r *MySQLUserRepository) Delete(id int) error {
+	query := `DELETE FROM users WHERE id = ?`
+	_, err := r.db.Exec(query, id)
+	return err
+}
+
+// AddRole assigns a role to a user; assigning a role the user has is a no-op
+func (r *MySQLUserRepository) AddRole(id int, role string) error {
+	return addRole(r.db, id, role)
+}
+
+// RemoveRole unassigns a role from a user; removing a role the user lacks is a no-op
+func (r *MySQLUserRepository) RemoveRole(id int, role string) error {
+	query := `
+		DELETE user_roles FROM user_roles
+		JOIN roles ON roles.id = user_roles.role_id
+		WHERE user_roles.user_id = ? AND roles.name = ?
+	`
+	_, err := r.db.Exec(query, id, role)
+	return err
+}
+
+// CountActiveWithRole counts the users with a role who are not disabled
+func (r *MySQLUserRepository) CountActiveWithRole(role string) (int, error) {
+	query := `
+		SELECT COUNT(*)
+		FROM users
+		JOIN user_roles ON user_roles.user_id = users.id
+		JOIN roles ON roles.id = user_roles.role_id
+		WHERE roles.name = ? AND users.disabled_at IS NULL
+	`
+
+	var count int
+	err := r.db.QueryRow(query, role).Scan(&count)
+	return count, err
+}
+
+// getOne runs a query selecting at most one user and loads their roles, returning nil
+// if no user matched
+func (r *MySQLUserRepository) getOne(query string, arg interface{}) (*domain.User, error) {
+	user, err := scanUser(r.db.QueryRow(query, arg))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	roles, err := r.getRoles(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	user.Roles = roles
+	return user, nil
+}
+
+// getRoles lists the names of the roles assigned to a user
+func (r *MySQLUserRepository) getRoles(userID int) ([]string, error) {
+	query := `
+		SELECT roles.name
+		FROM user_roles
+		JOIN roles ON roles.id = user_roles.role_id
+		WHERE user_roles.user_id = ?
+		ORDER BY roles.name
+	`
+
+	rows, err := r.db.Query(query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	roles := []string{}
+	for rows.Next() {
+		var role string
+		if err := rows.Scan(&role); err != nil {
+			return nil, err
+		}
+		roles = append(roles, role)
+	}
+	return roles, rows.Err()
+}
+
+// dbOrTx is implemented by both *sql.DB and *sql.Tx
+type dbOrTx interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// addRole assigns a role by name to a user, returning ErrRoleNotFound for an unknown role
+func addRole(db dbOrTx, userID int, role string) error {
+	var roleID int
+	err := db.QueryRow(`SELECT id FROM roles WHERE name = ?`, role).Scan(&roleID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.ErrRoleNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	query := `INSERT IGNORE INTO user_roles (user_id, role_id) VALUES (?, ?)`
+	_, err = db.Exec(query, userID, roleID)
+	return mapUserError(err)
+}
+
+// scanUser reads a user without their roles from a result row
+func scanUser(row rowScanner) (*domain.User, error) {
+	var user domain.User
+	var disabledAt sql.NullInt64
+	var createdAt, updatedAt int64
+
+	err := row.Scan(
+		&user.ID,
+		&user.Username,
+		&user.PasswordHash,
+		&user.Email,
+		&disabledAt,
+		&createdAt,
+		&updatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	user.DisabledAt = timeFromNullMillis(disabledAt)
+	user.CreatedAt = time.UnixMilli(createdAt)
+	user.UpdatedAt = time.UnixMilli(updatedAt)
+	return &user, nil
+}
+
+// mapUserError converts MySQL errors of user writes to domain errors
+func mapUserError(err error) error {
+	var mysqlErr *mysqlDriver.MySQLError
+	if errors.As(err, &mysqlErr) {
+		switch mysqlErr.Number {
+		case errDuplicateEntry:
+			return domain.ErrUserExists
+		case errNoReferencedRow:
+			return domain.ErrUserNotFound
+		}
+	}
+	return err
+}
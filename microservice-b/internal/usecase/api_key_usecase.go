@@ -0,0 +1,190 @@
+package usecase
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"sensor_project/microservice-b/internal/domain"
+)
+
+// apiKeyPrefix starts every API key, so leaked keys are easy to recognise
+const apiKeyPrefix = "sk_"
+
+// apiKeyDisplayLength is how many leading characters of a key are kept to tell keys apart
+const apiKeyDisplayLength = 12
+
+// maxAPIKeyNameLength matches the size of the api_keys.name column
+const maxAPIKeyNameLength = 100
+
+// apiKeyCacheTTL is how long a validated key is trusted before it is looked up again.
+// Ingest validates a key per reading, so this saves a query per reading.
+const apiKeyCacheTTL = 30 * time.Second
+
+// cachedAPIKey is a validated key and its user
+type cachedAPIKey struct {
+	key       *domain.APIKey
+	user      *domain.User
+	expiresAt time.Time
+}
+
+// APIKeyUseCase implements the domain.APIKeyUseCase interface. Keys are random secrets
+// stored as their SHA-256 hash, which is enough for secrets this long.
+type APIKeyUseCase struct {
+	repo     domain.APIKeyRepository
+	userRepo domain.UserRepository
+
+	mu    sync.Mutex
+	cache map[string]cachedAPIKey
+}
+
+// NewAPIKeyUseCase creates a new API key use case
+func NewAPIKeyUseCase(repo domain.APIKeyRepository, userRepo domain.UserRepository) *APIKeyUseCase {
+	return &APIKeyUseCase{
+		repo:     repo,
+		userRepo: userRepo,
+		cache:    make(map[string]cachedAPIKey),
+	}
+}
+
+// Create issues a new API key for key.UserID with the key's name, scope and expiry. The
+// returned key carries its secret, which cannot be retrieved again.
+func (uc *APIKeyUseCase) Create(key *domain.APIKey) (*domain.APIKey, error) {
+	if err := validateAPIKey(key); err != nil {
+		return nil, err
+	}
+	user, err := uc.userRepo.GetByID(key.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, fmt.Errorf("%w: user %d does not exist", domain.ErrInvalidInput, key.UserID)
+	}
+
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+	secret := apiKeyPrefix + base64.RawURLEncoding.EncodeToString(b)
+
+	created := *key
+	created.Key = secret
+	created.Prefix = secret[:apiKeyDisplayLength]
+	created.RevokedAt = nil
+	created.CreatedAt = time.Now()
+	if err := uc.repo.Create(&created, hashAPIKey(secret)); err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+// List retrieves the API keys of a user, or every key if userID is 0
+func (uc *APIKeyUseCase) List(userID int) ([]*domain.APIKey, error) {
+	return uc.repo.List(userID)
+}
+
+// Revoke revokes an API key of a user, or any key if userID is 0. The key stops
+// working immediately.
+func (uc *APIKeyUseCase) Revoke(id, userID int) error {
+	key, err := uc.repo.GetByID(id)
+	if err != nil {
+		return err
+	}
+	if key == nil || (userID != 0 && key.UserID != userID) {
+		return domain.ErrAPIKeyNotFound
+	}
+
+	if err := uc.repo.Revoke(id, time.Now()); err != nil {
+		return err
+	}
+
+	uc.mu.Lock()
+	defer uc.mu.Unlock()
+	for hash, cached := range uc.cache {
+		if cached.key.ID == id {
+			delete(uc.cache, hash)
+		}
+	}
+	return nil
+}
+
+// Validate returns the API key matching a secret and the user it acts as, or
+// ErrInvalidAPIKey if the key is unknown, expired or revoked or its user is disabled
+func (uc *APIKeyUseCase) Validate(secret string) (*domain.APIKey, *domain.User, error) {
+	if !strings.HasPrefix(secret, apiKeyPrefix) {
+		return nil, nil, domain.ErrInvalidAPIKey
+	}
+	hash := hashAPIKey(secret)
+	now := time.Now()
+
+	uc.mu.Lock()
+	cached, ok := uc.cache[hash]
+	uc.mu.Unlock()
+	if ok && now.Before(cached.expiresAt) {
+		if !cached.key.Active(now) {
+			return nil, nil, domain.ErrInvalidAPIKey
+		}
+		return cached.key, cached.user, nil
+	}
+
+	key, err := uc.repo.GetByHash(hash)
+	if err != nil {
+		return nil, nil, err
+	}
+	if key == nil || !key.Active(now) {
+		return nil, nil, domain.ErrInvalidAPIKey
+	}
+	user, err := uc.userRepo.GetByID(key.UserID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if user == nil || user.DisabledAt != nil {
+		return nil, nil, domain.ErrInvalidAPIKey
+	}
+
+	uc.mu.Lock()
+	defer uc.mu.Unlock()
+	for h, c := range uc.cache {
+		if !now.Before(c.expiresAt) {
+			delete(uc.cache, h)
+		}
+	}
+	uc.cache[hash] = cachedAPIKey{key: key, user: user, expiresAt: now.Add(apiKeyCacheTTL)}
+	return key, user, nil
+}
+
+// validateAPIKey checks the name, scope and expiry of a key to be issued
+func validateAPIKey(key *domain.APIKey) error {
+	key.Name = strings.TrimSpace(key.Name)
+	if key.Name == "" {
+		return fmt.Errorf("%w: name is required", domain.ErrInvalidInput)
+	}
+	if len(key.Name) > maxAPIKeyNameLength {
+		return fmt.Errorf("%w: name must be at most %d characters", domain.ErrInvalidInput, maxAPIKeyNameLength)
+	}
+	for _, sensorType := range key.SensorTypes {
+		if strings.TrimSpace(sensorType) == "" {
+			return fmt.Errorf("%w: sensor_types must not contain empty names", domain.ErrInvalidInput)
+		}
+	}
+	for _, device := range key.Devices {
+		if strings.TrimSpace(device) == "" {
+			return fmt.Errorf("%w: devices must not contain empty IDs", domain.ErrInvalidInput)
+		}
+	}
+	if key.ExpiresAt != nil && !key.ExpiresAt.After(time.Now()) {
+		return fmt.Errorf("%w: expires_at must be in the future", domain.ErrInvalidInput)
+	}
+	return nil
+}
+
+// hashAPIKey returns the hex SHA-256 hash an API key is stored under
+func hashAPIKey(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,47 @@
+package usecase
+
+import (
+	"fmt"
+
+	"sensor_project/microservice-b/internal/domain"
+)
+
+// Number of audit entries returned by default and at most
+const (
+	defaultAuditLimit = 100
+	maxAuditLimit     = 1000
+)
+
+// AuditUseCase implements the domain.AuditUseCase interface
+type AuditUseCase struct {
+	repo domain.AuditRepository
+}
+
+// NewAuditUseCase creates a new audit use case
+func NewAuditUseCase(repo domain.AuditRepository) *AuditUseCase {
+	return &AuditUseCase{
+		repo: repo,
+	}
+}
+
+// List retrieves the audit entries matching the filter, newest first. A zero limit
+// returns defaultAuditLimit entries.
+func (uc *AuditUseCase) List(filter *domain.AuditFilter) ([]*domain.AuditEntry, error) {
+	switch filter.Operation {
+	case "", domain.AuditUpdate, domain.AuditDelete, domain.AuditDeleteByFilter, domain.AuditRestore:
+	default:
+		return nil, fmt.Errorf("%w: operation must be %s, %s, %s or %s", domain.ErrInvalidInput,
+			domain.AuditUpdate, domain.AuditDelete, domain.AuditDeleteByFilter, domain.AuditRestore)
+	}
+	if filter.StartTime != nil && filter.EndTime != nil && filter.StartTime.After(*filter.EndTime) {
+		return nil, fmt.Errorf("%w: start_time must not be after end_time", domain.ErrInvalidInput)
+	}
+	if filter.Limit < 0 || filter.Limit > maxAuditLimit {
+		return nil, fmt.Errorf("%w: limit must be between 1 and %d", domain.ErrInvalidInput, maxAuditLimit)
+	}
+	if filter.Limit == 0 {
+		filter.Limit = defaultAuditLimit
+	}
+
+	return uc.repo.List(filter)
+}
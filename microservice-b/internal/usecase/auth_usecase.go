@@ -0,0 +1,161 @@
+package usecase
+
+import (
+	"strconv"
+	"time"
+
+	"sensor_project/microservice-b/internal/domain"
+
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Values of the token_type claim telling access and refresh tokens apart
+const (
+	tokenTypeAccess  = "access"
+	tokenTypeRefresh = "refresh"
+)
+
+// dummyPasswordHash is compared against when a username is unknown, so failed logins
+// take as long whether or not the user exists
+var dummyPasswordHash = []byte("$2a$10$PJL9a1Vzz4atyaxQqXKRqOzmfWw8ChB4v8wrOr5hKkZha63gClxlC")
+
+// tokenClaims are the claims of the tokens issued by AuthUseCase. The subject is the
+// user ID.
+type tokenClaims struct {
+	Username  string   `json:"username"`
+	Roles     []string `json:"roles,omitempty"`
+	TokenType string   `json:"token_type"`
+	jwt.RegisteredClaims
+}
+
+// AuthUseCase implements the domain.AuthUseCase interface with bcrypt password hashes
+// and HS256-signed JWTs
+type AuthUseCase struct {
+	repo          domain.UserRepository
+	secret        []byte
+	accessExpiry  time.Duration
+	refreshExpiry time.Duration
+}
+
+// NewAuthUseCase creates an auth use case signing tokens with secret. Access tokens are
+// valid for accessExpiry and refresh tokens for refreshExpiry.
+func NewAuthUseCase(repo domain.UserRepository, secret string, accessExpiry, refreshExpiry time.Duration) *AuthUseCase {
+	return &AuthUseCase{
+		repo:          repo,
+		secret:        []byte(secret),
+		accessExpiry:  accessExpiry,
+		refreshExpiry: refreshExpiry,
+	}
+}
+
+// Authenticate checks a username and password, returning ErrInvalidCredentials if they
+// do not match a user or the user is disabled
+func (uc *AuthUseCase) Authenticate(username, password string) (*domain.User, error) {
+	user, err := uc.repo.GetByUsername(username)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		bcrypt.CompareHashAndPassword(dummyPasswordHash, []byte(password))
+		return nil, domain.ErrInvalidCredentials
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
+		return nil, domain.ErrInvalidCredentials
+	}
+	if user.DisabledAt != nil {
+		return nil, domain.ErrInvalidCredentials
+	}
+	return user, nil
+}
+
+// GenerateTokens issues an access token carrying the user's roles and a refresh token
+func (uc *AuthUseCase) GenerateTokens(user *domain.User) (*domain.AuthTokens, error) {
+	now := time.Now()
+	tokens := &domain.AuthTokens{
+		AccessExpiresAt:  now.Add(uc.accessExpiry),
+		RefreshExpiresAt: now.Add(uc.refreshExpiry),
+	}
+
+	var err error
+	tokens.AccessToken, err = uc.sign(user, tokenTypeAccess, now, tokens.AccessExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+	tokens.RefreshToken, err = uc.sign(user, tokenTypeRefresh, now, tokens.RefreshExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+	return tokens, nil
+}
+
+// Refresh exchanges a valid refresh token for new tokens. The user is looked up again,
+// so the new access token carries their current roles.
+func (uc *AuthUseCase) Refresh(refreshToken string) (*domain.AuthTokens, error) {
+	user, err := uc.tokenUser(refreshToken, tokenTypeRefresh)
+	if err != nil {
+		return nil, err
+	}
+	return uc.GenerateTokens(user)
+}
+
+// ValidateToken checks an access token and returns the user it was issued to. The user
+// is looked up again, so disabling or deleting them, or changing their roles, applies
+// to tokens already issued.
+func (uc *AuthUseCase) ValidateToken(token string) (*domain.User, error) {
+	return uc.tokenUser(token, tokenTypeAccess)
+}
+
+// tokenUser returns the current state of the user a token of the given type was issued
+// to, or ErrInvalidToken if the token is invalid or the user is gone or disabled
+func (uc *AuthUseCase) tokenUser(token, tokenType string) (*domain.User, error) {
+	claims, err := uc.parse(token, tokenType)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := strconv.Atoi(claims.Subject)
+	if err != nil {
+		return nil, domain.ErrInvalidToken
+	}
+	user, err := uc.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil || user.DisabledAt != nil {
+		return nil, domain.ErrInvalidToken
+	}
+	return user, nil
+}
+
+// sign creates a signed token of the given type for a user
+func (uc *AuthUseCase) sign(user *domain.User, tokenType string, issuedAt, expiresAt time.Time) (string, error) {
+	claims := tokenClaims{
+		Username:  user.Username,
+		TokenType: tokenType,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   strconv.Itoa(user.ID),
+			IssuedAt:  jwt.NewNumericDate(issuedAt),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+	}
+	if tokenType == tokenTypeAccess {
+		claims.Roles = user.Roles
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(uc.secret)
+}
+
+// parse verifies the signature and expiry of a token of the given type and returns its
+// claims, or ErrInvalidToken
+func (uc *AuthUseCase) parse(token, tokenType string) (*tokenClaims, error) {
+	var claims tokenClaims
+	_, err := jwt.ParseWithClaims(token, &claims, func(*jwt.Token) (interface{}, error) {
+		return uc.secret, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+	// Every token issued here expires, so one without an expiry is not ours
+	if err != nil || claims.ExpiresAt == nil || claims.TokenType != tokenType {
+		return nil, domain.ErrInvalidToken
+	}
+	return &claims, nil
+}
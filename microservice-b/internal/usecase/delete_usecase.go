@@ -0,0 +1,202 @@
+package usecase
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"sensor_project/microservice-b/internal/domain"
+)
+
+// DeleteUseCase implements the domain.DeleteUseCase interface. Deletes matching up to
+// maxRows records run in the request; larger ones run in the background in chunks
+// of batchSize records, tracked in memory. Deleted records form a deletion batch that
+// can be restored until they are purged after gracePeriod.
+type DeleteUseCase struct {
+	repo        domain.SensorDataRepository
+	maxRows     int
+	batchSize   int
+	gracePeriod time.Duration
+
+	mu   sync.Mutex
+	jobs map[string]*domain.DeleteJob
+}
+
+// NewDeleteUseCase creates a delete use case that deletes at most maxRows records in
+// one request and batchSize records per statement in background jobs, and reports
+// deleted records as purged gracePeriod after their deletion
+func NewDeleteUseCase(repo domain.SensorDataRepository, maxRows, batchSize int, gracePeriod time.Duration) *DeleteUseCase {
+	return &DeleteUseCase{
+		repo:        repo,
+		maxRows:     maxRows,
+		batchSize:   batchSize,
+		gracePeriod: gracePeriod,
+		jobs:        make(map[string]*domain.DeleteJob),
+	}
+}
+
+// DeleteByFilter marks the sensor data records matching the filter deleted in a new
+// deletion batch. An empty filter is refused unless opts.All is set, and a dry run only
+// counts the matching records. A background job uses its ID as the batch ID.
+func (uc *DeleteUseCase) DeleteByFilter(filter *domain.SensorDataFilter, opts domain.DeleteOptions) (*domain.DeleteResult, error) {
+	filter.IncludeDeleted = false
+	if isEmptyFilter(filter) && !opts.All {
+		return nil, domain.ErrEmptyFilter
+	}
+	if err := validateFilter(filter); err != nil {
+		return nil, err
+	}
+
+	matched, err := uc.repo.Count(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &domain.DeleteResult{Matched: int64(matched), DryRun: opts.DryRun}
+	if opts.DryRun || matched == 0 {
+		return result, nil
+	}
+
+	if matched <= uc.maxRows {
+		batchID, err := newJobID()
+		if err != nil {
+			return nil, err
+		}
+		deleted, err := uc.repo.DeleteByFilter(filter, batchID, opts.Actor)
+		if err != nil {
+			return nil, err
+		}
+		result.Deleted = int64(deleted)
+		result.BatchID = batchID
+		return result, nil
+	}
+
+	job, err := uc.startJob(filter, opts.Actor, int64(matched))
+	if err != nil {
+		return nil, err
+	}
+	result.Job = job
+	result.BatchID = job.ID
+	return result, nil
+}
+
+// ListDeletions lists the deletion batches that can still be restored, most recent first
+func (uc *DeleteUseCase) ListDeletions() ([]*domain.DeletionBatch, error) {
+	batches, err := uc.repo.ListDeletions()
+	if err != nil {
+		return nil, err
+	}
+	for _, batch := range batches {
+		batch.PurgeAt = batch.DeletedAt + uc.gracePeriod.Milliseconds()
+	}
+	return batches, nil
+}
+
+// Restore undeletes the records of a deletion batch on behalf of actor and returns how
+// many were restored. Restoring a batch whose job is still running only restores the
+// records deleted so far.
+func (uc *DeleteUseCase) Restore(batchID string, actor domain.Actor) (int, error) {
+	restored, err := uc.repo.Restore(batchID, actor)
+	if err != nil {
+		return 0, err
+	}
+	if restored == 0 {
+		return 0, domain.ErrDeletionBatchNotFound
+	}
+	return restored, nil
+}
+
+// GetJob returns the current state of a delete job, or nil if it is unknown or expired
+func (uc *DeleteUseCase) GetJob(id string) *domain.DeleteJob {
+	uc.mu.Lock()
+	defer uc.mu.Unlock()
+
+	job, ok := uc.jobs[id]
+	if !ok {
+		return nil
+	}
+	snapshot := *job
+	return &snapshot
+}
+
+// startJob starts deleting the records matching the filter in the background on behalf
+// of actor. Only records stored by the time the job starts are deleted, so the job ends
+// even while matching readings keep arriving.
+func (uc *DeleteUseCase) startJob(filter *domain.SensorDataFilter, actor domain.Actor, matched int64) (*domain.DeleteJob, error) {
+	id, err := newJobID()
+	if err != nil {
+		return nil, err
+	}
+	maxID, err := uc.repo.MaxID()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	job := &domain.DeleteJob{
+		ID:        id,
+		Status:    domain.JobStatusRunning,
+		Matched:   matched,
+		CreatedAt: now.UnixMilli(),
+	}
+
+	uc.mu.Lock()
+	uc.pruneJobs(now)
+	uc.jobs[id] = job
+	snapshot := *job
+	uc.mu.Unlock()
+
+	go uc.run(job, filter, maxID, actor)
+	return &snapshot, nil
+}
+
+// run deletes matching records with an id up to maxID one chunk at a time until none
+// are left, so no statement holds locks for long
+func (uc *DeleteUseCase) run(job *domain.DeleteJob, filter *domain.SensorDataFilter, maxID int64, actor domain.Actor) {
+	var err error
+	for {
+		var deleted int
+		deleted, err = uc.repo.DeleteBatch(filter, maxID, uc.batchSize, job.ID, actor)
+		if err != nil {
+			break
+		}
+
+		uc.mu.Lock()
+		job.Deleted += int64(deleted)
+		uc.mu.Unlock()
+
+		if deleted < uc.batchSize {
+			break
+		}
+	}
+
+	uc.mu.Lock()
+	defer uc.mu.Unlock()
+
+	job.FinishedAt = time.Now().UnixMilli()
+	if err != nil {
+		job.Status = domain.JobStatusFailed
+		job.Error = err.Error()
+		log.Printf("Delete job %s failed after %d rows: %v", job.ID, job.Deleted, err)
+		return
+	}
+	job.Status = domain.JobStatusCompleted
+	log.Printf("Delete job %s completed: %d rows deleted", job.ID, job.Deleted)
+}
+
+// pruneJobs forgets jobs that finished more than jobTTL ago
+func (uc *DeleteUseCase) pruneJobs(now time.Time) {
+	cutoff := now.Add(-jobTTL).UnixMilli()
+	for id, job := range uc.jobs {
+		if job.FinishedAt != 0 && job.FinishedAt < cutoff {
+			delete(uc.jobs, id)
+		}
+	}
+}
+
+// isEmptyFilter reports whether a filter matches every record
+func isEmptyFilter(filter *domain.SensorDataFilter) bool {
+	return len(filter.ID1) == 0 && len(filter.ID2) == 0 && filter.ID2Min == nil && filter.ID2Max == nil &&
+		len(filter.SensorType) == 0 && filter.MinValue == nil && filter.MaxValue == nil &&
+		filter.StartTime == nil && filter.EndTime == nil && filter.QualityFlags == nil
+}
@@ -0,0 +1,141 @@
+package usecase
+
+import (
+	"slices"
+	"strings"
+	"sync"
+
+	"sensor_project/microservice-b/internal/domain"
+)
+
+// LiveFeed implements the domain.SensorFeed interface in memory. Every subscriber has
+// a buffer of bufferSize readings; one whose buffer is full when a reading is published
+// is dropped rather than holding up the ingest path.
+type LiveFeed struct {
+	bufferSize int
+
+	mu   sync.Mutex
+	subs map[*feedSubscription]struct{}
+}
+
+// NewLiveFeed creates a feed that buffers up to bufferSize readings per subscriber
+func NewLiveFeed(bufferSize int) *LiveFeed {
+	return &LiveFeed{
+		bufferSize: bufferSize,
+		subs:       make(map[*feedSubscription]struct{}),
+	}
+}
+
+// Publish delivers a stored reading to every subscriber whose filter it matches
+func (f *LiveFeed) Publish(data *domain.SensorData) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for sub := range f.subs {
+		if !matchesFilter(&sub.filter, data) {
+			continue
+		}
+		select {
+		case sub.ch <- data:
+		default:
+			f.end(sub, domain.ErrSubscriberTooSlow)
+		}
+	}
+}
+
+// Subscribe starts delivering the readings matching the filter
+func (f *LiveFeed) Subscribe(filter *domain.SensorDataFilter) domain.FeedSubscription {
+	sub := &feedSubscription{
+		feed:   f,
+		filter: *filter,
+		ch:     make(chan *domain.SensorData, f.bufferSize),
+	}
+
+	f.mu.Lock()
+	f.subs[sub] = struct{}{}
+	f.mu.Unlock()
+
+	return sub
+}
+
+// end removes a subscription and closes its channel; the caller holds f.mu
+func (f *LiveFeed) end(sub *feedSubscription, err error) {
+	if _, ok := f.subs[sub]; !ok {
+		return
+	}
+	delete(f.subs, sub)
+	sub.err = err
+	close(sub.ch)
+}
+
+// feedSubscription is a subscriber of a LiveFeed
+type feedSubscription struct {
+	feed   *LiveFeed
+	filter domain.SensorDataFilter
+	ch     chan *domain.SensorData
+	err    error
+}
+
+// Readings returns the channel readings are delivered on
+func (s *feedSubscription) Readings() <-chan *domain.SensorData {
+	return s.ch
+}
+
+// Err reports why the subscription ended, once its channel is closed
+func (s *feedSubscription) Err() error {
+	s.feed.mu.Lock()
+	defer s.feed.mu.Unlock()
+	return s.err
+}
+
+// Close ends the subscription
+func (s *feedSubscription) Close() {
+	s.feed.mu.Lock()
+	defer s.feed.mu.Unlock()
+	s.feed.end(s, nil)
+}
+
+// matchesFilter reports whether a reading satisfies the criteria of a filter, as the
+// repository's WHERE clause would. Sensor types and id1 values compare case-insensitively
+// like the database's default collation.
+func matchesFilter(filter *domain.SensorDataFilter, data *domain.SensorData) bool {
+	if len(filter.ID1) > 0 && !containsFold(filter.ID1, data.ID1) {
+		return false
+	}
+	if len(filter.ID2) > 0 && !slices.Contains(filter.ID2, data.ID2) {
+		return false
+	}
+	if (filter.ID2Min != nil && data.ID2 < *filter.ID2Min) || (filter.ID2Max != nil && data.ID2 > *filter.ID2Max) {
+		return false
+	}
+	if len(filter.SensorType) > 0 && !containsFold(filter.SensorType, data.SensorType) {
+		return false
+	}
+	if (filter.MinValue != nil && data.SensorValue < *filter.MinValue) || (filter.MaxValue != nil && data.SensorValue > *filter.MaxValue) {
+		return false
+	}
+
+	// A zero mask selects readings without quality flags
+	if flags := filter.QualityFlags; flags != nil {
+		if *flags == 0 && data.QualityFlags != 0 || *flags != 0 && data.QualityFlags&*flags == 0 {
+			return false
+		}
+	}
+
+	t := data.EventTime
+	if filter.TimeField == domain.TimeFieldIngest {
+		t = data.CreatedAt
+	}
+	if (filter.StartTime != nil && t < filter.StartTime.UnixMilli()) || (filter.EndTime != nil && t > filter.EndTime.UnixMilli()) {
+		return false
+	}
+
+	return true
+}
+
+// containsFold reports whether values holds s, ignoring case
+func containsFold(values []string, s string) bool {
+	return slices.ContainsFunc(values, func(v string) bool {
+		return strings.EqualFold(v, s)
+	})
+}
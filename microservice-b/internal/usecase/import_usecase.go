@@ -0,0 +1,309 @@
+package usecase
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"sensor_project/microservice-b/internal/domain"
+	"sensor_project/microservice-b/internal/export"
+)
+
+// maxImportErrors limits the row errors kept in an import job's report
+const maxImportErrors = 1000
+
+// jobTTL is how long finished import and delete jobs can still be polled
+const jobTTL = 24 * time.Hour
+
+// ImportUseCase implements the domain.ImportUseCase interface. Uploaded files are
+// spooled to disk and imported in the background; jobs are tracked in memory.
+type ImportUseCase struct {
+	repo         domain.SensorDataRepository
+	typeRepo     domain.SensorTypeRepository
+	ingestPolicy domain.IngestPolicy
+	batchSize    int
+
+	mu   sync.Mutex
+	jobs map[string]*domain.ImportJob
+}
+
+// NewImportUseCase creates an import use case that stores valid rows in multi-row
+// inserts of batchSize rows
+func NewImportUseCase(repo domain.SensorDataRepository, typeRepo domain.SensorTypeRepository, ingestPolicy domain.IngestPolicy, batchSize int) *ImportUseCase {
+	return &ImportUseCase{
+		repo:         repo,
+		typeRepo:     typeRepo,
+		ingestPolicy: ingestPolicy,
+		batchSize:    batchSize,
+		jobs:         make(map[string]*domain.ImportJob),
+	}
+}
+
+// Start reads an import file from src and imports its rows in the background. It
+// returns the new job once the whole file has been received.
+func (uc *ImportUseCase) Start(format string, dryRun bool, src io.Reader) (*domain.ImportJob, error) {
+	if format != export.FormatCSV && format != export.FormatNDJSON {
+		return nil, fmt.Errorf("%w: format must be %s or %s", domain.ErrInvalidInput, export.FormatCSV, export.FormatNDJSON)
+	}
+
+	// Spool the file so the caller can return while its rows are imported
+	file, err := os.CreateTemp("", "sensor-import-*")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := io.Copy(file, src); err != nil {
+		file.Close()
+		os.Remove(file.Name())
+		return nil, err
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		file.Close()
+		os.Remove(file.Name())
+		return nil, err
+	}
+
+	id, err := newJobID()
+	if err != nil {
+		file.Close()
+		os.Remove(file.Name())
+		return nil, err
+	}
+
+	now := time.Now()
+	job := &domain.ImportJob{
+		ID:        id,
+		Status:    domain.JobStatusRunning,
+		Format:    format,
+		DryRun:    dryRun,
+		Errors:    []domain.ImportRowError{},
+		CreatedAt: now.UnixMilli(),
+	}
+
+	uc.mu.Lock()
+	uc.pruneJobs(now)
+	uc.jobs[id] = job
+	snapshot := copyImportJob(job)
+	uc.mu.Unlock()
+
+	go uc.run(job, file)
+	return snapshot, nil
+}
+
+// Get returns the current state of an import job, or nil if it is unknown or expired
+func (uc *ImportUseCase) Get(id string) *domain.ImportJob {
+	uc.mu.Lock()
+	defer uc.mu.Unlock()
+
+	job, ok := uc.jobs[id]
+	if !ok {
+		return nil
+	}
+	return copyImportJob(job)
+}
+
+// run imports a spooled file and records the outcome of the job
+func (uc *ImportUseCase) run(job *domain.ImportJob, file *os.File) {
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	err := uc.importRows(job, file)
+
+	uc.mu.Lock()
+	defer uc.mu.Unlock()
+
+	job.FinishedAt = time.Now().UnixMilli()
+	if err != nil {
+		job.Status = domain.JobStatusFailed
+		job.Error = err.Error()
+		log.Printf("Import job %s failed after %d rows: %v", job.ID, job.RowsRead, err)
+		return
+	}
+	job.Status = domain.JobStatusCompleted
+	log.Printf("Import job %s completed: %d rows read, %d imported, %d rejected", job.ID, job.RowsRead, job.RowsImported, job.RowsRejected)
+}
+
+// importRows validates every row of the file, in file order, and stores the valid ones
+// in batches. Each import checks rates of change against the earlier rows of the file
+// only. Row errors are reported in the job; any other error stops the import.
+func (uc *ImportUseCase) importRows(job *domain.ImportJob, r io.Reader) error {
+	reader, err := export.NewReader(job.Format, r)
+	if err != nil {
+		return err
+	}
+
+	validator := newReadingValidator()
+	types := make(map[string]*domain.SensorType)
+	createdAt := time.Now().UnixMilli()
+	batch := make([]*domain.SensorData, 0, uc.batchSize)
+
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		if !job.DryRun {
+			if err := uc.repo.StoreBatch(batch); err != nil {
+				return err
+			}
+		}
+		uc.mu.Lock()
+		job.RowsImported += int64(len(batch))
+		uc.mu.Unlock()
+		batch = batch[:0]
+		return nil
+	}
+
+	for {
+		data, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+
+		var rowErr *export.RowError
+		if errors.As(err, &rowErr) {
+			uc.reject(job, rowErr.Line, rowErr.Err)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		data.CreatedAt = createdAt
+		if err := uc.validateRow(data, job.DryRun, validator, types); err != nil {
+			if !isRowRejection(err) {
+				return err
+			}
+			uc.reject(job, reader.Line(), err)
+			continue
+		}
+		validator.Remember(data)
+
+		uc.mu.Lock()
+		job.RowsRead++
+		uc.mu.Unlock()
+
+		batch = append(batch, data)
+		if len(batch) >= uc.batchSize {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+
+	return flush()
+}
+
+// validateRow applies the ingest checks to an imported row. The event time may lie
+// arbitrarily far in the past, since imports backfill history.
+func (uc *ImportUseCase) validateRow(data *domain.SensorData, dryRun bool, validator *readingValidator, types map[string]*domain.SensorType) error {
+	latest := data.CreatedAt + uc.ingestPolicy.EventTime.ClockSkewTolerance.Milliseconds()
+	if data.EventTime > latest {
+		if uc.ingestPolicy.EventTime.FutureAction != domain.EventTimeClamp {
+			return fmt.Errorf("%w: %d ms ahead of import time", domain.ErrEventTimeInFuture, data.EventTime-data.CreatedAt)
+		}
+		data.EventTime = latest
+	}
+
+	if err := checkReading(data); err != nil {
+		return err
+	}
+
+	sensorType, err := uc.sensorType(data.SensorType, dryRun, types)
+	if err != nil {
+		return err
+	}
+
+	flags, err := validator.Validate(data, sensorType)
+	if err != nil {
+		return err
+	}
+	data.QualityFlags = flags
+	return nil
+}
+
+// isRowRejection reports whether a validation error rejects only the row, as opposed
+// to a failure that stops the import
+func isRowRejection(err error) bool {
+	return errors.Is(err, domain.ErrInvalidReading) ||
+		errors.Is(err, domain.ErrEventTimeInFuture) ||
+		errors.Is(err, domain.ErrUnknownSensorType)
+}
+
+// sensorType looks up a sensor type once per import. Unknown types are registered
+// under the auto_register policy, or in a dry run assumed to be, and rejected otherwise.
+func (uc *ImportUseCase) sensorType(name string, dryRun bool, types map[string]*domain.SensorType) (*domain.SensorType, error) {
+	if sensorType, ok := types[name]; ok {
+		if sensorType == nil {
+			return nil, fmt.Errorf("%w %q", domain.ErrUnknownSensorType, name)
+		}
+		return sensorType, nil
+	}
+
+	sensorType, err := uc.typeRepo.GetByName(name)
+	if err != nil {
+		return nil, err
+	}
+	if sensorType == nil && uc.ingestPolicy.UnknownSensorType == domain.UnknownTypeAutoRegister {
+		if dryRun {
+			sensorType = &domain.SensorType{Name: name}
+		} else {
+			if err := registerSensorType(uc.typeRepo, name); err != nil {
+				return nil, err
+			}
+			if sensorType, err = uc.typeRepo.GetByName(name); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	types[name] = sensorType
+	if sensorType == nil {
+		return nil, fmt.Errorf("%w %q", domain.ErrUnknownSensorType, name)
+	}
+	return sensorType, nil
+}
+
+// reject records a rejected row in the job report
+func (uc *ImportUseCase) reject(job *domain.ImportJob, line int64, reason error) {
+	uc.mu.Lock()
+	defer uc.mu.Unlock()
+
+	job.RowsRead++
+	job.RowsRejected++
+	if len(job.Errors) >= maxImportErrors {
+		job.ErrorsTruncated = true
+		return
+	}
+	job.Errors = append(job.Errors, domain.ImportRowError{Line: line, Error: reason.Error()})
+}
+
+// pruneJobs forgets jobs that finished more than jobTTL ago
+func (uc *ImportUseCase) pruneJobs(now time.Time) {
+	cutoff := now.Add(-jobTTL).UnixMilli()
+	for id, job := range uc.jobs {
+		if job.FinishedAt != 0 && job.FinishedAt < cutoff {
+			delete(uc.jobs, id)
+		}
+	}
+}
+
+// copyImportJob copies a job so it can be read while the import goes on
+func copyImportJob(job *domain.ImportJob) *domain.ImportJob {
+	snapshot := *job
+	snapshot.Errors = append([]domain.ImportRowError{}, job.Errors...)
+	return &snapshot
+}
+
+// newJobID generates a random job ID
+func newJobID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
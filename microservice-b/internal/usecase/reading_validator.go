@@ -0,0 +1,249 @@
+package usecase
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"sensor_project/microservice-b/internal/domain"
+)
+
+// maxPatternLength matches the size of the sensor_types pattern columns
+const maxPatternLength = 255
+
+// seriesKey identifies the readings of one sensor for rate of change checks
+type seriesKey struct {
+	sensorType string
+	id1        string
+	id2        int
+}
+
+// lastReading is the most recent stored reading of a series
+type lastReading struct {
+	value     float64
+	eventTime int64
+}
+
+// readingValidator runs the ingest validation pipeline. It remembers the last stored
+// reading of every series to check the rate of change, and caches compiled patterns.
+type readingValidator struct {
+	mu       sync.Mutex
+	last     map[seriesKey]lastReading
+	patterns map[string]*regexp.Regexp
+}
+
+// newReadingValidator creates a validator with no reading history
+func newReadingValidator() *readingValidator {
+	return &readingValidator{
+		last:     make(map[seriesKey]lastReading),
+		patterns: make(map[string]*regexp.Regexp),
+	}
+}
+
+// checkReading applies the rules every reading must pass regardless of its sensor type
+func checkReading(data *domain.SensorData) error {
+	if math.IsNaN(data.SensorValue) || math.IsInf(data.SensorValue, 0) {
+		return fmt.Errorf("%w: sensor value %v is not a finite number", domain.ErrInvalidReading, data.SensorValue)
+	}
+	if data.ID1 == "" {
+		return fmt.Errorf("%w: id1 is required", domain.ErrInvalidReading)
+	}
+	if len(data.ID1) > domain.MaxID1Length {
+		return fmt.Errorf("%w: id1 must be at most %d characters", domain.ErrInvalidReading, domain.MaxID1Length)
+	}
+	return checkReadingID(data.ReadingID)
+}
+
+// checkReadingID accepts an empty reading ID or one of up to MaxReadingIDLength
+// letters, digits and hyphens, such as a UUID or a ULID
+func checkReadingID(readingID string) error {
+	if len(readingID) > domain.MaxReadingIDLength {
+		return fmt.Errorf("%w: reading_id must be at most %d characters", domain.ErrInvalidReading, domain.MaxReadingIDLength)
+	}
+	for _, r := range readingID {
+		if !(r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r == '-') {
+			return fmt.Errorf("%w: reading_id may only contain letters, digits and hyphens", domain.ErrInvalidReading)
+		}
+	}
+	return nil
+}
+
+// Validate checks a reading against the rules of its sensor type. Depending on the
+// type's validation action a violation rejects the reading, clamps its value or only
+// flags it. It returns the quality flags to store with the reading.
+func (v *readingValidator) Validate(data *domain.SensorData, sensorType *domain.SensorType) (int, error) {
+	action := sensorType.ValidationAction
+	if action == "" {
+		action = domain.ValidationFlag
+	}
+
+	flags := 0
+	for _, rule := range []func(*domain.SensorData, *domain.SensorType, string) (int, error){
+		v.checkPatterns,
+		checkRange,
+		v.checkRate,
+	} {
+		ruleFlags, err := rule(data, sensorType, action)
+		if err != nil {
+			return 0, err
+		}
+		flags |= ruleFlags
+	}
+
+	return flags, nil
+}
+
+// Remember records a stored reading as the latest of its series
+func (v *readingValidator) Remember(data *domain.SensorData) {
+	key := seriesKey{data.SensorType, data.ID1, data.ID2}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if last, ok := v.last[key]; ok && last.eventTime > data.EventTime {
+		return
+	}
+	v.last[key] = lastReading{value: data.SensorValue, eventTime: data.EventTime}
+}
+
+// checkPatterns matches id1 and id2 against the sensor type's patterns
+func (v *readingValidator) checkPatterns(data *domain.SensorData, sensorType *domain.SensorType, action string) (int, error) {
+	ids := []struct {
+		name, pattern, value string
+	}{
+		{"id1", sensorType.ID1Pattern, data.ID1},
+		{"id2", sensorType.ID2Pattern, strconv.Itoa(data.ID2)},
+	}
+
+	for _, id := range ids {
+		if id.pattern == "" {
+			continue
+		}
+		re, err := v.compile(id.pattern)
+		if err != nil {
+			return 0, err
+		}
+		if re.MatchString(id.value) {
+			continue
+		}
+		if action == domain.ValidationReject {
+			return 0, fmt.Errorf("%w: %s %q does not match pattern %q", domain.ErrInvalidReading, id.name, id.value, id.pattern)
+		}
+		return domain.QualityPatternMismatch, nil
+	}
+
+	return 0, nil
+}
+
+// checkRange compares the value with the sensor type's minimum and maximum
+func checkRange(data *domain.SensorData, sensorType *domain.SensorType, action string) (int, error) {
+	bound := data.SensorValue
+	switch {
+	case sensorType.MinValue != nil && data.SensorValue < *sensorType.MinValue:
+		bound = *sensorType.MinValue
+	case sensorType.MaxValue != nil && data.SensorValue > *sensorType.MaxValue:
+		bound = *sensorType.MaxValue
+	default:
+		return 0, nil
+	}
+
+	switch action {
+	case domain.ValidationReject:
+		return 0, fmt.Errorf("%w: sensor value %g is outside the range of %s", domain.ErrInvalidReading, data.SensorValue, sensorType.Name)
+	case domain.ValidationClamp:
+		data.SensorValue = bound
+		return domain.QualityOutOfRange | domain.QualityClamped, nil
+	default:
+		return domain.QualityOutOfRange, nil
+	}
+}
+
+// checkRate compares the change since the last stored reading of the series with the
+// sensor type's maximum rate of change. Readings older than the last one are not checked.
+func (v *readingValidator) checkRate(data *domain.SensorData, sensorType *domain.SensorType, action string) (int, error) {
+	if sensorType.MaxRateOfChange == nil {
+		return 0, nil
+	}
+
+	v.mu.Lock()
+	last, ok := v.last[seriesKey{data.SensorType, data.ID1, data.ID2}]
+	v.mu.Unlock()
+	if !ok || data.EventTime <= last.eventTime {
+		return 0, nil
+	}
+
+	seconds := float64(data.EventTime-last.eventTime) / 1000
+	maxDelta := *sensorType.MaxRateOfChange * seconds
+	delta := data.SensorValue - last.value
+	if math.Abs(delta) <= maxDelta {
+		return 0, nil
+	}
+
+	switch action {
+	case domain.ValidationReject:
+		return 0, fmt.Errorf("%w: sensor value changed by %g in %gs, more than %s allows", domain.ErrInvalidReading, delta, seconds, sensorType.Name)
+	case domain.ValidationClamp:
+		data.SensorValue = last.value + math.Copysign(maxDelta, delta)
+		return domain.QualityRateExceeded | domain.QualityClamped, nil
+	default:
+		return domain.QualityRateExceeded, nil
+	}
+}
+
+// compile returns the compiled form of a pattern, which must match the whole value
+func (v *readingValidator) compile(pattern string) (*regexp.Regexp, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if re, ok := v.patterns[pattern]; ok {
+		return re, nil
+	}
+	re, err := compilePattern(pattern)
+	if err != nil {
+		return nil, err
+	}
+	v.patterns[pattern] = re
+	return re, nil
+}
+
+// compilePattern anchors and compiles an id pattern
+func compilePattern(pattern string) (*regexp.Regexp, error) {
+	return regexp.Compile("^(?:" + pattern + ")$")
+}
+
+// validateValidationRules checks the validation action and patterns of a sensor type
+func validateValidationRules(sensorType *domain.SensorType) error {
+	sensorType.ValidationAction = strings.ToLower(strings.TrimSpace(sensorType.ValidationAction))
+	switch sensorType.ValidationAction {
+	case "":
+		sensorType.ValidationAction = domain.ValidationFlag
+	case domain.ValidationReject, domain.ValidationClamp, domain.ValidationFlag:
+	default:
+		return fmt.Errorf("%w: validation_action must be %s, %s or %s", domain.ErrInvalidInput, domain.ValidationReject, domain.ValidationClamp, domain.ValidationFlag)
+	}
+
+	if sensorType.MaxRateOfChange != nil && *sensorType.MaxRateOfChange <= 0 {
+		return fmt.Errorf("%w: max_rate_of_change must be positive", domain.ErrInvalidInput)
+	}
+
+	patterns := []struct{ name, pattern string }{
+		{"id1_pattern", sensorType.ID1Pattern},
+		{"id2_pattern", sensorType.ID2Pattern},
+	}
+	for _, p := range patterns {
+		if p.pattern == "" {
+			continue
+		}
+		if len(p.pattern) > maxPatternLength {
+			return fmt.Errorf("%w: %s must be at most %d characters", domain.ErrInvalidInput, p.name, maxPatternLength)
+		}
+		if _, err := compilePattern(p.pattern); err != nil {
+			return fmt.Errorf("%w: %s is not a valid regular expression: %v", domain.ErrInvalidInput, p.name, err)
+		}
+	}
+
+	return nil
+}
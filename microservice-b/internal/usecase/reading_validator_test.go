@@ -0,0 +1,269 @@
+package usecase
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"testing"
+
+	"sensor_project/microservice-b/internal/domain"
+)
+
+func float(v float64) *float64 {
+	return &v
+}
+
+func TestReadingValidatorValidate(t *testing.T) {
+	// ranged has a 0-100 range and a rate limit of 1 unit per second
+	ranged := func(action string) *domain.SensorType {
+		return &domain.SensorType{
+			Name:             "humidity",
+			MinValue:         float(0),
+			MaxValue:         float(100),
+			MaxRateOfChange:  float(1),
+			ValidationAction: action,
+		}
+	}
+	patterned := func(action string) *domain.SensorType {
+		return &domain.SensorType{
+			Name:             "humidity",
+			ID1Pattern:       "[A-Z]",
+			ID2Pattern:       "[0-9]{2}",
+			ValidationAction: action,
+		}
+	}
+
+	tests := []struct {
+		name       string
+		sensorType *domain.SensorType
+		// last is the stored reading of the series at time 0, if any
+		last      *float64
+		value     float64
+		eventTime int64
+		id1       string
+		id2       int
+		wantErr   bool
+		wantFlags int
+		wantValue float64
+	}{
+		{
+			name:       "within every rule",
+			sensorType: ranged(domain.ValidationReject),
+			last:       float(50),
+			value:      55, eventTime: 10000,
+			wantValue: 55,
+		},
+		{
+			name:       "no rules",
+			sensorType: &domain.SensorType{Name: "humidity"},
+			value:      -1e9,
+			wantValue:  -1e9,
+		},
+		{
+			name:       "range bounds are inclusive",
+			sensorType: ranged(domain.ValidationReject),
+			value:      100,
+			wantValue:  100,
+		},
+		{
+			name:       "above range rejected",
+			sensorType: ranged(domain.ValidationReject),
+			value:      100.5,
+			wantErr:    true,
+		},
+		{
+			name:       "below range clamped",
+			sensorType: ranged(domain.ValidationClamp),
+			value:      -3,
+			wantFlags:  domain.QualityOutOfRange | domain.QualityClamped,
+			wantValue:  0,
+		},
+		{
+			name:       "above range clamped",
+			sensorType: ranged(domain.ValidationClamp),
+			value:      250,
+			wantFlags:  domain.QualityOutOfRange | domain.QualityClamped,
+			wantValue:  100,
+		},
+		{
+			name:       "out of range flagged",
+			sensorType: ranged(domain.ValidationFlag),
+			value:      250,
+			wantFlags:  domain.QualityOutOfRange,
+			wantValue:  250,
+		},
+		{
+			name:       "no action flags",
+			sensorType: ranged(""),
+			value:      250,
+			wantFlags:  domain.QualityOutOfRange,
+			wantValue:  250,
+		},
+		{
+			name:       "rate exceeded rejected",
+			sensorType: ranged(domain.ValidationReject),
+			last:       float(50),
+			value:      60, eventTime: 5000,
+			wantErr: true,
+		},
+		{
+			name:       "rate exceeded upwards clamped",
+			sensorType: ranged(domain.ValidationClamp),
+			last:       float(50),
+			value:      60, eventTime: 5000,
+			wantFlags: domain.QualityRateExceeded | domain.QualityClamped,
+			wantValue: 55,
+		},
+		{
+			name:       "rate exceeded downwards clamped",
+			sensorType: ranged(domain.ValidationClamp),
+			last:       float(50),
+			value:      20, eventTime: 2000,
+			wantFlags: domain.QualityRateExceeded | domain.QualityClamped,
+			wantValue: 48,
+		},
+		{
+			name:       "rate exceeded flagged",
+			sensorType: ranged(domain.ValidationFlag),
+			last:       float(50),
+			value:      60, eventTime: 5000,
+			wantFlags: domain.QualityRateExceeded,
+			wantValue: 60,
+		},
+		{
+			name:       "rate at the limit",
+			sensorType: ranged(domain.ValidationReject),
+			last:       float(50),
+			value:      55, eventTime: 5000,
+			wantValue: 55,
+		},
+		{
+			name:       "rate not checked without a stored reading",
+			sensorType: ranged(domain.ValidationReject),
+			value:      99,
+			wantValue:  99,
+		},
+		{
+			name:       "rate not checked for older readings",
+			sensorType: ranged(domain.ValidationReject),
+			last:       float(50),
+			value:      90, eventTime: -5000,
+			wantValue: 90,
+		},
+		{
+			name:       "clamped into range, then to the rate",
+			sensorType: ranged(domain.ValidationClamp),
+			last:       float(90),
+			value:      250, eventTime: 2000,
+			wantFlags: domain.QualityOutOfRange | domain.QualityRateExceeded | domain.QualityClamped,
+			wantValue: 92,
+		},
+		{
+			name:       "patterns matched",
+			sensorType: patterned(domain.ValidationReject),
+			id1:        "A", id2: 42,
+		},
+		{
+			name:       "patterns match the whole id",
+			sensorType: patterned(domain.ValidationReject),
+			id1:        "AB", id2: 42,
+			wantErr: true,
+		},
+		{
+			name:       "id2 pattern mismatch rejected",
+			sensorType: patterned(domain.ValidationReject),
+			id1:        "A", id2: 7,
+			wantErr: true,
+		},
+		{
+			name:       "pattern mismatch is flagged when clamping",
+			sensorType: patterned(domain.ValidationClamp),
+			id1:        "a", id2: 42,
+			wantFlags: domain.QualityPatternMismatch,
+		},
+		{
+			name:       "pattern mismatch flagged",
+			sensorType: patterned(domain.ValidationFlag),
+			id1:        "a", id2: 7,
+			wantFlags: domain.QualityPatternMismatch,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := newReadingValidator()
+			if tt.last != nil {
+				v.Remember(&domain.SensorData{SensorType: "humidity", ID1: tt.id1, ID2: tt.id2, SensorValue: *tt.last})
+			}
+
+			data := &domain.SensorData{SensorType: "humidity", ID1: tt.id1, ID2: tt.id2, SensorValue: tt.value, EventTime: tt.eventTime}
+			flags, err := v.Validate(data, tt.sensorType)
+			if tt.wantErr {
+				if !errors.Is(err, domain.ErrInvalidReading) {
+					t.Fatalf("Validate() error = %v, want %v", err, domain.ErrInvalidReading)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Validate() error = %v", err)
+			}
+			if flags != tt.wantFlags {
+				t.Errorf("Validate() flags = %v, want %v", domain.QualityFlagList(flags), domain.QualityFlagList(tt.wantFlags))
+			}
+			if math.Abs(data.SensorValue-tt.wantValue) > 1e-9 {
+				t.Errorf("sensor value = %g, want %g", data.SensorValue, tt.wantValue)
+			}
+		})
+	}
+}
+
+func TestReadingValidatorRemember(t *testing.T) {
+	v := newReadingValidator()
+	sensorType := &domain.SensorType{Name: "humidity", MaxRateOfChange: float(1), ValidationAction: domain.ValidationReject}
+	reading := func(value float64, eventTime int64) *domain.SensorData {
+		return &domain.SensorData{SensorType: "humidity", ID1: "A", ID2: 1, SensorValue: value, EventTime: eventTime}
+	}
+
+	v.Remember(reading(50, 10000))
+	// An older reading arriving late does not replace the latest one
+	v.Remember(reading(0, 5000))
+
+	if _, err := v.Validate(reading(51, 11000), sensorType); err != nil {
+		t.Errorf("Validate() against the latest reading: %v", err)
+	}
+	// Other series are checked against their own readings
+	other := reading(0, 11000)
+	other.ID2 = 2
+	if _, err := v.Validate(other, sensorType); err != nil {
+		t.Errorf("Validate() of another series: %v", err)
+	}
+}
+
+func TestCheckReading(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    domain.SensorData
+		wantErr bool
+	}{
+		{"valid", domain.SensorData{ID1: "A", SensorValue: 1}, false},
+		{"valid reading ID", domain.SensorData{ID1: "A", ReadingID: "0b7e3c52-9d4f-4a61-8c2e-5f1a7d9b3e40"}, false},
+		{"NaN", domain.SensorData{ID1: "A", SensorValue: math.NaN()}, true},
+		{"infinite", domain.SensorData{ID1: "A", SensorValue: math.Inf(-1)}, true},
+		{"missing id1", domain.SensorData{}, true},
+		{"long id1", domain.SensorData{ID1: strings.Repeat("A", domain.MaxID1Length+1)}, true},
+		{"long reading ID", domain.SensorData{ID1: "A", ReadingID: strings.Repeat("a", domain.MaxReadingIDLength+1)}, true},
+		{"reading ID with other characters", domain.SensorData{ID1: "A", ReadingID: "a b"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkReading(&tt.data)
+			if tt.wantErr && !errors.Is(err, domain.ErrInvalidReading) {
+				t.Errorf("checkReading() error = %v, want %v", err, domain.ErrInvalidReading)
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("checkReading() error = %v", err)
+			}
+		})
+	}
+}
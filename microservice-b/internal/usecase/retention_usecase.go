@@ -0,0 +1,253 @@
+package usecase
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"sensor_project/microservice-b/internal/domain"
+)
+
+// RetentionUseCase implements the domain.RetentionUseCase interface. It purges expired
+// readings and rollups, and readings deleted more than gracePeriod ago, in small
+// batches so no delete holds locks for long.
+type RetentionUseCase struct {
+	repo        domain.RetentionRepository
+	interval    time.Duration
+	batchSize   int
+	batchPause  time.Duration
+	gracePeriod time.Duration
+
+	runMu sync.Mutex
+	mu    sync.RWMutex
+	last  *domain.RetentionReport
+}
+
+// NewRetentionUseCase creates a retention use case that purges every interval, deleting
+// at most batchSize rows per statement and pausing batchPause between statements.
+// Deleted readings are purged once they have been deleted for gracePeriod.
+func NewRetentionUseCase(repo domain.RetentionRepository, interval time.Duration, batchSize int, batchPause, gracePeriod time.Duration) *RetentionUseCase {
+	return &RetentionUseCase{
+		repo:        repo,
+		interval:    interval,
+		batchSize:   batchSize,
+		batchPause:  batchPause,
+		gracePeriod: gracePeriod,
+	}
+}
+
+// List retrieves all retention policies
+func (uc *RetentionUseCase) List() ([]*domain.RetentionPolicy, error) {
+	return uc.repo.List()
+}
+
+// Get retrieves the retention policy of a sensor type
+func (uc *RetentionUseCase) Get(sensorType string) (*domain.RetentionPolicy, error) {
+	return uc.repo.GetBySensorType(sensorType)
+}
+
+// Save validates and stores the retention policy of a sensor type
+func (uc *RetentionUseCase) Save(policy *domain.RetentionPolicy) error {
+	policy.SensorType = strings.TrimSpace(policy.SensorType)
+	if policy.SensorType == "" {
+		return fmt.Errorf("%w: sensor_type is required", domain.ErrInvalidInput)
+	}
+
+	for _, target := range retentionTargets(policy) {
+		if target.retention < 0 {
+			return fmt.Errorf("%w: %s retention must not be negative", domain.ErrInvalidInput, target.name)
+		}
+		if target.retention > 0 && target.retention < target.resolution {
+			return fmt.Errorf("%w: %s retention must be at least %s", domain.ErrInvalidInput, target.name, target.resolution)
+		}
+	}
+
+	return uc.repo.Save(policy)
+}
+
+// Delete removes the retention policy of a sensor type, keeping its data forever
+func (uc *RetentionUseCase) Delete(sensorType string) error {
+	return uc.repo.Delete(sensorType)
+}
+
+// Preview reports what a purge run would remove now without deleting anything
+func (uc *RetentionUseCase) Preview() (*domain.RetentionReport, error) {
+	return uc.run(true)
+}
+
+// Purge deletes all expired data and records the report as the last run
+func (uc *RetentionUseCase) Purge() (*domain.RetentionReport, error) {
+	report, err := uc.run(false)
+
+	uc.mu.Lock()
+	uc.last = report
+	uc.mu.Unlock()
+
+	return report, err
+}
+
+// LastReport returns the report of the last purge run, or nil if none has run yet
+func (uc *RetentionUseCase) LastReport() *domain.RetentionReport {
+	uc.mu.RLock()
+	defer uc.mu.RUnlock()
+	return uc.last
+}
+
+// Run purges expired data every interval until stopChan is closed
+func (uc *RetentionUseCase) Run(stopChan <-chan struct{}) {
+	ticker := time.NewTicker(uc.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stopChan:
+			return
+		case <-ticker.C:
+			report, err := uc.Purge()
+			if err != nil {
+				log.Printf("Retention run failed after purging %d rows: %v", report.TotalRows, err)
+				continue
+			}
+			if report.TotalRows > 0 {
+				log.Printf("Retention run purged %d rows in %d ms", report.TotalRows, report.FinishedAt-report.StartedAt)
+			}
+		}
+	}
+}
+
+// run applies every retention policy, counting the expired rows in a dry run and
+// deleting them otherwise. On failure the report covers the work done so far.
+func (uc *RetentionUseCase) run(dryRun bool) (*domain.RetentionReport, error) {
+	uc.runMu.Lock()
+	defer uc.runMu.Unlock()
+
+	now := time.Now().UnixMilli()
+	report := &domain.RetentionReport{
+		DryRun:    dryRun,
+		StartedAt: now,
+		Purged:    []domain.RetentionPurge{},
+	}
+
+	err := uc.applyPolicies(report, now)
+	report.FinishedAt = time.Now().UnixMilli()
+	if err != nil {
+		report.Error = err.Error()
+		return report, err
+	}
+	return report, nil
+}
+
+// applyPolicies purges or counts the readings deleted before the grace period and the
+// expired data of every policy into report
+func (uc *RetentionUseCase) applyPolicies(report *domain.RetentionReport, now int64) error {
+	cutoff := now - uc.gracePeriod.Milliseconds()
+	var rows int64
+	var err error
+	if report.DryRun {
+		rows, err = uc.repo.CountDeleted(cutoff)
+	} else {
+		rows, err = uc.batches(func() (int64, error) { return uc.repo.PurgeDeleted(cutoff, uc.batchSize) })
+	}
+	if rows > 0 {
+		report.Purged = append(report.Purged, domain.RetentionPurge{
+			Target: domain.RetentionTargetDeleted,
+			Cutoff: cutoff,
+			Rows:   rows,
+		})
+		report.TotalRows += rows
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", domain.RetentionTargetDeleted, err)
+	}
+
+	policies, err := uc.repo.List()
+	if err != nil {
+		return err
+	}
+
+	for _, policy := range policies {
+		cutoffs := retentionCutoffs(policy, now)
+		for _, target := range retentionTargets(policy) {
+			cutoff, ok := cutoffs[target.resolution]
+			if !ok {
+				continue
+			}
+
+			var rows int64
+			if report.DryRun {
+				rows, err = uc.repo.CountExpired(target.resolution, policy.SensorTypeID, cutoff)
+			} else {
+				resolution, sensorTypeID := target.resolution, policy.SensorTypeID
+				rows, err = uc.batches(func() (int64, error) {
+					return uc.repo.PurgeExpired(resolution, sensorTypeID, cutoff, uc.batchSize)
+				})
+			}
+
+			if rows > 0 {
+				report.Purged = append(report.Purged, domain.RetentionPurge{
+					SensorType: policy.SensorType,
+					Target:     target.name,
+					Cutoff:     cutoff,
+					Rows:       rows,
+				})
+				report.TotalRows += rows
+			}
+			if err != nil {
+				return fmt.Errorf("%s %s: %w", policy.SensorType, target.name, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// batches runs purge, which deletes up to batchSize rows, one batch at a time until none
+// are left and returns the total deleted
+func (uc *RetentionUseCase) batches(purge func() (int64, error)) (int64, error) {
+	var total int64
+	for {
+		deleted, err := purge()
+		total += deleted
+		if err != nil || deleted < int64(uc.batchSize) {
+			return total, err
+		}
+		time.Sleep(uc.batchPause)
+	}
+}
+
+// retentionTarget is the retention of one resolution of a policy
+type retentionTarget struct {
+	name       string
+	resolution time.Duration
+	retention  time.Duration
+}
+
+// retentionCutoffs maps each resolution a policy expires to the time before which its
+// data is purged
+func retentionCutoffs(policy *domain.RetentionPolicy, now int64) map[time.Duration]int64 {
+	cutoffs := make(map[time.Duration]int64)
+	for resolution, retention := range policy.Retentions() {
+		if retention > 0 {
+			cutoffs[resolution] = now - retention.Milliseconds()
+		}
+	}
+	return cutoffs
+}
+
+// retentionTargets lists the retentions of a policy, raw readings first
+func retentionTargets(policy *domain.RetentionPolicy) []retentionTarget {
+	var targets []retentionTarget
+	for resolution, retention := range policy.Retentions() {
+		name := domain.AggregateSourceRaw
+		if resolution > 0 {
+			name = domain.AggregateSourceRollup + intervalName(resolution)
+		}
+		targets = append(targets, retentionTarget{name: name, resolution: resolution, retention: retention})
+	}
+
+	sort.Slice(targets, func(i, j int) bool { return targets[i].resolution < targets[j].resolution })
+	return targets
+}
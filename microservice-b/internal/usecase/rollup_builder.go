@@ -0,0 +1,195 @@
+package usecase
+
+import (
+	"log"
+	"log/slog"
+	"sort"
+	"time"
+
+	"sensor_project/microservice-b/internal/domain"
+)
+
+// rollupWindow bounds the span of ingest time processed in one step, so catching up on
+// a long backlog is done in pieces that each advance the watermark
+const rollupWindow = time.Hour
+
+// RollupBuilder keeps the pre-computed rollups up to date in the background. Each refresh
+// rebuilds the buckets holding readings ingested since the watermark, including late
+// readings for old buckets, and buckets marked dirty by updates and deletes. Buckets
+// whose source retention may have purged part of cannot be rebuilt; the readings
+// ingested into them are added to the existing buckets instead, and updates and deletes
+// of their readings are not reflected.
+type RollupBuilder struct {
+	repo      domain.RollupRepository
+	retention domain.RetentionRepository
+	interval  time.Duration
+	lag       time.Duration
+}
+
+// NewRollupBuilder creates a builder that refreshes the rollups every interval. Readings
+// ingested within lag of the present are left for the next refresh, so inserts still in
+// flight are not skipped.
+func NewRollupBuilder(repo domain.RollupRepository, retention domain.RetentionRepository, interval, lag time.Duration) *RollupBuilder {
+	return &RollupBuilder{
+		repo:      repo,
+		retention: retention,
+		interval:  interval,
+		lag:       lag,
+	}
+}
+
+// Run refreshes the rollups every interval until stopChan is closed
+func (b *RollupBuilder) Run(stopChan <-chan struct{}) {
+	ticker := time.NewTicker(b.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stopChan:
+			return
+		case <-ticker.C:
+			if err := b.Refresh(); err != nil {
+				log.Printf("Failed to refresh rollups: %v", err)
+			}
+		}
+	}
+}
+
+// Refresh brings the rollups up to date with the readings ingested so far
+func (b *RollupBuilder) Refresh() error {
+	watermark, err := b.repo.GetWatermark()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	until := now.Add(-b.lag).UnixMilli()
+	if watermark == 0 {
+		// No readings yet, so there is nothing to build up to now
+		return b.repo.Advance(0, until, 0, nil)
+	}
+
+	cutoffs, err := b.policyCutoffs(now.UnixMilli())
+	if err != nil {
+		return err
+	}
+
+	for watermark < until {
+		next := watermark + rollupWindow.Milliseconds()
+		if next > until {
+			next = until
+		}
+
+		changes, markID, err := b.repo.GetChanges(watermark, next)
+		if err != nil {
+			return err
+		}
+		merges, err := b.rebuild(changes, cutoffs)
+		if err != nil {
+			return err
+		}
+		if err := b.repo.Advance(watermark, next, markID, merges); err != nil {
+			return err
+		}
+
+		if len(changes) > 0 {
+			slog.Debug("Refreshed rollups", "changed_buckets", len(changes), "merged_runs", len(merges), "watermark", next)
+		}
+		watermark = next
+	}
+
+	return nil
+}
+
+// policyCutoffs maps the id of each sensor type with a retention policy to its retention
+// cutoffs by resolution
+func (b *RollupBuilder) policyCutoffs(now int64) (map[int]map[time.Duration]int64, error) {
+	policies, err := b.retention.List()
+	if err != nil {
+		return nil, err
+	}
+
+	cutoffs := make(map[int]map[time.Duration]int64, len(policies))
+	for _, policy := range policies {
+		cutoffs[policy.SensorTypeID] = retentionCutoffs(policy, now)
+	}
+	return cutoffs, nil
+}
+
+// rebuild recomputes the changed buckets at every resolution, finest first so each
+// coarser rollup is built from up-to-date finer ones, and returns the runs of buckets to
+// merge the ingested readings into instead. A bucket is merged when it starts before the
+// retention cutoff of its source, or holds a finer bucket that is merged, since its
+// source is only brought up to date by the merge. Buckets past their own retention are
+// left for the purge.
+func (b *RollupBuilder) rebuild(changes []domain.RollupChange, cutoffs map[int]map[time.Duration]int64) ([]domain.RollupMerge, error) {
+	var merges []domain.RollupMerge
+	mergedUntil := make(map[domain.RollupSeries]int64)
+	var source time.Duration
+	for _, resolution := range domain.RollupResolutions {
+		resolutionMs := resolution.Milliseconds()
+		for series, buckets := range groupRollupChanges(changes, resolution) {
+			expiry, expires := cutoffs[series.SensorTypeID][resolution]
+			sourceCutoff, sourceExpires := cutoffs[series.SensorTypeID][source]
+
+			var rebuilt, merged []int64
+			for _, start := range buckets {
+				switch {
+				case expires && start+resolutionMs <= expiry:
+					// The purge removes the bucket itself
+				case sourceExpires && start < sourceCutoff || start < mergedUntil[series]:
+					merged = append(merged, start)
+				default:
+					rebuilt = append(rebuilt, start)
+				}
+			}
+
+			for _, run := range contiguousRuns(rebuilt, resolutionMs) {
+				if err := b.repo.Rebuild(resolution, source, series, run[0], run[1]); err != nil {
+					return nil, err
+				}
+			}
+			for _, run := range contiguousRuns(merged, resolutionMs) {
+				merges = append(merges, domain.RollupMerge{RollupSeries: series, Resolution: resolution, From: run[0], To: run[1]})
+				mergedUntil[series] = max(mergedUntil[series], run[1])
+			}
+		}
+		source = resolution
+	}
+	return merges, nil
+}
+
+// groupRollupChanges maps each changed series to the sorted, distinct starts of its
+// changed buckets at the given resolution
+func groupRollupChanges(changes []domain.RollupChange, resolution time.Duration) map[domain.RollupSeries][]int64 {
+	resolutionMs := resolution.Milliseconds()
+	seen := make(map[domain.RollupChange]bool)
+	grouped := make(map[domain.RollupSeries][]int64)
+
+	for _, change := range changes {
+		change.BucketStart -= change.BucketStart % resolutionMs
+		if seen[change] {
+			continue
+		}
+		seen[change] = true
+		grouped[change.RollupSeries] = append(grouped[change.RollupSeries], change.BucketStart)
+	}
+
+	for _, buckets := range grouped {
+		sort.Slice(buckets, func(i, j int) bool { return buckets[i] < buckets[j] })
+	}
+	return grouped
+}
+
+// contiguousRuns merges sorted bucket starts into [from, to) ranges of adjacent buckets
+func contiguousRuns(buckets []int64, size int64) [][2]int64 {
+	var runs [][2]int64
+	for _, start := range buckets {
+		if n := len(runs); n > 0 && runs[n-1][1] == start {
+			runs[n-1][1] = start + size
+			continue
+		}
+		runs = append(runs, [2]int64{start, start + size})
+	}
+	return runs
+}
@@ -0,0 +1,107 @@
+package usecase
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"sensor_project/microservice-b/internal/domain"
+)
+
+// rollupRun is a run of buckets at a resolution that was rebuilt or merged
+type rollupRun struct {
+	resolution time.Duration
+	from, to   int64
+}
+
+// fakeRollupRepo records the rebuilt runs
+type fakeRollupRepo struct {
+	domain.RollupRepository
+	rebuilt []rollupRun
+}
+
+func (r *fakeRollupRepo) Rebuild(resolution, source time.Duration, series domain.RollupSeries, from, to int64) error {
+	r.rebuilt = append(r.rebuilt, rollupRun{resolution, from, to})
+	return nil
+}
+
+func TestRollupBuilderRebuildRespectsRetention(t *testing.T) {
+	minute, hour, day := time.Minute.Milliseconds(), time.Hour.Milliseconds(), (24 * time.Hour).Milliseconds()
+	base := 100 * day
+	series := domain.RollupSeries{SensorTypeID: 1, ID1: "A", ID2: 1}
+	changes := []domain.RollupChange{
+		{RollupSeries: series, BucketStart: base + hour},
+		{RollupSeries: series, BucketStart: base + 5*hour},
+	}
+
+	tests := []struct {
+		name        string
+		cutoffs     map[time.Duration]int64
+		wantRebuilt []rollupRun
+		wantMerged  []rollupRun
+	}{
+		{
+			name: "no retention",
+			wantRebuilt: []rollupRun{
+				{time.Minute, base + hour, base + hour + minute},
+				{time.Minute, base + 5*hour, base + 5*hour + minute},
+				{time.Hour, base + hour, base + 2*hour},
+				{time.Hour, base + 5*hour, base + 6*hour},
+				{24 * time.Hour, base, base + day},
+			},
+		},
+		{
+			name:    "raw readings purged",
+			cutoffs: map[time.Duration]int64{0: base + 2*hour},
+			wantRebuilt: []rollupRun{
+				{time.Minute, base + 5*hour, base + 5*hour + minute},
+				{time.Hour, base + 5*hour, base + 6*hour},
+			},
+			// Coarser buckets holding a merged one are merged too
+			wantMerged: []rollupRun{
+				{time.Minute, base + hour, base + hour + minute},
+				{time.Hour, base + hour, base + 2*hour},
+				{24 * time.Hour, base, base + day},
+			},
+		},
+		{
+			name:    "minute rollups purged",
+			cutoffs: map[time.Duration]int64{time.Minute: base + 3*hour},
+			// The expired minute bucket is left for the purge
+			wantRebuilt: []rollupRun{
+				{time.Minute, base + 5*hour, base + 5*hour + minute},
+				{time.Hour, base + 5*hour, base + 6*hour},
+			},
+			wantMerged: []rollupRun{
+				{time.Hour, base + hour, base + 2*hour},
+				{24 * time.Hour, base, base + day},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeRollupRepo{}
+			b := NewRollupBuilder(repo, nil, time.Minute, 0)
+
+			merges, err := b.rebuild(changes, map[int]map[time.Duration]int64{series.SensorTypeID: tt.cutoffs})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !slices.Equal(repo.rebuilt, tt.wantRebuilt) {
+				t.Errorf("rebuilt %v, want %v", repo.rebuilt, tt.wantRebuilt)
+			}
+			var merged []rollupRun
+			for _, m := range merges {
+				if m.RollupSeries != series {
+					t.Errorf("merged series %v, want %v", m.RollupSeries, series)
+				}
+				merged = append(merged, rollupRun{m.Resolution, m.From, m.To})
+			}
+			if !slices.Equal(merged, tt.wantMerged) {
+				t.Errorf("merged %v, want %v", merged, tt.wantMerged)
+			}
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package usecase
+
+import (
+	"fmt"
+	"strings"
+
+	"sensor_project/microservice-b/internal/domain"
+)
+
+// maxSensorTypeNameLength matches the size of the sensor_types.name column
+const maxSensorTypeNameLength = 50
+
+// SensorTypeUseCase implements the domain.SensorTypeUseCase interface
+type SensorTypeUseCase struct {
+	repo domain.SensorTypeRepository
+}
+
+// NewSensorTypeUseCase creates a new sensor type use case
+func NewSensorTypeUseCase(repo domain.SensorTypeRepository) domain.SensorTypeUseCase {
+	return &SensorTypeUseCase{
+		repo: repo,
+	}
+}
+
+// Create validates and saves a new sensor type
+func (uc *SensorTypeUseCase) Create(sensorType *domain.SensorType) error {
+	if err := validateSensorType(sensorType); err != nil {
+		return err
+	}
+	return uc.repo.Create(sensorType)
+}
+
+// GetByID retrieves a sensor type by ID
+func (uc *SensorTypeUseCase) GetByID(id int) (*domain.SensorType, error) {
+	return uc.repo.GetByID(id)
+}
+
+// List retrieves all sensor types
+func (uc *SensorTypeUseCase) List() ([]*domain.SensorType, error) {
+	return uc.repo.List()
+}
+
+// Update validates and replaces the fields of an existing sensor type
+func (uc *SensorTypeUseCase) Update(id int, sensorType *domain.SensorType) error {
+	existing, err := uc.repo.GetByID(id)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		return domain.ErrSensorTypeNotFound
+	}
+
+	if err := validateSensorType(sensorType); err != nil {
+		return err
+	}
+
+	sensorType.ID = id
+	sensorType.CreatedAt = existing.CreatedAt
+	return uc.repo.Update(sensorType)
+}
+
+// Delete removes a sensor type; types that still have readings cannot be deleted
+func (uc *SensorTypeUseCase) Delete(id int) error {
+	existing, err := uc.repo.GetByID(id)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		return domain.ErrSensorTypeNotFound
+	}
+
+	return uc.repo.Delete(id)
+}
+
+// validateSensorType checks the name, value range and validation rules of a sensor type
+func validateSensorType(sensorType *domain.SensorType) error {
+	sensorType.Name = strings.TrimSpace(sensorType.Name)
+	if sensorType.Name == "" {
+		return fmt.Errorf("%w: name is required", domain.ErrInvalidInput)
+	}
+	if len(sensorType.Name) > maxSensorTypeNameLength {
+		return fmt.Errorf("%w: name must be at most %d characters", domain.ErrInvalidInput, maxSensorTypeNameLength)
+	}
+	if sensorType.MinValue != nil && sensorType.MaxValue != nil && *sensorType.MinValue > *sensorType.MaxValue {
+		return fmt.Errorf("%w: min_value must not be greater than max_value", domain.ErrInvalidInput)
+	}
+	return validateValidationRules(sensorType)
+}
@@ -1,29 +1,325 @@
 package usecase
 
 import (
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
 	"sensor_project/microservice-b/internal/domain"
 )
 
+// maxAggregateBuckets limits the number of buckets one aggregation returns
+const maxAggregateBuckets = 10000
+
+// maxPendingRuns limits the runs of buckets with readings not yet rolled up that an
+// aggregation computes from raw readings between rollup segments; past them, the rest
+// of the time range is computed from raw readings as a whole
+const maxPendingRuns = 8
+
+// defaultAggregateFunctions are computed when an aggregation names no functions
+var defaultAggregateFunctions = []string{domain.AggregateCount, domain.AggregateAvg, domain.AggregateMin, domain.AggregateMax}
+
+// sensorTypeCacheTTL is how long ingest uses a sensor type before looking it up again,
+// saving a query per reading; changes to a type's rules reach ingest within this time
+const sensorTypeCacheTTL = 5 * time.Second
+
+// cachedSensorType is a sensor type looked up at ingest
+type cachedSensorType struct {
+	sensorType *domain.SensorType
+	expiresAt  time.Time
+}
+
 // SensorDataUseCase implements the domain.SensorDataUseCase interface
 type SensorDataUseCase struct {
-	repo domain.SensorDataRepository
+	repo         domain.SensorDataRepository
+	typeRepo     domain.SensorTypeRepository
+	rollupRepo   domain.RollupRepository
+	feed         domain.SensorFeed
+	writer       *WritePipeline
+	ingestPolicy domain.IngestPolicy
+	validator    *readingValidator
+
+	mu    sync.Mutex
+	types map[string]cachedSensorType
 }
 
-// NewSensorDataUseCase creates a new sensor data use case
-func NewSensorDataUseCase(repo domain.SensorDataRepository) domain.SensorDataUseCase {
+// NewSensorDataUseCase creates a new sensor data use case that stores readings through
+// writer. A nil rollupRepo computes every aggregation from raw readings; stored readings
+// are published to feed unless it is nil.
+func NewSensorDataUseCase(repo domain.SensorDataRepository, typeRepo domain.SensorTypeRepository, rollupRepo domain.RollupRepository, feed domain.SensorFeed, writer *WritePipeline, ingestPolicy domain.IngestPolicy) domain.SensorDataUseCase {
 	return &SensorDataUseCase{
-		repo: repo,
+		repo:         repo,
+		typeRepo:     typeRepo,
+		rollupRepo:   rollupRepo,
+		feed:         feed,
+		writer:       writer,
+		ingestPolicy: ingestPolicy,
+		validator:    newReadingValidator(),
+		types:        make(map[string]cachedSensorType),
+	}
+}
+
+// pendingStore implements domain.PendingStore for a reading handed to the write
+// pipeline, or one whose outcome was settled without writing it
+type pendingStore struct {
+	uc      *SensorDataUseCase
+	data    *domain.SensorData
+	message string
+	write   *pendingWrite
+	result  *domain.StoreResult
+	err     error
+}
+
+// settledStore returns a PendingStore whose outcome is already known
+func settledStore(result *domain.StoreResult, err error) *pendingStore {
+	return &pendingStore{result: result, err: err}
+}
+
+// Wait blocks until the reading has been written and reports the outcome
+func (p *pendingStore) Wait() (*domain.StoreResult, error) {
+	if p.write != nil {
+		p.result, p.err = p.uc.written(p.data, p.write.wait(), p.message)
+		p.write = nil
+	}
+	return p.result, p.err
+}
+
+// Store validates and saves a sensor data record, waiting until it has been written
+func (uc *SensorDataUseCase) Store(data *domain.SensorData) (*domain.StoreResult, error) {
+	return uc.StoreAsync(data).Wait()
+}
+
+// StoreAsync validates a sensor data record and hands it to the write pipeline, blocking
+// only while the pipeline's queue is full. Its event time is checked against the ingest
+// time and its value and ids against the rules of its sensor type; readings of
+// unregistered sensor types are handled by the ingest policy. A reading whose reading ID
+// is already stored is not stored again but reported as a duplicate, even if it fails
+// the checks, so retries succeed however late they come.
+func (uc *SensorDataUseCase) StoreAsync(data *domain.SensorData) domain.PendingStore {
+	pending, err := uc.submit(data)
+	if err != nil && (isRowRejection(err) || errors.Is(err, domain.ErrEventTimeTooOld)) {
+		return settledStore(uc.duplicateOrReject(data, err))
+	}
+	if err != nil {
+		return settledStore(nil, err)
+	}
+	return pending
+}
+
+// submit checks a reading and hands it to the write pipeline
+func (uc *SensorDataUseCase) submit(data *domain.SensorData) (*pendingStore, error) {
+	if data.CreatedAt == 0 {
+		data.CreatedAt = time.Now().UnixMilli()
+	}
+
+	if err := uc.applyEventTimePolicy(data); err != nil {
+		return nil, err
+	}
+
+	if err := checkReading(data); err != nil {
+		return nil, err
+	}
+
+	sensorType, err := uc.sensorType(data.SensorType)
+	if err != nil {
+		return nil, err
+	}
+	if sensorType == nil {
+		return uc.storeUnknownType(data)
+	}
+
+	flags, err := uc.validator.Validate(data, sensorType)
+	if err != nil {
+		return nil, err
+	}
+	data.QualityFlags = flags
+
+	message := "Sensor data stored successfully"
+	if flags != 0 {
+		message = "Sensor data stored with quality flags: " + strings.Join(domain.QualityFlagList(flags), ", ")
+	}
+	return uc.write(data, message), nil
+}
+
+// write hands a checked reading to the write pipeline
+func (uc *SensorDataUseCase) write(data *domain.SensorData, message string) *pendingStore {
+	return &pendingStore{
+		uc:      uc,
+		data:    data,
+		message: message,
+		write:   uc.writer.submit(data),
+	}
+}
+
+// written reports the outcome of writing a reading. A stored reading is remembered for
+// rate of change checks, so readings that fail to be written never become the baseline
+// of their series, and published to live subscribers.
+func (uc *SensorDataUseCase) written(data *domain.SensorData, err error, message string) (*domain.StoreResult, error) {
+	switch {
+	case errors.Is(err, domain.ErrDuplicateReading):
+		return duplicateResult(data), nil
+	case errors.Is(err, domain.ErrUnknownSensorType):
+		// The sensor type was deleted since the reading was checked
+		uc.mu.Lock()
+		delete(uc.types, data.SensorType)
+		uc.mu.Unlock()
+		pending, err := uc.storeUnknownType(data)
+		if err != nil {
+			return nil, err
+		}
+		return pending.Wait()
+	case err != nil:
+		return nil, err
+	}
+
+	uc.validator.Remember(data)
+	if uc.feed != nil {
+		published := *data
+		uc.feed.Publish(&published)
+	}
+
+	return &domain.StoreResult{
+		Status:       domain.StoreStatusStored,
+		Message:      message,
+		QualityFlags: data.QualityFlags,
+	}, nil
+}
+
+// duplicateOrReject reports a rejected reading as a duplicate if its reading ID is
+// already stored, and otherwise returns the rejection
+func (uc *SensorDataUseCase) duplicateOrReject(data *domain.SensorData, rejection error) (*domain.StoreResult, error) {
+	if data.ReadingID == "" || checkReadingID(data.ReadingID) != nil {
+		return nil, rejection
+	}
+	stored, err := uc.repo.GetByReadingID(data.ReadingID)
+	if err != nil {
+		return nil, err
+	}
+	if stored == nil {
+		return nil, rejection
+	}
+	data.ID = stored.ID
+	return duplicateResult(data), nil
+}
+
+// duplicateResult reports that a reading was already stored under its reading ID
+func duplicateResult(data *domain.SensorData) *domain.StoreResult {
+	return &domain.StoreResult{
+		Status:  domain.StoreStatusDuplicate,
+		Message: fmt.Sprintf("Sensor data with reading_id %s already stored", data.ReadingID),
+	}
+}
+
+// sensorType looks up a sensor type by name, returning nil if it is not registered.
+// Registered types are cached for sensorTypeCacheTTL.
+func (uc *SensorDataUseCase) sensorType(name string) (*domain.SensorType, error) {
+	now := time.Now()
+	uc.mu.Lock()
+	cached, ok := uc.types[name]
+	uc.mu.Unlock()
+	if ok && now.Before(cached.expiresAt) {
+		return cached.sensorType, nil
+	}
+
+	sensorType, err := uc.typeRepo.GetByName(name)
+	if err != nil || sensorType == nil {
+		return nil, err
+	}
+
+	uc.mu.Lock()
+	uc.types[name] = cachedSensorType{sensorType: sensorType, expiresAt: now.Add(sensorTypeCacheTTL)}
+	uc.mu.Unlock()
+	return sensorType, nil
+}
+
+// storeUnknownType applies the unknown sensor type policy to a reading whose type is not registered
+func (uc *SensorDataUseCase) storeUnknownType(data *domain.SensorData) (*pendingStore, error) {
+	switch uc.ingestPolicy.UnknownSensorType {
+	case domain.UnknownTypeAutoRegister:
+		if err := registerSensorType(uc.typeRepo, data.SensorType); err != nil {
+			return nil, err
+		}
+		return uc.write(data, fmt.Sprintf("Sensor data stored, sensor type %q registered", data.SensorType)), nil
+
+	case domain.UnknownTypeQuarantine:
+		reason := fmt.Sprintf("unknown sensor type %q", data.SensorType)
+		err := uc.repo.Quarantine(data, reason)
+		if errors.Is(err, domain.ErrDuplicateReading) {
+			// An earlier attempt of the reading was quarantined already
+			return settledStore(&domain.StoreResult{
+				Status:  domain.StoreStatusDuplicate,
+				Message: fmt.Sprintf("Sensor data with reading_id %s already quarantined", data.ReadingID),
+			}, nil), nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		return settledStore(&domain.StoreResult{
+			Status:  domain.StoreStatusQuarantined,
+			Message: "Sensor data quarantined: " + reason,
+		}, nil), nil
+
+	default:
+		return nil, fmt.Errorf("%w %q", domain.ErrUnknownSensorType, data.SensorType)
 	}
 }
 
-// Store saves a sensor data record
-func (uc *SensorDataUseCase) Store(data *domain.SensorData) error {
-	return uc.repo.Store(data)
+// registerSensorType registers a sensor type without validation rules for readings of
+// an unknown type under the auto_register policy
+func registerSensorType(typeRepo domain.SensorTypeRepository, name string) error {
+	sensorType := &domain.SensorType{
+		Name:        name,
+		Description: "Registered automatically at ingest",
+	}
+	if err := validateSensorType(sensorType); err != nil {
+		return fmt.Errorf("%w %q: %v", domain.ErrUnknownSensorType, name, err)
+	}
+	// Another reading may have registered the type concurrently
+	if err := typeRepo.Create(sensorType); err != nil && !errors.Is(err, domain.ErrSensorTypeExists) {
+		return err
+	}
+	log.Printf("Registered sensor type %q at ingest", name)
+	return nil
+}
+
+// applyEventTimePolicy rejects or clamps readings whose event time lies outside the
+// accepted window around the ingest time
+func (uc *SensorDataUseCase) applyEventTimePolicy(data *domain.SensorData) error {
+	// Readings without a device timestamp fall back to the ingest time
+	if data.EventTime <= 0 {
+		data.EventTime = data.CreatedAt
+		return nil
+	}
+
+	latest := data.CreatedAt + uc.ingestPolicy.EventTime.ClockSkewTolerance.Milliseconds()
+	if data.EventTime > latest {
+		if uc.ingestPolicy.EventTime.FutureAction != domain.EventTimeClamp {
+			return fmt.Errorf("%w: %d ms ahead of ingest time", domain.ErrEventTimeInFuture, data.EventTime-data.CreatedAt)
+		}
+		data.EventTime = latest
+	}
+
+	if uc.ingestPolicy.EventTime.MaxEventAge > 0 {
+		earliest := data.CreatedAt - uc.ingestPolicy.EventTime.MaxEventAge.Milliseconds()
+		if data.EventTime < earliest {
+			if uc.ingestPolicy.EventTime.PastAction != domain.EventTimeClamp {
+				return fmt.Errorf("%w: %d ms behind ingest time", domain.ErrEventTimeTooOld, data.CreatedAt-data.EventTime)
+			}
+			data.EventTime = earliest
+		}
+	}
+
+	return nil
 }
 
-// GetByID retrieves a sensor data record by ID
-func (uc *SensorDataUseCase) GetByID(id int64) (*domain.SensorData, error) {
-	return uc.repo.GetByID(id)
+// GetByID retrieves a sensor data record by ID, including a deleted one if includeDeleted
+// is set
+func (uc *SensorDataUseCase) GetByID(id int64, includeDeleted bool) (*domain.SensorData, error) {
+	return uc.repo.GetByID(id, includeDeleted)
 }
 
 // GetByFilter retrieves sensor data records based on filter criteria
@@ -40,20 +336,354 @@ func (uc *SensorDataUseCase) GetByFilter(filter *domain.SensorDataFilter) ([]*do
 		filter.PageSize = 100
 	}
 
+	if err := validateFilter(filter); err != nil {
+		return nil, 0, err
+	}
+
 	return uc.repo.GetByFilter(filter)
 }
 
-// Update updates a sensor data record
-func (uc *SensorDataUseCase) Update(id int64, update *domain.SensorDataUpdate) error {
-	return uc.repo.Update(id, update)
+// GetPage retrieves a page of sensor data records newest first by ingest time, starting
+// after or before the cursor. Unlike offset pages, a page is not shifted by readings
+// that arrive while paging, but the order cannot be changed with a sort. The total is
+// only counted when includeTotal is set.
+func (uc *SensorDataUseCase) GetPage(filter *domain.SensorDataFilter, cursor *domain.PageCursor, includeTotal bool) (*domain.SensorDataPage, error) {
+	if len(filter.Sort) > 0 {
+		return nil, fmt.Errorf("%w: cursor pages cannot be sorted", domain.ErrInvalidInput)
+	}
+	if filter.PageSize <= 0 {
+		filter.PageSize = 10
+	}
+	if filter.PageSize > 100 {
+		filter.PageSize = 100
+	}
+	if err := validateFilter(filter); err != nil {
+		return nil, err
+	}
+
+	// One extra row tells whether there is another page in the same direction
+	rows, err := uc.repo.GetByCursor(filter, cursor, filter.PageSize+1)
+	if err != nil {
+		return nil, err
+	}
+	more := len(rows) > filter.PageSize
+	if more {
+		rows = rows[:filter.PageSize]
+	}
+	backward := cursor != nil && cursor.Before
+	if backward {
+		slices.Reverse(rows)
+	}
+
+	page := &domain.SensorDataPage{Data: rows}
+	switch {
+	case len(rows) > 0:
+		first, last := rows[0], rows[len(rows)-1]
+		if more || backward {
+			page.NextCursor = &domain.PageCursor{CreatedAt: last.CreatedAt, ID: last.ID}
+		}
+		if cursor != nil && (more || !backward) {
+			page.PrevCursor = &domain.PageCursor{CreatedAt: first.CreatedAt, ID: first.ID, Before: true}
+		}
+	case cursor != nil:
+		// Nothing left in this direction; offer the way back from the same position
+		back := *cursor
+		back.Before = !cursor.Before
+		if backward {
+			page.NextCursor = &back
+		} else {
+			page.PrevCursor = &back
+		}
+	}
+
+	if includeTotal {
+		total, err := uc.repo.Count(filter)
+		if err != nil {
+			return nil, err
+		}
+		page.Total = &total
+	}
+
+	return page, nil
+}
+
+// Export passes every sensor data record matching the filter, oldest first, to fn
+// without loading them all into memory. Pagination fields are ignored.
+func (uc *SensorDataUseCase) Export(filter *domain.SensorDataFilter, fn func(*domain.SensorData) error) error {
+	if err := validateFilter(filter); err != nil {
+		return err
+	}
+
+	return uc.repo.Stream(filter, fn)
+}
+
+// Update corrects the value of a sensor data record on behalf of actor, keeping its
+// earlier values as revisions
+func (uc *SensorDataUseCase) Update(id int64, update *domain.SensorDataUpdate, actor domain.Actor) error {
+	if !slices.Contains(domain.CorrectionReasons, update.Reason) {
+		return fmt.Errorf("%w: reason must be one of %s", domain.ErrInvalidInput, strings.Join(domain.CorrectionReasons, ", "))
+	}
+	return uc.repo.Update(id, update, actor)
+}
+
+// GetHistory retrieves a sensor data record and every value it has had, oldest first,
+// including a deleted record if includeDeleted is set. The record is nil if it does not
+// exist.
+func (uc *SensorDataUseCase) GetHistory(id int64, includeDeleted bool) (*domain.SensorData, []*domain.SensorDataRevision, error) {
+	data, err := uc.repo.GetByID(id, includeDeleted)
+	if err != nil || data == nil {
+		return nil, nil, err
+	}
+
+	revisions, err := uc.repo.GetRevisions(id)
+	if err != nil {
+		return nil, nil, err
+	}
+	return data, revisions, nil
+}
+
+// Delete marks a sensor data record deleted on behalf of actor in a new deletion batch
+// and returns the batch ID, which restores it until it is purged
+func (uc *SensorDataUseCase) Delete(id int64, actor domain.Actor) (string, error) {
+	batchID, err := newJobID()
+	if err != nil {
+		return "", err
+	}
+	if err := uc.repo.Delete(id, batchID, actor); err != nil {
+		return "", err
+	}
+	return batchID, nil
+}
+
+// Aggregate groups the filtered sensor data into time buckets and computes the requested
+// functions over each. When rollups are enabled, whole buckets up to the rollup watermark
+// are read from the coarsest rollup the interval allows; partial buckets at the edges of
+// the time range, buckets past the watermark and buckets with readings ingested late or
+// changed since the last refresh are computed from raw readings.
+func (uc *SensorDataUseCase) Aggregate(query *domain.AggregateQuery) (*domain.AggregateResult, error) {
+	if err := validateAggregateQuery(query); err != nil {
+		return nil, err
+	}
+
+	if err := validateFilter(&query.Filter); err != nil {
+		return nil, err
+	}
+
+	segments, err := uc.planAggregate(query)
+	if err != nil {
+		return nil, err
+	}
+
+	// Ask for one bucket more than allowed to detect truncation
+	result := &domain.AggregateResult{Source: domain.AggregateSourceRaw}
+	remaining := maxAggregateBuckets + 1
+	for _, segment := range segments {
+		segment.query.Limit = remaining
+
+		var buckets []*domain.AggregateBucket
+		if segment.resolution == 0 {
+			buckets, err = uc.repo.Aggregate(segment.query)
+		} else {
+			buckets, err = uc.rollupRepo.Aggregate(segment.query, segment.resolution)
+			result.Source = domain.AggregateSourceRollup + intervalName(segment.resolution)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		result.Buckets = append(result.Buckets, buckets...)
+		remaining -= len(buckets)
+		if remaining == 0 {
+			break
+		}
+	}
+
+	if len(result.Buckets) > maxAggregateBuckets {
+		result.Buckets = result.Buckets[:maxAggregateBuckets]
+		result.Truncated = true
+	}
+	return result, nil
+}
+
+// aggregateSegment is the part of an aggregation computed from one source; a zero
+// resolution means raw readings
+type aggregateSegment struct {
+	query      *domain.AggregateQuery
+	resolution time.Duration
+}
+
+// planAggregate splits an aggregation into time-ordered segments: whole buckets covered
+// by the rollups, and raw segments before, after and between them for the buckets the
+// rollups do not reflect yet
+func (uc *SensorDataUseCase) planAggregate(query *domain.AggregateQuery) ([]aggregateSegment, error) {
+	raw := []aggregateSegment{{query: query}}
+
+	// Rollups hold every reading by event time as it is now, so they cannot serve
+	// filters on the readings themselves or on their past values
+	filter := query.Filter
+	if uc.rollupRepo == nil || filter.TimeField != domain.TimeFieldEvent || filter.QualityFlags != nil || filter.MinValue != nil || filter.MaxValue != nil || filter.AsOf != nil {
+		return raw, nil
+	}
+
+	resolution := rollupResolution(query.Interval)
+	if resolution == 0 {
+		return raw, nil
+	}
+
+	watermark, err := uc.rollupRepo.GetWatermark()
+	if err != nil {
+		return nil, err
+	}
+
+	// Whole buckets in [from, to) are read from the rollups
+	intervalMs := query.Interval.Milliseconds()
+	to := watermark / intervalMs * intervalMs
+	if end := query.Filter.EndTime; end != nil {
+		to = min(to, (end.UnixMilli()+1)/intervalMs*intervalMs)
+	}
+	from := int64(0)
+	if start := query.Filter.StartTime; start != nil {
+		from = (start.UnixMilli() + intervalMs - 1) / intervalMs * intervalMs
+	}
+	if to <= from {
+		return raw, nil
+	}
+
+	// The watermark is an ingest time, so buckets before it can still hold readings
+	// ingested late, or changed, since the last refresh
+	pendingFilter := query.Filter
+	pendingFilter.StartTime, pendingFilter.EndTime = millisPtr(from), millisPtr(to-1)
+	pending, err := uc.rollupRepo.GetPendingBuckets(&pendingFilter, query.Interval, watermark)
+	if err != nil {
+		return nil, err
+	}
+	runs := contiguousRuns(pending, intervalMs)
+	if len(runs) > maxPendingRuns {
+		to = runs[maxPendingRuns][0]
+		runs = runs[:maxPendingRuns]
+	}
+
+	var segments []aggregateSegment
+	if start := query.Filter.StartTime; start != nil && start.UnixMilli() < from {
+		head := *query
+		head.Filter.EndTime = millisPtr(from - 1)
+		segments = append(segments, aggregateSegment{query: &head})
+	}
+
+	next := from
+	for _, run := range runs {
+		if next < run[0] {
+			segments = append(segments, rangeSegment(query, next, run[0], resolution))
+		}
+		segments = append(segments, rangeSegment(query, run[0], run[1], 0))
+		next = run[1]
+	}
+	if next < to {
+		segments = append(segments, rangeSegment(query, next, to, resolution))
+	}
+
+	if end := query.Filter.EndTime; end == nil || end.UnixMilli() >= to {
+		tail := *query
+		tail.Filter.StartTime = millisPtr(to)
+		segments = append(segments, aggregateSegment{query: &tail})
+	}
+
+	return segments, nil
 }
 
-// Delete removes a sensor data record by ID
-func (uc *SensorDataUseCase) Delete(id int64) error {
-	return uc.repo.Delete(id)
+// rangeSegment returns the part of an aggregation in [from, to) computed at resolution.
+// A query without a start time keeps it open when from is zero.
+func rangeSegment(query *domain.AggregateQuery, from, to int64, resolution time.Duration) aggregateSegment {
+	part := *query
+	if query.Filter.StartTime != nil || from != 0 {
+		part.Filter.StartTime = millisPtr(from)
+	}
+	part.Filter.EndTime = millisPtr(to - 1)
+	return aggregateSegment{query: &part, resolution: resolution}
+}
+
+// rollupResolution returns the coarsest rollup resolution that divides interval, or
+// zero if there is none
+func rollupResolution(interval time.Duration) time.Duration {
+	for i := len(domain.RollupResolutions) - 1; i >= 0; i-- {
+		if resolution := domain.RollupResolutions[i]; interval%resolution == 0 {
+			return resolution
+		}
+	}
+	return 0
 }
 
-// DeleteByFilter removes sensor data records based on filter criteria
-func (uc *SensorDataUseCase) DeleteByFilter(filter *domain.SensorDataFilter) (int, error) {
-	return uc.repo.DeleteByFilter(filter)
+// intervalName returns the API name of an aggregate interval
+func intervalName(interval time.Duration) string {
+	for name, d := range domain.AggregateIntervals {
+		if d == interval {
+			return name
+		}
+	}
+	return interval.String()
+}
+
+// millisPtr converts Unix milliseconds to a time pointer for filters
+func millisPtr(ms int64) *time.Time {
+	t := time.UnixMilli(ms)
+	return &t
+}
+
+// validateFilter checks the time field and sort keys of a filter, applying the default
+// time field
+func validateFilter(filter *domain.SensorDataFilter) error {
+	switch filter.TimeField {
+	case "":
+		filter.TimeField = domain.TimeFieldEvent
+	case domain.TimeFieldEvent, domain.TimeFieldIngest:
+	default:
+		return fmt.Errorf("%w: time field must be %s or %s", domain.ErrInvalidInput, domain.TimeFieldEvent, domain.TimeFieldIngest)
+	}
+
+	for _, field := range filter.Sort {
+		switch field.Key {
+		case domain.SortValue, domain.SortTime, domain.SortID:
+		default:
+			return fmt.Errorf("%w: sort key must be %s, %s or %s", domain.ErrInvalidInput, domain.SortValue, domain.SortTime, domain.SortID)
+		}
+	}
+	return nil
+}
+
+// validateAggregateQuery checks the interval and functions of an aggregation, applying
+// the default functions and dropping duplicates
+func validateAggregateQuery(query *domain.AggregateQuery) error {
+	validInterval := false
+	for _, interval := range domain.AggregateIntervals {
+		if query.Interval == interval {
+			validInterval = true
+			break
+		}
+	}
+	if !validInterval {
+		return fmt.Errorf("%w: unsupported bucket interval %s", domain.ErrInvalidInput, query.Interval)
+	}
+
+	if len(query.Functions) == 0 {
+		query.Functions = defaultAggregateFunctions
+		return nil
+	}
+
+	seen := make(map[string]bool, len(query.Functions))
+	functions := make([]string, 0, len(query.Functions))
+	for _, fn := range query.Functions {
+		switch fn {
+		case domain.AggregateMin, domain.AggregateMax, domain.AggregateAvg, domain.AggregateCount,
+			domain.AggregateSum, domain.AggregateStdDev, domain.AggregateFirst, domain.AggregateLast:
+		default:
+			return fmt.Errorf("%w: unknown aggregate function %q", domain.ErrInvalidInput, fn)
+		}
+		if !seen[fn] {
+			seen[fn] = true
+			functions = append(functions, fn)
+		}
+	}
+	query.Functions = functions
+
+	return nil
 }
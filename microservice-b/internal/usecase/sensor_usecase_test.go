@@ -0,0 +1,236 @@
+package usecase
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"sensor_project/microservice-b/internal/domain"
+)
+
+// fakeSensorRepo keeps sensor data in memory. Methods it does not implement panic
+// through the nil embedded interface.
+type fakeSensorRepo struct {
+	domain.SensorDataRepository
+
+	mu   sync.Mutex
+	rows []*domain.SensorData
+}
+
+// GetByCursor mirrors the newest-first (created_at, id) keyset order of the MySQL
+// repository
+func (r *fakeSensorRepo) GetByCursor(filter *domain.SensorDataFilter, cursor *domain.PageCursor, limit int) ([]*domain.SensorData, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	newer := func(a, b *domain.SensorData) bool {
+		return a.CreatedAt > b.CreatedAt || a.CreatedAt == b.CreatedAt && a.ID > b.ID
+	}
+	rows := slices.Clone(r.rows)
+	slices.SortFunc(rows, func(a, b *domain.SensorData) int {
+		if newer(a, b) {
+			return -1
+		}
+		return 1
+	})
+
+	var results []*domain.SensorData
+	if cursor != nil && cursor.Before {
+		slices.Reverse(rows)
+	}
+	for _, row := range rows {
+		if cursor != nil {
+			position := &domain.SensorData{CreatedAt: cursor.CreatedAt, ID: cursor.ID}
+			if cursor.Before && !newer(row, position) || !cursor.Before && !newer(position, row) {
+				continue
+			}
+		}
+		results = append(results, row)
+		if len(results) == limit {
+			break
+		}
+	}
+	return results, nil
+}
+
+func TestGetPageWalksTiesOnce(t *testing.T) {
+	// Readings ingested in the same millisecond are ordered by id
+	repo := &fakeSensorRepo{}
+	for i, createdAt := range []int64{100, 100, 100, 200, 200, 300, 300} {
+		repo.rows = append(repo.rows, &domain.SensorData{ID: int64(i + 1), CreatedAt: createdAt})
+	}
+	want := []int64{7, 6, 5, 4, 3, 2, 1}
+	uc := NewSensorDataUseCase(repo, nil, nil, nil, nil, domain.IngestPolicy{})
+
+	for _, pageSize := range []int{1, 2, 3, 7, 10} {
+		t.Run(fmt.Sprintf("page size %d", pageSize), func(t *testing.T) {
+			// Cursors go through their encoded form as they would over HTTP
+			roundTrip := func(cursor *domain.PageCursor) *domain.PageCursor {
+				if cursor == nil {
+					return nil
+				}
+				decoded, err := domain.DecodePageCursor(cursor.Encode())
+				if err != nil {
+					t.Fatal(err)
+				}
+				return decoded
+			}
+
+			var forward []int64
+			var cursor, last *domain.PageCursor
+			for pages := 0; ; pages++ {
+				if pages > len(want) {
+					t.Fatal("paging forward does not end")
+				}
+				page, err := uc.GetPage(&domain.SensorDataFilter{PageSize: pageSize}, cursor, false)
+				if err != nil {
+					t.Fatal(err)
+				}
+				for _, data := range page.Data {
+					forward = append(forward, data.ID)
+				}
+				if pages == 0 && page.PrevCursor != nil {
+					t.Errorf("first page has a previous cursor %+v", page.PrevCursor)
+				}
+				if page.NextCursor == nil {
+					last = roundTrip(page.PrevCursor)
+					break
+				}
+				cursor = roundTrip(page.NextCursor)
+			}
+			if !slices.Equal(forward, want) {
+				t.Fatalf("paging forward = %v, want %v", forward, want)
+			}
+			if len(want) <= pageSize {
+				return
+			}
+
+			// Paging back from the last page visits the earlier pages in reverse
+			var backward []int64
+			cursor = last
+			for pages := 0; cursor != nil; pages++ {
+				if pages > len(want) {
+					t.Fatal("paging backward does not end")
+				}
+				page, err := uc.GetPage(&domain.SensorDataFilter{PageSize: pageSize}, cursor, false)
+				if err != nil {
+					t.Fatal(err)
+				}
+				ids := make([]int64, len(page.Data))
+				for i, data := range page.Data {
+					ids[i] = data.ID
+				}
+				backward = append(ids, backward...)
+				cursor = roundTrip(page.PrevCursor)
+			}
+			lastPage := (len(want) - 1) / pageSize * pageSize
+			if !slices.Equal(backward, want[:lastPage]) {
+				t.Errorf("paging backward = %v, want %v", backward, want[:lastPage])
+			}
+		})
+	}
+}
+
+// fakeAggregateRollups reports a rollup watermark and the buckets pending past it
+type fakeAggregateRollups struct {
+	domain.RollupRepository
+	watermark int64
+	pending   []int64
+}
+
+func (r *fakeAggregateRollups) GetWatermark() (int64, error) {
+	return r.watermark, nil
+}
+
+func (r *fakeAggregateRollups) GetPendingBuckets(filter *domain.SensorDataFilter, interval time.Duration, watermark int64) ([]int64, error) {
+	return r.pending, nil
+}
+
+func TestPlanAggregateServesPendingBucketsRaw(t *testing.T) {
+	hour := time.Hour.Milliseconds()
+	// segment is a planned [from, to] range computed at a resolution
+	type segment struct {
+		from, to   int64
+		resolution time.Duration
+	}
+
+	manyRuns := make([]int64, maxPendingRuns+2)
+	for i := range manyRuns {
+		manyRuns[i] = int64(2*i+1) * hour
+	}
+
+	tests := []struct {
+		name    string
+		pending []int64
+		want    []segment
+	}{
+		{
+			name: "nothing pending",
+			want: []segment{
+				{0, 50*hour - 1, time.Hour},
+				{50 * hour, 100 * hour, 0},
+			},
+		},
+		{
+			name:    "late readings between rollup segments",
+			pending: []int64{3 * hour, 4 * hour, 10 * hour},
+			want: []segment{
+				{0, 3*hour - 1, time.Hour},
+				{3 * hour, 5*hour - 1, 0},
+				{5 * hour, 10*hour - 1, time.Hour},
+				{10 * hour, 11*hour - 1, 0},
+				{11 * hour, 50*hour - 1, time.Hour},
+				{50 * hour, 100 * hour, 0},
+			},
+		},
+		{
+			name:    "pending bucket just before the watermark",
+			pending: []int64{49 * hour},
+			want: []segment{
+				{0, 49*hour - 1, time.Hour},
+				{49 * hour, 50*hour - 1, 0},
+				{50 * hour, 100 * hour, 0},
+			},
+		},
+		{
+			name:    "too many pending runs",
+			pending: manyRuns,
+			want: func() []segment {
+				var want []segment
+				for i := 0; i < maxPendingRuns; i++ {
+					start := int64(2*i+1) * hour
+					want = append(want, segment{start - hour, start - 1, time.Hour}, segment{start, start + hour - 1, 0})
+				}
+				// The rest of the range from the next run is computed from raw readings
+				rest := int64(2*maxPendingRuns+1) * hour
+				return append(want, segment{rest - hour, rest - 1, time.Hour}, segment{rest, 100 * hour, 0})
+			}(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rollups := &fakeAggregateRollups{watermark: 50*hour + 30*time.Minute.Milliseconds(), pending: tt.pending}
+			uc := NewSensorDataUseCase(nil, nil, rollups, nil, nil, domain.IngestPolicy{}).(*SensorDataUseCase)
+
+			query := &domain.AggregateQuery{
+				Filter:   domain.SensorDataFilter{StartTime: millisPtr(0), EndTime: millisPtr(100 * hour), TimeField: domain.TimeFieldEvent},
+				Interval: time.Hour,
+			}
+			segments, err := uc.planAggregate(query)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := make([]segment, len(segments))
+			for i, s := range segments {
+				got[i] = segment{s.query.Filter.StartTime.UnixMilli(), s.query.Filter.EndTime.UnixMilli(), s.resolution}
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("segments = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
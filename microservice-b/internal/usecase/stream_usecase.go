@@ -0,0 +1,63 @@
+package usecase
+
+import (
+	"fmt"
+	"time"
+
+	"sensor_project/microservice-b/internal/domain"
+)
+
+// StreamUseCase implements the domain.StreamUseCase interface on top of a live feed,
+// replaying readings a reconnecting subscriber missed from the repository
+type StreamUseCase struct {
+	repo          domain.SensorDataRepository
+	feed          domain.SensorFeed
+	replayLimit   int
+	replayOverlap time.Duration
+}
+
+// NewStreamUseCase creates a stream use case that replays at most replayLimit readings
+// when a subscriber resumes. replayOverlap is the longest a reading may take from its
+// ingest time to being stored.
+func NewStreamUseCase(repo domain.SensorDataRepository, feed domain.SensorFeed, replayLimit int, replayOverlap time.Duration) *StreamUseCase {
+	return &StreamUseCase{
+		repo:          repo,
+		feed:          feed,
+		replayLimit:   replayLimit,
+		replayOverlap: replayOverlap,
+	}
+}
+
+// Subscribe starts a live subscription to the readings matching the filter. A positive
+// resumeAfter, the id of the last reading received, also returns the matching readings
+// the subscriber may have missed: those with a higher id and, since concurrent writes
+// store readings out of id order, those with a lower id ingested up to replayOverlap
+// before it, which it may also have received already. Readings stored while the replay
+// is read can be both replayed and delivered live.
+func (uc *StreamUseCase) Subscribe(filter *domain.SensorDataFilter, resumeAfter int64) (*domain.StreamReplay, domain.FeedSubscription, error) {
+	if err := validateFilter(filter); err != nil {
+		return nil, nil, err
+	}
+	if filter.AsOf != nil {
+		return nil, nil, fmt.Errorf("%w: as_of cannot be used with live data", domain.ErrInvalidInput)
+	}
+
+	// Subscribe before reading the replay so no reading falls between the two
+	sub := uc.feed.Subscribe(filter)
+	if resumeAfter <= 0 {
+		return &domain.StreamReplay{}, sub, nil
+	}
+
+	// One more reading than the limit tells whether the replay is complete
+	readings, err := uc.repo.GetAfterID(filter, resumeAfter, uc.replayOverlap.Milliseconds(), uc.replayLimit+1)
+	if err != nil {
+		sub.Close()
+		return nil, nil, err
+	}
+	replay := &domain.StreamReplay{Readings: readings}
+	if len(readings) > uc.replayLimit {
+		replay.Readings = readings[:uc.replayLimit]
+		replay.Truncated = true
+	}
+	return replay, sub, nil
+}
@@ -0,0 +1,229 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+	"slices"
+	"strings"
+	"time"
+
+	"sensor_project/microservice-b/internal/domain"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Limits on account fields, matching the sizes of the users columns
+const (
+	maxUsernameLength = 50
+	maxEmailLength    = 100
+)
+
+// Password length limits; bcrypt only uses the first 72 bytes of a password
+const (
+	minPasswordLength = 8
+	maxPasswordLength = 72
+)
+
+// UserUseCase implements the domain.UserUseCase interface. The last active admin can
+// not be disabled, deleted or lose the admin role, so the service cannot be locked out
+// of its own administration.
+type UserUseCase struct {
+	repo domain.UserRepository
+}
+
+// NewUserUseCase creates a new user use case
+func NewUserUseCase(repo domain.UserRepository) *UserUseCase {
+	return &UserUseCase{
+		repo: repo,
+	}
+}
+
+// Create validates and saves a new user with a bcrypt hash of their password
+func (uc *UserUseCase) Create(user *domain.User, password string) error {
+	if err := validateAccount(user.Username, user.Email); err != nil {
+		return err
+	}
+	hash, err := hashPassword(password)
+	if err != nil {
+		return err
+	}
+	user.PasswordHash = hash
+	user.DisabledAt = nil
+	return uc.repo.Create(user)
+}
+
+// GetByID retrieves a user by ID
+func (uc *UserUseCase) GetByID(id int) (*domain.User, error) {
+	return uc.repo.GetByID(id)
+}
+
+// List retrieves all users
+func (uc *UserUseCase) List() ([]*domain.User, error) {
+	return uc.repo.List()
+}
+
+// Update changes the username and email of a user
+func (uc *UserUseCase) Update(id int, update *domain.UserUpdate) (*domain.User, error) {
+	user, err := uc.get(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if update.Username != nil {
+		user.Username = *update.Username
+	}
+	if update.Email != nil {
+		user.Email = *update.Email
+	}
+	if err := validateAccount(user.Username, user.Email); err != nil {
+		return nil, err
+	}
+
+	if err := uc.repo.Update(user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// SetDisabled disables or re-enables a user
+func (uc *UserUseCase) SetDisabled(id int, disabled bool) (*domain.User, error) {
+	user, err := uc.get(id)
+	if err != nil {
+		return nil, err
+	}
+	if disabled == (user.DisabledAt != nil) {
+		return user, nil
+	}
+
+	if disabled {
+		if err := uc.checkNotLastAdmin(user); err != nil {
+			return nil, err
+		}
+		now := time.Now()
+		user.DisabledAt = &now
+	} else {
+		user.DisabledAt = nil
+	}
+
+	if err := uc.repo.SetDisabled(id, user.DisabledAt); err != nil {
+		return nil, err
+	}
+	return uc.get(id)
+}
+
+// Delete removes a user with their roles and API keys
+func (uc *UserUseCase) Delete(id int) error {
+	user, err := uc.get(id)
+	if err != nil {
+		return err
+	}
+	if err := uc.checkNotLastAdmin(user); err != nil {
+		return err
+	}
+	return uc.repo.Delete(id)
+}
+
+// AddRole assigns a role to a user
+func (uc *UserUseCase) AddRole(id int, role string) (*domain.User, error) {
+	if _, err := uc.get(id); err != nil {
+		return nil, err
+	}
+	if err := uc.repo.AddRole(id, role); err != nil {
+		return nil, err
+	}
+	return uc.get(id)
+}
+
+// RemoveRole unassigns a role from a user
+func (uc *UserUseCase) RemoveRole(id int, role string) (*domain.User, error) {
+	user, err := uc.get(id)
+	if err != nil {
+		return nil, err
+	}
+	if !slices.Contains(user.Roles, role) {
+		return user, nil
+	}
+	if role == domain.RoleAdmin {
+		if err := uc.checkNotLastAdmin(user); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := uc.repo.RemoveRole(id, role); err != nil {
+		return nil, err
+	}
+	return uc.get(id)
+}
+
+// ResetPassword replaces the password of a user
+func (uc *UserUseCase) ResetPassword(id int, password string) error {
+	if _, err := uc.get(id); err != nil {
+		return err
+	}
+	hash, err := hashPassword(password)
+	if err != nil {
+		return err
+	}
+	return uc.repo.SetPassword(id, hash)
+}
+
+// get retrieves a user by ID, returning ErrUserNotFound if there is none
+func (uc *UserUseCase) get(id int) (*domain.User, error) {
+	user, err := uc.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, domain.ErrUserNotFound
+	}
+	return user, nil
+}
+
+// checkNotLastAdmin returns ErrLastAdmin if the user is the only active admin
+func (uc *UserUseCase) checkNotLastAdmin(user *domain.User) error {
+	if user.DisabledAt != nil || !slices.Contains(user.Roles, domain.RoleAdmin) {
+		return nil
+	}
+	admins, err := uc.repo.CountActiveWithRole(domain.RoleAdmin)
+	if err != nil {
+		return err
+	}
+	if admins <= 1 {
+		return domain.ErrLastAdmin
+	}
+	return nil
+}
+
+// validateAccount checks the username and email of a user
+func validateAccount(username, email string) error {
+	if strings.TrimSpace(username) == "" {
+		return fmt.Errorf("%w: username is required", domain.ErrInvalidInput)
+	}
+	if len(username) > maxUsernameLength {
+		return fmt.Errorf("%w: username must be at most %d characters", domain.ErrInvalidInput, maxUsernameLength)
+	}
+	if len(email) > maxEmailLength {
+		return fmt.Errorf("%w: email must be at most %d characters", domain.ErrInvalidInput, maxEmailLength)
+	}
+	if addr, err := mail.ParseAddress(email); err != nil || addr.Address != email {
+		return fmt.Errorf("%w: email %q is not a valid address", domain.ErrInvalidInput, email)
+	}
+	return nil
+}
+
+// hashPassword checks the length of a password and returns its bcrypt hash
+func hashPassword(password string) (string, error) {
+	if len(password) < minPasswordLength {
+		return "", fmt.Errorf("%w: password must be at least %d characters", domain.ErrInvalidInput, minPasswordLength)
+	}
+	if len(password) > maxPasswordLength {
+		return "", fmt.Errorf("%w: password must be at most %d bytes", domain.ErrInvalidInput, maxPasswordLength)
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", errors.Join(domain.ErrInvalidInput, err)
+	}
+	return string(hash), nil
+}
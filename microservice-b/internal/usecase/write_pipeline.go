@@ -0,0 +1,227 @@
+package usecase
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"sensor_project/microservice-b/internal/domain"
+)
+
+// pendingWrite is a reading waiting in the write pipeline. err is set and done closed
+// once it has been written or has failed.
+type pendingWrite struct {
+	data *domain.SensorData
+	err  error
+	done chan struct{}
+}
+
+// wait blocks until the reading has been written and returns the error writing it
+func (w *pendingWrite) wait() error {
+	<-w.done
+	return w.err
+}
+
+// WritePipeline coalesces the readings stored concurrently, whether sent one at a time,
+// on a stream or in a batch, into multi-row INSERTs. A batch is written once it holds
+// maxBatch readings or its first reading has waited maxDelay, by one of workers
+// concurrent writers; while every writer is busy, batches keep filling up to maxBatch.
+// At most queueSize readings wait for a writer. When the database falls that far
+// behind, new readings wait up to queueTimeout for room and are then refused with
+// domain.ErrWriteQueueFull.
+type WritePipeline struct {
+	repo         domain.SensorDataRepository
+	maxBatch     int
+	maxDelay     time.Duration
+	queueTimeout time.Duration
+
+	mu      sync.RWMutex
+	closed  bool
+	queue   chan *pendingWrite
+	batches chan []*pendingWrite
+	wg      sync.WaitGroup
+}
+
+// NewWritePipeline creates a write pipeline and starts its writers
+func NewWritePipeline(repo domain.SensorDataRepository, maxBatch int, maxDelay time.Duration, queueSize, workers int, queueTimeout time.Duration) *WritePipeline {
+	p := &WritePipeline{
+		repo:         repo,
+		maxBatch:     maxBatch,
+		maxDelay:     maxDelay,
+		queueTimeout: queueTimeout,
+		queue:        make(chan *pendingWrite, queueSize),
+		batches:      make(chan []*pendingWrite),
+	}
+
+	go p.collect()
+	p.wg.Add(workers)
+	for range workers {
+		go p.write()
+	}
+	return p
+}
+
+// Close stops taking readings and returns once the queued ones have been written
+func (p *WritePipeline) Close() {
+	p.mu.Lock()
+	if !p.closed {
+		p.closed = true
+		close(p.queue)
+	}
+	p.mu.Unlock()
+
+	p.wg.Wait()
+}
+
+// submit queues a reading to be written, waiting up to the queue timeout while the
+// queue is full
+func (p *WritePipeline) submit(data *domain.SensorData) *pendingWrite {
+	w := &pendingWrite{data: data, done: make(chan struct{})}
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if p.closed {
+		w.err = domain.ErrWriteQueueClosed
+		close(w.done)
+		return w
+	}
+
+	select {
+	case p.queue <- w:
+		return w
+	default:
+	}
+
+	timer := time.NewTimer(p.queueTimeout)
+	defer timer.Stop()
+	select {
+	case p.queue <- w:
+	case <-timer.C:
+		w.err = domain.ErrWriteQueueFull
+		close(w.done)
+	}
+	return w
+}
+
+// collect gathers queued readings into batches for the writers until the queue is
+// closed and drained
+func (p *WritePipeline) collect() {
+	defer close(p.batches)
+
+	queue := p.queue
+	var batch []*pendingWrite
+	var due <-chan time.Time
+	ready := false
+	for queue != nil || len(batch) > 0 {
+		// Take readings while the batch has room, and offer it to the writers once it is
+		// full, its delay is up or nothing more will come
+		var in <-chan *pendingWrite
+		if queue != nil && len(batch) < p.maxBatch {
+			in = queue
+		}
+		var out chan<- []*pendingWrite
+		if ready || queue == nil {
+			out = p.batches
+		}
+
+		select {
+		case w, ok := <-in:
+			if !ok {
+				queue = nil
+				continue
+			}
+			batch = append(batch, w)
+			if len(batch) == 1 {
+				due = time.After(p.maxDelay)
+			}
+			if len(batch) >= p.maxBatch {
+				ready = true
+			}
+		case <-due:
+			ready = true
+			due = nil
+		case out <- batch:
+			batch = nil
+			due = nil
+			ready = false
+		}
+	}
+}
+
+// write writes batches until the collector stops
+func (p *WritePipeline) write() {
+	defer p.wg.Done()
+	for batch := range p.batches {
+		p.flush(batch)
+	}
+}
+
+// flush writes a batch in one INSERT. Readings whose reading ID is already stored, or
+// repeated within the batch, are left out and fail with domain.ErrDuplicateReading and
+// the ID of the stored reading.
+func (p *WritePipeline) flush(batch []*pendingWrite) {
+	defer func() {
+		for _, w := range batch {
+			close(w.done)
+		}
+	}()
+
+	var readingIDs []string
+	for _, w := range batch {
+		if w.data.ReadingID != "" {
+			readingIDs = append(readingIDs, w.data.ReadingID)
+		}
+	}
+	storedIDs, err := p.repo.GetIDsByReadingID(readingIDs)
+	if err != nil {
+		for _, w := range batch {
+			w.err = err
+		}
+		return
+	}
+
+	first := make(map[string]*pendingWrite)
+	var inserts, repeats []*pendingWrite
+	for _, w := range batch {
+		if readingID := w.data.ReadingID; readingID != "" {
+			if id, ok := storedIDs[readingID]; ok {
+				w.data.ID = id
+				w.err = domain.ErrDuplicateReading
+				continue
+			}
+			if _, ok := first[readingID]; ok {
+				repeats = append(repeats, w)
+				continue
+			}
+			first[readingID] = w
+		}
+		inserts = append(inserts, w)
+	}
+
+	data := make([]*domain.SensorData, 0, len(inserts))
+	for _, w := range inserts {
+		data = append(data, w.data)
+	}
+	err = p.repo.StoreBatch(data)
+	if errors.Is(err, domain.ErrDuplicateReading) || errors.Is(err, domain.ErrUnknownSensorType) {
+		// A reading stored concurrently, or a sensor type deleted since the readings
+		// were validated, fails the whole INSERT; store the readings one by one instead
+		for _, w := range inserts {
+			w.err = p.repo.Store(w.data)
+		}
+	} else {
+		for _, w := range inserts {
+			w.err = err
+		}
+	}
+
+	for _, w := range repeats {
+		original := first[w.data.ReadingID]
+		w.err = original.err
+		if original.err == nil || errors.Is(original.err, domain.ErrDuplicateReading) {
+			w.data.ID = original.data.ID
+			w.err = domain.ErrDuplicateReading
+		}
+	}
+}
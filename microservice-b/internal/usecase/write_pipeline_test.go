@@ -0,0 +1,305 @@
+package usecase
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"sensor_project/microservice-b/internal/domain"
+)
+
+// fakeWriteRepo stores readings in memory for the write pipeline. Methods it does not
+// implement panic through the nil embedded interface.
+type fakeWriteRepo struct {
+	domain.SensorDataRepository
+
+	mu   sync.Mutex
+	rows []*domain.SensorData
+
+	// Stored reading IDs, readings stored concurrently that lookups do not see yet,
+	// unregistered sensor types and errors to fail calls with
+	readingIDs   map[string]int64
+	concurrent   map[string]int64
+	unknownTypes map[string]bool
+	lookupErr    error
+	batchErr     error
+
+	// Calls made by the pipeline
+	batches [][]*domain.SensorData
+	stores  []*domain.SensorData
+}
+
+// GetIDsByReadingID returns the IDs of the stored readings among readingIDs, not seeing
+// the ones stored concurrently
+func (r *fakeWriteRepo) GetIDsByReadingID(readingIDs []string) (map[string]int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.lookupErr != nil {
+		return nil, r.lookupErr
+	}
+	ids := make(map[string]int64)
+	for _, readingID := range readingIDs {
+		if id, ok := r.readingIDs[readingID]; ok {
+			ids[readingID] = id
+		}
+	}
+	return ids, nil
+}
+
+// StoreBatch stores all readings or none, like a multi-row INSERT
+func (r *fakeWriteRepo) StoreBatch(data []*domain.SensorData) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.batches = append(r.batches, data)
+	if r.batchErr != nil {
+		return r.batchErr
+	}
+	for _, d := range data {
+		if err := r.check(d); err != nil {
+			return err
+		}
+	}
+	for _, d := range data {
+		r.insert(d)
+	}
+	return nil
+}
+
+// Store stores a single reading, setting the ID of the stored one on a duplicate
+func (r *fakeWriteRepo) Store(data *domain.SensorData) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.stores = append(r.stores, data)
+	if err := r.check(data); err != nil {
+		if errors.Is(err, domain.ErrDuplicateReading) {
+			data.ID = r.storedID(data.ReadingID)
+		}
+		return err
+	}
+	r.insert(data)
+	return nil
+}
+
+// check reports why a reading cannot be inserted
+func (r *fakeWriteRepo) check(data *domain.SensorData) error {
+	if r.unknownTypes[data.SensorType] {
+		return domain.ErrUnknownSensorType
+	}
+	if data.ReadingID != "" && r.storedID(data.ReadingID) != 0 {
+		return domain.ErrDuplicateReading
+	}
+	return nil
+}
+
+// storedID returns the ID of the reading stored with a reading ID, or 0
+func (r *fakeWriteRepo) storedID(readingID string) int64 {
+	if id, ok := r.readingIDs[readingID]; ok {
+		return id
+	}
+	return r.concurrent[readingID]
+}
+
+// insert stores a reading under the next ID
+func (r *fakeWriteRepo) insert(data *domain.SensorData) {
+	data.ID = int64(len(r.rows) + 1000)
+	r.rows = append(r.rows, data)
+	if data.ReadingID != "" {
+		if r.readingIDs == nil {
+			r.readingIDs = make(map[string]int64)
+		}
+		r.readingIDs[data.ReadingID] = data.ID
+	}
+}
+
+func TestWritePipelineFlush(t *testing.T) {
+	errDatabase := errors.New("database is down")
+
+	// outcome is what a reading of the batch should end with
+	type outcome struct {
+		err error
+		id  int64 // 0 for a newly stored reading, whose ID is not known beforehand
+	}
+	tests := []struct {
+		name     string
+		repo     *fakeWriteRepo
+		batch    []*domain.SensorData
+		want     []outcome
+		batches  int // StoreBatch calls
+		stores   int // Store calls
+		inserted int // readings in the first StoreBatch call
+	}{
+		{
+			name: "new readings in one INSERT",
+			repo: &fakeWriteRepo{},
+			batch: []*domain.SensorData{
+				{ReadingID: "a", SensorType: "temperature"},
+				{ReadingID: "b", SensorType: "temperature"},
+				{SensorType: "humidity"},
+			},
+			want:     []outcome{{}, {}, {}},
+			batches:  1,
+			inserted: 3,
+		},
+		{
+			name: "repeats within the batch",
+			repo: &fakeWriteRepo{},
+			batch: []*domain.SensorData{
+				{ReadingID: "a", SensorType: "temperature"},
+				{ReadingID: "b", SensorType: "temperature"},
+				{ReadingID: "a", SensorType: "temperature"},
+				{ReadingID: "a", SensorType: "temperature"},
+			},
+			want: []outcome{
+				{},
+				{},
+				{err: domain.ErrDuplicateReading, id: 1000},
+				{err: domain.ErrDuplicateReading, id: 1000},
+			},
+			batches:  1,
+			inserted: 2,
+		},
+		{
+			name: "readings without reading IDs are not deduplicated",
+			repo: &fakeWriteRepo{},
+			batch: []*domain.SensorData{
+				{SensorType: "temperature"},
+				{SensorType: "temperature"},
+			},
+			want:     []outcome{{}, {}},
+			batches:  1,
+			inserted: 2,
+		},
+		{
+			name: "stored duplicates",
+			repo: &fakeWriteRepo{readingIDs: map[string]int64{"a": 7}},
+			batch: []*domain.SensorData{
+				{ReadingID: "a", SensorType: "temperature"},
+				{ReadingID: "b", SensorType: "temperature"},
+				{ReadingID: "a", SensorType: "temperature"},
+			},
+			want: []outcome{
+				{err: domain.ErrDuplicateReading, id: 7},
+				{},
+				{err: domain.ErrDuplicateReading, id: 7},
+			},
+			batches:  1,
+			inserted: 1,
+		},
+		{
+			name: "only stored duplicates",
+			repo: &fakeWriteRepo{readingIDs: map[string]int64{"a": 7}},
+			batch: []*domain.SensorData{
+				{ReadingID: "a", SensorType: "temperature"},
+			},
+			want:    []outcome{{err: domain.ErrDuplicateReading, id: 7}},
+			batches: 1,
+		},
+		{
+			name: "reading stored concurrently falls back to single inserts",
+			repo: &fakeWriteRepo{concurrent: map[string]int64{"b": 9}},
+			batch: []*domain.SensorData{
+				{ReadingID: "a", SensorType: "temperature"},
+				{ReadingID: "b", SensorType: "temperature"},
+				{ReadingID: "c", SensorType: "temperature"},
+				{ReadingID: "b", SensorType: "temperature"},
+			},
+			want: []outcome{
+				{},
+				{err: domain.ErrDuplicateReading, id: 9},
+				{},
+				{err: domain.ErrDuplicateReading, id: 9},
+			},
+			batches:  1,
+			stores:   3,
+			inserted: 3,
+		},
+		{
+			name: "deleted sensor type falls back to single inserts",
+			repo: &fakeWriteRepo{unknownTypes: map[string]bool{"pressure": true}},
+			batch: []*domain.SensorData{
+				{ReadingID: "a", SensorType: "temperature"},
+				{ReadingID: "b", SensorType: "pressure"},
+				{ReadingID: "b", SensorType: "pressure"},
+				{SensorType: "humidity"},
+			},
+			want: []outcome{
+				{},
+				{err: domain.ErrUnknownSensorType},
+				{err: domain.ErrUnknownSensorType},
+				{},
+			},
+			batches:  1,
+			stores:   3,
+			inserted: 3,
+		},
+		{
+			name: "failed lookup fails the batch",
+			repo: &fakeWriteRepo{lookupErr: errDatabase},
+			batch: []*domain.SensorData{
+				{ReadingID: "a", SensorType: "temperature"},
+				{SensorType: "temperature"},
+			},
+			want: []outcome{{err: errDatabase}, {err: errDatabase}},
+		},
+		{
+			name: "failed INSERT fails the batch",
+			repo: &fakeWriteRepo{batchErr: errDatabase},
+			batch: []*domain.SensorData{
+				{ReadingID: "a", SensorType: "temperature"},
+				{ReadingID: "a", SensorType: "temperature"},
+				{SensorType: "temperature"},
+			},
+			want:     []outcome{{err: errDatabase}, {err: errDatabase}, {err: errDatabase}},
+			batches:  1,
+			inserted: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &WritePipeline{repo: tt.repo}
+			batch := make([]*pendingWrite, len(tt.batch))
+			for i, data := range tt.batch {
+				data.CreatedAt = int64(i + 1)
+				batch[i] = &pendingWrite{data: data, done: make(chan struct{})}
+			}
+
+			p.flush(batch)
+
+			for i, w := range batch {
+				select {
+				case <-w.done:
+				default:
+					t.Fatalf("reading %d not done", i)
+				}
+				want := tt.want[i]
+				if !errors.Is(w.err, want.err) || (w.err == nil) != (want.err == nil) {
+					t.Errorf("reading %d: err = %v, want %v", i, w.err, want.err)
+				}
+				if want.id != 0 && w.data.ID != want.id {
+					t.Errorf("reading %d: ID = %d, want %d", i, w.data.ID, want.id)
+				}
+				if want.id == 0 && want.err == nil && w.data.ID < 1000 {
+					t.Errorf("reading %d: ID = %d, want a newly stored one", i, w.data.ID)
+				}
+				// Readings keep the ingest time they were submitted with
+				if w.data.CreatedAt != int64(i+1) {
+					t.Errorf("reading %d: CreatedAt = %d, want %d", i, w.data.CreatedAt, i+1)
+				}
+			}
+
+			if len(tt.repo.batches) != tt.batches {
+				t.Errorf("StoreBatch calls = %d, want %d", len(tt.repo.batches), tt.batches)
+			}
+			if len(tt.repo.batches) > 0 && len(tt.repo.batches[0]) != tt.inserted {
+				t.Errorf("first StoreBatch inserted %d readings, want %d", len(tt.repo.batches[0]), tt.inserted)
+			}
+			if len(tt.repo.stores) != tt.stores {
+				t.Errorf("Store calls = %d, want %d", len(tt.repo.stores), tt.stores)
+			}
+		})
+	}
+}
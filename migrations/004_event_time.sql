@@ -0,0 +1,16 @@
+USE sensor_data;
+
+-- Device event time; readings stored before it was kept take their ingest time
+ALTER TABLE sensor_data
+    ADD COLUMN event_time BIGINT NULL AFTER id2;
+
+UPDATE sensor_data SET event_time = created_at;
+
+ALTER TABLE sensor_data
+    MODIFY COLUMN event_time BIGINT NOT NULL,
+    ADD INDEX idx_event_time (event_time);
+
+-- Earlier versions of schema.sql failed to insert the default roles
+INSERT IGNORE INTO roles (name, description) VALUES
+('admin', 'Administrator with full access'),
+('user', 'Regular user with limited access');
@@ -0,0 +1,26 @@
+USE sensor_data;
+
+-- Sensor type units and value ranges
+ALTER TABLE sensor_types
+    ADD COLUMN unit VARCHAR(20) AFTER name,
+    ADD COLUMN min_value DOUBLE NULL AFTER description,
+    ADD COLUMN max_value DOUBLE NULL AFTER min_value;
+
+UPDATE sensor_types SET unit = '°C', min_value = -50, max_value = 150 WHERE name = 'temperature' AND unit IS NULL;
+UPDATE sensor_types SET unit = '%', min_value = 0, max_value = 100 WHERE name = 'humidity' AND unit IS NULL;
+UPDATE sensor_types SET unit = 'hPa', min_value = 300, max_value = 1100 WHERE name = 'pressure' AND unit IS NULL;
+UPDATE sensor_types SET unit = 'lux', min_value = 0, max_value = 100000 WHERE name = 'light' AND unit IS NULL;
+
+-- Readings held back by the unknown sensor type policy
+CREATE TABLE IF NOT EXISTS quarantined_sensor_data (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    sensor_value FLOAT NOT NULL,
+    sensor_type VARCHAR(50) NOT NULL,
+    id1 VARCHAR(255) NOT NULL,
+    id2 INT NOT NULL,
+    event_time BIGINT NOT NULL,
+    created_at BIGINT NOT NULL,
+    reason VARCHAR(255) NOT NULL,
+    INDEX idx_quarantine_sensor_type (sensor_type),
+    INDEX idx_quarantine_created_at (created_at)
+);
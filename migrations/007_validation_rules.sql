@@ -0,0 +1,11 @@
+USE sensor_data;
+
+-- Per-type validation rules and the quality flags they set on readings
+ALTER TABLE sensor_types
+    ADD COLUMN max_rate_of_change DOUBLE NULL AFTER max_value,
+    ADD COLUMN id1_pattern VARCHAR(255) NULL AFTER max_rate_of_change,
+    ADD COLUMN id2_pattern VARCHAR(255) NULL AFTER id1_pattern,
+    ADD COLUMN validation_action VARCHAR(10) NOT NULL DEFAULT 'flag' AFTER id2_pattern;
+
+ALTER TABLE sensor_data
+    ADD COLUMN quality_flags INT NOT NULL DEFAULT 0 AFTER created_at;
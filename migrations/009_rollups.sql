@@ -0,0 +1,37 @@
+USE sensor_data;
+
+-- Per-minute, hour and day rollups; stored readings are rolled up once the builder runs
+ALTER TABLE sensor_data
+    ADD INDEX idx_series_event_time (sensor_type_id, id1, id2, event_time);
+
+CREATE TABLE IF NOT EXISTS sensor_data_rollups (
+    resolution_ms BIGINT NOT NULL,
+    sensor_type_id INT NOT NULL,
+    id1 VARCHAR(10) NOT NULL,
+    id2 INT NOT NULL,
+    bucket_start BIGINT NOT NULL,
+    reading_count BIGINT NOT NULL,
+    value_sum DOUBLE NOT NULL,
+    value_sum_sq DOUBLE NOT NULL,
+    min_value FLOAT NOT NULL,
+    max_value FLOAT NOT NULL,
+    first_value FLOAT NOT NULL,
+    first_time BIGINT NOT NULL,
+    last_value FLOAT NOT NULL,
+    last_time BIGINT NOT NULL,
+    PRIMARY KEY (resolution_ms, sensor_type_id, id1, id2, bucket_start),
+    INDEX idx_rollup_bucket (resolution_ms, bucket_start)
+);
+
+CREATE TABLE IF NOT EXISTS sensor_data_rollup_dirty (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    sensor_type_id INT NOT NULL,
+    id1 VARCHAR(10) NOT NULL,
+    id2 INT NOT NULL,
+    bucket_start BIGINT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS rollup_state (
+    id TINYINT PRIMARY KEY,
+    watermark BIGINT NOT NULL
+);
@@ -0,0 +1,16 @@
+USE sensor_data;
+
+-- Per-sensor-type retention policies
+ALTER TABLE sensor_data
+    ADD INDEX idx_type_event_time (sensor_type_id, event_time);
+
+CREATE TABLE IF NOT EXISTS retention_policies (
+    sensor_type_id INT PRIMARY KEY,
+    raw_retention_ms BIGINT NOT NULL DEFAULT 0,
+    minute_rollup_retention_ms BIGINT NOT NULL DEFAULT 0,
+    hour_rollup_retention_ms BIGINT NOT NULL DEFAULT 0,
+    day_rollup_retention_ms BIGINT NOT NULL DEFAULT 0,
+    created_at BIGINT NOT NULL,
+    updated_at BIGINT NOT NULL,
+    FOREIGN KEY (sensor_type_id) REFERENCES sensor_types(id) ON DELETE CASCADE
+);
@@ -0,0 +1,17 @@
+USE sensor_data;
+
+-- Hashed, scoped API keys
+CREATE TABLE IF NOT EXISTS api_keys (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    user_id INT NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    key_prefix VARCHAR(16) NOT NULL,
+    key_hash CHAR(64) NOT NULL UNIQUE,
+    sensor_types JSON NULL,
+    devices JSON NULL,
+    expires_at BIGINT NULL,
+    revoked_at BIGINT NULL,
+    created_at BIGINT NOT NULL,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    INDEX idx_api_keys_user (user_id)
+);
@@ -0,0 +1,5 @@
+USE sensor_data;
+
+-- Disabled users
+ALTER TABLE users
+    ADD COLUMN disabled_at BIGINT NULL AFTER email;
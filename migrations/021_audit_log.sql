@@ -0,0 +1,25 @@
+USE sensor_data;
+
+-- Append-only audit log of sensor data changes
+CREATE TABLE IF NOT EXISTS audit_log (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    actor_user_id INT NULL,
+    actor_username VARCHAR(50) NOT NULL,
+    actor_api_key_id INT NULL,
+    operation VARCHAR(32) NOT NULL,
+    sensor_data_id BIGINT NOT NULL,
+    filter JSON NULL,
+    before_value JSON NULL,
+    after_value JSON NULL,
+    created_at BIGINT NOT NULL,
+    INDEX idx_audit_created (created_at),
+    INDEX idx_audit_actor (actor_user_id, id),
+    INDEX idx_audit_operation (operation, id),
+    INDEX idx_audit_sensor_data (sensor_data_id, id)
+);
+
+CREATE TRIGGER IF NOT EXISTS audit_log_no_update BEFORE UPDATE ON audit_log
+FOR EACH ROW SIGNAL SQLSTATE '45000' SET MESSAGE_TEXT = 'audit_log is append-only';
+
+CREATE TRIGGER IF NOT EXISTS audit_log_no_delete BEFORE DELETE ON audit_log
+FOR EACH ROW SIGNAL SQLSTATE '45000' SET MESSAGE_TEXT = 'audit_log is append-only';
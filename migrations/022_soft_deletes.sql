@@ -0,0 +1,8 @@
+USE sensor_data;
+
+-- Soft deletes in restorable batches
+ALTER TABLE sensor_data
+    ADD COLUMN deleted_at BIGINT NULL AFTER quality_flags,
+    ADD COLUMN deletion_batch_id CHAR(32) NULL AFTER deleted_at,
+    ADD INDEX idx_deleted_at (deleted_at),
+    ADD INDEX idx_deletion_batch (deletion_batch_id);
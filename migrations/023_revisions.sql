@@ -0,0 +1,19 @@
+USE sensor_data;
+
+-- Corrections kept as revisions, with the measured value in raw_value
+ALTER TABLE sensor_data
+    ADD COLUMN raw_value FLOAT NULL AFTER sensor_value,
+    ADD COLUMN revision INT NOT NULL DEFAULT 0 AFTER raw_value;
+
+CREATE TABLE IF NOT EXISTS sensor_data_revisions (
+    sensor_data_id BIGINT NOT NULL,
+    revision INT NOT NULL,
+    sensor_value FLOAT NOT NULL,
+    reason VARCHAR(32) NOT NULL,
+    actor_user_id INT NULL,
+    actor_username VARCHAR(50) NOT NULL,
+    actor_api_key_id INT NULL,
+    created_at BIGINT NOT NULL,
+    PRIMARY KEY (sensor_data_id, revision),
+    FOREIGN KEY (sensor_data_id) REFERENCES sensor_data(id) ON DELETE CASCADE
+);
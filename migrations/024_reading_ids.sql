@@ -0,0 +1,10 @@
+USE sensor_data;
+
+-- Client-supplied reading IDs, unique so retried readings are stored or quarantined once
+ALTER TABLE sensor_data
+    ADD COLUMN reading_id VARCHAR(36) NULL AFTER id,
+    ADD UNIQUE INDEX uniq_reading_id (reading_id);
+
+ALTER TABLE quarantined_sensor_data
+    ADD COLUMN reading_id VARCHAR(36) NULL AFTER id,
+    ADD UNIQUE INDEX uniq_quarantine_reading_id (reading_id);
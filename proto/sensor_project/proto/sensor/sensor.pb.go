@@ -21,7 +21,8 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
-// SensorData represents a single sensor reading
+// SensorData represents a single sensor reading. reading_id optionally identifies the
+// reading with a UUID or ULID so the server stores it only once however often it is sent.
 type SensorData struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	SensorValue   float32                `protobuf:"fixed32,1,opt,name=sensor_value,json=sensorValue,proto3" json:"sensor_value,omitempty"`
@@ -29,6 +30,7 @@ type SensorData struct {
 	Id1           string                 `protobuf:"bytes,3,opt,name=id1,proto3" json:"id1,omitempty"`
 	Id2           int32                  `protobuf:"varint,4,opt,name=id2,proto3" json:"id2,omitempty"`
 	Timestamp     int64                  `protobuf:"varint,5,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	ReadingId     string                 `protobuf:"bytes,6,opt,name=reading_id,json=readingId,proto3" json:"reading_id,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -98,30 +100,1275 @@ func (x *SensorData) GetTimestamp() int64 {
 	return 0
 }
 
+func (x *SensorData) GetReadingId() string {
+	if x != nil {
+		return x.ReadingId
+	}
+	return ""
+}
+
+// SensorReading is a stored sensor reading. Times are Unix milliseconds. revisions
+// counts the corrections made to the measured value.
+type SensorReading struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	SensorValue   float64                `protobuf:"fixed64,2,opt,name=sensor_value,json=sensorValue,proto3" json:"sensor_value,omitempty"`
+	SensorType    string                 `protobuf:"bytes,3,opt,name=sensor_type,json=sensorType,proto3" json:"sensor_type,omitempty"`
+	Id1           string                 `protobuf:"bytes,4,opt,name=id1,proto3" json:"id1,omitempty"`
+	Id2           int32                  `protobuf:"varint,5,opt,name=id2,proto3" json:"id2,omitempty"`
+	EventTime     int64                  `protobuf:"varint,6,opt,name=event_time,json=eventTime,proto3" json:"event_time,omitempty"`
+	CreatedAt     int64                  `protobuf:"varint,7,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	QualityFlags  int32                  `protobuf:"varint,8,opt,name=quality_flags,json=qualityFlags,proto3" json:"quality_flags,omitempty"`
+	Quality       []string               `protobuf:"bytes,9,rep,name=quality,proto3" json:"quality,omitempty"`
+	Revisions     int32                  `protobuf:"varint,10,opt,name=revisions,proto3" json:"revisions,omitempty"`
+	ReadingId     string                 `protobuf:"bytes,11,opt,name=reading_id,json=readingId,proto3" json:"reading_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SensorReading) Reset() {
+	*x = SensorReading{}
+	mi := &file_sensor_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SensorReading) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SensorReading) ProtoMessage() {}
+
+func (x *SensorReading) ProtoReflect() protoreflect.Message {
+	mi := &file_sensor_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SensorReading.ProtoReflect.Descriptor instead.
+func (*SensorReading) Descriptor() ([]byte, []int) {
+	return file_sensor_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *SensorReading) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *SensorReading) GetSensorValue() float64 {
+	if x != nil {
+		return x.SensorValue
+	}
+	return 0
+}
+
+func (x *SensorReading) GetSensorType() string {
+	if x != nil {
+		return x.SensorType
+	}
+	return ""
+}
+
+func (x *SensorReading) GetId1() string {
+	if x != nil {
+		return x.Id1
+	}
+	return ""
+}
+
+func (x *SensorReading) GetId2() int32 {
+	if x != nil {
+		return x.Id2
+	}
+	return 0
+}
+
+func (x *SensorReading) GetEventTime() int64 {
+	if x != nil {
+		return x.EventTime
+	}
+	return 0
+}
+
+func (x *SensorReading) GetCreatedAt() int64 {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return 0
+}
+
+func (x *SensorReading) GetQualityFlags() int32 {
+	if x != nil {
+		return x.QualityFlags
+	}
+	return 0
+}
+
+func (x *SensorReading) GetQuality() []string {
+	if x != nil {
+		return x.Quality
+	}
+	return nil
+}
+
+func (x *SensorReading) GetRevisions() int32 {
+	if x != nil {
+		return x.Revisions
+	}
+	return 0
+}
+
+func (x *SensorReading) GetReadingId() string {
+	if x != nil {
+		return x.ReadingId
+	}
+	return ""
+}
+
+// SensorDataID identifies a stored reading
+type SensorDataID struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SensorDataID) Reset() {
+	*x = SensorDataID{}
+	mi := &file_sensor_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SensorDataID) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SensorDataID) ProtoMessage() {}
+
+func (x *SensorDataID) ProtoReflect() protoreflect.Message {
+	mi := &file_sensor_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SensorDataID.ProtoReflect.Descriptor instead.
+func (*SensorDataID) Descriptor() ([]byte, []int) {
+	return file_sensor_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *SensorDataID) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+// SensorDataFilter selects stored readings. Repeated criteria match any of their
+// values and unset ones match everything. Times are Unix milliseconds.
+type SensorDataFilter struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id1           []string               `protobuf:"bytes,1,rep,name=id1,proto3" json:"id1,omitempty"`
+	Id2           []int32                `protobuf:"varint,2,rep,packed,name=id2,proto3" json:"id2,omitempty"`
+	Id2Min        *int32                 `protobuf:"varint,3,opt,name=id2_min,json=id2Min,proto3,oneof" json:"id2_min,omitempty"`
+	Id2Max        *int32                 `protobuf:"varint,4,opt,name=id2_max,json=id2Max,proto3,oneof" json:"id2_max,omitempty"`
+	SensorType    []string               `protobuf:"bytes,5,rep,name=sensor_type,json=sensorType,proto3" json:"sensor_type,omitempty"`
+	MinValue      *float64               `protobuf:"fixed64,6,opt,name=min_value,json=minValue,proto3,oneof" json:"min_value,omitempty"`
+	MaxValue      *float64               `protobuf:"fixed64,7,opt,name=max_value,json=maxValue,proto3,oneof" json:"max_value,omitempty"`
+	StartTime     *int64                 `protobuf:"varint,8,opt,name=start_time,json=startTime,proto3,oneof" json:"start_time,omitempty"`
+	EndTime       *int64                 `protobuf:"varint,9,opt,name=end_time,json=endTime,proto3,oneof" json:"end_time,omitempty"`
+	TimeField     string                 `protobuf:"bytes,10,opt,name=time_field,json=timeField,proto3" json:"time_field,omitempty"`                 // event_time (default) or created_at
+	QualityFlags  *int32                 `protobuf:"varint,11,opt,name=quality_flags,json=qualityFlags,proto3,oneof" json:"quality_flags,omitempty"` // any of these flags; 0 selects readings without flags
+	AsOf          *int64                 `protobuf:"varint,12,opt,name=as_of,json=asOf,proto3,oneof" json:"as_of,omitempty"`                         // readings and values as they were at this time; not for Subscribe
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SensorDataFilter) Reset() {
+	*x = SensorDataFilter{}
+	mi := &file_sensor_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SensorDataFilter) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SensorDataFilter) ProtoMessage() {}
+
+func (x *SensorDataFilter) ProtoReflect() protoreflect.Message {
+	mi := &file_sensor_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SensorDataFilter.ProtoReflect.Descriptor instead.
+func (*SensorDataFilter) Descriptor() ([]byte, []int) {
+	return file_sensor_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *SensorDataFilter) GetId1() []string {
+	if x != nil {
+		return x.Id1
+	}
+	return nil
+}
+
+func (x *SensorDataFilter) GetId2() []int32 {
+	if x != nil {
+		return x.Id2
+	}
+	return nil
+}
+
+func (x *SensorDataFilter) GetId2Min() int32 {
+	if x != nil && x.Id2Min != nil {
+		return *x.Id2Min
+	}
+	return 0
+}
+
+func (x *SensorDataFilter) GetId2Max() int32 {
+	if x != nil && x.Id2Max != nil {
+		return *x.Id2Max
+	}
+	return 0
+}
+
+func (x *SensorDataFilter) GetSensorType() []string {
+	if x != nil {
+		return x.SensorType
+	}
+	return nil
+}
+
+func (x *SensorDataFilter) GetMinValue() float64 {
+	if x != nil && x.MinValue != nil {
+		return *x.MinValue
+	}
+	return 0
+}
+
+func (x *SensorDataFilter) GetMaxValue() float64 {
+	if x != nil && x.MaxValue != nil {
+		return *x.MaxValue
+	}
+	return 0
+}
+
+func (x *SensorDataFilter) GetStartTime() int64 {
+	if x != nil && x.StartTime != nil {
+		return *x.StartTime
+	}
+	return 0
+}
+
+func (x *SensorDataFilter) GetEndTime() int64 {
+	if x != nil && x.EndTime != nil {
+		return *x.EndTime
+	}
+	return 0
+}
+
+func (x *SensorDataFilter) GetTimeField() string {
+	if x != nil {
+		return x.TimeField
+	}
+	return ""
+}
+
+func (x *SensorDataFilter) GetQualityFlags() int32 {
+	if x != nil && x.QualityFlags != nil {
+		return *x.QualityFlags
+	}
+	return 0
+}
+
+func (x *SensorDataFilter) GetAsOf() int64 {
+	if x != nil && x.AsOf != nil {
+		return *x.AsOf
+	}
+	return 0
+}
+
+// SortField is one key of a sort order
+type SortField struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"` // value, time or id
+	Desc          bool                   `protobuf:"varint,2,opt,name=desc,proto3" json:"desc,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SortField) Reset() {
+	*x = SortField{}
+	mi := &file_sensor_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SortField) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SortField) ProtoMessage() {}
+
+func (x *SortField) ProtoReflect() protoreflect.Message {
+	mi := &file_sensor_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SortField.ProtoReflect.Descriptor instead.
+func (*SortField) Descriptor() ([]byte, []int) {
+	return file_sensor_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *SortField) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *SortField) GetDesc() bool {
+	if x != nil {
+		return x.Desc
+	}
+	return false
+}
+
+// QuerySensorDataRequest selects a page of readings. Offset pages are numbered from 1
+// and ordered by sort, newest first by default. Cursor pages, used when cursor is set
+// or use_cursor is true, are ordered newest first by ingest time and cannot be sorted.
+type QuerySensorDataRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Filter        *SensorDataFilter      `protobuf:"bytes,1,opt,name=filter,proto3" json:"filter,omitempty"`
+	Sort          []*SortField           `protobuf:"bytes,2,rep,name=sort,proto3" json:"sort,omitempty"`
+	Page          int32                  `protobuf:"varint,3,opt,name=page,proto3" json:"page,omitempty"`
+	PageSize      int32                  `protobuf:"varint,4,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"` // default 10, at most 100
+	UseCursor     bool                   `protobuf:"varint,5,opt,name=use_cursor,json=useCursor,proto3" json:"use_cursor,omitempty"`
+	Cursor        string                 `protobuf:"bytes,6,opt,name=cursor,proto3" json:"cursor,omitempty"`                                  // next_cursor or prev_cursor of a previous cursor page
+	IncludeTotal  bool                   `protobuf:"varint,7,opt,name=include_total,json=includeTotal,proto3" json:"include_total,omitempty"` // count matching readings for a cursor page
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *QuerySensorDataRequest) Reset() {
+	*x = QuerySensorDataRequest{}
+	mi := &file_sensor_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *QuerySensorDataRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*QuerySensorDataRequest) ProtoMessage() {}
+
+func (x *QuerySensorDataRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_sensor_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use QuerySensorDataRequest.ProtoReflect.Descriptor instead.
+func (*QuerySensorDataRequest) Descriptor() ([]byte, []int) {
+	return file_sensor_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *QuerySensorDataRequest) GetFilter() *SensorDataFilter {
+	if x != nil {
+		return x.Filter
+	}
+	return nil
+}
+
+func (x *QuerySensorDataRequest) GetSort() []*SortField {
+	if x != nil {
+		return x.Sort
+	}
+	return nil
+}
+
+func (x *QuerySensorDataRequest) GetPage() int32 {
+	if x != nil {
+		return x.Page
+	}
+	return 0
+}
+
+func (x *QuerySensorDataRequest) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+func (x *QuerySensorDataRequest) GetUseCursor() bool {
+	if x != nil {
+		return x.UseCursor
+	}
+	return false
+}
+
+func (x *QuerySensorDataRequest) GetCursor() string {
+	if x != nil {
+		return x.Cursor
+	}
+	return ""
+}
+
+func (x *QuerySensorDataRequest) GetIncludeTotal() bool {
+	if x != nil {
+		return x.IncludeTotal
+	}
+	return false
+}
+
+// QuerySensorDataResponse is a page of readings
+type QuerySensorDataResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Data          []*SensorReading       `protobuf:"bytes,1,rep,name=data,proto3" json:"data,omitempty"`
+	Total         *int32                 `protobuf:"varint,2,opt,name=total,proto3,oneof" json:"total,omitempty"`
+	Page          int32                  `protobuf:"varint,3,opt,name=page,proto3" json:"page,omitempty"`
+	PageSize      int32                  `protobuf:"varint,4,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`
+	TotalPages    *int32                 `protobuf:"varint,5,opt,name=total_pages,json=totalPages,proto3,oneof" json:"total_pages,omitempty"`
+	NextCursor    string                 `protobuf:"bytes,6,opt,name=next_cursor,json=nextCursor,proto3" json:"next_cursor,omitempty"`
+	PrevCursor    string                 `protobuf:"bytes,7,opt,name=prev_cursor,json=prevCursor,proto3" json:"prev_cursor,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *QuerySensorDataResponse) Reset() {
+	*x = QuerySensorDataResponse{}
+	mi := &file_sensor_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *QuerySensorDataResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*QuerySensorDataResponse) ProtoMessage() {}
+
+func (x *QuerySensorDataResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_sensor_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use QuerySensorDataResponse.ProtoReflect.Descriptor instead.
+func (*QuerySensorDataResponse) Descriptor() ([]byte, []int) {
+	return file_sensor_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *QuerySensorDataResponse) GetData() []*SensorReading {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+func (x *QuerySensorDataResponse) GetTotal() int32 {
+	if x != nil && x.Total != nil {
+		return *x.Total
+	}
+	return 0
+}
+
+func (x *QuerySensorDataResponse) GetPage() int32 {
+	if x != nil {
+		return x.Page
+	}
+	return 0
+}
+
+func (x *QuerySensorDataResponse) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+func (x *QuerySensorDataResponse) GetTotalPages() int32 {
+	if x != nil && x.TotalPages != nil {
+		return *x.TotalPages
+	}
+	return 0
+}
+
+func (x *QuerySensorDataResponse) GetNextCursor() string {
+	if x != nil {
+		return x.NextCursor
+	}
+	return ""
+}
+
+func (x *QuerySensorDataResponse) GetPrevCursor() string {
+	if x != nil {
+		return x.PrevCursor
+	}
+	return ""
+}
+
+// AggregateRequest groups the readings matching a filter into time buckets per sensor
+// type and device
+type AggregateRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Filter        *SensorDataFilter      `protobuf:"bytes,1,opt,name=filter,proto3" json:"filter,omitempty"`
+	Interval      string                 `protobuf:"bytes,2,opt,name=interval,proto3" json:"interval,omitempty"`   // 1m, 5m, 1h or 1d
+	Functions     []string               `protobuf:"bytes,3,rep,name=functions,proto3" json:"functions,omitempty"` // min, max, avg, count, sum, stddev, first, last
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AggregateRequest) Reset() {
+	*x = AggregateRequest{}
+	mi := &file_sensor_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AggregateRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AggregateRequest) ProtoMessage() {}
+
+func (x *AggregateRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_sensor_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AggregateRequest.ProtoReflect.Descriptor instead.
+func (*AggregateRequest) Descriptor() ([]byte, []int) {
+	return file_sensor_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *AggregateRequest) GetFilter() *SensorDataFilter {
+	if x != nil {
+		return x.Filter
+	}
+	return nil
+}
+
+func (x *AggregateRequest) GetInterval() string {
+	if x != nil {
+		return x.Interval
+	}
+	return ""
+}
+
+func (x *AggregateRequest) GetFunctions() []string {
+	if x != nil {
+		return x.Functions
+	}
+	return nil
+}
+
+// AggregateBucket holds the values of the aggregate functions over one bucket
+type AggregateBucket struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	BucketStart   int64                  `protobuf:"varint,1,opt,name=bucket_start,json=bucketStart,proto3" json:"bucket_start,omitempty"` // Unix milliseconds
+	SensorType    string                 `protobuf:"bytes,2,opt,name=sensor_type,json=sensorType,proto3" json:"sensor_type,omitempty"`
+	Id1           string                 `protobuf:"bytes,3,opt,name=id1,proto3" json:"id1,omitempty"`
+	Id2           int32                  `protobuf:"varint,4,opt,name=id2,proto3" json:"id2,omitempty"`
+	Values        map[string]float64     `protobuf:"bytes,5,rep,name=values,proto3" json:"values,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"fixed64,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AggregateBucket) Reset() {
+	*x = AggregateBucket{}
+	mi := &file_sensor_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AggregateBucket) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AggregateBucket) ProtoMessage() {}
+
+func (x *AggregateBucket) ProtoReflect() protoreflect.Message {
+	mi := &file_sensor_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AggregateBucket.ProtoReflect.Descriptor instead.
+func (*AggregateBucket) Descriptor() ([]byte, []int) {
+	return file_sensor_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *AggregateBucket) GetBucketStart() int64 {
+	if x != nil {
+		return x.BucketStart
+	}
+	return 0
+}
+
+func (x *AggregateBucket) GetSensorType() string {
+	if x != nil {
+		return x.SensorType
+	}
+	return ""
+}
+
+func (x *AggregateBucket) GetId1() string {
+	if x != nil {
+		return x.Id1
+	}
+	return ""
+}
+
+func (x *AggregateBucket) GetId2() int32 {
+	if x != nil {
+		return x.Id2
+	}
+	return 0
+}
+
+func (x *AggregateBucket) GetValues() map[string]float64 {
+	if x != nil {
+		return x.Values
+	}
+	return nil
+}
+
+// AggregateResponse holds the buckets of an aggregation
+type AggregateResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Interval      string                 `protobuf:"bytes,1,opt,name=interval,proto3" json:"interval,omitempty"`
+	Functions     []string               `protobuf:"bytes,2,rep,name=functions,proto3" json:"functions,omitempty"`
+	Buckets       []*AggregateBucket     `protobuf:"bytes,3,rep,name=buckets,proto3" json:"buckets,omitempty"`
+	Truncated     bool                   `protobuf:"varint,4,opt,name=truncated,proto3" json:"truncated,omitempty"`
+	Source        string                 `protobuf:"bytes,5,opt,name=source,proto3" json:"source,omitempty"` // raw or the rollup used, e.g. rollup_1h
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AggregateResponse) Reset() {
+	*x = AggregateResponse{}
+	mi := &file_sensor_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AggregateResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AggregateResponse) ProtoMessage() {}
+
+func (x *AggregateResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_sensor_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AggregateResponse.ProtoReflect.Descriptor instead.
+func (*AggregateResponse) Descriptor() ([]byte, []int) {
+	return file_sensor_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *AggregateResponse) GetInterval() string {
+	if x != nil {
+		return x.Interval
+	}
+	return ""
+}
+
+func (x *AggregateResponse) GetFunctions() []string {
+	if x != nil {
+		return x.Functions
+	}
+	return nil
+}
+
+func (x *AggregateResponse) GetBuckets() []*AggregateBucket {
+	if x != nil {
+		return x.Buckets
+	}
+	return nil
+}
+
+func (x *AggregateResponse) GetTruncated() bool {
+	if x != nil {
+		return x.Truncated
+	}
+	return false
+}
+
+func (x *AggregateResponse) GetSource() string {
+	if x != nil {
+		return x.Source
+	}
+	return ""
+}
+
+// SubscribeRequest starts a live stream of readings. A resume_after, the id of the
+// last reading received, first replays the matching readings stored since.
+type SubscribeRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Filter        *SensorDataFilter      `protobuf:"bytes,1,opt,name=filter,proto3" json:"filter,omitempty"`
+	ResumeAfter   int64                  `protobuf:"varint,2,opt,name=resume_after,json=resumeAfter,proto3" json:"resume_after,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SubscribeRequest) Reset() {
+	*x = SubscribeRequest{}
+	mi := &file_sensor_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SubscribeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SubscribeRequest) ProtoMessage() {}
+
+func (x *SubscribeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_sensor_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SubscribeRequest.ProtoReflect.Descriptor instead.
+func (*SubscribeRequest) Descriptor() ([]byte, []int) {
+	return file_sensor_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *SubscribeRequest) GetFilter() *SensorDataFilter {
+	if x != nil {
+		return x.Filter
+	}
+	return nil
+}
+
+func (x *SubscribeRequest) GetResumeAfter() int64 {
+	if x != nil {
+		return x.ResumeAfter
+	}
+	return 0
+}
+
 // SensorResponse is the response from the sensor service
 type SensorResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
-	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	Duplicate     bool                   `protobuf:"varint,3,opt,name=duplicate,proto3" json:"duplicate,omitempty"` // the reading_id was already stored, so the reading was not stored again
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SensorResponse) Reset() {
+	*x = SensorResponse{}
+	mi := &file_sensor_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SensorResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SensorResponse) ProtoMessage() {}
+
+func (x *SensorResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_sensor_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SensorResponse.ProtoReflect.Descriptor instead.
+func (*SensorResponse) Descriptor() ([]byte, []int) {
+	return file_sensor_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *SensorResponse) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+func (x *SensorResponse) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *SensorResponse) GetDuplicate() bool {
+	if x != nil {
+		return x.Duplicate
+	}
+	return false
+}
+
+// SensorDataBatch carries several sensor readings sent in one call
+type SensorDataBatch struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Readings      []*SensorData          `protobuf:"bytes,1,rep,name=readings,proto3" json:"readings,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SensorDataBatch) Reset() {
+	*x = SensorDataBatch{}
+	mi := &file_sensor_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SensorDataBatch) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SensorDataBatch) ProtoMessage() {}
+
+func (x *SensorDataBatch) ProtoReflect() protoreflect.Message {
+	mi := &file_sensor_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SensorDataBatch.ProtoReflect.Descriptor instead.
+func (*SensorDataBatch) Descriptor() ([]byte, []int) {
+	return file_sensor_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *SensorDataBatch) GetReadings() []*SensorData {
+	if x != nil {
+		return x.Readings
+	}
+	return nil
+}
+
+// SensorDataBatchResponse reports the outcome of every reading of a batch, in the order
+// they were sent, and how many were stored, duplicates, quarantined or rejected
+type SensorDataBatchResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Results       []*SensorResponse      `protobuf:"bytes,1,rep,name=results,proto3" json:"results,omitempty"`
+	Stored        int32                  `protobuf:"varint,2,opt,name=stored,proto3" json:"stored,omitempty"`
+	Duplicates    int32                  `protobuf:"varint,3,opt,name=duplicates,proto3" json:"duplicates,omitempty"`
+	Quarantined   int32                  `protobuf:"varint,4,opt,name=quarantined,proto3" json:"quarantined,omitempty"`
+	Rejected      int32                  `protobuf:"varint,5,opt,name=rejected,proto3" json:"rejected,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SensorDataBatchResponse) Reset() {
+	*x = SensorDataBatchResponse{}
+	mi := &file_sensor_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SensorDataBatchResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SensorDataBatchResponse) ProtoMessage() {}
+
+func (x *SensorDataBatchResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_sensor_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SensorDataBatchResponse.ProtoReflect.Descriptor instead.
+func (*SensorDataBatchResponse) Descriptor() ([]byte, []int) {
+	return file_sensor_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *SensorDataBatchResponse) GetResults() []*SensorResponse {
+	if x != nil {
+		return x.Results
+	}
+	return nil
+}
+
+func (x *SensorDataBatchResponse) GetStored() int32 {
+	if x != nil {
+		return x.Stored
+	}
+	return 0
+}
+
+func (x *SensorDataBatchResponse) GetDuplicates() int32 {
+	if x != nil {
+		return x.Duplicates
+	}
+	return 0
+}
+
+func (x *SensorDataBatchResponse) GetQuarantined() int32 {
+	if x != nil {
+		return x.Quarantined
+	}
+	return 0
+}
+
+func (x *SensorDataBatchResponse) GetRejected() int32 {
+	if x != nil {
+		return x.Rejected
+	}
+	return 0
+}
+
+// FrequencyRequest is used to change the data generation frequency
+type FrequencyRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	IntervalMs    int32                  `protobuf:"varint,1,opt,name=interval_ms,json=intervalMs,proto3" json:"interval_ms,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FrequencyRequest) Reset() {
+	*x = FrequencyRequest{}
+	mi := &file_sensor_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FrequencyRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FrequencyRequest) ProtoMessage() {}
+
+func (x *FrequencyRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_sensor_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FrequencyRequest.ProtoReflect.Descriptor instead.
+func (*FrequencyRequest) Descriptor() ([]byte, []int) {
+	return file_sensor_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *FrequencyRequest) GetIntervalMs() int32 {
+	if x != nil {
+		return x.IntervalMs
+	}
+	return 0
+}
+
+// FrequencyResponse is the response to a frequency change request
+type FrequencyResponse struct {
+	state             protoimpl.MessageState `protogen:"open.v1"`
+	Success           bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	Message           string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	CurrentIntervalMs int32                  `protobuf:"varint,3,opt,name=current_interval_ms,json=currentIntervalMs,proto3" json:"current_interval_ms,omitempty"`
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
+}
+
+func (x *FrequencyResponse) Reset() {
+	*x = FrequencyResponse{}
+	mi := &file_sensor_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FrequencyResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FrequencyResponse) ProtoMessage() {}
+
+func (x *FrequencyResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_sensor_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FrequencyResponse.ProtoReflect.Descriptor instead.
+func (*FrequencyResponse) Descriptor() ([]byte, []int) {
+	return file_sensor_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *FrequencyResponse) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+func (x *FrequencyResponse) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *FrequencyResponse) GetCurrentIntervalMs() int32 {
+	if x != nil {
+		return x.CurrentIntervalMs
+	}
+	return 0
+}
+
+// SensorType describes a kind of sensor and the rules its readings are validated against
+type SensorType struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	Id               int32                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name             string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Unit             string                 `protobuf:"bytes,3,opt,name=unit,proto3" json:"unit,omitempty"`
+	Description      string                 `protobuf:"bytes,4,opt,name=description,proto3" json:"description,omitempty"`
+	MinValue         *float64               `protobuf:"fixed64,5,opt,name=min_value,json=minValue,proto3,oneof" json:"min_value,omitempty"`
+	MaxValue         *float64               `protobuf:"fixed64,6,opt,name=max_value,json=maxValue,proto3,oneof" json:"max_value,omitempty"`
+	CreatedAt        int64                  `protobuf:"varint,7,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	UpdatedAt        int64                  `protobuf:"varint,8,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	MaxRateOfChange  *float64               `protobuf:"fixed64,9,opt,name=max_rate_of_change,json=maxRateOfChange,proto3,oneof" json:"max_rate_of_change,omitempty"` // units per second
+	Id1Pattern       string                 `protobuf:"bytes,10,opt,name=id1_pattern,json=id1Pattern,proto3" json:"id1_pattern,omitempty"`
+	Id2Pattern       string                 `protobuf:"bytes,11,opt,name=id2_pattern,json=id2Pattern,proto3" json:"id2_pattern,omitempty"`
+	ValidationAction string                 `protobuf:"bytes,12,opt,name=validation_action,json=validationAction,proto3" json:"validation_action,omitempty"` // reject, clamp or flag
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *SensorType) Reset() {
+	*x = SensorType{}
+	mi := &file_sensor_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SensorType) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SensorType) ProtoMessage() {}
+
+func (x *SensorType) ProtoReflect() protoreflect.Message {
+	mi := &file_sensor_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SensorType.ProtoReflect.Descriptor instead.
+func (*SensorType) Descriptor() ([]byte, []int) {
+	return file_sensor_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *SensorType) GetId() int32 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *SensorType) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *SensorType) GetUnit() string {
+	if x != nil {
+		return x.Unit
+	}
+	return ""
+}
+
+func (x *SensorType) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *SensorType) GetMinValue() float64 {
+	if x != nil && x.MinValue != nil {
+		return *x.MinValue
+	}
+	return 0
+}
+
+func (x *SensorType) GetMaxValue() float64 {
+	if x != nil && x.MaxValue != nil {
+		return *x.MaxValue
+	}
+	return 0
+}
+
+func (x *SensorType) GetCreatedAt() int64 {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return 0
+}
+
+func (x *SensorType) GetUpdatedAt() int64 {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return 0
+}
+
+func (x *SensorType) GetMaxRateOfChange() float64 {
+	if x != nil && x.MaxRateOfChange != nil {
+		return *x.MaxRateOfChange
+	}
+	return 0
+}
+
+func (x *SensorType) GetId1Pattern() string {
+	if x != nil {
+		return x.Id1Pattern
+	}
+	return ""
+}
+
+func (x *SensorType) GetId2Pattern() string {
+	if x != nil {
+		return x.Id2Pattern
+	}
+	return ""
+}
+
+func (x *SensorType) GetValidationAction() string {
+	if x != nil {
+		return x.ValidationAction
+	}
+	return ""
+}
+
+// SensorTypeID identifies a sensor type
+type SensorTypeID struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int32                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *SensorResponse) Reset() {
-	*x = SensorResponse{}
-	mi := &file_sensor_proto_msgTypes[1]
+func (x *SensorTypeID) Reset() {
+	*x = SensorTypeID{}
+	mi := &file_sensor_proto_msgTypes[17]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *SensorResponse) String() string {
+func (x *SensorTypeID) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*SensorResponse) ProtoMessage() {}
+func (*SensorTypeID) ProtoMessage() {}
 
-func (x *SensorResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_sensor_proto_msgTypes[1]
+func (x *SensorTypeID) ProtoReflect() protoreflect.Message {
+	mi := &file_sensor_proto_msgTypes[17]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -132,48 +1379,231 @@ func (x *SensorResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use SensorResponse.ProtoReflect.Descriptor instead.
-func (*SensorResponse) Descriptor() ([]byte, []int) {
-	return file_sensor_proto_rawDescGZIP(), []int{1}
+// Deprecated: Use SensorTypeID.ProtoReflect.Descriptor instead.
+func (*SensorTypeID) Descriptor() ([]byte, []int) {
+	return file_sensor_proto_rawDescGZIP(), []int{17}
 }
 
-func (x *SensorResponse) GetSuccess() bool {
+func (x *SensorTypeID) GetId() int32 {
 	if x != nil {
-		return x.Success
+		return x.Id
+	}
+	return 0
+}
+
+// ListSensorTypesRequest is used to list all sensor types
+type ListSensorTypesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListSensorTypesRequest) Reset() {
+	*x = ListSensorTypesRequest{}
+	mi := &file_sensor_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListSensorTypesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListSensorTypesRequest) ProtoMessage() {}
+
+func (x *ListSensorTypesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_sensor_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListSensorTypesRequest.ProtoReflect.Descriptor instead.
+func (*ListSensorTypesRequest) Descriptor() ([]byte, []int) {
+	return file_sensor_proto_rawDescGZIP(), []int{18}
+}
+
+// ListSensorTypesResponse contains all sensor types
+type ListSensorTypesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	SensorTypes   []*SensorType          `protobuf:"bytes,1,rep,name=sensor_types,json=sensorTypes,proto3" json:"sensor_types,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListSensorTypesResponse) Reset() {
+	*x = ListSensorTypesResponse{}
+	mi := &file_sensor_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListSensorTypesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListSensorTypesResponse) ProtoMessage() {}
+
+func (x *ListSensorTypesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_sensor_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListSensorTypesResponse.ProtoReflect.Descriptor instead.
+func (*ListSensorTypesResponse) Descriptor() ([]byte, []int) {
+	return file_sensor_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *ListSensorTypesResponse) GetSensorTypes() []*SensorType {
+	if x != nil {
+		return x.SensorTypes
+	}
+	return nil
+}
+
+// ImportChunk carries part of an import file. The format and dry_run flag are taken
+// from the first chunk.
+type ImportChunk struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Format        string                 `protobuf:"bytes,1,opt,name=format,proto3" json:"format,omitempty"` // csv or ndjson
+	DryRun        bool                   `protobuf:"varint,2,opt,name=dry_run,json=dryRun,proto3" json:"dry_run,omitempty"`
+	Data          []byte                 `protobuf:"bytes,3,opt,name=data,proto3" json:"data,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ImportChunk) Reset() {
+	*x = ImportChunk{}
+	mi := &file_sensor_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ImportChunk) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ImportChunk) ProtoMessage() {}
+
+func (x *ImportChunk) ProtoReflect() protoreflect.Message {
+	mi := &file_sensor_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ImportChunk.ProtoReflect.Descriptor instead.
+func (*ImportChunk) Descriptor() ([]byte, []int) {
+	return file_sensor_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *ImportChunk) GetFormat() string {
+	if x != nil {
+		return x.Format
+	}
+	return ""
+}
+
+func (x *ImportChunk) GetDryRun() bool {
+	if x != nil {
+		return x.DryRun
 	}
 	return false
 }
 
-func (x *SensorResponse) GetMessage() string {
+func (x *ImportChunk) GetData() []byte {
 	if x != nil {
-		return x.Message
+		return x.Data
+	}
+	return nil
+}
+
+// ImportJobID identifies an import job
+type ImportJobID struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ImportJobID) Reset() {
+	*x = ImportJobID{}
+	mi := &file_sensor_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ImportJobID) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ImportJobID) ProtoMessage() {}
+
+func (x *ImportJobID) ProtoReflect() protoreflect.Message {
+	mi := &file_sensor_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ImportJobID.ProtoReflect.Descriptor instead.
+func (*ImportJobID) Descriptor() ([]byte, []int) {
+	return file_sensor_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *ImportJobID) GetId() string {
+	if x != nil {
+		return x.Id
 	}
 	return ""
 }
 
-// FrequencyRequest is used to change the data generation frequency
-type FrequencyRequest struct {
+// ImportRowError reports why a row of an import file was rejected
+type ImportRowError struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	IntervalMs    int32                  `protobuf:"varint,1,opt,name=interval_ms,json=intervalMs,proto3" json:"interval_ms,omitempty"`
+	Line          int64                  `protobuf:"varint,1,opt,name=line,proto3" json:"line,omitempty"`
+	Error         string                 `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *FrequencyRequest) Reset() {
-	*x = FrequencyRequest{}
-	mi := &file_sensor_proto_msgTypes[2]
+func (x *ImportRowError) Reset() {
+	*x = ImportRowError{}
+	mi := &file_sensor_proto_msgTypes[22]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *FrequencyRequest) String() string {
+func (x *ImportRowError) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*FrequencyRequest) ProtoMessage() {}
+func (*ImportRowError) ProtoMessage() {}
 
-func (x *FrequencyRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_sensor_proto_msgTypes[2]
+func (x *ImportRowError) ProtoReflect() protoreflect.Message {
+	mi := &file_sensor_proto_msgTypes[22]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -184,43 +1614,59 @@ func (x *FrequencyRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use FrequencyRequest.ProtoReflect.Descriptor instead.
-func (*FrequencyRequest) Descriptor() ([]byte, []int) {
-	return file_sensor_proto_rawDescGZIP(), []int{2}
+// Deprecated: Use ImportRowError.ProtoReflect.Descriptor instead.
+func (*ImportRowError) Descriptor() ([]byte, []int) {
+	return file_sensor_proto_rawDescGZIP(), []int{22}
 }
 
-func (x *FrequencyRequest) GetIntervalMs() int32 {
+func (x *ImportRowError) GetLine() int64 {
 	if x != nil {
-		return x.IntervalMs
+		return x.Line
 	}
 	return 0
 }
 
-// FrequencyResponse is the response to a frequency change request
-type FrequencyResponse struct {
-	state             protoimpl.MessageState `protogen:"open.v1"`
-	Success           bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
-	Message           string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
-	CurrentIntervalMs int32                  `protobuf:"varint,3,opt,name=current_interval_ms,json=currentIntervalMs,proto3" json:"current_interval_ms,omitempty"`
-	unknownFields     protoimpl.UnknownFields
-	sizeCache         protoimpl.SizeCache
+func (x *ImportRowError) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
 }
 
-func (x *FrequencyResponse) Reset() {
-	*x = FrequencyResponse{}
-	mi := &file_sensor_proto_msgTypes[3]
+// ImportJob reports the progress of a bulk import
+type ImportJob struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Id              string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Status          string                 `protobuf:"bytes,2,opt,name=status,proto3" json:"status,omitempty"` // running, completed or failed
+	Format          string                 `protobuf:"bytes,3,opt,name=format,proto3" json:"format,omitempty"`
+	DryRun          bool                   `protobuf:"varint,4,opt,name=dry_run,json=dryRun,proto3" json:"dry_run,omitempty"`
+	RowsRead        int64                  `protobuf:"varint,5,opt,name=rows_read,json=rowsRead,proto3" json:"rows_read,omitempty"`
+	RowsImported    int64                  `protobuf:"varint,6,opt,name=rows_imported,json=rowsImported,proto3" json:"rows_imported,omitempty"`
+	RowsRejected    int64                  `protobuf:"varint,7,opt,name=rows_rejected,json=rowsRejected,proto3" json:"rows_rejected,omitempty"`
+	Errors          []*ImportRowError      `protobuf:"bytes,8,rep,name=errors,proto3" json:"errors,omitempty"`
+	ErrorsTruncated bool                   `protobuf:"varint,9,opt,name=errors_truncated,json=errorsTruncated,proto3" json:"errors_truncated,omitempty"`
+	Error           string                 `protobuf:"bytes,10,opt,name=error,proto3" json:"error,omitempty"`
+	CreatedAt       int64                  `protobuf:"varint,11,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	FinishedAt      int64                  `protobuf:"varint,12,opt,name=finished_at,json=finishedAt,proto3" json:"finished_at,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *ImportJob) Reset() {
+	*x = ImportJob{}
+	mi := &file_sensor_proto_msgTypes[23]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *FrequencyResponse) String() string {
+func (x *ImportJob) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*FrequencyResponse) ProtoMessage() {}
+func (*ImportJob) ProtoMessage() {}
 
-func (x *FrequencyResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_sensor_proto_msgTypes[3]
+func (x *ImportJob) ProtoReflect() protoreflect.Message {
+	mi := &file_sensor_proto_msgTypes[23]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -231,28 +1677,91 @@ func (x *FrequencyResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use FrequencyResponse.ProtoReflect.Descriptor instead.
-func (*FrequencyResponse) Descriptor() ([]byte, []int) {
-	return file_sensor_proto_rawDescGZIP(), []int{3}
+// Deprecated: Use ImportJob.ProtoReflect.Descriptor instead.
+func (*ImportJob) Descriptor() ([]byte, []int) {
+	return file_sensor_proto_rawDescGZIP(), []int{23}
 }
 
-func (x *FrequencyResponse) GetSuccess() bool {
+func (x *ImportJob) GetId() string {
 	if x != nil {
-		return x.Success
+		return x.Id
+	}
+	return ""
+}
+
+func (x *ImportJob) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *ImportJob) GetFormat() string {
+	if x != nil {
+		return x.Format
+	}
+	return ""
+}
+
+func (x *ImportJob) GetDryRun() bool {
+	if x != nil {
+		return x.DryRun
 	}
 	return false
 }
 
-func (x *FrequencyResponse) GetMessage() string {
+func (x *ImportJob) GetRowsRead() int64 {
 	if x != nil {
-		return x.Message
+		return x.RowsRead
+	}
+	return 0
+}
+
+func (x *ImportJob) GetRowsImported() int64 {
+	if x != nil {
+		return x.RowsImported
+	}
+	return 0
+}
+
+func (x *ImportJob) GetRowsRejected() int64 {
+	if x != nil {
+		return x.RowsRejected
+	}
+	return 0
+}
+
+func (x *ImportJob) GetErrors() []*ImportRowError {
+	if x != nil {
+		return x.Errors
+	}
+	return nil
+}
+
+func (x *ImportJob) GetErrorsTruncated() bool {
+	if x != nil {
+		return x.ErrorsTruncated
+	}
+	return false
+}
+
+func (x *ImportJob) GetError() string {
+	if x != nil {
+		return x.Error
 	}
 	return ""
 }
 
-func (x *FrequencyResponse) GetCurrentIntervalMs() int32 {
+func (x *ImportJob) GetCreatedAt() int64 {
 	if x != nil {
-		return x.CurrentIntervalMs
+		return x.CreatedAt
+	}
+	return 0
+}
+
+func (x *ImportJob) GetFinishedAt() int64 {
+	if x != nil {
+		return x.FinishedAt
 	}
 	return 0
 }
@@ -261,7 +1770,7 @@ var File_sensor_proto protoreflect.FileDescriptor
 
 const file_sensor_proto_rawDesc = "" +
 	"\n" +
-	"\fsensor.proto\x12\x06sensor\"\x92\x01\n" +
+	"\fsensor.proto\x12\x06sensor\"\xb1\x01\n" +
 	"\n" +
 	"SensorData\x12!\n" +
 	"\fsensor_value\x18\x01 \x01(\x02R\vsensorValue\x12\x1f\n" +
@@ -269,20 +1778,197 @@ const file_sensor_proto_rawDesc = "" +
 	"sensorType\x12\x10\n" +
 	"\x03id1\x18\x03 \x01(\tR\x03id1\x12\x10\n" +
 	"\x03id2\x18\x04 \x01(\x05R\x03id2\x12\x1c\n" +
-	"\ttimestamp\x18\x05 \x01(\x03R\ttimestamp\"D\n" +
+	"\ttimestamp\x18\x05 \x01(\x03R\ttimestamp\x12\x1d\n" +
+	"\n" +
+	"reading_id\x18\x06 \x01(\tR\treadingId\"\xc1\x02\n" +
+	"\rSensorReading\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12!\n" +
+	"\fsensor_value\x18\x02 \x01(\x01R\vsensorValue\x12\x1f\n" +
+	"\vsensor_type\x18\x03 \x01(\tR\n" +
+	"sensorType\x12\x10\n" +
+	"\x03id1\x18\x04 \x01(\tR\x03id1\x12\x10\n" +
+	"\x03id2\x18\x05 \x01(\x05R\x03id2\x12\x1d\n" +
+	"\n" +
+	"event_time\x18\x06 \x01(\x03R\teventTime\x12\x1d\n" +
+	"\n" +
+	"created_at\x18\a \x01(\x03R\tcreatedAt\x12#\n" +
+	"\rquality_flags\x18\b \x01(\x05R\fqualityFlags\x12\x18\n" +
+	"\aquality\x18\t \x03(\tR\aquality\x12\x1c\n" +
+	"\trevisions\x18\n" +
+	" \x01(\x05R\trevisions\x12\x1d\n" +
+	"\n" +
+	"reading_id\x18\v \x01(\tR\treadingId\"\x1e\n" +
+	"\fSensorDataID\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\"\xea\x03\n" +
+	"\x10SensorDataFilter\x12\x10\n" +
+	"\x03id1\x18\x01 \x03(\tR\x03id1\x12\x10\n" +
+	"\x03id2\x18\x02 \x03(\x05R\x03id2\x12\x1c\n" +
+	"\aid2_min\x18\x03 \x01(\x05H\x00R\x06id2Min\x88\x01\x01\x12\x1c\n" +
+	"\aid2_max\x18\x04 \x01(\x05H\x01R\x06id2Max\x88\x01\x01\x12\x1f\n" +
+	"\vsensor_type\x18\x05 \x03(\tR\n" +
+	"sensorType\x12 \n" +
+	"\tmin_value\x18\x06 \x01(\x01H\x02R\bminValue\x88\x01\x01\x12 \n" +
+	"\tmax_value\x18\a \x01(\x01H\x03R\bmaxValue\x88\x01\x01\x12\"\n" +
+	"\n" +
+	"start_time\x18\b \x01(\x03H\x04R\tstartTime\x88\x01\x01\x12\x1e\n" +
+	"\bend_time\x18\t \x01(\x03H\x05R\aendTime\x88\x01\x01\x12\x1d\n" +
+	"\n" +
+	"time_field\x18\n" +
+	" \x01(\tR\ttimeField\x12(\n" +
+	"\rquality_flags\x18\v \x01(\x05H\x06R\fqualityFlags\x88\x01\x01\x12\x18\n" +
+	"\x05as_of\x18\f \x01(\x03H\aR\x04asOf\x88\x01\x01B\n" +
+	"\n" +
+	"\b_id2_minB\n" +
+	"\n" +
+	"\b_id2_maxB\f\n" +
+	"\n" +
+	"_min_valueB\f\n" +
+	"\n" +
+	"_max_valueB\r\n" +
+	"\v_start_timeB\v\n" +
+	"\t_end_timeB\x10\n" +
+	"\x0e_quality_flagsB\b\n" +
+	"\x06_as_of\"1\n" +
+	"\tSortField\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x12\n" +
+	"\x04desc\x18\x02 \x01(\bR\x04desc\"\xfe\x01\n" +
+	"\x16QuerySensorDataRequest\x120\n" +
+	"\x06filter\x18\x01 \x01(\v2\x18.sensor.SensorDataFilterR\x06filter\x12%\n" +
+	"\x04sort\x18\x02 \x03(\v2\x11.sensor.SortFieldR\x04sort\x12\x12\n" +
+	"\x04page\x18\x03 \x01(\x05R\x04page\x12\x1b\n" +
+	"\tpage_size\x18\x04 \x01(\x05R\bpageSize\x12\x1d\n" +
+	"\n" +
+	"use_cursor\x18\x05 \x01(\bR\tuseCursor\x12\x16\n" +
+	"\x06cursor\x18\x06 \x01(\tR\x06cursor\x12#\n" +
+	"\rinclude_total\x18\a \x01(\bR\fincludeTotal\"\x92\x02\n" +
+	"\x17QuerySensorDataResponse\x12)\n" +
+	"\x04data\x18\x01 \x03(\v2\x15.sensor.SensorReadingR\x04data\x12\x19\n" +
+	"\x05total\x18\x02 \x01(\x05H\x00R\x05total\x88\x01\x01\x12\x12\n" +
+	"\x04page\x18\x03 \x01(\x05R\x04page\x12\x1b\n" +
+	"\tpage_size\x18\x04 \x01(\x05R\bpageSize\x12$\n" +
+	"\vtotal_pages\x18\x05 \x01(\x05H\x01R\n" +
+	"totalPages\x88\x01\x01\x12\x1f\n" +
+	"\vnext_cursor\x18\x06 \x01(\tR\n" +
+	"nextCursor\x12\x1f\n" +
+	"\vprev_cursor\x18\a \x01(\tR\n" +
+	"prevCursorB\b\n" +
+	"\x06_totalB\x0e\n" +
+	"\f_total_pages\"~\n" +
+	"\x10AggregateRequest\x120\n" +
+	"\x06filter\x18\x01 \x01(\v2\x18.sensor.SensorDataFilterR\x06filter\x12\x1a\n" +
+	"\binterval\x18\x02 \x01(\tR\binterval\x12\x1c\n" +
+	"\tfunctions\x18\x03 \x03(\tR\tfunctions\"\xf1\x01\n" +
+	"\x0fAggregateBucket\x12!\n" +
+	"\fbucket_start\x18\x01 \x01(\x03R\vbucketStart\x12\x1f\n" +
+	"\vsensor_type\x18\x02 \x01(\tR\n" +
+	"sensorType\x12\x10\n" +
+	"\x03id1\x18\x03 \x01(\tR\x03id1\x12\x10\n" +
+	"\x03id2\x18\x04 \x01(\x05R\x03id2\x12;\n" +
+	"\x06values\x18\x05 \x03(\v2#.sensor.AggregateBucket.ValuesEntryR\x06values\x1a9\n" +
+	"\vValuesEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\x01R\x05value:\x028\x01\"\xb6\x01\n" +
+	"\x11AggregateResponse\x12\x1a\n" +
+	"\binterval\x18\x01 \x01(\tR\binterval\x12\x1c\n" +
+	"\tfunctions\x18\x02 \x03(\tR\tfunctions\x121\n" +
+	"\abuckets\x18\x03 \x03(\v2\x17.sensor.AggregateBucketR\abuckets\x12\x1c\n" +
+	"\ttruncated\x18\x04 \x01(\bR\ttruncated\x12\x16\n" +
+	"\x06source\x18\x05 \x01(\tR\x06source\"g\n" +
+	"\x10SubscribeRequest\x120\n" +
+	"\x06filter\x18\x01 \x01(\v2\x18.sensor.SensorDataFilterR\x06filter\x12!\n" +
+	"\fresume_after\x18\x02 \x01(\x03R\vresumeAfter\"b\n" +
 	"\x0eSensorResponse\x12\x18\n" +
 	"\asuccess\x18\x01 \x01(\bR\asuccess\x12\x18\n" +
-	"\amessage\x18\x02 \x01(\tR\amessage\"3\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\x12\x1c\n" +
+	"\tduplicate\x18\x03 \x01(\bR\tduplicate\"A\n" +
+	"\x0fSensorDataBatch\x12.\n" +
+	"\breadings\x18\x01 \x03(\v2\x12.sensor.SensorDataR\breadings\"\xc1\x01\n" +
+	"\x17SensorDataBatchResponse\x120\n" +
+	"\aresults\x18\x01 \x03(\v2\x16.sensor.SensorResponseR\aresults\x12\x16\n" +
+	"\x06stored\x18\x02 \x01(\x05R\x06stored\x12\x1e\n" +
+	"\n" +
+	"duplicates\x18\x03 \x01(\x05R\n" +
+	"duplicates\x12 \n" +
+	"\vquarantined\x18\x04 \x01(\x05R\vquarantined\x12\x1a\n" +
+	"\brejected\x18\x05 \x01(\x05R\brejected\"3\n" +
 	"\x10FrequencyRequest\x12\x1f\n" +
 	"\vinterval_ms\x18\x01 \x01(\x05R\n" +
 	"intervalMs\"w\n" +
 	"\x11FrequencyResponse\x12\x18\n" +
 	"\asuccess\x18\x01 \x01(\bR\asuccess\x12\x18\n" +
 	"\amessage\x18\x02 \x01(\tR\amessage\x12.\n" +
-	"\x13current_interval_ms\x18\x03 \x01(\x05R\x11currentIntervalMs2\x93\x01\n" +
+	"\x13current_interval_ms\x18\x03 \x01(\x05R\x11currentIntervalMs\"\xbc\x03\n" +
+	"\n" +
+	"SensorType\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x05R\x02id\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x12\n" +
+	"\x04unit\x18\x03 \x01(\tR\x04unit\x12 \n" +
+	"\vdescription\x18\x04 \x01(\tR\vdescription\x12 \n" +
+	"\tmin_value\x18\x05 \x01(\x01H\x00R\bminValue\x88\x01\x01\x12 \n" +
+	"\tmax_value\x18\x06 \x01(\x01H\x01R\bmaxValue\x88\x01\x01\x12\x1d\n" +
+	"\n" +
+	"created_at\x18\a \x01(\x03R\tcreatedAt\x12\x1d\n" +
+	"\n" +
+	"updated_at\x18\b \x01(\x03R\tupdatedAt\x120\n" +
+	"\x12max_rate_of_change\x18\t \x01(\x01H\x02R\x0fmaxRateOfChange\x88\x01\x01\x12\x1f\n" +
+	"\vid1_pattern\x18\n" +
+	" \x01(\tR\n" +
+	"id1Pattern\x12\x1f\n" +
+	"\vid2_pattern\x18\v \x01(\tR\n" +
+	"id2Pattern\x12+\n" +
+	"\x11validation_action\x18\f \x01(\tR\x10validationActionB\f\n" +
+	"\n" +
+	"_min_valueB\f\n" +
+	"\n" +
+	"_max_valueB\x15\n" +
+	"\x13_max_rate_of_change\"\x1e\n" +
+	"\fSensorTypeID\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x05R\x02id\"\x18\n" +
+	"\x16ListSensorTypesRequest\"P\n" +
+	"\x17ListSensorTypesResponse\x125\n" +
+	"\fsensor_types\x18\x01 \x03(\v2\x12.sensor.SensorTypeR\vsensorTypes\"R\n" +
+	"\vImportChunk\x12\x16\n" +
+	"\x06format\x18\x01 \x01(\tR\x06format\x12\x17\n" +
+	"\adry_run\x18\x02 \x01(\bR\x06dryRun\x12\x12\n" +
+	"\x04data\x18\x03 \x01(\fR\x04data\"\x1d\n" +
+	"\vImportJobID\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\":\n" +
+	"\x0eImportRowError\x12\x12\n" +
+	"\x04line\x18\x01 \x01(\x03R\x04line\x12\x14\n" +
+	"\x05error\x18\x02 \x01(\tR\x05error\"\xfc\x02\n" +
+	"\tImportJob\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x16\n" +
+	"\x06status\x18\x02 \x01(\tR\x06status\x12\x16\n" +
+	"\x06format\x18\x03 \x01(\tR\x06format\x12\x17\n" +
+	"\adry_run\x18\x04 \x01(\bR\x06dryRun\x12\x1b\n" +
+	"\trows_read\x18\x05 \x01(\x03R\browsRead\x12#\n" +
+	"\rrows_imported\x18\x06 \x01(\x03R\frowsImported\x12#\n" +
+	"\rrows_rejected\x18\a \x01(\x03R\frowsRejected\x12.\n" +
+	"\x06errors\x18\b \x03(\v2\x16.sensor.ImportRowErrorR\x06errors\x12)\n" +
+	"\x10errors_truncated\x18\t \x01(\bR\x0ferrorsTruncated\x12\x14\n" +
+	"\x05error\x18\n" +
+	" \x01(\tR\x05error\x12\x1d\n" +
+	"\n" +
+	"created_at\x18\v \x01(\x03R\tcreatedAt\x12\x1f\n" +
+	"\vfinished_at\x18\f \x01(\x03R\n" +
+	"finishedAt2\x82\x04\n" +
 	"\rSensorService\x12>\n" +
 	"\x0eSendSensorData\x12\x12.sensor.SensorData\x1a\x16.sensor.SensorResponse\"\x00\x12B\n" +
-	"\x10StreamSensorData\x12\x12.sensor.SensorData\x1a\x16.sensor.SensorResponse\"\x00(\x01B\x1dZ\x1bsensor_project/proto/sensorb\x06proto3"
+	"\x10StreamSensorData\x12\x12.sensor.SensorData\x1a\x16.sensor.SensorResponse\"\x00(\x01\x12Q\n" +
+	"\x13SendSensorDataBatch\x12\x17.sensor.SensorDataBatch\x1a\x1f.sensor.SensorDataBatchResponse\"\x00\x12>\n" +
+	"\rGetSensorData\x12\x14.sensor.SensorDataID\x1a\x15.sensor.SensorReading\"\x00\x12T\n" +
+	"\x0fQuerySensorData\x12\x1e.sensor.QuerySensorDataRequest\x1a\x1f.sensor.QuerySensorDataResponse\"\x00\x12B\n" +
+	"\tAggregate\x12\x18.sensor.AggregateRequest\x1a\x19.sensor.AggregateResponse\"\x00\x12@\n" +
+	"\tSubscribe\x12\x18.sensor.SubscribeRequest\x1a\x15.sensor.SensorReading\"\x000\x012\xe6\x02\n" +
+	"\x11SensorTypeService\x12<\n" +
+	"\x10CreateSensorType\x12\x12.sensor.SensorType\x1a\x12.sensor.SensorType\"\x00\x12;\n" +
+	"\rGetSensorType\x12\x14.sensor.SensorTypeID\x1a\x12.sensor.SensorType\"\x00\x12T\n" +
+	"\x0fListSensorTypes\x12\x1e.sensor.ListSensorTypesRequest\x1a\x1f.sensor.ListSensorTypesResponse\"\x00\x12<\n" +
+	"\x10UpdateSensorType\x12\x12.sensor.SensorType\x1a\x12.sensor.SensorType\"\x00\x12B\n" +
+	"\x10DeleteSensorType\x12\x14.sensor.SensorTypeID\x1a\x16.sensor.SensorResponse\"\x002\x89\x01\n" +
+	"\rImportService\x12>\n" +
+	"\x10ImportSensorData\x12\x13.sensor.ImportChunk\x1a\x11.sensor.ImportJob\"\x00(\x01\x128\n" +
+	"\fGetImportJob\x12\x13.sensor.ImportJobID\x1a\x11.sensor.ImportJob\"\x00B\x1dZ\x1bsensor_project/proto/sensorb\x06proto3"
 
 var (
 	file_sensor_proto_rawDescOnce sync.Once
@@ -296,23 +1982,79 @@ func file_sensor_proto_rawDescGZIP() []byte {
 	return file_sensor_proto_rawDescData
 }
 
-var file_sensor_proto_msgTypes = make([]protoimpl.MessageInfo, 4)
+var file_sensor_proto_msgTypes = make([]protoimpl.MessageInfo, 25)
 var file_sensor_proto_goTypes = []any{
-	(*SensorData)(nil),        // 0: sensor.SensorData
-	(*SensorResponse)(nil),    // 1: sensor.SensorResponse
-	(*FrequencyRequest)(nil),  // 2: sensor.FrequencyRequest
-	(*FrequencyResponse)(nil), // 3: sensor.FrequencyResponse
+	(*SensorData)(nil),              // 0: sensor.SensorData
+	(*SensorReading)(nil),           // 1: sensor.SensorReading
+	(*SensorDataID)(nil),            // 2: sensor.SensorDataID
+	(*SensorDataFilter)(nil),        // 3: sensor.SensorDataFilter
+	(*SortField)(nil),               // 4: sensor.SortField
+	(*QuerySensorDataRequest)(nil),  // 5: sensor.QuerySensorDataRequest
+	(*QuerySensorDataResponse)(nil), // 6: sensor.QuerySensorDataResponse
+	(*AggregateRequest)(nil),        // 7: sensor.AggregateRequest
+	(*AggregateBucket)(nil),         // 8: sensor.AggregateBucket
+	(*AggregateResponse)(nil),       // 9: sensor.AggregateResponse
+	(*SubscribeRequest)(nil),        // 10: sensor.SubscribeRequest
+	(*SensorResponse)(nil),          // 11: sensor.SensorResponse
+	(*SensorDataBatch)(nil),         // 12: sensor.SensorDataBatch
+	(*SensorDataBatchResponse)(nil), // 13: sensor.SensorDataBatchResponse
+	(*FrequencyRequest)(nil),        // 14: sensor.FrequencyRequest
+	(*FrequencyResponse)(nil),       // 15: sensor.FrequencyResponse
+	(*SensorType)(nil),              // 16: sensor.SensorType
+	(*SensorTypeID)(nil),            // 17: sensor.SensorTypeID
+	(*ListSensorTypesRequest)(nil),  // 18: sensor.ListSensorTypesRequest
+	(*ListSensorTypesResponse)(nil), // 19: sensor.ListSensorTypesResponse
+	(*ImportChunk)(nil),             // 20: sensor.ImportChunk
+	(*ImportJobID)(nil),             // 21: sensor.ImportJobID
+	(*ImportRowError)(nil),          // 22: sensor.ImportRowError
+	(*ImportJob)(nil),               // 23: sensor.ImportJob
+	nil,                             // 24: sensor.AggregateBucket.ValuesEntry
 }
 var file_sensor_proto_depIdxs = []int32{
-	0, // 0: sensor.SensorService.SendSensorData:input_type -> sensor.SensorData
-	0, // 1: sensor.SensorService.StreamSensorData:input_type -> sensor.SensorData
-	1, // 2: sensor.SensorService.SendSensorData:output_type -> sensor.SensorResponse
-	1, // 3: sensor.SensorService.StreamSensorData:output_type -> sensor.SensorResponse
-	2, // [2:4] is the sub-list for method output_type
-	0, // [0:2] is the sub-list for method input_type
-	0, // [0:0] is the sub-list for extension type_name
-	0, // [0:0] is the sub-list for extension extendee
-	0, // [0:0] is the sub-list for field type_name
+	3,  // 0: sensor.QuerySensorDataRequest.filter:type_name -> sensor.SensorDataFilter
+	4,  // 1: sensor.QuerySensorDataRequest.sort:type_name -> sensor.SortField
+	1,  // 2: sensor.QuerySensorDataResponse.data:type_name -> sensor.SensorReading
+	3,  // 3: sensor.AggregateRequest.filter:type_name -> sensor.SensorDataFilter
+	24, // 4: sensor.AggregateBucket.values:type_name -> sensor.AggregateBucket.ValuesEntry
+	8,  // 5: sensor.AggregateResponse.buckets:type_name -> sensor.AggregateBucket
+	3,  // 6: sensor.SubscribeRequest.filter:type_name -> sensor.SensorDataFilter
+	0,  // 7: sensor.SensorDataBatch.readings:type_name -> sensor.SensorData
+	11, // 8: sensor.SensorDataBatchResponse.results:type_name -> sensor.SensorResponse
+	16, // 9: sensor.ListSensorTypesResponse.sensor_types:type_name -> sensor.SensorType
+	22, // 10: sensor.ImportJob.errors:type_name -> sensor.ImportRowError
+	0,  // 11: sensor.SensorService.SendSensorData:input_type -> sensor.SensorData
+	0,  // 12: sensor.SensorService.StreamSensorData:input_type -> sensor.SensorData
+	12, // 13: sensor.SensorService.SendSensorDataBatch:input_type -> sensor.SensorDataBatch
+	2,  // 14: sensor.SensorService.GetSensorData:input_type -> sensor.SensorDataID
+	5,  // 15: sensor.SensorService.QuerySensorData:input_type -> sensor.QuerySensorDataRequest
+	7,  // 16: sensor.SensorService.Aggregate:input_type -> sensor.AggregateRequest
+	10, // 17: sensor.SensorService.Subscribe:input_type -> sensor.SubscribeRequest
+	16, // 18: sensor.SensorTypeService.CreateSensorType:input_type -> sensor.SensorType
+	17, // 19: sensor.SensorTypeService.GetSensorType:input_type -> sensor.SensorTypeID
+	18, // 20: sensor.SensorTypeService.ListSensorTypes:input_type -> sensor.ListSensorTypesRequest
+	16, // 21: sensor.SensorTypeService.UpdateSensorType:input_type -> sensor.SensorType
+	17, // 22: sensor.SensorTypeService.DeleteSensorType:input_type -> sensor.SensorTypeID
+	20, // 23: sensor.ImportService.ImportSensorData:input_type -> sensor.ImportChunk
+	21, // 24: sensor.ImportService.GetImportJob:input_type -> sensor.ImportJobID
+	11, // 25: sensor.SensorService.SendSensorData:output_type -> sensor.SensorResponse
+	11, // 26: sensor.SensorService.StreamSensorData:output_type -> sensor.SensorResponse
+	13, // 27: sensor.SensorService.SendSensorDataBatch:output_type -> sensor.SensorDataBatchResponse
+	1,  // 28: sensor.SensorService.GetSensorData:output_type -> sensor.SensorReading
+	6,  // 29: sensor.SensorService.QuerySensorData:output_type -> sensor.QuerySensorDataResponse
+	9,  // 30: sensor.SensorService.Aggregate:output_type -> sensor.AggregateResponse
+	1,  // 31: sensor.SensorService.Subscribe:output_type -> sensor.SensorReading
+	16, // 32: sensor.SensorTypeService.CreateSensorType:output_type -> sensor.SensorType
+	16, // 33: sensor.SensorTypeService.GetSensorType:output_type -> sensor.SensorType
+	19, // 34: sensor.SensorTypeService.ListSensorTypes:output_type -> sensor.ListSensorTypesResponse
+	16, // 35: sensor.SensorTypeService.UpdateSensorType:output_type -> sensor.SensorType
+	11, // 36: sensor.SensorTypeService.DeleteSensorType:output_type -> sensor.SensorResponse
+	23, // 37: sensor.ImportService.ImportSensorData:output_type -> sensor.ImportJob
+	23, // 38: sensor.ImportService.GetImportJob:output_type -> sensor.ImportJob
+	25, // [25:39] is the sub-list for method output_type
+	11, // [11:25] is the sub-list for method input_type
+	11, // [11:11] is the sub-list for extension type_name
+	11, // [11:11] is the sub-list for extension extendee
+	0,  // [0:11] is the sub-list for field type_name
 }
 
 func init() { file_sensor_proto_init() }
@@ -320,15 +2062,18 @@ func file_sensor_proto_init() {
 	if File_sensor_proto != nil {
 		return
 	}
+	file_sensor_proto_msgTypes[3].OneofWrappers = []any{}
+	file_sensor_proto_msgTypes[6].OneofWrappers = []any{}
+	file_sensor_proto_msgTypes[16].OneofWrappers = []any{}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_sensor_proto_rawDesc), len(file_sensor_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   4,
+			NumMessages:   25,
 			NumExtensions: 0,
-			NumServices:   1,
+			NumServices:   3,
 		},
 		GoTypes:           file_sensor_proto_goTypes,
 		DependencyIndexes: file_sensor_proto_depIdxs,
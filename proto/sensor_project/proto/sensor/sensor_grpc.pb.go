@@ -19,20 +19,35 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	SensorService_SendSensorData_FullMethodName   = "/sensor.SensorService/SendSensorData"
-	SensorService_StreamSensorData_FullMethodName = "/sensor.SensorService/StreamSensorData"
+	SensorService_SendSensorData_FullMethodName      = "/sensor.SensorService/SendSensorData"
+	SensorService_StreamSensorData_FullMethodName    = "/sensor.SensorService/StreamSensorData"
+	SensorService_SendSensorDataBatch_FullMethodName = "/sensor.SensorService/SendSensorDataBatch"
+	SensorService_GetSensorData_FullMethodName       = "/sensor.SensorService/GetSensorData"
+	SensorService_QuerySensorData_FullMethodName     = "/sensor.SensorService/QuerySensorData"
+	SensorService_Aggregate_FullMethodName           = "/sensor.SensorService/Aggregate"
+	SensorService_Subscribe_FullMethodName           = "/sensor.SensorService/Subscribe"
 )
 
 // SensorServiceClient is the client API for SensorService service.
 //
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 //
-// SensorService defines the gRPC service for sending sensor data
+// SensorService defines the gRPC service for sending and reading back sensor data
 type SensorServiceClient interface {
 	// SendSensorData sends a single sensor reading
 	SendSensorData(ctx context.Context, in *SensorData, opts ...grpc.CallOption) (*SensorResponse, error)
 	// StreamSensorData establishes a stream for continuous sensor data
 	StreamSensorData(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[SensorData, SensorResponse], error)
+	// SendSensorDataBatch sends several sensor readings in one call
+	SendSensorDataBatch(ctx context.Context, in *SensorDataBatch, opts ...grpc.CallOption) (*SensorDataBatchResponse, error)
+	// GetSensorData returns a stored reading by ID
+	GetSensorData(ctx context.Context, in *SensorDataID, opts ...grpc.CallOption) (*SensorReading, error)
+	// QuerySensorData returns a page of the stored readings matching a filter
+	QuerySensorData(ctx context.Context, in *QuerySensorDataRequest, opts ...grpc.CallOption) (*QuerySensorDataResponse, error)
+	// Aggregate groups the readings matching a filter into time buckets
+	Aggregate(ctx context.Context, in *AggregateRequest, opts ...grpc.CallOption) (*AggregateResponse, error)
+	// Subscribe streams the readings matching a filter as they are stored
+	Subscribe(ctx context.Context, in *SubscribeRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[SensorReading], error)
 }
 
 type sensorServiceClient struct {
@@ -66,16 +81,85 @@ func (c *sensorServiceClient) StreamSensorData(ctx context.Context, opts ...grpc
 // This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
 type SensorService_StreamSensorDataClient = grpc.ClientStreamingClient[SensorData, SensorResponse]
 
+func (c *sensorServiceClient) SendSensorDataBatch(ctx context.Context, in *SensorDataBatch, opts ...grpc.CallOption) (*SensorDataBatchResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SensorDataBatchResponse)
+	err := c.cc.Invoke(ctx, SensorService_SendSensorDataBatch_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *sensorServiceClient) GetSensorData(ctx context.Context, in *SensorDataID, opts ...grpc.CallOption) (*SensorReading, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SensorReading)
+	err := c.cc.Invoke(ctx, SensorService_GetSensorData_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *sensorServiceClient) QuerySensorData(ctx context.Context, in *QuerySensorDataRequest, opts ...grpc.CallOption) (*QuerySensorDataResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(QuerySensorDataResponse)
+	err := c.cc.Invoke(ctx, SensorService_QuerySensorData_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *sensorServiceClient) Aggregate(ctx context.Context, in *AggregateRequest, opts ...grpc.CallOption) (*AggregateResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AggregateResponse)
+	err := c.cc.Invoke(ctx, SensorService_Aggregate_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *sensorServiceClient) Subscribe(ctx context.Context, in *SubscribeRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[SensorReading], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &SensorService_ServiceDesc.Streams[1], SensorService_Subscribe_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[SubscribeRequest, SensorReading]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type SensorService_SubscribeClient = grpc.ServerStreamingClient[SensorReading]
+
 // SensorServiceServer is the server API for SensorService service.
 // All implementations must embed UnimplementedSensorServiceServer
 // for forward compatibility.
 //
-// SensorService defines the gRPC service for sending sensor data
+// SensorService defines the gRPC service for sending and reading back sensor data
 type SensorServiceServer interface {
 	// SendSensorData sends a single sensor reading
 	SendSensorData(context.Context, *SensorData) (*SensorResponse, error)
 	// StreamSensorData establishes a stream for continuous sensor data
 	StreamSensorData(grpc.ClientStreamingServer[SensorData, SensorResponse]) error
+	// SendSensorDataBatch sends several sensor readings in one call
+	SendSensorDataBatch(context.Context, *SensorDataBatch) (*SensorDataBatchResponse, error)
+	// GetSensorData returns a stored reading by ID
+	GetSensorData(context.Context, *SensorDataID) (*SensorReading, error)
+	// QuerySensorData returns a page of the stored readings matching a filter
+	QuerySensorData(context.Context, *QuerySensorDataRequest) (*QuerySensorDataResponse, error)
+	// Aggregate groups the readings matching a filter into time buckets
+	Aggregate(context.Context, *AggregateRequest) (*AggregateResponse, error)
+	// Subscribe streams the readings matching a filter as they are stored
+	Subscribe(*SubscribeRequest, grpc.ServerStreamingServer[SensorReading]) error
 	mustEmbedUnimplementedSensorServiceServer()
 }
 
@@ -92,6 +176,21 @@ func (UnimplementedSensorServiceServer) SendSensorData(context.Context, *SensorD
 func (UnimplementedSensorServiceServer) StreamSensorData(grpc.ClientStreamingServer[SensorData, SensorResponse]) error {
 	return status.Errorf(codes.Unimplemented, "method StreamSensorData not implemented")
 }
+func (UnimplementedSensorServiceServer) SendSensorDataBatch(context.Context, *SensorDataBatch) (*SensorDataBatchResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SendSensorDataBatch not implemented")
+}
+func (UnimplementedSensorServiceServer) GetSensorData(context.Context, *SensorDataID) (*SensorReading, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetSensorData not implemented")
+}
+func (UnimplementedSensorServiceServer) QuerySensorData(context.Context, *QuerySensorDataRequest) (*QuerySensorDataResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method QuerySensorData not implemented")
+}
+func (UnimplementedSensorServiceServer) Aggregate(context.Context, *AggregateRequest) (*AggregateResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Aggregate not implemented")
+}
+func (UnimplementedSensorServiceServer) Subscribe(*SubscribeRequest, grpc.ServerStreamingServer[SensorReading]) error {
+	return status.Errorf(codes.Unimplemented, "method Subscribe not implemented")
+}
 func (UnimplementedSensorServiceServer) mustEmbedUnimplementedSensorServiceServer() {}
 func (UnimplementedSensorServiceServer) testEmbeddedByValue()                       {}
 
@@ -138,6 +237,89 @@ func _SensorService_StreamSensorData_Handler(srv interface{}, stream grpc.Server
 // This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
 type SensorService_StreamSensorDataServer = grpc.ClientStreamingServer[SensorData, SensorResponse]
 
+func _SensorService_SendSensorDataBatch_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SensorDataBatch)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SensorServiceServer).SendSensorDataBatch(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SensorService_SendSensorDataBatch_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SensorServiceServer).SendSensorDataBatch(ctx, req.(*SensorDataBatch))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SensorService_GetSensorData_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SensorDataID)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SensorServiceServer).GetSensorData(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SensorService_GetSensorData_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SensorServiceServer).GetSensorData(ctx, req.(*SensorDataID))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SensorService_QuerySensorData_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(QuerySensorDataRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SensorServiceServer).QuerySensorData(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SensorService_QuerySensorData_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SensorServiceServer).QuerySensorData(ctx, req.(*QuerySensorDataRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SensorService_Aggregate_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AggregateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SensorServiceServer).Aggregate(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SensorService_Aggregate_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SensorServiceServer).Aggregate(ctx, req.(*AggregateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SensorService_Subscribe_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(SubscribeRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(SensorServiceServer).Subscribe(m, &grpc.GenericServerStream[SubscribeRequest, SensorReading]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type SensorService_SubscribeServer = grpc.ServerStreamingServer[SensorReading]
+
 // SensorService_ServiceDesc is the grpc.ServiceDesc for SensorService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -149,6 +331,22 @@ var SensorService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "SendSensorData",
 			Handler:    _SensorService_SendSensorData_Handler,
 		},
+		{
+			MethodName: "SendSensorDataBatch",
+			Handler:    _SensorService_SendSensorDataBatch_Handler,
+		},
+		{
+			MethodName: "GetSensorData",
+			Handler:    _SensorService_GetSensorData_Handler,
+		},
+		{
+			MethodName: "QuerySensorData",
+			Handler:    _SensorService_QuerySensorData_Handler,
+		},
+		{
+			MethodName: "Aggregate",
+			Handler:    _SensorService_Aggregate_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
@@ -156,6 +354,421 @@ var SensorService_ServiceDesc = grpc.ServiceDesc{
 			Handler:       _SensorService_StreamSensorData_Handler,
 			ClientStreams: true,
 		},
+		{
+			StreamName:    "Subscribe",
+			Handler:       _SensorService_Subscribe_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "sensor.proto",
+}
+
+const (
+	SensorTypeService_CreateSensorType_FullMethodName = "/sensor.SensorTypeService/CreateSensorType"
+	SensorTypeService_GetSensorType_FullMethodName    = "/sensor.SensorTypeService/GetSensorType"
+	SensorTypeService_ListSensorTypes_FullMethodName  = "/sensor.SensorTypeService/ListSensorTypes"
+	SensorTypeService_UpdateSensorType_FullMethodName = "/sensor.SensorTypeService/UpdateSensorType"
+	SensorTypeService_DeleteSensorType_FullMethodName = "/sensor.SensorTypeService/DeleteSensorType"
+)
+
+// SensorTypeServiceClient is the client API for SensorTypeService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// SensorTypeService defines the gRPC service for managing sensor types
+type SensorTypeServiceClient interface {
+	// CreateSensorType registers a new sensor type
+	CreateSensorType(ctx context.Context, in *SensorType, opts ...grpc.CallOption) (*SensorType, error)
+	// GetSensorType returns a sensor type by ID
+	GetSensorType(ctx context.Context, in *SensorTypeID, opts ...grpc.CallOption) (*SensorType, error)
+	// ListSensorTypes returns all sensor types
+	ListSensorTypes(ctx context.Context, in *ListSensorTypesRequest, opts ...grpc.CallOption) (*ListSensorTypesResponse, error)
+	// UpdateSensorType replaces the fields of an existing sensor type
+	UpdateSensorType(ctx context.Context, in *SensorType, opts ...grpc.CallOption) (*SensorType, error)
+	// DeleteSensorType removes a sensor type that has no readings
+	DeleteSensorType(ctx context.Context, in *SensorTypeID, opts ...grpc.CallOption) (*SensorResponse, error)
+}
+
+type sensorTypeServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewSensorTypeServiceClient(cc grpc.ClientConnInterface) SensorTypeServiceClient {
+	return &sensorTypeServiceClient{cc}
+}
+
+func (c *sensorTypeServiceClient) CreateSensorType(ctx context.Context, in *SensorType, opts ...grpc.CallOption) (*SensorType, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SensorType)
+	err := c.cc.Invoke(ctx, SensorTypeService_CreateSensorType_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *sensorTypeServiceClient) GetSensorType(ctx context.Context, in *SensorTypeID, opts ...grpc.CallOption) (*SensorType, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SensorType)
+	err := c.cc.Invoke(ctx, SensorTypeService_GetSensorType_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *sensorTypeServiceClient) ListSensorTypes(ctx context.Context, in *ListSensorTypesRequest, opts ...grpc.CallOption) (*ListSensorTypesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListSensorTypesResponse)
+	err := c.cc.Invoke(ctx, SensorTypeService_ListSensorTypes_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *sensorTypeServiceClient) UpdateSensorType(ctx context.Context, in *SensorType, opts ...grpc.CallOption) (*SensorType, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SensorType)
+	err := c.cc.Invoke(ctx, SensorTypeService_UpdateSensorType_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *sensorTypeServiceClient) DeleteSensorType(ctx context.Context, in *SensorTypeID, opts ...grpc.CallOption) (*SensorResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SensorResponse)
+	err := c.cc.Invoke(ctx, SensorTypeService_DeleteSensorType_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// SensorTypeServiceServer is the server API for SensorTypeService service.
+// All implementations must embed UnimplementedSensorTypeServiceServer
+// for forward compatibility.
+//
+// SensorTypeService defines the gRPC service for managing sensor types
+type SensorTypeServiceServer interface {
+	// CreateSensorType registers a new sensor type
+	CreateSensorType(context.Context, *SensorType) (*SensorType, error)
+	// GetSensorType returns a sensor type by ID
+	GetSensorType(context.Context, *SensorTypeID) (*SensorType, error)
+	// ListSensorTypes returns all sensor types
+	ListSensorTypes(context.Context, *ListSensorTypesRequest) (*ListSensorTypesResponse, error)
+	// UpdateSensorType replaces the fields of an existing sensor type
+	UpdateSensorType(context.Context, *SensorType) (*SensorType, error)
+	// DeleteSensorType removes a sensor type that has no readings
+	DeleteSensorType(context.Context, *SensorTypeID) (*SensorResponse, error)
+	mustEmbedUnimplementedSensorTypeServiceServer()
+}
+
+// UnimplementedSensorTypeServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedSensorTypeServiceServer struct{}
+
+func (UnimplementedSensorTypeServiceServer) CreateSensorType(context.Context, *SensorType) (*SensorType, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateSensorType not implemented")
+}
+func (UnimplementedSensorTypeServiceServer) GetSensorType(context.Context, *SensorTypeID) (*SensorType, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetSensorType not implemented")
+}
+func (UnimplementedSensorTypeServiceServer) ListSensorTypes(context.Context, *ListSensorTypesRequest) (*ListSensorTypesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListSensorTypes not implemented")
+}
+func (UnimplementedSensorTypeServiceServer) UpdateSensorType(context.Context, *SensorType) (*SensorType, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateSensorType not implemented")
+}
+func (UnimplementedSensorTypeServiceServer) DeleteSensorType(context.Context, *SensorTypeID) (*SensorResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteSensorType not implemented")
+}
+func (UnimplementedSensorTypeServiceServer) mustEmbedUnimplementedSensorTypeServiceServer() {}
+func (UnimplementedSensorTypeServiceServer) testEmbeddedByValue()                           {}
+
+// UnsafeSensorTypeServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to SensorTypeServiceServer will
+// result in compilation errors.
+type UnsafeSensorTypeServiceServer interface {
+	mustEmbedUnimplementedSensorTypeServiceServer()
+}
+
+func RegisterSensorTypeServiceServer(s grpc.ServiceRegistrar, srv SensorTypeServiceServer) {
+	// If the following call pancis, it indicates UnimplementedSensorTypeServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&SensorTypeService_ServiceDesc, srv)
+}
+
+func _SensorTypeService_CreateSensorType_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SensorType)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SensorTypeServiceServer).CreateSensorType(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SensorTypeService_CreateSensorType_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SensorTypeServiceServer).CreateSensorType(ctx, req.(*SensorType))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SensorTypeService_GetSensorType_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SensorTypeID)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SensorTypeServiceServer).GetSensorType(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SensorTypeService_GetSensorType_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SensorTypeServiceServer).GetSensorType(ctx, req.(*SensorTypeID))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SensorTypeService_ListSensorTypes_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListSensorTypesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SensorTypeServiceServer).ListSensorTypes(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SensorTypeService_ListSensorTypes_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SensorTypeServiceServer).ListSensorTypes(ctx, req.(*ListSensorTypesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SensorTypeService_UpdateSensorType_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SensorType)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SensorTypeServiceServer).UpdateSensorType(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SensorTypeService_UpdateSensorType_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SensorTypeServiceServer).UpdateSensorType(ctx, req.(*SensorType))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SensorTypeService_DeleteSensorType_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SensorTypeID)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SensorTypeServiceServer).DeleteSensorType(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SensorTypeService_DeleteSensorType_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SensorTypeServiceServer).DeleteSensorType(ctx, req.(*SensorTypeID))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// SensorTypeService_ServiceDesc is the grpc.ServiceDesc for SensorTypeService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var SensorTypeService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "sensor.SensorTypeService",
+	HandlerType: (*SensorTypeServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "CreateSensorType",
+			Handler:    _SensorTypeService_CreateSensorType_Handler,
+		},
+		{
+			MethodName: "GetSensorType",
+			Handler:    _SensorTypeService_GetSensorType_Handler,
+		},
+		{
+			MethodName: "ListSensorTypes",
+			Handler:    _SensorTypeService_ListSensorTypes_Handler,
+		},
+		{
+			MethodName: "UpdateSensorType",
+			Handler:    _SensorTypeService_UpdateSensorType_Handler,
+		},
+		{
+			MethodName: "DeleteSensorType",
+			Handler:    _SensorTypeService_DeleteSensorType_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "sensor.proto",
+}
+
+const (
+	ImportService_ImportSensorData_FullMethodName = "/sensor.ImportService/ImportSensorData"
+	ImportService_GetImportJob_FullMethodName     = "/sensor.ImportService/GetImportJob"
+)
+
+// ImportServiceClient is the client API for ImportService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// ImportService defines the gRPC service for bulk imports of historical readings
+type ImportServiceClient interface {
+	// ImportSensorData uploads a CSV or NDJSON file in chunks and starts importing it
+	ImportSensorData(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[ImportChunk, ImportJob], error)
+	// GetImportJob returns the progress of an import job
+	GetImportJob(ctx context.Context, in *ImportJobID, opts ...grpc.CallOption) (*ImportJob, error)
+}
+
+type importServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewImportServiceClient(cc grpc.ClientConnInterface) ImportServiceClient {
+	return &importServiceClient{cc}
+}
+
+func (c *importServiceClient) ImportSensorData(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[ImportChunk, ImportJob], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &ImportService_ServiceDesc.Streams[0], ImportService_ImportSensorData_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ImportChunk, ImportJob]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ImportService_ImportSensorDataClient = grpc.ClientStreamingClient[ImportChunk, ImportJob]
+
+func (c *importServiceClient) GetImportJob(ctx context.Context, in *ImportJobID, opts ...grpc.CallOption) (*ImportJob, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ImportJob)
+	err := c.cc.Invoke(ctx, ImportService_GetImportJob_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ImportServiceServer is the server API for ImportService service.
+// All implementations must embed UnimplementedImportServiceServer
+// for forward compatibility.
+//
+// ImportService defines the gRPC service for bulk imports of historical readings
+type ImportServiceServer interface {
+	// ImportSensorData uploads a CSV or NDJSON file in chunks and starts importing it
+	ImportSensorData(grpc.ClientStreamingServer[ImportChunk, ImportJob]) error
+	// GetImportJob returns the progress of an import job
+	GetImportJob(context.Context, *ImportJobID) (*ImportJob, error)
+	mustEmbedUnimplementedImportServiceServer()
+}
+
+// UnimplementedImportServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedImportServiceServer struct{}
+
+func (UnimplementedImportServiceServer) ImportSensorData(grpc.ClientStreamingServer[ImportChunk, ImportJob]) error {
+	return status.Errorf(codes.Unimplemented, "method ImportSensorData not implemented")
+}
+func (UnimplementedImportServiceServer) GetImportJob(context.Context, *ImportJobID) (*ImportJob, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetImportJob not implemented")
+}
+func (UnimplementedImportServiceServer) mustEmbedUnimplementedImportServiceServer() {}
+func (UnimplementedImportServiceServer) testEmbeddedByValue()                       {}
+
+// UnsafeImportServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ImportServiceServer will
+// result in compilation errors.
+type UnsafeImportServiceServer interface {
+	mustEmbedUnimplementedImportServiceServer()
+}
+
+func RegisterImportServiceServer(s grpc.ServiceRegistrar, srv ImportServiceServer) {
+	// If the following call pancis, it indicates UnimplementedImportServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&ImportService_ServiceDesc, srv)
+}
+
+func _ImportService_ImportSensorData_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(ImportServiceServer).ImportSensorData(&grpc.GenericServerStream[ImportChunk, ImportJob]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ImportService_ImportSensorDataServer = grpc.ClientStreamingServer[ImportChunk, ImportJob]
+
+func _ImportService_GetImportJob_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ImportJobID)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ImportServiceServer).GetImportJob(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ImportService_GetImportJob_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ImportServiceServer).GetImportJob(ctx, req.(*ImportJobID))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// ImportService_ServiceDesc is the grpc.ServiceDesc for ImportService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var ImportService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "sensor.ImportService",
+	HandlerType: (*ImportServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetImportJob",
+			Handler:    _ImportService_GetImportJob_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "ImportSensorData",
+			Handler:       _ImportService_ImportSensorData_Handler,
+			ClientStreams: true,
+		},
 	},
 	Metadata: "sensor.proto",
 }
@@ -8,31 +8,138 @@ USE sensor_data;
 CREATE TABLE IF NOT EXISTS sensor_types (
     id INT AUTO_INCREMENT PRIMARY KEY,
     name VARCHAR(50) NOT NULL UNIQUE,
+    unit VARCHAR(20),
     description VARCHAR(255),
+    min_value DOUBLE NULL,
+    max_value DOUBLE NULL,
+    max_rate_of_change DOUBLE NULL,
+    id1_pattern VARCHAR(255) NULL,
+    id2_pattern VARCHAR(255) NULL,
+    validation_action VARCHAR(10) NOT NULL DEFAULT 'flag',
     created_at BIGINT NOT NULL,
     updated_at BIGINT NOT NULL
 );
 
--- Create sensor_data table
+-- Create sensor_data table. Deleted readings are kept with deleted_at and the
+-- deletion_batch_id of the delete that removed them until they are purged. sensor_value
+-- is the current value; once corrected, raw_value keeps the measured value and revision
+-- counts the corrections in sensor_data_revisions. reading_id is the optional ID the
+-- client gave the reading; it is unique so retried readings are stored only once.
 CREATE TABLE IF NOT EXISTS sensor_data (
     id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    reading_id VARCHAR(36) NULL,
     sensor_value FLOAT NOT NULL,
+    raw_value FLOAT NULL,
+    revision INT NOT NULL DEFAULT 0,
     sensor_type_id INT NOT NULL,
     id1 VARCHAR(10) NOT NULL,
     id2 INT NOT NULL,
+    event_time BIGINT NOT NULL,
     created_at BIGINT NOT NULL,
+    quality_flags INT NOT NULL DEFAULT 0,
+    deleted_at BIGINT NULL,
+    deletion_batch_id CHAR(32) NULL,
     FOREIGN KEY (sensor_type_id) REFERENCES sensor_types(id),
     INDEX idx_id1_id2 (id1, id2),
+    INDEX idx_event_time (event_time),
     INDEX idx_created_at (created_at),
-    INDEX idx_sensor_type (sensor_type_id)
+    INDEX idx_sensor_type (sensor_type_id),
+    INDEX idx_series_event_time (sensor_type_id, id1, id2, event_time),
+    INDEX idx_type_event_time (sensor_type_id, event_time),
+    INDEX idx_deleted_at (deleted_at),
+    INDEX idx_deletion_batch (deletion_batch_id),
+    UNIQUE INDEX uniq_reading_id (reading_id)
 );
 
--- Create users table for authentication
+-- Create sensor_data_revisions table with every correction of a reading's value,
+-- numbered from 1, with its reason code and who made it
+CREATE TABLE IF NOT EXISTS sensor_data_revisions (
+    sensor_data_id BIGINT NOT NULL,
+    revision INT NOT NULL,
+    sensor_value FLOAT NOT NULL,
+    reason VARCHAR(32) NOT NULL,
+    actor_user_id INT NULL,
+    actor_username VARCHAR(50) NOT NULL,
+    actor_api_key_id INT NULL,
+    created_at BIGINT NOT NULL,
+    PRIMARY KEY (sensor_data_id, revision),
+    FOREIGN KEY (sensor_data_id) REFERENCES sensor_data(id) ON DELETE CASCADE
+);
+
+-- Create sensor_data_rollups table with pre-computed per-minute, per-hour and per-day
+-- aggregates of each sensor, keyed by bucket size in milliseconds
+CREATE TABLE IF NOT EXISTS sensor_data_rollups (
+    resolution_ms BIGINT NOT NULL,
+    sensor_type_id INT NOT NULL,
+    id1 VARCHAR(10) NOT NULL,
+    id2 INT NOT NULL,
+    bucket_start BIGINT NOT NULL,
+    reading_count BIGINT NOT NULL,
+    value_sum DOUBLE NOT NULL,
+    value_sum_sq DOUBLE NOT NULL,
+    min_value FLOAT NOT NULL,
+    max_value FLOAT NOT NULL,
+    first_value FLOAT NOT NULL,
+    first_time BIGINT NOT NULL,
+    last_value FLOAT NOT NULL,
+    last_time BIGINT NOT NULL,
+    PRIMARY KEY (resolution_ms, sensor_type_id, id1, id2, bucket_start),
+    INDEX idx_rollup_bucket (resolution_ms, bucket_start)
+);
+
+-- Create sensor_data_rollup_dirty table marking per-minute buckets whose readings were
+-- updated or deleted, to be recomputed by the rollup builder
+CREATE TABLE IF NOT EXISTS sensor_data_rollup_dirty (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    sensor_type_id INT NOT NULL,
+    id1 VARCHAR(10) NOT NULL,
+    id2 INT NOT NULL,
+    bucket_start BIGINT NOT NULL
+);
+
+-- Create rollup_state table holding the ingest time up to which readings are rolled up
+CREATE TABLE IF NOT EXISTS rollup_state (
+    id TINYINT PRIMARY KEY,
+    watermark BIGINT NOT NULL
+);
+
+-- Create retention_policies table holding how long each sensor type's raw readings and
+-- rollups are kept, in milliseconds; 0 keeps them forever
+CREATE TABLE IF NOT EXISTS retention_policies (
+    sensor_type_id INT PRIMARY KEY,
+    raw_retention_ms BIGINT NOT NULL DEFAULT 0,
+    minute_rollup_retention_ms BIGINT NOT NULL DEFAULT 0,
+    hour_rollup_retention_ms BIGINT NOT NULL DEFAULT 0,
+    day_rollup_retention_ms BIGINT NOT NULL DEFAULT 0,
+    created_at BIGINT NOT NULL,
+    updated_at BIGINT NOT NULL,
+    FOREIGN KEY (sensor_type_id) REFERENCES sensor_types(id) ON DELETE CASCADE
+);
+
+-- Create quarantined_sensor_data table for readings held back by the unknown sensor type
+-- policy; reading_id is unique so retried readings are quarantined only once
+CREATE TABLE IF NOT EXISTS quarantined_sensor_data (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    reading_id VARCHAR(36) NULL,
+    sensor_value FLOAT NOT NULL,
+    sensor_type VARCHAR(50) NOT NULL,
+    id1 VARCHAR(255) NOT NULL,
+    id2 INT NOT NULL,
+    event_time BIGINT NOT NULL,
+    created_at BIGINT NOT NULL,
+    reason VARCHAR(255) NOT NULL,
+    INDEX idx_quarantine_sensor_type (sensor_type),
+    INDEX idx_quarantine_created_at (created_at),
+    UNIQUE INDEX uniq_quarantine_reading_id (reading_id)
+);
+
+-- Create users table for authentication; disabled users cannot log in
 CREATE TABLE IF NOT EXISTS users (
     id INT AUTO_INCREMENT PRIMARY KEY,
     username VARCHAR(50) NOT NULL UNIQUE,
     password_hash VARCHAR(255) NOT NULL,
     email VARCHAR(100) NOT NULL UNIQUE,
+    disabled_at BIGINT NULL,
     created_at BIGINT NOT NULL DEFAULT (UNIX_TIMESTAMP()*1000),
     updated_at BIGINT NOT NULL DEFAULT (UNIX_TIMESTAMP()*1000)
 );
@@ -53,14 +160,61 @@ CREATE TABLE IF NOT EXISTS user_roles (
     FOREIGN KEY (role_id) REFERENCES roles(id) ON DELETE CASCADE
 );
 
+-- Create api_keys table for machine clients and devices. Only the SHA-256 hash of a key
+-- is stored, with its first characters to tell keys apart. sensor_types and devices are
+-- JSON arrays limiting the readings a key may send and read; NULL places no limit.
+CREATE TABLE IF NOT EXISTS api_keys (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    user_id INT NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    key_prefix VARCHAR(16) NOT NULL,
+    key_hash CHAR(64) NOT NULL UNIQUE,
+    sensor_types JSON NULL,
+    devices JSON NULL,
+    expires_at BIGINT NULL,
+    revoked_at BIGINT NULL,
+    created_at BIGINT NOT NULL,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    INDEX idx_api_keys_user (user_id)
+);
+
+-- Create audit_log table recording every update, delete and restore of sensor data,
+-- one row per reading changed, written in the same transaction as the change.
+-- before_value and after_value are the reading as JSON, filter the filter of a delete
+-- by filter. Actors are not foreign keys so entries outlive the users and API keys that
+-- made them.
+CREATE TABLE IF NOT EXISTS audit_log (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    actor_user_id INT NULL,
+    actor_username VARCHAR(50) NOT NULL,
+    actor_api_key_id INT NULL,
+    operation VARCHAR(32) NOT NULL,
+    sensor_data_id BIGINT NOT NULL,
+    filter JSON NULL,
+    before_value JSON NULL,
+    after_value JSON NULL,
+    created_at BIGINT NOT NULL,
+    INDEX idx_audit_created (created_at),
+    INDEX idx_audit_actor (actor_user_id, id),
+    INDEX idx_audit_operation (operation, id),
+    INDEX idx_audit_sensor_data (sensor_data_id, id)
+);
+
+-- The audit log is append-only
+CREATE TRIGGER IF NOT EXISTS audit_log_no_update BEFORE UPDATE ON audit_log
+FOR EACH ROW SIGNAL SQLSTATE '45000' SET MESSAGE_TEXT = 'audit_log is append-only';
+
+CREATE TRIGGER IF NOT EXISTS audit_log_no_delete BEFORE DELETE ON audit_log
+FOR EACH ROW SIGNAL SQLSTATE '45000' SET MESSAGE_TEXT = 'audit_log is append-only';
+
 -- Insert default roles
 INSERT INTO roles (name, description) VALUES 
 ('admin', 'Administrator with full access'),
-('user', 'Regular user with limited access'),
+('user', 'Regular user with limited access');
 
 -- Insert default sensor types
-INSERT INTO sensor_types (name, description, created_at, updated_at) VALUES 
-('temperature', 'Temperature sensor in Celsius', 1761908040000, 1761908040000),
-('humidity', 'Humidity sensor in percentage', 1761908040000, 1761908040000),
-('pressure', 'Pressure sensor in hPa', 1761908040000, 1761908040000),
-('light', 'Light sensor in lux', 1761908040000, 1761908040000);
\ No newline at end of file
+INSERT INTO sensor_types (name, unit, description, min_value, max_value, created_at, updated_at) VALUES 
+('temperature', '°C', 'Temperature sensor in Celsius', -50, 150, 1761908040000, 1761908040000),
+('humidity', '%', 'Humidity sensor in percentage', 0, 100, 1761908040000, 1761908040000),
+('pressure', 'hPa', 'Pressure sensor in hPa', 300, 1100, 1761908040000, 1761908040000),
+('light', 'lux', 'Light sensor in lux', 0, 100000, 1761908040000, 1761908040000);
\ No newline at end of file